@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"strings"
 	"syscall"
@@ -14,9 +15,12 @@ import (
 	"github.com/h44z/wg-portal/internal/app/audit"
 	"github.com/h44z/wg-portal/internal/app/auth"
 	"github.com/h44z/wg-portal/internal/app/configfile"
+	"github.com/h44z/wg-portal/internal/app/dnsexport"
+	"github.com/h44z/wg-portal/internal/app/eventstream"
 	"github.com/h44z/wg-portal/internal/app/mail"
 	"github.com/h44z/wg-portal/internal/app/route"
 	"github.com/h44z/wg-portal/internal/app/users"
+	"github.com/h44z/wg-portal/internal/app/webhooks"
 	"github.com/h44z/wg-portal/internal/app/wireguard"
 
 	"github.com/h44z/wg-portal/internal"
@@ -48,7 +52,8 @@ func main() {
 
 	wireGuard := adapters.NewWireGuardRepository()
 
-	wgQuick := adapters.NewWgQuickRepo()
+	wgQuick, err := newWgQuickController(cfg)
+	internal.AssertNoError(err)
 
 	mailer := adapters.NewSmtpMailRepo(cfg.Mail)
 
@@ -57,7 +62,7 @@ func main() {
 	cfgFileSystem, err := adapters.NewFileSystemRepository(cfg.Advanced.ConfigStoragePath)
 	internal.AssertNoError(err)
 
-	shouldExit, err := app.HandleProgramArgs(cfg, rawDb)
+	shouldExit, err := app.HandleProgramArgs(cfg, rawDb, audit.NewAuditVerifier(cfg, database))
 	switch {
 	case shouldExit && err == nil:
 		return
@@ -71,10 +76,10 @@ func main() {
 	queueSize := 100
 	eventBus := evbus.New(queueSize)
 
-	userManager, err := users.NewUserManager(cfg, eventBus, database, database)
+	userManager, err := users.NewUserManager(cfg, eventBus, database, database, database)
 	internal.AssertNoError(err)
 
-	authenticator, err := auth.NewAuthenticator(&cfg.Auth, cfg.Web.ExternalUrl, eventBus, userManager)
+	authenticator, err := auth.NewAuthenticator(&cfg.Auth, cfg.Web.ExternalUrl, eventBus, userManager, database)
 	internal.AssertNoError(err)
 
 	wireGuardManager, err := wireguard.NewWireGuardManager(cfg, eventBus, wireGuard, wgQuick, database)
@@ -86,43 +91,77 @@ func main() {
 	cfgFileManager, err := configfile.NewConfigFileManager(cfg, eventBus, database, database, cfgFileSystem)
 	internal.AssertNoError(err)
 
-	mailManager, err := mail.NewMailManager(cfg, mailer, cfgFileManager, database, database)
+	dnsExportManager, err := dnsexport.NewDnsExportManager(cfg, eventBus, database, cfgFileSystem)
+	internal.AssertNoError(err)
+
+	mailManager, err := mail.NewMailManager(cfg, eventBus, mailer, cfgFileManager, database, database)
 	internal.AssertNoError(err)
 
 	auditRecorder, err := audit.NewAuditRecorder(cfg, eventBus, database)
 	internal.AssertNoError(err)
+	for _, sinkCfg := range cfg.AuditSinks {
+		auditSink, err := newAuditSink(sinkCfg)
+		internal.AssertNoError(err)
+		internal.AssertNoError(auditRecorder.RegisterSink(sinkCfg, auditSink))
+	}
 	auditRecorder.StartBackgroundJobs(ctx)
 
-	routeManager, err := route.NewRouteManager(cfg, eventBus, database)
+	routeManager, err := route.NewRouteManager(cfg, eventBus, database, metricsServer)
 	internal.AssertNoError(err)
 	routeManager.StartBackgroundJobs(ctx)
 
+	webhookManager, err := webhooks.NewWebhookManager(cfg, eventBus, database)
+	internal.AssertNoError(err)
+	webhookManager.StartBackgroundJobs(ctx)
+
+	eventStreamManager, err := eventstream.NewEventStreamManager(cfg, eventBus, database)
+	internal.AssertNoError(err)
+
 	backend, err := app.New(cfg, eventBus, authenticator, userManager, wireGuardManager,
 		statisticsCollector, cfgFileManager, mailManager)
 	internal.AssertNoError(err)
 	err = backend.Startup(ctx)
 	internal.AssertNoError(err)
 
-	apiFrontend := handlersV0.NewRestApi(cfg, backend)
+	apiFrontend := handlersV0.NewRestApi(cfg, backend, eventBus)
 
 	apiV1BackendUsers := backendV1.NewUserService(cfg, userManager)
+	apiV1BackendApiTokens := backendV1.NewApiTokenService(cfg, userManager)
 	apiV1BackendPeers := backendV1.NewPeerService(cfg, wireGuardManager, userManager)
 	apiV1BackendInterfaces := backendV1.NewInterfaceService(cfg, wireGuardManager)
 	apiV1BackendProvisioning := backendV1.NewProvisioningService(cfg, userManager, wireGuardManager, cfgFileManager)
 	apiV1BackendMetrics := backendV1.NewMetricsService(cfg, database, userManager, wireGuardManager)
+	apiV1BackendDns := backendV1.NewDnsService(cfg, dnsExportManager)
+	apiV1BackendRouting := backendV1.NewRoutingService(cfg, routeManager)
+	apiV1BackendWebhooks := backendV1.NewWebhookService(cfg, webhookManager)
+	apiV1BackendAudit := backendV1.NewAuditService(cfg, auditRecorder)
+	apiV1BackendEvents := backendV1.NewEventService(cfg, eventStreamManager)
 	apiV1EndpointUsers := handlersV1.NewUserEndpoint(apiV1BackendUsers)
 	apiV1EndpointPeers := handlersV1.NewPeerEndpoint(apiV1BackendPeers)
+	apiV1EndpointApiTokens := handlersV1.NewApiTokenEndpoint(apiV1BackendApiTokens)
 	apiV1EndpointInterfaces := handlersV1.NewInterfaceEndpoint(apiV1BackendInterfaces)
 	apiV1EndpointProvisioning := handlersV1.NewProvisioningEndpoint(apiV1BackendProvisioning)
 	apiV1EndpointMetrics := handlersV1.NewMetricsEndpoint(apiV1BackendMetrics)
+	apiV1EndpointDns := handlersV1.NewDnsEndpoint(apiV1BackendDns)
+	apiV1EndpointRouting := handlersV1.NewRoutingEndpoint(apiV1BackendRouting)
+	apiV1EndpointWebhooks := handlersV1.NewWebhookEndpoint(apiV1BackendWebhooks)
+	apiV1EndpointAudit := handlersV1.NewAuditEndpoint(apiV1BackendAudit)
+	apiV1EndpointEvents := handlersV1.NewEventEndpoint(apiV1BackendEvents)
 
 	apiV1 := handlersV1.NewRestApi(
 		userManager,
+		authenticator,
 		apiV1EndpointUsers,
+		apiV1EndpointApiTokens,
 		apiV1EndpointPeers,
 		apiV1EndpointInterfaces,
 		apiV1EndpointProvisioning,
 		apiV1EndpointMetrics,
+		apiV1EndpointDns,
+		apiV1EndpointRouting,
+		apiV1EndpointWebhooks,
+		apiV1EndpointAudit,
+		apiV1EndpointEvents,
 	)
 
 	webSrv, err := core.NewServer(cfg, apiFrontend, apiV1)
@@ -141,6 +180,43 @@ func main() {
 	logrus.Infof("Stopped WireGuard Portal")
 }
 
+// newAuditSink returns the audit sink for the configured sink type.
+func newAuditSink(cfg config.AuditSinkConfig) (audit.Sink, error) {
+	switch cfg.Type {
+	case config.AuditSinkTypeSyslog:
+		return adapters.NewSyslogAuditSink(cfg)
+	case config.AuditSinkTypeFile:
+		return adapters.NewFileAuditSink(cfg)
+	default:
+		return nil, fmt.Errorf("unsupported audit sink type %q for sink %s", cfg.Type, cfg.Name)
+	}
+}
+
+// newWgQuickController returns the wg-quick controller for the configured DNS backend.
+func newWgQuickController(cfg *config.Config) (wireguard.WgQuickController, error) {
+	switch cfg.Advanced.DnsBackend {
+	case config.DnsBackendResolved:
+		return adapters.NewResolvedRepo()
+	case config.DnsBackendAuto:
+		if !adapters.IsResolvedAvailable() {
+			logrus.Infof("Using resolvconf as DNS backend, systemd-resolved is not reachable")
+			return adapters.NewWgQuickRepo(), nil
+		}
+
+		resolved, err := adapters.NewResolvedRepo()
+		if err != nil {
+			logrus.Warnf("Using resolvconf as DNS backend, failed to connect to systemd-resolved: %v", err)
+			return adapters.NewWgQuickRepo(), nil
+		}
+		logrus.Infof("Using systemd-resolved as DNS backend")
+		return resolved, nil
+	case config.DnsBackendResolvconf, "":
+		return adapters.NewWgQuickRepo(), nil
+	default:
+		return nil, fmt.Errorf("unsupported dns backend: %s", cfg.Advanced.DnsBackend)
+	}
+}
+
 func setupLogging(cfg *config.Config) {
 	switch strings.ToLower(cfg.Advanced.LogLevel) {
 	case "trace":
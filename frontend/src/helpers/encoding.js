@@ -4,4 +4,23 @@ export function base64_url_encode(input) {
   output = output.replace('/', '_')
   output = output.replace('=', '-')
   return output
-}
\ No newline at end of file
+}
+
+export function buffer_to_base64_url(buffer) {
+  const bytes = new Uint8Array(buffer)
+  let binary = ''
+  for (let i = 0; i < bytes.length; i++) {
+    binary += String.fromCharCode(bytes[i])
+  }
+  return btoa(binary).replace(/\+/g, '-').replace(/\//g, '_').replace(/=+$/, '')
+}
+
+export function base64_url_to_buffer(input) {
+  const base64 = input.replace(/-/g, '+').replace(/_/g, '/')
+  const binary = atob(base64.padEnd(base64.length + (4 - base64.length % 4) % 4, '='))
+  const bytes = new Uint8Array(binary.length)
+  for (let i = 0; i < binary.length; i++) {
+    bytes[i] = binary.charCodeAt(i)
+  }
+  return bytes.buffer
+}
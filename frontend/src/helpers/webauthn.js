@@ -0,0 +1,58 @@
+import { base64_url_to_buffer, buffer_to_base64_url } from '@/helpers/encoding'
+
+// WebauthnSupported returns true if passkeys are enabled in the backend and supported by the browser.
+export function WebauthnSupported() {
+  return WGPORTAL_WEBAUTHN_ENABLED === true && window.PublicKeyCredential !== undefined
+}
+
+// CreateCredential calls navigator.credentials.create() with the options of the backend and returns
+// the JSON encodable result.
+export async function CreateCredential(options) {
+  const publicKey = {
+    ...options.publicKey,
+    challenge: base64_url_to_buffer(options.publicKey.challenge),
+    user: { ...options.publicKey.user, id: base64_url_to_buffer(options.publicKey.user.id) },
+    excludeCredentials: (options.publicKey.excludeCredentials || []).map(descriptorToBuffer),
+  }
+
+  const credential = await navigator.credentials.create({ publicKey })
+  return {
+    id: credential.id,
+    rawId: buffer_to_base64_url(credential.rawId),
+    type: credential.type,
+    response: {
+      clientDataJSON: buffer_to_base64_url(credential.response.clientDataJSON),
+      attestationObject: buffer_to_base64_url(credential.response.attestationObject),
+      transports: credential.response.getTransports ? credential.response.getTransports() : [],
+    },
+    clientExtensionResults: credential.getClientExtensionResults(),
+  }
+}
+
+// GetCredential calls navigator.credentials.get() with the options of the backend and returns
+// the JSON encodable result.
+export async function GetCredential(options) {
+  const publicKey = {
+    ...options.publicKey,
+    challenge: base64_url_to_buffer(options.publicKey.challenge),
+    allowCredentials: (options.publicKey.allowCredentials || []).map(descriptorToBuffer),
+  }
+
+  const credential = await navigator.credentials.get({ publicKey })
+  return {
+    id: credential.id,
+    rawId: buffer_to_base64_url(credential.rawId),
+    type: credential.type,
+    response: {
+      clientDataJSON: buffer_to_base64_url(credential.response.clientDataJSON),
+      authenticatorData: buffer_to_base64_url(credential.response.authenticatorData),
+      signature: buffer_to_base64_url(credential.response.signature),
+      userHandle: credential.response.userHandle ? buffer_to_base64_url(credential.response.userHandle) : null,
+    },
+    clientExtensionResults: credential.getClientExtensionResults(),
+  }
+}
+
+function descriptorToBuffer(descriptor) {
+  return { ...descriptor, id: base64_url_to_buffer(descriptor.id) }
+}
@@ -19,6 +19,26 @@ const router = createRouter({
       name: 'login',
       component: LoginView
     },
+    {
+      path: '/password-reset',
+      name: 'password-reset',
+      component: () => import('../views/PasswordResetView.vue')
+    },
+    {
+      path: '/invitation',
+      name: 'invitation',
+      component: () => import('../views/InvitationView.vue')
+    },
+    {
+      path: '/signup',
+      name: 'signup',
+      component: () => import('../views/SignupView.vue')
+    },
+    {
+      path: '/signup/verify',
+      name: 'signup-verify',
+      component: () => import('../views/SignupVerifyView.vue')
+    },
     {
       path: '/interface',
       name: 'interface',
@@ -105,7 +125,7 @@ router.beforeEach(async (to) => {
   }
 
   // redirect to login page if not logged in and trying to access a restricted page
-  const publicPages = ['/', '/login']
+  const publicPages = ['/', '/login', '/password-reset', '/invitation', '/signup', '/signup/verify']
   const authRequired = !publicPages.includes(to.path)
 
   if (authRequired && !auth.IsAuthenticated) {
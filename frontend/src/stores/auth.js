@@ -3,6 +3,7 @@ import { defineStore } from 'pinia'
 import { notify } from "@kyvg/vue3-notification";
 import { apiWrapper } from '@/helpers/fetch-wrapper'
 import router from '../router'
+import { CreateCredential, GetCredential } from '@/helpers/webauthn'
 
 export const authStore = defineStore({
     id: 'auth',
@@ -10,7 +11,8 @@ export const authStore = defineStore({
         // initialize state from local storage to enable user to stay logged in
         user: JSON.parse(localStorage.getItem('user')),
         providers: [],
-        returnUrl: localStorage.getItem('returnUrl')
+        returnUrl: localStorage.getItem('returnUrl'),
+        secondFactor: null, // 'verify' or 'enrollment' if the password login waits for the second factor
     }),
     getters: {
         UserIdentifier: (state) => state.user?.Identifier || 'unknown',
@@ -19,6 +21,8 @@ export const authStore = defineStore({
         IsAuthenticated: (state) => state.user != null,
         IsAdmin: (state) => state.user?.IsAdmin || false,
         ReturnUrl: (state) => state.returnUrl || '/',
+        SecondFactorRequired: (state) => state.secondFactor === 'verify',
+        SecondFactorEnrollmentRequired: (state) => state.secondFactor === 'enrollment',
     },
     actions: {
         SetReturnUrl(link) {
@@ -62,12 +66,20 @@ export const authStore = defineStore({
                 })
         },
         // Login returns promise that might have been rejected if the login attempt was not successful.
+        // If a second factor is required, the promise resolves to null and SecondFactorRequired or
+        // SecondFactorEnrollmentRequired is set.
         async Login(username, password) {
             return apiWrapper.post(`/auth/login`, { username, password })
-                .then(user =>  {
+                .then(result =>  {
+                    if (result.SecondFactorRequired || result.EnrollmentRequired) {
+                        this.setUserInfo(null)
+                        this.secondFactor = result.SecondFactorRequired ? 'verify' : 'enrollment'
+                        return null
+                    }
+
                     this.ResetReturnUrl()
-                    this.setUserInfo(user)
-                    return user.Identifier
+                    this.setUserInfo(result)
+                    return result.Identifier
                 })
                 .catch(err => {
                     console.log("Login failed:", err)
@@ -75,12 +87,93 @@ export const authStore = defineStore({
                     return Promise.reject(new Error("login failed"))
                 })
         },
+        // LoginSecondFactor completes a pending password login with a TOTP or recovery code.
+        async LoginSecondFactor(code) {
+            return apiWrapper.post(`/auth/login/totp`, { Code: code })
+                .then(user =>  {
+                    this.secondFactor = null
+                    this.setUserInfo(user)
+                    return user.Identifier
+                })
+                .catch(err => {
+                    console.log("Second factor login failed:", err)
+                    return Promise.reject(new Error("login failed"))
+                })
+        },
+        CancelSecondFactor() {
+            this.secondFactor = null
+        },
+        // SetupTotp returns a new TOTP secret, including the QR code for authenticator apps.
+        async SetupTotp() {
+            return apiWrapper.post(`/auth/totp/setup`)
+        },
+        // ActivateTotp enables TOTP and returns the recovery codes. A pending login that waits for the mandatory
+        // enrollment is completed.
+        async ActivateTotp(code) {
+            const activation = await apiWrapper.post(`/auth/totp/activate`, { Code: code })
+            if (this.secondFactor === 'enrollment') {
+                this.secondFactor = null
+                await this.LoadSession()
+            }
+            return activation.RecoveryCodes
+        },
+        async DisableTotp(code) {
+            return apiWrapper.post(`/auth/totp/disable`, { Code: code })
+        },
+        // RequestPasswordReset sends a password reset link to the user. The promise is fulfilled even if the
+        // user does not exist.
+        async RequestPasswordReset(identifier) {
+            return apiWrapper.post(`/auth/password-reset/request`, { Identifier: identifier })
+        },
+        async ResetPassword(token, password) {
+            return apiWrapper.post(`/auth/password-reset`, { Token: token, Password: password })
+        },
+        async AcceptInvitation(token, password) {
+            return apiWrapper.post(`/auth/invitation`, { Token: token, Password: password })
+        },
+        // Signup registers a new user, a verification link is sent to the email address.
+        async Signup(user) {
+            return apiWrapper.post(`/auth/signup`, user)
+        },
+        async VerifySignup(token) {
+            return apiWrapper.post(`/auth/signup/verify`, { Token: token })
+        },
+        // LoginWebauthn performs a passwordless login with a passkey. If a password login is pending, the passkey
+        // is used as second factor instead.
+        async LoginWebauthn() {
+            try {
+                const options = await apiWrapper.post(`/auth/login/webauthn/init`)
+                const credential = await GetCredential(options)
+                const user = await apiWrapper.post(`/auth/login/webauthn`, credential)
+                this.secondFactor = null
+                this.setUserInfo(user)
+                return user.Identifier
+            } catch (err) {
+                console.log("Passkey login failed:", err)
+                return Promise.reject(new Error("login failed"))
+            }
+        },
+        async LoadWebauthnCredentials() {
+            return apiWrapper.get(`/auth/webauthn/credentials`)
+        },
+        // RegisterWebauthnCredential registers a new passkey or security key for the current user.
+        async RegisterWebauthnCredential(name) {
+            const options = await apiWrapper.post(`/auth/webauthn/credentials/init`)
+            const credential = await CreateCredential(options)
+            return apiWrapper.post(`/auth/webauthn/credentials`, { Name: name, Credential: credential })
+        },
+        async DeleteWebauthnCredential(id) {
+            return apiWrapper.delete(`/auth/webauthn/credentials/${encodeURIComponent(id)}`)
+        },
         async Logout() {
             this.setUserInfo(null)
+            this.secondFactor = null
             this.ResetReturnUrl() // just to be sure^^
 
+            let redirectUrl = null
             try {
-                await apiWrapper.post(`/auth/logout`)
+                const result = await apiWrapper.post(`/auth/logout`)
+                redirectUrl = result?.RedirectUrl
             } catch (e) {
                 console.log("Logout request failed:", e)
             }
@@ -91,6 +184,11 @@ export const authStore = defineStore({
                 type: "warn",
             })
 
+            if (redirectUrl) { // end the session at the OpenID Connect provider as well
+                window.location.href = redirectUrl
+                return
+            }
+
 
             await router.push('/login')
         },
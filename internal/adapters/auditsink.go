@@ -0,0 +1,313 @@
+package adapters
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// region syslog
+
+// syslogStructuredDataId is the SD-ID of the structured data element that contains the audit fields.
+const syslogStructuredDataId = "audit@32473"
+
+// localSyslogSockets are the default paths of the local syslog socket.
+var localSyslogSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// SyslogAuditSink sends audit entries as RFC 5424 messages to a local or remote syslog server.
+type SyslogAuditSink struct {
+	cfg      config.AuditSinkConfig
+	hostname string
+
+	mux  sync.Mutex
+	conn net.Conn
+}
+
+func NewSyslogAuditSink(cfg config.AuditSinkConfig) (*SyslogAuditSink, error) {
+	switch cfg.Network {
+	case "":
+	case "udp", "tcp", "tls":
+		if cfg.Address == "" {
+			return nil, fmt.Errorf("missing address for syslog sink %s", cfg.Name)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported network %q for syslog sink %s", cfg.Network, cfg.Name)
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+
+	return &SyslogAuditSink{
+		cfg:      cfg,
+		hostname: hostname,
+	}, nil
+}
+
+// Write sends the entry to the syslog server. If the connection was lost, it is re-established once.
+func (s *SyslogAuditSink) Write(entry *domain.AuditEntry) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	msg := formatSyslogMessage(entry, s.cfg.GetFacility(), s.hostname, s.cfg.GetAppName(), os.Getpid())
+	if s.cfg.Network == "tcp" || s.cfg.Network == "tls" {
+		msg = strconv.Itoa(len(msg)) + " " + msg // octet counting framing (RFC 6587)
+	}
+
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if s.conn == nil {
+			if s.conn, err = s.dial(); err != nil {
+				return fmt.Errorf("failed to connect to syslog: %w", err)
+			}
+		}
+
+		_ = s.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		if _, err = s.conn.Write([]byte(msg)); err == nil {
+			return nil
+		}
+
+		_ = s.conn.Close()
+		s.conn = nil
+	}
+
+	return fmt.Errorf("failed to write syslog message: %w", err)
+}
+
+func (s *SyslogAuditSink) Close() error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+func (s *SyslogAuditSink) dial() (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+
+	switch s.cfg.Network {
+	case "tls":
+		return tls.DialWithDialer(dialer, "tcp", s.cfg.Address, &tls.Config{
+			InsecureSkipVerify: s.cfg.TlsSkipVerify,
+		})
+	case "udp", "tcp":
+		return dialer.Dial(s.cfg.Network, s.cfg.Address)
+	}
+
+	for _, socket := range localSyslogSockets {
+		for _, network := range []string{"unixgram", "unix"} {
+			conn, err := dialer.Dial(network, socket)
+			if err == nil {
+				return conn, nil
+			}
+		}
+	}
+	return nil, errors.New("no local syslog socket found")
+}
+
+// formatSyslogMessage formats the entry as RFC 5424 message. The audit fields are stored as structured data.
+func formatSyslogMessage(entry *domain.AuditEntry, facility int, hostname, appName string, pid int) string {
+	var severity int
+	switch entry.Severity {
+	case domain.AuditSeverityLevelHigh:
+		severity = 4 // warning
+	case domain.AuditSeverityLevelMedium:
+		severity = 5 // notice
+	default:
+		severity = 6 // informational
+	}
+
+	params := []struct{ name, value string }{
+		{"id", strconv.FormatUint(entry.UniqueId, 10)},
+		{"severity", string(entry.Severity)},
+		{"actor", string(entry.Actor)},
+		{"clientIp", entry.ClientIp},
+		{"objectType", string(entry.ObjectType)},
+		{"objectId", entry.ObjectId},
+		{"hash", entry.Hash},
+	}
+
+	sd := strings.Builder{}
+	sd.WriteString("[" + syslogStructuredDataId)
+	for _, param := range params {
+		if param.value == "" {
+			continue
+		}
+		sd.WriteString(" " + param.name + "=\"" + escapeSyslogParamValue(param.value) + "\"")
+	}
+	sd.WriteString("]")
+
+	msg := entry.Message
+	if len(entry.Details.Changes) > 0 || len(entry.Details.Info) > 0 {
+		details, _ := json.Marshal(entry.Details)
+		msg += " " + string(details)
+	}
+
+	return fmt.Sprintf("<%d>1 %s %s %s %d %s %s %s",
+		facility*8+severity,
+		entry.CreatedAt.Format("2006-01-02T15:04:05.000Z07:00"),
+		syslogHeaderValue(hostname, 255),
+		syslogHeaderValue(appName, 48),
+		pid,
+		syslogHeaderValue(entry.Origin, 32),
+		sd.String(),
+		strings.ReplaceAll(msg, "\n", " "))
+}
+
+// syslogHeaderValue returns a valid header field value: printable ASCII without spaces, limited in length.
+func syslogHeaderValue(value string, maxLen int) string {
+	value = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return '_'
+		}
+		return r
+	}, value)
+
+	if value == "" {
+		return "-" // nil value
+	}
+	if len(value) > maxLen {
+		return value[:maxLen]
+	}
+	return value
+}
+
+// escapeSyslogParamValue escapes the characters that are not allowed in structured data parameter values.
+func escapeSyslogParamValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(value)
+}
+
+// endregion syslog
+
+// region file
+
+// FileAuditSink writes audit entries as JSON Lines to a file. The file is rotated if it exceeds the maximum size.
+// If the file could not be (re-)opened, for example after a failed rotation, it is opened again on the next write.
+type FileAuditSink struct {
+	cfg config.AuditSinkConfig
+
+	mux    sync.Mutex
+	file   *os.File
+	writer *bufio.Writer
+	size   int64
+}
+
+func NewFileAuditSink(cfg config.AuditSinkConfig) (*FileAuditSink, error) {
+	if cfg.Path == "" {
+		return nil, fmt.Errorf("missing path for file sink %s", cfg.Name)
+	}
+
+	s := &FileAuditSink{cfg: cfg}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *FileAuditSink) Write(entry *domain.AuditEntry) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry %d: %w", entry.UniqueId, err)
+	}
+	line = append(line, '\n')
+
+	if s.file == nil {
+		if err := s.open(); err != nil {
+			return err
+		}
+	}
+
+	if s.size > 0 && s.size+int64(len(line)) > int64(s.cfg.GetMaxSizeMB())*1024*1024 {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := s.writer.Write(line)
+	s.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write audit entry %d: %w", entry.UniqueId, err)
+	}
+
+	return s.writer.Flush()
+}
+
+func (s *FileAuditSink) Close() error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	_ = s.writer.Flush()
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+func (s *FileAuditSink) open() error {
+	if err := os.MkdirAll(filepath.Dir(s.cfg.Path), os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create directory for file sink %s: %w", s.cfg.Name, err)
+	}
+
+	file, err := os.OpenFile(s.cfg.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open file %s: %w", s.cfg.Path, err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to stat file %s: %w", s.cfg.Path, err)
+	}
+
+	s.file = file
+	s.writer = bufio.NewWriter(file)
+	s.size = info.Size()
+
+	return nil
+}
+
+// rotate renames the current file to <path>.1, shifting older backups. The oldest backup is removed.
+func (s *FileAuditSink) rotate() error {
+	_ = s.writer.Flush()
+	err := s.file.Close()
+	s.file = nil // the file is opened again on the next write, even if the rotation fails
+	if err != nil {
+		return fmt.Errorf("failed to close file %s: %w", s.cfg.Path, err)
+	}
+
+	maxBackups := s.cfg.GetMaxBackups()
+	_ = os.Remove(fmt.Sprintf("%s.%d", s.cfg.Path, maxBackups))
+	for i := maxBackups - 1; i > 0; i-- {
+		_ = os.Rename(fmt.Sprintf("%s.%d", s.cfg.Path, i), fmt.Sprintf("%s.%d", s.cfg.Path, i+1))
+	}
+	if err := os.Rename(s.cfg.Path, s.cfg.Path+".1"); err != nil {
+		return fmt.Errorf("failed to rotate file %s: %w", s.cfg.Path, err)
+	}
+
+	return s.open()
+}
+
+// endregion file
@@ -0,0 +1,102 @@
+package adapters
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+func Test_formatSyslogMessage(t *testing.T) {
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 6000000, time.UTC)
+
+	tests := []struct {
+		name  string
+		entry domain.AuditEntry
+		want  string
+	}{
+		{
+			name: "minimal entry",
+			entry: domain.AuditEntry{
+				UniqueId:  1,
+				CreatedAt: createdAt,
+				Severity:  domain.AuditSeverityLevelLow,
+				Message:   "hello",
+			},
+			want: `<110>1 2024-01-02T03:04:05.006Z host wg-portal 42 - [audit@32473 id="1" severity="low"] hello`,
+		},
+		{
+			name: "escaped values",
+			entry: domain.AuditEntry{
+				UniqueId:   2,
+				CreatedAt:  createdAt,
+				Severity:   domain.AuditSeverityLevelHigh,
+				Origin:     "user deleted",
+				Actor:      `a"b]c\d`,
+				ObjectType: domain.AuditObjectTypeUser,
+				ObjectId:   "u1",
+				Message:    "line1\nline2",
+			},
+			want: `<108>1 2024-01-02T03:04:05.006Z host wg-portal 42 user_deleted [audit@32473 id="2" severity="high" ` +
+				`actor="a\"b\]c\\d" objectType="user" objectId="u1"] line1 line2`,
+		},
+		{
+			name: "with details",
+			entry: domain.AuditEntry{
+				UniqueId:  3,
+				CreatedAt: createdAt,
+				Severity:  domain.AuditSeverityLevelMedium,
+				Message:   "changed",
+				Details:   domain.AuditDetails{Info: map[string]string{"k": "v"}},
+			},
+			want: `<109>1 2024-01-02T03:04:05.006Z host wg-portal 42 - [audit@32473 id="3" severity="medium"] ` +
+				`changed {"info":{"k":"v"}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatSyslogMessage(&tt.entry, 13, "host", "wg-portal", 42); got != tt.want {
+				t.Errorf("formatSyslogMessage() =\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileAuditSink_RecoversFromFailedRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	sink, err := NewFileAuditSink(config.AuditSinkConfig{Name: "file", Path: path, MaxSizeMB: 1, MaxBackups: 1})
+	if err != nil {
+		t.Fatalf("NewFileAuditSink() error = %v", err)
+	}
+	defer sink.Close()
+
+	entry := &domain.AuditEntry{UniqueId: 1, Message: "test"}
+	if err := sink.Write(entry); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	// a non-empty directory in place of the backup file lets the rotation fail
+	if err := os.MkdirAll(filepath.Join(path+".1", "blocked"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	sink.size = 1024 * 1024 // force a rotation on the next write
+	if err := sink.Write(entry); err == nil {
+		t.Fatalf("Write() expected rotation error")
+	}
+
+	if err := os.RemoveAll(path + ".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Write(entry); err != nil {
+		t.Fatalf("Write() after failed rotation error = %v", err)
+	}
+
+	content, _ := os.ReadFile(path)
+	if lines := strings.Count(string(content), "\n"); lines != 2 {
+		t.Errorf("Write() file contains %d entries, want 2", lines)
+	}
+}
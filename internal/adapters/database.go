@@ -212,11 +212,15 @@ func (r *SqlRepo) preCheck() error {
 func (r *SqlRepo) migrate() error {
 	logrus.Tracef("sysstat migration: %v", r.db.AutoMigrate(&SysStat{}))
 	logrus.Tracef("user migration: %v", r.db.AutoMigrate(&domain.User{}))
+	logrus.Tracef("webauthn credential migration: %v", r.db.AutoMigrate(&domain.WebauthnCredential{}))
+	logrus.Tracef("api token migration: %v", r.db.AutoMigrate(&domain.ApiToken{}))
 	logrus.Tracef("interface migration: %v", r.db.AutoMigrate(&domain.Interface{}))
 	logrus.Tracef("peer migration: %v", r.db.AutoMigrate(&domain.Peer{}))
 	logrus.Tracef("peer status migration: %v", r.db.AutoMigrate(&domain.PeerStatus{}))
 	logrus.Tracef("interface status migration: %v", r.db.AutoMigrate(&domain.InterfaceStatus{}))
 	logrus.Tracef("audit data migration: %v", r.db.AutoMigrate(&domain.AuditEntry{}))
+	logrus.Tracef("audit checkpoint migration: %v", r.db.AutoMigrate(&domain.AuditCheckpoint{}))
+	logrus.Tracef("webhook delivery migration: %v", r.db.AutoMigrate(&domain.WebhookDelivery{}))
 
 	existingSysStat := SysStat{}
 	r.db.Where("schema_version = ?", SchemaVersion).First(&existingSysStat)
@@ -808,7 +812,24 @@ func (r *SqlRepo) SaveUser(
 }
 
 func (r *SqlRepo) DeleteUser(ctx context.Context, id domain.UserIdentifier) error {
-	err := r.db.WithContext(ctx).Delete(&domain.User{}, id).Error
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("user_identifier = ?", id).Delete(&domain.WebauthnCredential{}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Where("user_identifier = ?", id).Delete(&domain.ApiToken{}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Delete(&domain.User{}, id).Error
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
 	if err != nil {
 		return err
 	}
@@ -857,6 +878,120 @@ func (r *SqlRepo) upsertUser(ui *domain.ContextUserInfo, tx *gorm.DB, user *doma
 
 // endregion users
 
+// region webauthn
+
+func (r *SqlRepo) GetWebauthnCredentials(ctx context.Context, id domain.UserIdentifier) (
+	[]domain.WebauthnCredential,
+	error,
+) {
+	var credentials []domain.WebauthnCredential
+
+	err := r.db.WithContext(ctx).Where("user_identifier = ?", id).Order("created_at").Find(&credentials).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return credentials, nil
+}
+
+func (r *SqlRepo) GetWebauthnCredential(ctx context.Context, credentialId string) (
+	*domain.WebauthnCredential,
+	error,
+) {
+	var credential domain.WebauthnCredential
+
+	err := r.db.WithContext(ctx).Where("credential_identifier = ?", credentialId).First(&credential).Error
+	if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &credential, nil
+}
+
+func (r *SqlRepo) SaveWebauthnCredential(ctx context.Context, credential *domain.WebauthnCredential) error {
+	err := r.db.WithContext(ctx).Save(credential).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *SqlRepo) DeleteWebauthnCredential(
+	ctx context.Context,
+	id domain.UserIdentifier,
+	credentialId string,
+) error {
+	res := r.db.WithContext(ctx).
+		Where("user_identifier = ? AND credential_identifier = ?", id, credentialId).
+		Delete(&domain.WebauthnCredential{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// endregion webauthn
+
+// region api tokens
+
+func (r *SqlRepo) GetApiTokens(ctx context.Context, id domain.UserIdentifier) ([]domain.ApiToken, error) {
+	var tokens []domain.ApiToken
+
+	err := r.db.WithContext(ctx).Where("user_identifier = ?", id).Order("created_at").Find(&tokens).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+func (r *SqlRepo) GetApiToken(ctx context.Context, tokenId string) (*domain.ApiToken, error) {
+	var token domain.ApiToken
+
+	err := r.db.WithContext(ctx).Where("identifier = ?", tokenId).First(&token).Error
+	if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+func (r *SqlRepo) SaveApiToken(ctx context.Context, token *domain.ApiToken) error {
+	err := r.db.WithContext(ctx).Save(token).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *SqlRepo) DeleteApiToken(ctx context.Context, id domain.UserIdentifier, tokenId string) error {
+	res := r.db.WithContext(ctx).
+		Where("user_identifier = ? AND identifier = ?", id, tokenId).
+		Delete(&domain.ApiToken{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// endregion api tokens
+
 // region statistics
 
 func (r *SqlRepo) UpdateInterfaceStatus(
@@ -998,4 +1133,147 @@ func (r *SqlRepo) SaveAuditEntry(ctx context.Context, entry *domain.AuditEntry)
 	return nil
 }
 
+func (r *SqlRepo) GetAuditEntries(ctx context.Context, filter domain.AuditEntryFilter) ([]domain.AuditEntry, error) {
+	var entries []domain.AuditEntry
+
+	tx := r.db.WithContext(ctx)
+	if filter.Ascending {
+		tx = tx.Order("id asc")
+	} else {
+		tx = tx.Order("id desc")
+	}
+	if filter.From != nil {
+		tx = tx.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		tx = tx.Where("created_at < ?", *filter.To)
+	}
+	if filter.Severity != "" {
+		tx = tx.Where("severity = ?", filter.Severity)
+	}
+	if filter.Origin != "" {
+		tx = tx.Where("origin = ?", filter.Origin)
+	}
+	if filter.Actor != "" {
+		tx = tx.Where("actor = ?", filter.Actor)
+	}
+	if filter.ObjectType != "" {
+		tx = tx.Where("object_type = ?", filter.ObjectType)
+	}
+	if filter.ObjectId != "" {
+		tx = tx.Where("object_id = ?", filter.ObjectId)
+	}
+	if filter.Cursor > 0 && filter.Ascending {
+		tx = tx.Where("id > ?", filter.Cursor)
+	}
+	if filter.Cursor > 0 && !filter.Ascending {
+		tx = tx.Where("id < ?", filter.Cursor)
+	}
+	if filter.Limit > 0 {
+		tx = tx.Limit(filter.Limit)
+	}
+
+	err := tx.Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func (r *SqlRepo) DeleteAuditEntries(ctx context.Context, before time.Time, maxId uint64) (int64, error) {
+	tx := r.db.WithContext(ctx).Where("created_at < ?", before)
+	if maxId > 0 {
+		tx = tx.Where("id <= ?", maxId)
+	}
+
+	res := tx.Delete(&domain.AuditEntry{})
+	if res.Error != nil {
+		return 0, res.Error
+	}
+
+	return res.RowsAffected, nil
+}
+
+func (r *SqlRepo) GetAuditCheckpoints(ctx context.Context) ([]domain.AuditCheckpoint, error) {
+	var checkpoints []domain.AuditCheckpoint
+
+	err := r.db.WithContext(ctx).Order("entry_id asc").Find(&checkpoints).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return checkpoints, nil
+}
+
+func (r *SqlRepo) SaveAuditCheckpoint(ctx context.Context, checkpoint *domain.AuditCheckpoint) error {
+	err := r.db.WithContext(ctx).Save(checkpoint).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // endregion audit
+
+// region webhooks
+
+func (r *SqlRepo) GetWebhookDelivery(ctx context.Context, id string) (*domain.WebhookDelivery, error) {
+	var delivery domain.WebhookDelivery
+
+	err := r.db.WithContext(ctx).First(&delivery, "identifier = ?", id).Error
+
+	if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &delivery, nil
+}
+
+func (r *SqlRepo) GetWebhookDeliveries(ctx context.Context, state domain.WebhookDeliveryState, limit int) (
+	[]domain.WebhookDelivery,
+	error,
+) {
+	var deliveries []domain.WebhookDelivery
+
+	tx := r.db.WithContext(ctx).Order("created_at desc")
+	if state != "" {
+		tx = tx.Where("state = ?", state)
+	}
+	if limit > 0 {
+		tx = tx.Limit(limit)
+	}
+
+	err := tx.Find(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (r *SqlRepo) SaveWebhookDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	err := r.db.WithContext(ctx).Save(delivery).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *SqlRepo) DeleteWebhookDeliveries(ctx context.Context, state domain.WebhookDeliveryState, before time.Time) error {
+	err := r.db.WithContext(ctx).Where("state = ? AND created_at < ?", state, before).
+		Delete(&domain.WebhookDelivery{}).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// endregion webhooks
@@ -52,3 +52,14 @@ func (r *FilesystemRepo) WriteFile(path string, contents io.Reader) error {
 	return nil
 
 }
+
+// DeleteFile removes the given file. Missing files are ignored.
+func (r *FilesystemRepo) DeleteFile(path string) error {
+	filePath := filepath.Join(r.basePath, path)
+
+	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete file %s: %w", filePath, err)
+	}
+
+	return nil
+}
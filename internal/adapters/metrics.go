@@ -19,10 +19,14 @@ type MetricsServer struct {
 
 	ifaceReceivedBytesTotal  *prometheus.GaugeVec
 	ifaceSendBytesTotal      *prometheus.GaugeVec
+	ifaceConnectedPeers      *prometheus.GaugeVec
 	peerIsConnected          *prometheus.GaugeVec
 	peerLastHandshakeSeconds *prometheus.GaugeVec
 	peerReceivedBytesTotal   *prometheus.GaugeVec
 	peerSendBytesTotal       *prometheus.GaugeVec
+	ifaceManagedRoutes       *prometheus.GaugeVec
+	ifaceManagedRules        *prometheus.GaugeVec
+	routeLastSyncSeconds     prometheus.Gauge
 }
 
 // Wireguard metrics labels
@@ -56,6 +60,12 @@ func NewMetricsServer(cfg *config.Config) *MetricsServer {
 				Help: "Bytes sent through the interface.",
 			}, ifaceLabels,
 		),
+		ifaceConnectedPeers: promauto.With(reg).NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "wireguard_interface_connected_peers",
+				Help: "Number of currently connected peers.",
+			}, ifaceLabels,
+		),
 
 		peerIsConnected: promauto.With(reg).NewGaugeVec(
 			prometheus.GaugeOpts{
@@ -81,6 +91,25 @@ func NewMetricsServer(cfg *config.Config) *MetricsServer {
 				Help: "Bytes sent to the peer.",
 			}, peerLabels,
 		),
+
+		ifaceManagedRoutes: promauto.With(reg).NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "wireguard_interface_managed_routes",
+				Help: "Number of routes that are managed for the interface.",
+			}, ifaceLabels,
+		),
+		ifaceManagedRules: promauto.With(reg).NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "wireguard_interface_managed_rules",
+				Help: "Number of fwmark routing rules that are managed for the interface.",
+			}, ifaceLabels,
+		),
+		routeLastSyncSeconds: promauto.With(reg).NewGauge(
+			prometheus.GaugeOpts{
+				Name: "wireguard_routes_last_sync_seconds",
+				Help: "Timestamp of the last route and rule synchronization.",
+			},
+		),
 	}
 }
 
@@ -117,6 +146,11 @@ func (m *MetricsServer) UpdateInterfaceMetrics(status domain.InterfaceStatus) {
 	m.ifaceSendBytesTotal.WithLabelValues(labels...).Set(float64(status.BytesTransmitted))
 }
 
+// UpdateInterfaceConnectedPeers updates the number of connected peers for the given interface
+func (m *MetricsServer) UpdateInterfaceConnectedPeers(id domain.InterfaceIdentifier, count int) {
+	m.ifaceConnectedPeers.WithLabelValues(string(id)).Set(float64(count))
+}
+
 // UpdatePeerMetrics updates the metrics for the given peer
 func (m *MetricsServer) UpdatePeerMetrics(peer *domain.Peer, status domain.PeerStatus) {
 	labels := []string{
@@ -133,3 +167,21 @@ func (m *MetricsServer) UpdatePeerMetrics(peer *domain.Peer, status domain.PeerS
 	m.peerSendBytesTotal.WithLabelValues(labels...).Set(float64(status.BytesTransmitted))
 	m.peerIsConnected.WithLabelValues(labels...).Set(internal.BoolToFloat64(status.IsConnected()))
 }
+
+// UpdateRouteMetrics updates the managed route and rule metrics for the given interface
+func (m *MetricsServer) UpdateRouteMetrics(id domain.InterfaceIdentifier, routeCount, ruleCount int) {
+	labels := []string{string(id)}
+	m.ifaceManagedRoutes.WithLabelValues(labels...).Set(float64(routeCount))
+	m.ifaceManagedRules.WithLabelValues(labels...).Set(float64(ruleCount))
+}
+
+// RemoveRouteMetrics removes the managed route and rule metrics of an interface that is no longer managed
+func (m *MetricsServer) RemoveRouteMetrics(id domain.InterfaceIdentifier) {
+	m.ifaceManagedRoutes.DeleteLabelValues(string(id))
+	m.ifaceManagedRules.DeleteLabelValues(string(id))
+}
+
+// UpdateRouteSyncTime updates the timestamp of the last route synchronization
+func (m *MetricsServer) UpdateRouteSyncTime(syncTime time.Time) {
+	m.routeLastSyncSeconds.Set(float64(syncTime.Unix()))
+}
@@ -0,0 +1,215 @@
+package adapters
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/h44z/wg-portal/internal"
+	"github.com/h44z/wg-portal/internal/domain"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	resolvedBusName       = "org.freedesktop.resolve1"
+	resolvedObjectPath    = "/org/freedesktop/resolve1"
+	resolvedManagerIface  = "org.freedesktop.resolve1.Manager"
+	resolvedRuntimeFolder = "/run/systemd/resolve"
+)
+
+// resolvedBus is the subset of the D-Bus connection that is used to talk to systemd-resolved.
+type resolvedBus interface {
+	Call(method string, args ...any) error
+}
+
+// dbusResolvedBus calls methods of the systemd-resolved manager object on the system bus.
+type dbusResolvedBus struct {
+	obj dbus.BusObject
+}
+
+func (b dbusResolvedBus) Call(method string, args ...any) error {
+	return b.obj.Call(resolvedManagerIface+"."+method, 0, args...).Err
+}
+
+// resolvedLinkDns is the D-Bus representation of a DNS server address, signature (iay).
+type resolvedLinkDns struct {
+	Family  int32
+	Address []byte
+}
+
+// resolvedLinkDomain is the D-Bus representation of a link domain, signature (sb).
+type resolvedLinkDomain struct {
+	Domain      string
+	RoutingOnly bool
+}
+
+// ResolvedRepo sets per-link DNS settings using systemd-resolved via D-Bus.
+// Interface hooks are executed like in the wg-quick implementation.
+type ResolvedRepo struct {
+	*WgQuickRepo
+
+	bus       resolvedBus
+	linkIndex func(name string) (int, error)
+}
+
+func NewResolvedRepo() (*ResolvedRepo, error) {
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to system bus: %w", err)
+	}
+
+	bus := dbusResolvedBus{obj: conn.Object(resolvedBusName, resolvedObjectPath)}
+
+	return newResolvedRepo(bus, interfaceIndex), nil
+}
+
+func newResolvedRepo(bus resolvedBus, linkIndex func(name string) (int, error)) *ResolvedRepo {
+	return &ResolvedRepo{
+		WgQuickRepo: NewWgQuickRepo(),
+		bus:         bus,
+		linkIndex:   linkIndex,
+	}
+}
+
+// IsResolvedAvailable checks if systemd-resolved is running on the host system and reachable via D-Bus.
+func IsResolvedAvailable() bool {
+	if _, err := os.Stat(resolvedRuntimeFolder); err != nil {
+		return false
+	}
+
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return false
+	}
+	defer conn.Close()
+
+	var hasOwner bool
+	err = conn.BusObject().Call("org.freedesktop.DBus.NameHasOwner", 0, resolvedBusName).Store(&hasOwner)
+	if err != nil {
+		logrus.Debugf("failed to query systemd-resolved bus name: %v", err)
+		return false
+	}
+	if !hasOwner {
+		return false
+	}
+
+	// the bus policy might still deny access to systemd-resolved, so make sure it answers
+	err = conn.Object(resolvedBusName, resolvedObjectPath).Call("org.freedesktop.DBus.Peer.Ping", 0).Err
+	if err != nil {
+		logrus.Debugf("failed to reach systemd-resolved: %v", err)
+		return false
+	}
+
+	return true
+}
+
+// SetDNS configures the DNS servers and domains of the given link.
+// Search domains prefixed with a tilde (~) are configured as routing-only domains.
+func (r *ResolvedRepo) SetDNS(id domain.InterfaceIdentifier, dnsStr, dnsSearchStr string) error {
+	if dnsStr == "" && dnsSearchStr == "" {
+		return nil
+	}
+
+	ifIndex, err := r.linkIndex(string(id))
+	if err != nil {
+		return fmt.Errorf("failed to find link index of %s: %w", id, err)
+	}
+
+	dnsServers, err := parseResolvedDnsServers(internal.SliceString(dnsStr))
+	if err != nil {
+		return err
+	}
+	dnsDomains := parseResolvedDomains(internal.SliceString(dnsSearchStr))
+
+	logrus.Tracef("interface %s: setting resolved dns servers %v, domains %v", id, dnsServers, dnsDomains)
+
+	if err := r.bus.Call("SetLinkDNS", int32(ifIndex), dnsServers); err != nil {
+		return fmt.Errorf("failed to set link dns servers (is systemd-resolved running?): %w", err)
+	}
+	if err := r.bus.Call("SetLinkDomains", int32(ifIndex), dnsDomains); err != nil {
+		return fmt.Errorf("failed to set link dns domains: %w", err)
+	}
+	if len(dnsServers) > 0 {
+		// only route DNS queries without a matching domain through this link if it is a default route
+		if err := r.bus.Call("SetLinkDefaultRoute", int32(ifIndex), hasResolvedDefaultRoute(dnsDomains)); err != nil {
+			logrus.Warnf("failed to set dns default route for %s: %v", id, err)
+		}
+	}
+
+	return nil
+}
+
+// UnsetDNS resets all DNS settings of the given link.
+func (r *ResolvedRepo) UnsetDNS(id domain.InterfaceIdentifier) error {
+	ifIndex, err := r.linkIndex(string(id))
+	if err != nil {
+		return fmt.Errorf("failed to find link index of %s: %w", id, err)
+	}
+
+	if err := r.bus.Call("RevertLink", int32(ifIndex)); err != nil {
+		return fmt.Errorf("failed to unset link dns settings: %w", err)
+	}
+
+	return nil
+}
+
+func interfaceIndex(name string) (int, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return 0, err
+	}
+
+	return iface.Index, nil
+}
+
+func parseResolvedDnsServers(servers []string) ([]resolvedLinkDns, error) {
+	result := make([]resolvedLinkDns, 0, len(servers))
+	for _, server := range servers {
+		addr, err := netip.ParseAddr(server)
+		if err != nil {
+			return nil, fmt.Errorf("invalid dns server address %s: %w", server, err)
+		}
+
+		family := int32(10) // AF_INET6
+		if addr.Is4() {
+			family = 2 // AF_INET
+		}
+		result = append(result, resolvedLinkDns{Family: family, Address: addr.AsSlice()})
+	}
+
+	return result, nil
+}
+
+func parseResolvedDomains(domains []string) []resolvedLinkDomain {
+	result := make([]resolvedLinkDomain, 0, len(domains))
+	for _, d := range domains {
+		routingOnly := strings.HasPrefix(d, "~")
+		d = strings.TrimPrefix(d, "~")
+		if d == "" {
+			d = "." // a single tilde is the same as ~.
+		}
+		result = append(result, resolvedLinkDomain{Domain: d, RoutingOnly: routingOnly})
+	}
+
+	return result
+}
+
+func hasResolvedDefaultRoute(domains []resolvedLinkDomain) bool {
+	for _, d := range domains {
+		if d.Domain == "." {
+			return true
+		}
+	}
+
+	// without routing domains, the link acts like a classic resolvconf entry and receives all queries
+	for _, d := range domains {
+		if d.RoutingOnly {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,140 @@
+package adapters
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+type fakeResolvedCall struct {
+	method string
+	args   []any
+}
+
+type fakeResolvedBus struct {
+	calls []fakeResolvedCall
+	err   error
+}
+
+func (b *fakeResolvedBus) Call(method string, args ...any) error {
+	b.calls = append(b.calls, fakeResolvedCall{method: method, args: args})
+	return b.err
+}
+
+func fakeLinkIndex(name string) (int, error) {
+	if name == "wg0" {
+		return 7, nil
+	}
+	return 0, errors.New("link not found")
+}
+
+func TestResolvedRepo_SetDNS(t *testing.T) {
+	tests := []struct {
+		name         string
+		dnsStr       string
+		dnsSearchStr string
+		want         []fakeResolvedCall
+	}{
+		{
+			name: "nothing to set",
+			want: nil,
+		},
+		{
+			name:         "servers and search domain",
+			dnsStr:       "10.0.0.1, fd00::1",
+			dnsSearchStr: "example.com",
+			want: []fakeResolvedCall{
+				{method: "SetLinkDNS", args: []any{int32(7), []resolvedLinkDns{
+					{Family: 2, Address: []byte{10, 0, 0, 1}},
+					{Family: 10, Address: []byte{0xfd, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1}},
+				}}},
+				{method: "SetLinkDomains", args: []any{int32(7), []resolvedLinkDomain{
+					{Domain: "example.com", RoutingOnly: false},
+				}}},
+				{method: "SetLinkDefaultRoute", args: []any{int32(7), true}},
+			},
+		},
+		{
+			name:         "routing domains only",
+			dnsStr:       "10.0.0.1",
+			dnsSearchStr: "~corp.local,~internal",
+			want: []fakeResolvedCall{
+				{method: "SetLinkDNS", args: []any{int32(7), []resolvedLinkDns{
+					{Family: 2, Address: []byte{10, 0, 0, 1}},
+				}}},
+				{method: "SetLinkDomains", args: []any{int32(7), []resolvedLinkDomain{
+					{Domain: "corp.local", RoutingOnly: true},
+					{Domain: "internal", RoutingOnly: true},
+				}}},
+				{method: "SetLinkDefaultRoute", args: []any{int32(7), false}},
+			},
+		},
+		{
+			name:         "routing domain with default route",
+			dnsStr:       "10.0.0.1",
+			dnsSearchStr: "~corp.local,~.",
+			want: []fakeResolvedCall{
+				{method: "SetLinkDNS", args: []any{int32(7), []resolvedLinkDns{
+					{Family: 2, Address: []byte{10, 0, 0, 1}},
+				}}},
+				{method: "SetLinkDomains", args: []any{int32(7), []resolvedLinkDomain{
+					{Domain: "corp.local", RoutingOnly: true},
+					{Domain: ".", RoutingOnly: true},
+				}}},
+				{method: "SetLinkDefaultRoute", args: []any{int32(7), true}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := &fakeResolvedBus{}
+			r := newResolvedRepo(bus, fakeLinkIndex)
+
+			if err := r.SetDNS("wg0", tt.dnsStr, tt.dnsSearchStr); err != nil {
+				t.Fatalf("SetDNS() error = %v", err)
+			}
+			if !reflect.DeepEqual(bus.calls, tt.want) {
+				t.Errorf("SetDNS() calls = %v, want %v", bus.calls, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolvedRepo_SetDNS_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		id     string
+		dnsStr string
+		busErr error
+	}{
+		{name: "unknown link", id: "wg1", dnsStr: "10.0.0.1"},
+		{name: "invalid server", id: "wg0", dnsStr: "not-an-ip"},
+		{name: "bus failure", id: "wg0", dnsStr: "10.0.0.1", busErr: errors.New("no resolved")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := &fakeResolvedBus{err: tt.busErr}
+			r := newResolvedRepo(bus, fakeLinkIndex)
+
+			if err := r.SetDNS(domain.InterfaceIdentifier(tt.id), tt.dnsStr, ""); err == nil {
+				t.Errorf("SetDNS() expected error")
+			}
+		})
+	}
+}
+
+func TestResolvedRepo_UnsetDNS(t *testing.T) {
+	bus := &fakeResolvedBus{}
+	r := newResolvedRepo(bus, fakeLinkIndex)
+
+	if err := r.UnsetDNS("wg0"); err != nil {
+		t.Fatalf("UnsetDNS() error = %v", err)
+	}
+
+	want := []fakeResolvedCall{{method: "RevertLink", args: []any{int32(7)}}}
+	if !reflect.DeepEqual(bus.calls, want) {
+		t.Errorf("UnsetDNS() calls = %v, want %v", bus.calls, want)
+	}
+}
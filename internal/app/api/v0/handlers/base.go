@@ -2,17 +2,18 @@ package handlers
 
 import (
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-contrib/sessions"
-	"github.com/gin-contrib/sessions/memstore"
 	"github.com/gin-gonic/gin"
 	"github.com/h44z/wg-portal/internal/app"
 	"github.com/h44z/wg-portal/internal/app/api/core"
 	"github.com/h44z/wg-portal/internal/app/api/v0/model"
 	"github.com/h44z/wg-portal/internal/config"
 	csrf "github.com/utrack/gin-csrf"
+	evbus "github.com/vardius/message-bus"
 )
 
 type handler interface {
@@ -34,10 +35,13 @@ type handler interface {
 // @BasePath /api/v0
 // @query.collection.format multi
 
-func NewRestApi(cfg *config.Config, app *app.App) core.ApiEndpointSetupFunc {
+func NewRestApi(cfg *config.Config, app *app.App, bus evbus.MessageBus) core.ApiEndpointSetupFunc {
+	sessionStore := newMemorySessionStore(cfg.Web.SessionIdentifier, []byte(cfg.Web.SessionSecret))
+	ginSessionStore := GinSessionStore{sessionIdentifier: cfg.Web.SessionIdentifier, store: sessionStore}
+	ginSessionStore.connectToMessageBus(bus)
 	authenticator := &authenticationHandler{
 		app:     app,
-		Session: GinSessionStore{sessionIdentifier: cfg.Web.SessionIdentifier},
+		Session: ginSessionStore,
 	}
 
 	handlers := make([]handler, 0, 1)
@@ -50,17 +54,16 @@ func NewRestApi(cfg *config.Config, app *app.App) core.ApiEndpointSetupFunc {
 
 	return func() (core.ApiVersion, core.GroupSetupFn) {
 		return "v0", func(group *gin.RouterGroup) {
-			cookieStore := memstore.NewStore([]byte(cfg.Web.SessionSecret))
-			cookieStore.Options(sessions.Options{
+			sessionStore.Options(sessions.Options{
 				Path:     "/",
 				MaxAge:   86400, // auth session is valid for 1 day
 				Secure:   strings.HasPrefix(cfg.Web.ExternalUrl, "https"),
 				HttpOnly: true,
 				SameSite: http.SameSiteLaxMode,
 			})
-			group.Use(sessions.Sessions(cfg.Web.SessionIdentifier, cookieStore))
+			group.Use(sessions.Sessions(cfg.Web.SessionIdentifier, sessionStore))
 			group.Use(cors.Default())
-			group.Use(csrf.Middleware(csrf.Options{
+			group.Use(csrfMiddleware(group.BasePath(), csrf.Middleware(csrf.Options{
 				Secret: cfg.Web.CsrfSecret,
 				ErrorFunc: func(c *gin.Context) {
 					c.JSON(http.StatusBadRequest, model.Error{
@@ -69,7 +72,7 @@ func NewRestApi(cfg *config.Config, app *app.App) core.ApiEndpointSetupFunc {
 					})
 					c.Abort()
 				},
-			}))
+			})))
 
 			group.GET("/csrf", handleCsrfGet())
 
@@ -81,6 +84,23 @@ func NewRestApi(cfg *config.Config, app *app.App) core.ApiEndpointSetupFunc {
 	}
 }
 
+// csrfExemptRoutes contains routes that are called by external systems, which cannot provide a CSRF token.
+var csrfExemptRoutes = []string{
+	"/auth/login/:provider/backchannel-logout",
+}
+
+// csrfMiddleware applies the CSRF protection to all routes except the csrfExemptRoutes.
+func csrfMiddleware(basePath string, csrfHandler gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if slices.Contains(csrfExemptRoutes, strings.TrimPrefix(c.FullPath(), basePath)) {
+			c.Next()
+			return
+		}
+
+		csrfHandler(c)
+	}
+}
+
 // handleCsrfGet returns a gorm handler function.
 //
 // @ID base_handleCsrfGet
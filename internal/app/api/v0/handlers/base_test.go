@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCsrfMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	group := router.Group("/api/v0")
+	group.Use(csrfMiddleware(group.BasePath(), func(c *gin.Context) {
+		c.AbortWithStatus(http.StatusBadRequest) // rejects every request like a missing CSRF token
+	}))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	group.POST("/auth/login/:provider/backchannel-logout", ok)
+	group.POST("/auth/logout", ok)
+	group.POST("/auth/login/:provider/callback", ok)
+
+	tests := []struct {
+		name string
+		path string
+		want int
+	}{
+		{name: "back-channel logout", path: "/api/v0/auth/login/idp/backchannel-logout", want: http.StatusOK},
+		{name: "logout", path: "/api/v0/auth/logout", want: http.StatusBadRequest},
+		{name: "other provider route", path: "/api/v0/auth/login/idp/callback", want: http.StatusBadRequest},
+		{name: "exempt route path as parameter", path: "/api/v0/auth/login/backchannel-logout/callback",
+			want: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, tt.path, nil))
+			if rec.Code != tt.want {
+				t.Errorf("POST %s = %d, want %d", tt.path, rec.Code, tt.want)
+			}
+		})
+	}
+}
@@ -2,6 +2,9 @@ package handlers
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
+	"io"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -31,9 +34,32 @@ func (e authEndpoint) RegisterRoutes(g *gin.RouterGroup, authenticator *authenti
 
 	apiGroup.GET("/login/:provider/init", e.handleOauthInitiateGet())
 	apiGroup.GET("/login/:provider/callback", e.handleOauthCallbackGet())
+	apiGroup.POST("/login/:provider/backchannel-logout", e.handleOidcBackChannelLogoutPost())
 
 	apiGroup.POST("/login", e.handleLoginPost())
+	apiGroup.POST("/login/totp", e.handleLoginTotpPost())
 	apiGroup.POST("/logout", authenticator.LoggedIn(), e.handleLogoutPost())
+
+	apiGroup.POST("/password-reset/request", e.handlePasswordResetRequestPost())
+	apiGroup.POST("/password-reset", e.handlePasswordResetPost())
+	apiGroup.POST("/invitation", e.handleInvitationPost())
+	apiGroup.POST("/signup", e.handleSignupPost())
+	apiGroup.POST("/signup/verify", e.handleSignupVerifyPost())
+
+	apiGroup.POST("/totp/setup", e.handleTotpSetupPost())
+	apiGroup.POST("/totp/activate", e.handleTotpActivatePost())
+	apiGroup.POST("/totp/disable", authenticator.LoggedIn(), e.handleTotpDisablePost())
+
+	apiGroup.POST("/login/webauthn/init", e.handleWebauthnLoginInitPost())
+	apiGroup.POST("/login/webauthn", e.handleWebauthnLoginPost())
+
+	apiGroup.GET("/webauthn/credentials", authenticator.LoggedIn(), e.handleWebauthnCredentialsGet())
+	apiGroup.POST("/webauthn/credentials/init", authenticator.LoggedIn(), e.handleWebauthnRegistrationInitPost())
+	apiGroup.POST("/webauthn/credentials", authenticator.LoggedIn(), e.handleWebauthnRegistrationPost())
+	apiGroup.DELETE("/webauthn/credentials/:id", authenticator.LoggedIn(), e.handleWebauthnCredentialDelete())
+
+	apiGroup.GET("/locks", authenticator.LoggedIn(ScopeUsersRead), e.handleLoginLocksGet())
+	apiGroup.DELETE("/locks/:type/:id", authenticator.LoggedIn(ScopeUsersWrite), e.handleLoginLockDelete())
 }
 
 // handleExternalLoginProvidersGet returns a gorm handler function.
@@ -83,12 +109,18 @@ func (e authEndpoint) handleSessionInfoGet() gin.HandlerFunc {
 		}
 
 		c.JSON(http.StatusOK, model.SessionInfo{
-			LoggedIn:       currentSession.LoggedIn,
-			IsAdmin:        currentSession.IsAdmin,
-			UserIdentifier: loggedInUid,
-			UserFirstname:  firstname,
-			UserLastname:   lastname,
-			UserEmail:      email,
+			LoggedIn:          currentSession.LoggedIn,
+			IsAdmin:           currentSession.IsAdmin,
+			Role:              currentSession.Role,
+			ManagedInterfaces: currentSession.ManagedInterfaces,
+			Permissions:       sessionUserPermissions(currentSession),
+			UserIdentifier:    loggedInUid,
+			UserFirstname:     firstname,
+			UserLastname:      lastname,
+			UserEmail:         email,
+
+			SecondFactorPending:    currentSession.HasPendingSecondFactor(domain.SecondFactorStateVerify),
+			SecondFactorEnrollment: currentSession.HasPendingSecondFactor(domain.SecondFactorStateEnrollment),
 		})
 	}
 }
@@ -142,7 +174,7 @@ func (e authEndpoint) handleOauthInitiateGet() gin.HandlerFunc {
 		}
 
 		ctx := domain.SetUserInfoFromGin(c)
-		authCodeUrl, state, nonce, err := e.app.Authenticator.OauthLoginStep1(ctx, provider)
+		authCodeUrl, state, nonce, verifier, err := e.app.Authenticator.OauthLoginStep1(ctx, provider)
 		if err != nil {
 			if autoRedirect && e.isValidReturnUrl(returnTo) {
 				redirectToReturn()
@@ -156,6 +188,7 @@ func (e authEndpoint) handleOauthInitiateGet() gin.HandlerFunc {
 		authSession := e.authenticator.Session.DefaultSessionData()
 		authSession.OauthState = state
 		authSession.OauthNonce = nonce
+		authSession.OauthVerifier = verifier
 		authSession.OauthProvider = provider
 		authSession.OauthReturnTo = returnTo
 		e.authenticator.Session.SetData(c, authSession)
@@ -233,8 +266,9 @@ func (e authEndpoint) handleOauthCallbackGet() gin.HandlerFunc {
 			return
 		}
 
-		loginCtx, cancel := context.WithTimeout(context.Background(), 1000*time.Second)
-		user, err := e.app.Authenticator.OauthLoginStep2(loginCtx, provider, currentSession.OauthNonce, oauthCode)
+		loginCtx, cancel := context.WithTimeout(domain.SetUserInfoFromGin(c), 1000*time.Second)
+		user, oidcSession, err := e.app.Authenticator.OauthLoginStep2(loginCtx, provider, currentSession.OauthNonce,
+			currentSession.OauthVerifier, oauthCode)
 		cancel()
 		if err != nil {
 			if returnUrl != nil && e.isValidReturnUrl(returnUrl.String()) {
@@ -246,6 +280,9 @@ func (e authEndpoint) handleOauthCallbackGet() gin.HandlerFunc {
 		}
 
 		e.setAuthenticatedUser(c, user)
+		if oidcSession != nil {
+			e.setOidcSession(c, oidcSession)
+		}
 
 		if returnUrl != nil && e.isValidReturnUrl(returnUrl.String()) {
 			queryParams := returnUrl.Query()
@@ -263,6 +300,11 @@ func (e authEndpoint) setAuthenticatedUser(c *gin.Context, user *domain.User) {
 
 	currentSession.LoggedIn = true
 	currentSession.IsAdmin = user.IsAdmin
+	currentSession.Role = string(user.EffectiveRole())
+	currentSession.ManagedInterfaces = make([]string, len(user.ManagedInterfaces))
+	for i, id := range user.ManagedInterfaces {
+		currentSession.ManagedInterfaces[i] = string(id)
+	}
 	currentSession.UserIdentifier = string(user.Identifier)
 	currentSession.Firstname = user.Firstname
 	currentSession.Lastname = user.Lastname
@@ -270,19 +312,110 @@ func (e authEndpoint) setAuthenticatedUser(c *gin.Context, user *domain.User) {
 
 	currentSession.OauthState = ""
 	currentSession.OauthNonce = ""
+	currentSession.OauthVerifier = ""
 	currentSession.OauthProvider = ""
 	currentSession.OauthReturnTo = ""
 
+	currentSession.OidcProvider = ""
+	currentSession.OidcSubject = ""
+	currentSession.OidcSessionId = ""
+	currentSession.OidcIdToken = ""
+
+	currentSession.SecondFactorUser = ""
+	currentSession.SecondFactorState = ""
+	currentSession.SecondFactorSince = time.Time{}
+
+	currentSession.WebauthnSession = ""
+
+	e.authenticator.Session.SetData(c, currentSession)
+}
+
+// setOidcSession stores the session at the OpenID Connect provider, so that it can be ended on logout.
+func (e authEndpoint) setOidcSession(c *gin.Context, session *domain.OidcSession) {
+	currentSession := e.authenticator.Session.GetData(c)
+
+	currentSession.OidcProvider = session.Provider
+	currentSession.OidcSubject = session.Subject
+	currentSession.OidcSessionId = session.SessionId
+	currentSession.OidcIdToken = session.IdToken
+
+	e.authenticator.Session.SetData(c, currentSession)
+}
+
+// setSecondFactorPending stores the user that passed the password login but still has to complete the second factor.
+func (e authEndpoint) setSecondFactorPending(c *gin.Context, user *domain.User, state domain.SecondFactorState) {
+	currentSession := e.authenticator.Session.DefaultSessionData()
+
+	currentSession.SecondFactorUser = string(user.Identifier)
+	currentSession.SecondFactorState = string(state)
+	currentSession.SecondFactorSince = time.Now()
+
 	e.authenticator.Session.SetData(c, currentSession)
 }
 
+// totpUserContext returns the user context for TOTP management requests. Besides logged-in users, sessions that
+// wait for the mandatory TOTP enrollment are allowed.
+func (e authEndpoint) totpUserContext(c *gin.Context) (ctx context.Context, enrollment bool, ok bool) {
+	currentSession := e.authenticator.Session.GetData(c)
+
+	var userInfo *domain.ContextUserInfo
+	switch {
+	case currentSession.LoggedIn:
+		userInfo = sessionUserInfo(currentSession)
+	case currentSession.HasPendingSecondFactor(domain.SecondFactorStateEnrollment):
+		userInfo = &domain.ContextUserInfo{
+			Id:      domain.UserIdentifier(currentSession.SecondFactorUser),
+			IsAdmin: false,
+			Role:    domain.RoleUser,
+		}
+		enrollment = true
+	default:
+		return nil, false, false
+	}
+	userInfo.ClientIp = c.ClientIP()
+
+	return domain.SetUserInfo(c.Request.Context(), userInfo), enrollment, true
+}
+
+// writeLoginError hides the reason of a failed login, only throttled attempts are reported.
+func (e authEndpoint) writeLoginError(c *gin.Context, err error) {
+	if errors.Is(err, domain.ErrTooManyAttempts) {
+		c.JSON(http.StatusTooManyRequests, model.Error{Code: http.StatusTooManyRequests,
+			Message: "too many failed login attempts, try again later"})
+		return
+	}
+
+	c.JSON(http.StatusUnauthorized, model.Error{Code: http.StatusUnauthorized, Message: "login failed"})
+}
+
+// writeServiceError maps errors of the TOTP and WebAuthn management functions to the corresponding HTTP status.
+func (e authEndpoint) writeServiceError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidData):
+		c.JSON(http.StatusBadRequest, model.Error{Code: http.StatusBadRequest, Message: err.Error()})
+	case errors.Is(err, domain.ErrNoPermission):
+		c.JSON(http.StatusForbidden, model.Error{Code: http.StatusForbidden, Message: err.Error()})
+	case errors.Is(err, domain.ErrNotFound):
+		c.JSON(http.StatusNotFound, model.Error{Code: http.StatusNotFound, Message: err.Error()})
+	case errors.Is(err, domain.ErrDuplicateEntry):
+		c.JSON(http.StatusConflict, model.Error{Code: http.StatusConflict, Message: err.Error()})
+	case errors.Is(err, domain.ErrTooManyAttempts):
+		c.JSON(http.StatusTooManyRequests, model.Error{Code: http.StatusTooManyRequests, Message: err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, model.Error{Code: http.StatusInternalServerError, Message: err.Error()})
+	}
+}
+
 // handleLoginPost returns a gorm handler function.
 //
 // @ID auth_handleLoginPost
 // @Tags Authentication
-// @Summary Get all available external login providers.
+// @Summary Login with username and password.
+// @Description If a second factor is required, the login is completed using /auth/login/totp or /auth/totp/activate.
 // @Produce json
-// @Success 200 {object} []model.LoginProviderInfo
+// @Success 200 {object} domain.User
+// @Success 202 {object} model.LoginChallenge
+// @Failure 401 {object} model.Error
 // @Router /auth/login [post]
 func (e authEndpoint) handleLoginPost() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -305,7 +438,59 @@ func (e authEndpoint) handleLoginPost() gin.HandlerFunc {
 		ctx := domain.SetUserInfoFromGin(c)
 		user, err := e.app.Authenticator.PlainLogin(ctx, loginData.Username, loginData.Password)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, model.Error{Code: http.StatusUnauthorized, Message: "login failed"})
+			e.writeLoginError(c, err)
+			return
+		}
+
+		if state := e.app.Authenticator.SecondFactorState(user); state != domain.SecondFactorStateNone {
+			e.setSecondFactorPending(c, user, state)
+			c.JSON(http.StatusAccepted, model.LoginChallenge{
+				SecondFactorRequired: state == domain.SecondFactorStateVerify,
+				EnrollmentRequired:   state == domain.SecondFactorStateEnrollment,
+			})
+			return
+		}
+
+		e.setAuthenticatedUser(c, user)
+
+		c.JSON(http.StatusOK, user)
+	}
+}
+
+// handleLoginTotpPost returns a gorm handler function.
+//
+// @ID auth_handleLoginTotpPost
+// @Tags Authentication
+// @Summary Complete the password login with a TOTP or recovery code.
+// @Produce json
+// @Param request body model.TotpCode true "The TOTP or recovery code"
+// @Success 200 {object} domain.User
+// @Failure 400 {object} model.Error
+// @Failure 401 {object} model.Error
+// @Router /auth/login/totp [post]
+func (e authEndpoint) handleLoginTotpPost() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		currentSession := e.authenticator.Session.GetData(c)
+		if currentSession.LoggedIn {
+			c.JSON(http.StatusOK, model.Error{Code: http.StatusOK, Message: "already logged in"})
+			return
+		}
+		if !currentSession.HasPendingSecondFactor(domain.SecondFactorStateVerify) {
+			c.JSON(http.StatusUnauthorized, model.Error{Code: http.StatusUnauthorized, Message: "no pending login"})
+			return
+		}
+
+		var req model.TotpCode
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, model.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+
+		ctx := domain.SetUserInfoFromGin(c)
+		user, err := e.app.Authenticator.SecondFactorLogin(ctx,
+			domain.UserIdentifier(currentSession.SecondFactorUser), req.Code)
+		if err != nil {
+			e.writeLoginError(c, err)
 			return
 		}
 
@@ -315,25 +500,609 @@ func (e authEndpoint) handleLoginPost() gin.HandlerFunc {
 	}
 }
 
+// handleTotpSetupPost returns a gorm handler function.
+//
+// @ID auth_handleTotpSetupPost
+// @Tags Authentication
+// @Summary Generate a new TOTP secret for the current user.
+// @Produce json
+// @Success 200 {object} model.TotpEnrollment
+// @Failure 400 {object} model.Error
+// @Failure 401 {object} model.Error
+// @Failure 500 {object} model.Error
+// @Router /auth/totp/setup [post]
+func (e authEndpoint) handleTotpSetupPost() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, _, ok := e.totpUserContext(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, model.Error{Code: http.StatusUnauthorized, Message: "not logged in"})
+			return
+		}
+
+		enrollment, err := e.app.SetupTotp(ctx, domain.GetUserInfo(ctx).Id)
+		if err != nil {
+			e.writeServiceError(c, err)
+			return
+		}
+
+		qrCode, err := e.app.GetQrCode(enrollment.ProvisioningUri)
+		if err != nil {
+			e.writeServiceError(c, err)
+			return
+		}
+		qrCodeData, err := io.ReadAll(qrCode)
+		if err != nil {
+			e.writeServiceError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, model.NewTotpEnrollment(enrollment,
+			"data:image/png;base64,"+base64.StdEncoding.EncodeToString(qrCodeData)))
+	}
+}
+
+// handleTotpActivatePost returns a gorm handler function.
+//
+// @ID auth_handleTotpActivatePost
+// @Tags Authentication
+// @Summary Enable TOTP for the current user by verifying the first code.
+// @Description If the session waits for the mandatory TOTP enrollment, the login is completed.
+// @Produce json
+// @Param request body model.TotpCode true "The current TOTP code"
+// @Success 200 {object} model.TotpActivation
+// @Failure 400 {object} model.Error
+// @Failure 401 {object} model.Error
+// @Failure 500 {object} model.Error
+// @Router /auth/totp/activate [post]
+func (e authEndpoint) handleTotpActivatePost() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, enrollment, ok := e.totpUserContext(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, model.Error{Code: http.StatusUnauthorized, Message: "not logged in"})
+			return
+		}
+
+		var req model.TotpCode
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, model.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+
+		userId := domain.GetUserInfo(ctx).Id
+		recoveryCodes, err := e.app.ActivateTotp(ctx, userId, req.Code)
+		if err != nil {
+			e.writeServiceError(c, err)
+			return
+		}
+
+		if enrollment {
+			user, err := e.app.Authenticator.CompleteEnrollmentLogin(ctx, userId)
+			if err != nil {
+				c.JSON(http.StatusUnauthorized, model.Error{Code: http.StatusUnauthorized, Message: "login failed"})
+				return
+			}
+			e.setAuthenticatedUser(c, user)
+		}
+
+		c.JSON(http.StatusOK, model.TotpActivation{RecoveryCodes: recoveryCodes})
+	}
+}
+
+// handleTotpDisablePost returns a gorm handler function.
+//
+// @ID auth_handleTotpDisablePost
+// @Tags Authentication
+// @Summary Disable TOTP for the current user.
+// @Produce json
+// @Param request body model.TotpCode true "The current TOTP or a recovery code"
+// @Success 204 "No content if TOTP was disabled successfully"
+// @Failure 400 {object} model.Error
+// @Failure 403 {object} model.Error
+// @Failure 500 {object} model.Error
+// @Router /auth/totp/disable [post]
+func (e authEndpoint) handleTotpDisablePost() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req model.TotpCode
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, model.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+
+		ctx := domain.SetUserInfoFromGin(c)
+		if err := e.app.DisableTotp(ctx, domain.GetUserInfo(ctx).Id, req.Code); err != nil {
+			e.writeServiceError(c, err)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// handleWebauthnLoginInitPost returns a gorm handler function.
+//
+// @ID auth_handleWebauthnLoginInitPost
+// @Tags Authentication
+// @Summary Start a login with a passkey or security key.
+// @Description Without a pending password login, a passwordless login is started. Otherwise, the passkey is used
+// @Description as second factor for the pending password login.
+// @Produce json
+// @Success 200 {object} object "The options for navigator.credentials.get()"
+// @Failure 400 {object} model.Error
+// @Failure 500 {object} model.Error
+// @Router /auth/login/webauthn/init [post]
+func (e authEndpoint) handleWebauthnLoginInitPost() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		currentSession := e.authenticator.Session.GetData(c)
+		if currentSession.LoggedIn {
+			c.JSON(http.StatusOK, model.Error{Code: http.StatusOK, Message: "already logged in"})
+			return
+		}
+
+		ctx := domain.SetUserInfoFromGin(c)
+
+		var challenge *domain.WebauthnChallenge
+		var err error
+		if e.hasPendingSecondFactor(currentSession) {
+			challenge, err = e.app.Authenticator.StartWebauthnSecondFactor(ctx,
+				domain.UserIdentifier(currentSession.SecondFactorUser))
+		} else {
+			challenge, err = e.app.Authenticator.StartWebauthnLogin(ctx)
+		}
+		if err != nil {
+			e.writeServiceError(c, err)
+			return
+		}
+
+		currentSession.WebauthnSession = challenge.Session
+		e.authenticator.Session.SetData(c, currentSession)
+
+		c.JSON(http.StatusOK, challenge.Options)
+	}
+}
+
+// handleWebauthnLoginPost returns a gorm handler function.
+//
+// @ID auth_handleWebauthnLoginPost
+// @Tags Authentication
+// @Summary Complete a login with a passkey or security key.
+// @Accept json
+// @Produce json
+// @Param request body object true "The result of navigator.credentials.get()"
+// @Success 200 {object} domain.User
+// @Failure 400 {object} model.Error
+// @Failure 401 {object} model.Error
+// @Router /auth/login/webauthn [post]
+func (e authEndpoint) handleWebauthnLoginPost() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		currentSession := e.authenticator.Session.GetData(c)
+		if currentSession.LoggedIn {
+			c.JSON(http.StatusOK, model.Error{Code: http.StatusOK, Message: "already logged in"})
+			return
+		}
+
+		response, err := c.GetRawData()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, model.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+
+		// the ceremony state can only be used once
+		webauthnSession := currentSession.WebauthnSession
+		currentSession.WebauthnSession = ""
+		e.authenticator.Session.SetData(c, currentSession)
+
+		ctx := domain.SetUserInfoFromGin(c)
+
+		var user *domain.User
+		if e.hasPendingSecondFactor(currentSession) {
+			user, err = e.app.Authenticator.WebauthnSecondFactorLogin(ctx,
+				domain.UserIdentifier(currentSession.SecondFactorUser), webauthnSession, response)
+		} else {
+			user, err = e.app.Authenticator.WebauthnLogin(ctx, webauthnSession, response)
+		}
+		if err != nil {
+			e.writeLoginError(c, err)
+			return
+		}
+
+		e.setAuthenticatedUser(c, user)
+
+		c.JSON(http.StatusOK, user)
+	}
+}
+
+// hasPendingSecondFactor returns true if the session waits for any second factor. A passkey can be used instead of
+// the TOTP code and also satisfies a mandatory TOTP enrollment.
+func (e authEndpoint) hasPendingSecondFactor(session SessionData) bool {
+	return session.HasPendingSecondFactor(domain.SecondFactorStateVerify) ||
+		session.HasPendingSecondFactor(domain.SecondFactorStateEnrollment)
+}
+
+// handleWebauthnCredentialsGet returns a gorm handler function.
+//
+// @ID auth_handleWebauthnCredentialsGet
+// @Tags Authentication
+// @Summary Get all passkeys and security keys of the current user.
+// @Produce json
+// @Success 200 {object} []model.WebauthnCredential
+// @Failure 500 {object} model.Error
+// @Router /auth/webauthn/credentials [get]
+func (e authEndpoint) handleWebauthnCredentialsGet() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := domain.SetUserInfoFromGin(c)
+
+		credentials, err := e.app.Authenticator.GetWebauthnCredentials(ctx, domain.GetUserInfo(ctx).Id)
+		if err != nil {
+			e.writeServiceError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, model.NewWebauthnCredentials(credentials))
+	}
+}
+
+// handleWebauthnRegistrationInitPost returns a gorm handler function.
+//
+// @ID auth_handleWebauthnRegistrationInitPost
+// @Tags Authentication
+// @Summary Start the registration of a new passkey or security key for the current user.
+// @Produce json
+// @Success 200 {object} object "The options for navigator.credentials.create()"
+// @Failure 400 {object} model.Error
+// @Failure 500 {object} model.Error
+// @Router /auth/webauthn/credentials/init [post]
+func (e authEndpoint) handleWebauthnRegistrationInitPost() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := domain.SetUserInfoFromGin(c)
+
+		challenge, err := e.app.Authenticator.StartWebauthnRegistration(ctx, domain.GetUserInfo(ctx).Id)
+		if err != nil {
+			e.writeServiceError(c, err)
+			return
+		}
+
+		currentSession := e.authenticator.Session.GetData(c)
+		currentSession.WebauthnSession = challenge.Session
+		e.authenticator.Session.SetData(c, currentSession)
+
+		c.JSON(http.StatusOK, challenge.Options)
+	}
+}
+
+// handleWebauthnRegistrationPost returns a gorm handler function.
+//
+// @ID auth_handleWebauthnRegistrationPost
+// @Tags Authentication
+// @Summary Complete the registration of a new passkey or security key for the current user.
+// @Accept json
+// @Produce json
+// @Param request body model.WebauthnRegistration true "The credential name and the result of navigator.credentials.create()"
+// @Success 200 {object} model.WebauthnCredential
+// @Failure 400 {object} model.Error
+// @Failure 409 {object} model.Error
+// @Failure 500 {object} model.Error
+// @Router /auth/webauthn/credentials [post]
+func (e authEndpoint) handleWebauthnRegistrationPost() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req model.WebauthnRegistration
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, model.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+
+		// the ceremony state can only be used once
+		currentSession := e.authenticator.Session.GetData(c)
+		webauthnSession := currentSession.WebauthnSession
+		currentSession.WebauthnSession = ""
+		e.authenticator.Session.SetData(c, currentSession)
+
+		ctx := domain.SetUserInfoFromGin(c)
+		credential, err := e.app.Authenticator.FinishWebauthnRegistration(ctx, domain.GetUserInfo(ctx).Id,
+			req.Name, webauthnSession, req.Credential)
+		if err != nil {
+			e.writeServiceError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, model.NewWebauthnCredential(credential))
+	}
+}
+
+// handleWebauthnCredentialDelete returns a gorm handler function.
+//
+// @ID auth_handleWebauthnCredentialDelete
+// @Tags Authentication
+// @Summary Revoke a passkey or security key of the current user.
+// @Produce json
+// @Param id path string true "The credential identifier"
+// @Success 204 "No content if the credential was revoked successfully"
+// @Failure 404 {object} model.Error
+// @Failure 500 {object} model.Error
+// @Router /auth/webauthn/credentials/{id} [delete]
+func (e authEndpoint) handleWebauthnCredentialDelete() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := domain.SetUserInfoFromGin(c)
+
+		err := e.app.Authenticator.DeleteWebauthnCredential(ctx, domain.GetUserInfo(ctx).Id, c.Param("id"))
+		if err != nil {
+			e.writeServiceError(c, err)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// handlePasswordResetRequestPost returns a gorm handler function.
+//
+// @ID auth_handlePasswordResetRequestPost
+// @Tags Authentication
+// @Summary Request a password reset link for a database user. The response does not reveal if the user exists.
+// @Produce json
+// @Param request body model.PasswordResetRequest true "The username or email address"
+// @Success 204 "No content if the request was accepted"
+// @Failure 400 {object} model.Error
+// @Failure 403 {object} model.Error
+// @Failure 429 {object} model.Error
+// @Router /auth/password-reset/request [post]
+func (e authEndpoint) handlePasswordResetRequestPost() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req model.PasswordResetRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, model.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+
+		ctx := domain.SetUserInfoFromGin(c)
+		if err := e.app.Authenticator.RequestPasswordReset(ctx, req.Identifier); err != nil {
+			e.writeServiceError(c, err)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// handlePasswordResetPost returns a gorm handler function.
+//
+// @ID auth_handlePasswordResetPost
+// @Tags Authentication
+// @Summary Set a new password using the token from a password reset mail.
+// @Produce json
+// @Param request body model.PasswordReset true "The reset token and the new password"
+// @Success 204 "No content if the password was changed"
+// @Failure 400 {object} model.Error
+// @Failure 403 {object} model.Error
+// @Failure 429 {object} model.Error
+// @Router /auth/password-reset [post]
+func (e authEndpoint) handlePasswordResetPost() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req model.PasswordReset
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, model.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+
+		ctx := domain.SetUserInfoFromGin(c)
+		if err := e.app.Authenticator.ResetPassword(ctx, req.Token, req.Password); err != nil {
+			e.writeServiceError(c, err)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// handleInvitationPost returns a gorm handler function.
+//
+// @ID auth_handleInvitationPost
+// @Tags Authentication
+// @Summary Accept an invitation by choosing a password using the token from the invitation mail.
+// @Produce json
+// @Param request body model.InvitationActivation true "The invitation token and the new password"
+// @Success 204 "No content if the account was activated"
+// @Failure 400 {object} model.Error
+// @Failure 429 {object} model.Error
+// @Router /auth/invitation [post]
+func (e authEndpoint) handleInvitationPost() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req model.InvitationActivation
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, model.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+
+		ctx := domain.SetUserInfoFromGin(c)
+		if err := e.app.Authenticator.AcceptInvitation(ctx, req.Token, req.Password); err != nil {
+			e.writeServiceError(c, err)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// handleSignupPost returns a gorm handler function.
+//
+// @ID auth_handleSignupPost
+// @Tags Authentication
+// @Summary Sign up as a new database user. A verification link is sent to the email address.
+// @Produce json
+// @Param request body model.UserSignup true "The registration data"
+// @Success 204 "No content if the registration was accepted"
+// @Failure 400 {object} model.Error
+// @Failure 403 {object} model.Error
+// @Failure 429 {object} model.Error
+// @Router /auth/signup [post]
+func (e authEndpoint) handleSignupPost() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req model.UserSignup
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, model.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+
+		ctx := domain.SetUserInfoFromGin(c)
+		if err := e.app.Authenticator.SignupUser(ctx, model.NewDomainSignupUser(&req)); err != nil {
+			e.writeServiceError(c, err)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// handleSignupVerifyPost returns a gorm handler function.
+//
+// @ID auth_handleSignupVerifyPost
+// @Tags Authentication
+// @Summary Verify the email address of a new registration using the token from the verification mail.
+// @Produce json
+// @Param request body model.SignupVerification true "The verification token"
+// @Success 204 "No content if the email address was verified"
+// @Failure 400 {object} model.Error
+// @Failure 429 {object} model.Error
+// @Router /auth/signup/verify [post]
+func (e authEndpoint) handleSignupVerifyPost() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req model.SignupVerification
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, model.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+
+		ctx := domain.SetUserInfoFromGin(c)
+		if err := e.app.Authenticator.VerifySignup(ctx, req.Token); err != nil {
+			e.writeServiceError(c, err)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// handleLoginLocksGet returns a gorm handler function.
+//
+// @ID auth_handleLoginLocksGet
+// @Tags Authentication
+// @Summary Get all users and source IPs that are locked because of too many failed login attempts.
+// @Produce json
+// @Success 200 {object} []model.LoginLock
+// @Failure 500 {object} model.Error
+// @Router /auth/locks [get]
+func (e authEndpoint) handleLoginLocksGet() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := domain.SetUserInfoFromGin(c)
+
+		locks, err := e.app.Authenticator.GetLoginLocks(ctx)
+		if err != nil {
+			e.writeServiceError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, model.NewLoginLocks(locks))
+	}
+}
+
+// handleLoginLockDelete returns a gorm handler function.
+//
+// @ID auth_handleLoginLockDelete
+// @Tags Authentication
+// @Summary Unlock a user or source IP and reset the failed login attempts.
+// @Produce json
+// @Param type path string true "The lock type (user or ip)"
+// @Param id path string true "The base64 url encoded user identifier or source IP address"
+// @Success 204 "No content if the lock was cleared successfully"
+// @Failure 400 {object} model.Error
+// @Failure 404 {object} model.Error
+// @Failure 500 {object} model.Error
+// @Router /auth/locks/{type}/{id} [delete]
+func (e authEndpoint) handleLoginLockDelete() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := domain.SetUserInfoFromGin(c)
+
+		id := Base64UrlDecode(c.Param("id"))
+		if id == "" {
+			c.JSON(http.StatusBadRequest, model.Error{Code: http.StatusBadRequest, Message: "missing lock id"})
+			return
+		}
+
+		err := e.app.Authenticator.ClearLoginLock(ctx, domain.LoginLockType(c.Param("type")), id)
+		if err != nil {
+			e.writeServiceError(c, err)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
 // handleLogoutPost returns a gorm handler function.
 //
 // @ID auth_handleLogoutGet
 // @Tags Authentication
-// @Summary Get all available external login providers.
+// @Summary Logout the current user.
+// @Description If the user logged in using an OpenID Connect provider that supports RP-initiated logout, the returned
+// @Description RedirectUrl ends the session at the provider. The provider redirects back to the return URL afterwards.
+// @Param return query string false "the URL the provider redirects to after the logout"
 // @Produce json
-// @Success 200 {object} []model.LoginProviderInfo
-// @Router /auth/logout [get]
+// @Success 200 {object} model.LogoutResult
+// @Router /auth/logout [post]
 func (e authEndpoint) handleLogoutPost() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		currentSession := e.authenticator.Session.GetData(c)
 
 		if !currentSession.LoggedIn { // Not logged in
-			c.JSON(http.StatusOK, model.Error{Code: http.StatusOK, Message: "not logged in"})
+			c.JSON(http.StatusOK, model.LogoutResult{Code: http.StatusOK, Message: "not logged in"})
 			return
 		}
 
 		e.authenticator.Session.DestroyData(c)
-		c.JSON(http.StatusOK, model.Error{Code: http.StatusOK, Message: "logout ok"})
+
+		var redirectUrl string
+		if currentSession.OidcProvider != "" {
+			returnUrl := e.app.Config.Web.ExternalUrl
+			if returnTo := c.Query("return"); returnTo != "" && e.isValidReturnUrl(returnTo) {
+				returnUrl = returnTo
+			}
+			redirectUrl = e.app.Authenticator.OidcLogoutUrl(c.Request.Context(), &domain.OidcSession{
+				Provider:  currentSession.OidcProvider,
+				Subject:   currentSession.OidcSubject,
+				SessionId: currentSession.OidcSessionId,
+				IdToken:   currentSession.OidcIdToken,
+			}, returnUrl)
+		}
+
+		c.JSON(http.StatusOK, model.LogoutResult{Code: http.StatusOK, Message: "logout ok", RedirectUrl: redirectUrl})
+	}
+}
+
+// handleOidcBackChannelLogoutPost returns a gorm handler function.
+//
+// @ID auth_handleOidcBackChannelLogoutPost
+// @Tags Authentication
+// @Summary Handle the back-channel logout of an OpenID Connect provider.
+// @Description Destroys all sessions that belong to the session that was ended at the provider.
+// @Accept x-www-form-urlencoded
+// @Param provider path string true "Provider Identifier"
+// @Param logout_token formData string true "The logout token"
+// @Success 200
+// @Failure 400 {object} model.Error
+// @Router /auth/login/{provider}/backchannel-logout [post]
+func (e authEndpoint) handleOidcBackChannelLogoutPost() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "no-store")
+
+		provider := c.Param("provider")
+		session, err := e.app.Authenticator.OidcBackChannelLogout(c.Request.Context(), provider,
+			c.PostForm("logout_token"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, model.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+
+		e.authenticator.Session.DestroyOidcSessions(session.Provider, session.Subject, session.SessionId)
+		c.Status(http.StatusOK)
 	}
 }
 
@@ -69,10 +69,13 @@ func (e configEndpoint) handleConfigJsGet() gin.HandlerFunc {
 		}
 		buf := &bytes.Buffer{}
 		err := e.tpl.ExecuteTemplate(buf, "frontend_config.js.gotpl", gin.H{
-			"BackendUrl":      backendUrl,
-			"Version":         "unknown",
-			"SiteTitle":       e.app.Config.Web.SiteTitle,
-			"SiteCompanyName": e.app.Config.Web.SiteCompanyName,
+			"BackendUrl":           backendUrl,
+			"Version":              "unknown",
+			"SiteTitle":            e.app.Config.Web.SiteTitle,
+			"SiteCompanyName":      e.app.Config.Web.SiteCompanyName,
+			"WebauthnEnabled":      e.app.Authenticator.IsWebauthnEnabled(),
+			"PasswordResetEnabled": e.app.Config.Auth.PasswordResetEnabled,
+			"SignupEnabled":        e.app.Config.Auth.SignupEnabled,
 		})
 		if err != nil {
 			c.Status(http.StatusInternalServerError)
@@ -99,6 +102,9 @@ func (e configEndpoint) handleSettingsGet() gin.HandlerFunc {
 			PersistentConfigSupported: e.app.Config.Advanced.ConfigStoragePath != "",
 			SelfProvisioning:          e.app.Config.Core.SelfProvisioningAllowed,
 			ApiAdminOnly:              e.app.Config.Advanced.ApiAdminOnly,
+			WebauthnEnabled:           e.app.Authenticator.IsWebauthnEnabled(),
+			PasswordResetEnabled:      e.app.Config.Auth.PasswordResetEnabled,
+			SignupEnabled:             e.app.Config.Auth.SignupEnabled,
 		})
 	}
 }
@@ -19,19 +19,20 @@ func (e interfaceEndpoint) GetName() string {
 }
 
 func (e interfaceEndpoint) RegisterRoutes(g *gin.RouterGroup, authenticator *authenticationHandler) {
-	apiGroup := g.Group("/interface", e.authenticator.LoggedIn(ScopeAdmin))
+	apiGroup := g.Group("/interface", e.authenticator.LoggedIn(ScopeInterfacesRead))
+	writeAccess := e.authenticator.LoggedIn(ScopeInterfacesWrite)
 
-	apiGroup.GET("/prepare", e.handlePrepareGet())
+	apiGroup.GET("/prepare", writeAccess, e.handlePrepareGet())
 	apiGroup.GET("/all", e.handleAllGet())
 	apiGroup.GET("/get/:id", e.handleSingleGet())
-	apiGroup.PUT("/:id", e.handleUpdatePut())
-	apiGroup.DELETE("/:id", e.handleDelete())
-	apiGroup.POST("/new", e.handleCreatePost())
-	apiGroup.GET("/config/:id", e.handleConfigGet())
-	apiGroup.POST("/:id/save-config", e.handleSaveConfigPost())
-	apiGroup.POST("/:id/apply-peer-defaults", e.handleApplyPeerDefaultsPost())
-
-	apiGroup.GET("/peers/:id", e.handlePeersGet())
+	apiGroup.PUT("/:id", writeAccess, e.handleUpdatePut())
+	apiGroup.DELETE("/:id", writeAccess, e.handleDelete())
+	apiGroup.POST("/new", writeAccess, e.handleCreatePost())
+	apiGroup.GET("/config/:id", writeAccess, e.handleConfigGet())
+	apiGroup.POST("/:id/save-config", writeAccess, e.handleSaveConfigPost())
+	apiGroup.POST("/:id/apply-peer-defaults", writeAccess, e.handleApplyPeerDefaultsPost())
+
+	apiGroup.GET("/peers/:id", e.authenticator.LoggedIn(ScopePeersRead), e.handlePeersGet())
 }
 
 // handlePrepareGet returns a gorm handler function.
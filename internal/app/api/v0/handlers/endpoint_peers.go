@@ -22,11 +22,11 @@ func (e peerEndpoint) GetName() string {
 func (e peerEndpoint) RegisterRoutes(g *gin.RouterGroup, authenticator *authenticationHandler) {
 	apiGroup := g.Group("/peer", e.authenticator.LoggedIn())
 
-	apiGroup.GET("/iface/:iface/all", e.authenticator.LoggedIn(ScopeAdmin), e.handleAllGet())
-	apiGroup.GET("/iface/:iface/stats", e.authenticator.LoggedIn(ScopeAdmin), e.handleStatsGet())
-	apiGroup.GET("/iface/:iface/prepare", e.authenticator.LoggedIn(ScopeAdmin), e.handlePrepareGet())
-	apiGroup.POST("/iface/:iface/new", e.authenticator.LoggedIn(ScopeAdmin), e.handleCreatePost())
-	apiGroup.POST("/iface/:iface/multiplenew", e.authenticator.LoggedIn(ScopeAdmin), e.handleCreateMultiplePost())
+	apiGroup.GET("/iface/:iface/all", e.authenticator.LoggedIn(ScopePeersRead), e.handleAllGet())
+	apiGroup.GET("/iface/:iface/stats", e.authenticator.LoggedIn(ScopePeersRead), e.handleStatsGet())
+	apiGroup.GET("/iface/:iface/prepare", e.authenticator.LoggedIn(ScopePeersWrite), e.handlePrepareGet())
+	apiGroup.POST("/iface/:iface/new", e.authenticator.LoggedIn(ScopePeersWrite), e.handleCreatePost())
+	apiGroup.POST("/iface/:iface/multiplenew", e.authenticator.LoggedIn(ScopePeersWrite), e.handleCreateMultiplePost())
 	apiGroup.GET("/config-qr/:id", e.handleQrCodeGet())
 	apiGroup.POST("/config-mail", e.handleEmailPost())
 	apiGroup.GET("/config/:id", e.handleConfigGet())
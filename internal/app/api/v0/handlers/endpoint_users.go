@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -21,15 +22,20 @@ func (e userEndpoint) GetName() string {
 func (e userEndpoint) RegisterRoutes(g *gin.RouterGroup, authenticator *authenticationHandler) {
 	apiGroup := g.Group("/user", e.authenticator.LoggedIn())
 
-	apiGroup.GET("/all", e.authenticator.LoggedIn(ScopeAdmin), e.handleAllGet())
-	apiGroup.GET("/:id", e.authenticator.UserIdMatch("id"), e.handleSingleGet())
-	apiGroup.PUT("/:id", e.authenticator.UserIdMatch("id"), e.handleUpdatePut())
-	apiGroup.DELETE("/:id", e.authenticator.UserIdMatch("id"), e.handleDelete())
-	apiGroup.POST("/new", e.authenticator.LoggedIn(ScopeAdmin), e.handleCreatePost())
-	apiGroup.GET("/:id/peers", e.authenticator.UserIdMatch("id"), e.handlePeersGet())
-	apiGroup.GET("/:id/stats", e.authenticator.UserIdMatch("id"), e.handleStatsGet())
+	apiGroup.GET("/all", e.authenticator.LoggedIn(ScopeUsersRead), e.handleAllGet())
+	apiGroup.GET("/:id", e.authenticator.UserIdMatch("id", ScopeUsersRead), e.handleSingleGet())
+	apiGroup.PUT("/:id", e.authenticator.UserIdMatch("id", ScopeUsersWrite), e.handleUpdatePut())
+	apiGroup.DELETE("/:id", e.authenticator.UserIdMatch("id", ScopeUsersWrite), e.handleDelete())
+	apiGroup.POST("/new", e.authenticator.LoggedIn(ScopeUsersWrite), e.handleCreatePost())
+	apiGroup.GET("/:id/peers", e.authenticator.UserIdMatch("id", ScopePeersRead), e.handlePeersGet())
+	apiGroup.GET("/:id/stats", e.authenticator.UserIdMatch("id", ScopePeersRead), e.handleStatsGet())
 	apiGroup.POST("/:id/api/enable", e.authenticator.UserIdMatch("id"), e.handleApiEnablePost())
 	apiGroup.POST("/:id/api/disable", e.authenticator.UserIdMatch("id"), e.handleApiDisablePost())
+	apiGroup.DELETE("/:id/totp", e.authenticator.LoggedIn(ScopeUsersWrite), e.handleTotpDelete())
+	apiGroup.POST("/invite", e.authenticator.LoggedIn(ScopeUsersWrite), e.handleInvitePost())
+	apiGroup.POST("/:id/invite/resend", e.authenticator.LoggedIn(ScopeUsersWrite), e.handleInviteResendPost())
+	apiGroup.DELETE("/:id/invite", e.authenticator.LoggedIn(ScopeUsersWrite), e.handleInviteDelete())
+	apiGroup.POST("/:id/approve", e.authenticator.LoggedIn(ScopeUsersWrite), e.handleSignupApprovePost())
 }
 
 // handleAllGet returns a gorm handler function.
@@ -324,3 +330,177 @@ func (e userEndpoint) handleApiDisablePost() gin.HandlerFunc {
 		c.JSON(http.StatusOK, model.NewUser(user, false))
 	}
 }
+
+// handleTotpDelete returns a gorm handler function.
+//
+// @ID users_handleTotpDelete
+// @Tags Users
+// @Summary Reset the TOTP second factor of the given user.
+// @Produce json
+// @Param id path string true "The user identifier"
+// @Success 204 "No content if the second factor was reset successfully"
+// @Failure 400 {object} model.Error
+// @Failure 403 {object} model.Error
+// @Failure 404 {object} model.Error
+// @Failure 500 {object} model.Error
+// @Router /user/{id}/totp [delete]
+func (e userEndpoint) handleTotpDelete() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := domain.SetUserInfoFromGin(c)
+
+		id := Base64UrlDecode(c.Param("id"))
+		if id == "" {
+			c.JSON(http.StatusBadRequest, model.Error{Code: http.StatusBadRequest, Message: "missing user id"})
+			return
+		}
+
+		err := e.app.ResetTotp(ctx, domain.UserIdentifier(id))
+		if err != nil {
+			e.writeUserServiceError(c, err)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// handleInvitePost returns a gorm handler function.
+//
+// @ID users_handleInvitePost
+// @Tags Users
+// @Summary Invite a new user. The user receives an activation link by mail and chooses the password.
+// @Produce json
+// @Param request body model.UserInvitation true "The invitation data"
+// @Success 200 {object} model.User
+// @Failure 400 {object} model.Error
+// @Failure 409 {object} model.Error
+// @Failure 500 {object} model.Error
+// @Router /user/invite [post]
+func (e userEndpoint) handleInvitePost() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := domain.SetUserInfoFromGin(c)
+
+		var invitation model.UserInvitation
+		if err := c.ShouldBindJSON(&invitation); err != nil {
+			c.JSON(http.StatusBadRequest, model.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+
+		newUser, err := e.app.InviteUser(ctx, model.NewDomainInvitedUser(&invitation), invitation.CreateDefaultPeer)
+		if err != nil {
+			e.writeUserServiceError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, model.NewUser(newUser, false))
+	}
+}
+
+// handleInviteResendPost returns a gorm handler function.
+//
+// @ID users_handleInviteResendPost
+// @Tags Users
+// @Summary Send a new activation link for a pending invitation. Previous links become invalid.
+// @Produce json
+// @Param id path string true "The user identifier"
+// @Success 204 "No content if the invitation was resent successfully"
+// @Failure 400 {object} model.Error
+// @Failure 404 {object} model.Error
+// @Failure 500 {object} model.Error
+// @Router /user/{id}/invite/resend [post]
+func (e userEndpoint) handleInviteResendPost() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := domain.SetUserInfoFromGin(c)
+
+		id := Base64UrlDecode(c.Param("id"))
+		if id == "" {
+			c.JSON(http.StatusBadRequest, model.Error{Code: http.StatusBadRequest, Message: "missing user id"})
+			return
+		}
+
+		if err := e.app.ResendInvitation(ctx, domain.UserIdentifier(id)); err != nil {
+			e.writeUserServiceError(c, err)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// handleInviteDelete returns a gorm handler function.
+//
+// @ID users_handleInviteDelete
+// @Tags Users
+// @Summary Revoke a pending invitation. The invited user is deleted.
+// @Produce json
+// @Param id path string true "The user identifier"
+// @Success 204 "No content if the invitation was revoked successfully"
+// @Failure 400 {object} model.Error
+// @Failure 404 {object} model.Error
+// @Failure 500 {object} model.Error
+// @Router /user/{id}/invite [delete]
+func (e userEndpoint) handleInviteDelete() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := domain.SetUserInfoFromGin(c)
+
+		id := Base64UrlDecode(c.Param("id"))
+		if id == "" {
+			c.JSON(http.StatusBadRequest, model.Error{Code: http.StatusBadRequest, Message: "missing user id"})
+			return
+		}
+
+		if err := e.app.RevokeInvitation(ctx, domain.UserIdentifier(id)); err != nil {
+			e.writeUserServiceError(c, err)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// handleSignupApprovePost returns a gorm handler function.
+//
+// @ID users_handleSignupApprovePost
+// @Tags Users
+// @Summary Approve a verified self-registration. The user is enabled afterwards.
+// @Produce json
+// @Param id path string true "The user identifier"
+// @Success 200 {object} model.User
+// @Failure 400 {object} model.Error
+// @Failure 404 {object} model.Error
+// @Failure 500 {object} model.Error
+// @Router /user/{id}/approve [post]
+func (e userEndpoint) handleSignupApprovePost() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := domain.SetUserInfoFromGin(c)
+
+		id := Base64UrlDecode(c.Param("id"))
+		if id == "" {
+			c.JSON(http.StatusBadRequest, model.Error{Code: http.StatusBadRequest, Message: "missing user id"})
+			return
+		}
+
+		user, err := e.app.ApproveSignup(ctx, domain.UserIdentifier(id))
+		if err != nil {
+			e.writeUserServiceError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, model.NewUser(user, false))
+	}
+}
+
+func (e userEndpoint) writeUserServiceError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidData):
+		c.JSON(http.StatusBadRequest, model.Error{Code: http.StatusBadRequest, Message: err.Error()})
+	case errors.Is(err, domain.ErrNoPermission):
+		c.JSON(http.StatusForbidden, model.Error{Code: http.StatusForbidden, Message: err.Error()})
+	case errors.Is(err, domain.ErrNotFound):
+		c.JSON(http.StatusNotFound, model.Error{Code: http.StatusNotFound, Message: err.Error()})
+	case errors.Is(err, domain.ErrDuplicateEntry):
+		c.JSON(http.StatusConflict, model.Error{Code: http.StatusConflict, Message: err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, model.Error{Code: http.StatusInternalServerError, Message: err.Error()})
+	}
+}
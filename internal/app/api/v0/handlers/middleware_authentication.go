@@ -1,11 +1,15 @@
 package handlers
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+
 	"github.com/gin-gonic/gin"
 	"github.com/h44z/wg-portal/internal/app"
 	"github.com/h44z/wg-portal/internal/app/api/v0/model"
 	"github.com/h44z/wg-portal/internal/domain"
-	"net/http"
 )
 
 type Scope string
@@ -14,6 +18,15 @@ const (
 	ScopeAdmin   Scope = "ADMIN" // Admin scope contains all other scopes
 	ScopeSwagger Scope = "SWAGGER"
 	ScopeUser    Scope = "USER"
+
+	// Permission scopes require the corresponding permission in the role of the user.
+
+	ScopeUsersRead       = Scope(domain.PermissionUsersRead)
+	ScopeUsersWrite      = Scope(domain.PermissionUsersWrite)
+	ScopeInterfacesRead  = Scope(domain.PermissionInterfacesRead)
+	ScopeInterfacesWrite = Scope(domain.PermissionInterfacesWrite)
+	ScopePeersRead       = Scope(domain.PermissionPeersRead)
+	ScopePeersWrite      = Scope(domain.PermissionPeersWrite)
 )
 
 type authenticationHandler struct {
@@ -33,25 +46,31 @@ func (h authenticationHandler) LoggedIn(scopes ...Scope) gin.HandlerFunc {
 			return
 		}
 
-		if !UserHasScopes(session, scopes...) {
-			// Abort the request with the appropriate error code
+		// Check if logged-in user is still valid
+		user, err := h.app.Authenticator.GetValidUser(c.Request.Context(), domain.UserIdentifier(session.UserIdentifier))
+		if err != nil {
+			h.Session.DestroyData(c)
 			c.Abort()
-			c.JSON(http.StatusForbidden, model.Error{Code: http.StatusForbidden, Message: "not enough permissions"})
+			c.JSON(http.StatusUnauthorized, model.Error{Code: http.StatusUnauthorized, Message: "session no longer available"})
 			return
 		}
 
-		// Check if logged-in user is still valid
-		if !h.app.Authenticator.IsUserValid(c.Request.Context(), domain.UserIdentifier(session.UserIdentifier)) {
-			h.Session.DestroyData(c)
+		// The role might have changed since the login, so the session is updated before the scopes are checked.
+		if updated, changed := sessionWithUserRole(session, user); changed {
+			session = updated
+			h.Session.SetData(c, session)
+		}
+
+		if !UserHasScopes(session, scopes...) {
+			domain.NotifyAccessDenied(newAccessDeniedContext(c, session),
+				fmt.Sprintf("missing scopes %v for %s", scopes, c.FullPath()))
+			// Abort the request with the appropriate error code
 			c.Abort()
-			c.JSON(http.StatusUnauthorized, model.Error{Code: http.StatusUnauthorized, Message: "session no longer available"})
+			c.JSON(http.StatusForbidden, model.Error{Code: http.StatusForbidden, Message: "not enough permissions"})
 			return
 		}
 
-		c.Set(domain.CtxUserInfo, &domain.ContextUserInfo{
-			Id:      domain.UserIdentifier(session.UserIdentifier),
-			IsAdmin: session.IsAdmin,
-		})
+		c.Set(domain.CtxUserInfo, sessionUserInfo(session))
 
 		// Continue down the chain to handler etc
 		c.Next()
@@ -59,11 +78,12 @@ func (h authenticationHandler) LoggedIn(scopes ...Scope) gin.HandlerFunc {
 }
 
 // UserIdMatch checks if the user id in the session matches the user id in the request. If not, the request is aborted.
-func (h authenticationHandler) UserIdMatch(idParameter string) gin.HandlerFunc {
+// Access to other users is granted to admins, and to users that have all given permission scopes.
+func (h authenticationHandler) UserIdMatch(idParameter string, scopes ...Scope) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		session := h.Session.GetData(c)
 
-		if session.IsAdmin {
+		if session.IsAdmin || (len(scopes) > 0 && UserHasScopes(session, scopes...)) {
 			c.Next() // Admins can do everything
 			return
 		}
@@ -72,6 +92,8 @@ func (h authenticationHandler) UserIdMatch(idParameter string) gin.HandlerFunc {
 		requestUserId := domain.UserIdentifier(Base64UrlDecode(c.Param(idParameter)))
 
 		if sessionUserId != requestUserId {
+			domain.NotifyAccessDenied(newAccessDeniedContext(c, session),
+				fmt.Sprintf("access to data of user %s denied", requestUserId))
 			// Abort the request with the appropriate error code
 			c.Abort()
 			c.JSON(http.StatusForbidden, model.Error{Code: http.StatusForbidden, Message: "not enough permissions"})
@@ -83,6 +105,61 @@ func (h authenticationHandler) UserIdMatch(idParameter string) gin.HandlerFunc {
 	}
 }
 
+// sessionWithUserRole returns the session with the admin flag, role and managed interfaces of the given user.
+// The second return value reports whether the session differed from the user.
+func sessionWithUserRole(session SessionData, user *domain.User) (SessionData, bool) {
+	managedInterfaces := make([]string, len(user.ManagedInterfaces))
+	for i, id := range user.ManagedInterfaces {
+		managedInterfaces[i] = string(id)
+	}
+
+	if session.IsAdmin == user.IsAdmin && session.Role == string(user.EffectiveRole()) &&
+		slices.Equal(session.ManagedInterfaces, managedInterfaces) {
+		return session, false
+	}
+
+	session.IsAdmin = user.IsAdmin
+	session.Role = string(user.EffectiveRole())
+	session.ManagedInterfaces = managedInterfaces
+	return session, true
+}
+
+// sessionUserInfo returns the context user info of the logged-in session user.
+func sessionUserInfo(session SessionData) *domain.ContextUserInfo {
+	managedInterfaces := make([]domain.InterfaceIdentifier, len(session.ManagedInterfaces))
+	for i, id := range session.ManagedInterfaces {
+		managedInterfaces[i] = domain.InterfaceIdentifier(id)
+	}
+
+	return &domain.ContextUserInfo{
+		Id:                domain.UserIdentifier(session.UserIdentifier),
+		IsAdmin:           session.IsAdmin,
+		Role:              domain.RoleName(session.Role),
+		ManagedInterfaces: managedInterfaces,
+	}
+}
+
+// sessionUserPermissions returns the permissions that are granted to the logged-in session user.
+func sessionUserPermissions(session SessionData) []string {
+	userInfo := sessionUserInfo(session)
+	permissions := make([]string, 0, len(domain.Permissions))
+	for _, permission := range domain.Permissions {
+		if userInfo.HasPermissions(permission) {
+			permissions = append(permissions, string(permission))
+		}
+	}
+
+	return permissions
+}
+
+// newAccessDeniedContext returns a context that contains the session user, used to report access denials.
+func newAccessDeniedContext(c *gin.Context, session SessionData) context.Context {
+	userInfo := sessionUserInfo(session)
+	userInfo.ClientIp = c.ClientIP()
+
+	return domain.SetUserInfo(c.Request.Context(), userInfo)
+}
+
 func UserHasScopes(session SessionData, scopes ...Scope) bool {
 	// No scopes give, so the check should succeed
 	if len(scopes) == 0 {
@@ -94,11 +171,17 @@ func UserHasScopes(session SessionData, scopes ...Scope) bool {
 		return true
 	}
 
-	// Check if admin scope is required
+	// Check if admin scope or a permission of the user role is required. Delegated interface admins pass the
+	// permission check, the managers limit their access to the managed interfaces.
+	userInfo := sessionUserInfo(session)
 	for _, scope := range scopes {
 		if scope == ScopeAdmin {
 			return false
 		}
+		if permission := domain.Permission(scope); slices.Contains(domain.Permissions, permission) &&
+			!userInfo.HasPermissions(permission) && !userInfo.HasDelegatedPermissions(permission) {
+			return false
+		}
 	}
 
 	// For all other scopes, a logged-in user is sufficient (for now)
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+func Test_sessionWithUserRole(t *testing.T) {
+	operator := &domain.User{Identifier: "bob"}
+	operator.SetRole(domain.RoleOperator)
+	user := &domain.User{Identifier: "bob"}
+	user.SetRole(domain.RoleUser)
+	delegatedAdmin := &domain.User{Identifier: "bob", ManagedInterfaces: []domain.InterfaceIdentifier{"wg0"}}
+	delegatedAdmin.SetRole(domain.RoleUser)
+
+	tests := []struct {
+		name        string
+		session     SessionData
+		user        *domain.User
+		wantChanged bool
+		wantScopes  bool // ScopePeersWrite
+	}{
+		{name: "unchanged", session: SessionData{LoggedIn: true, Role: string(domain.RoleOperator)}, user: operator,
+			wantScopes: true},
+		{name: "demoted operator", session: SessionData{LoggedIn: true, Role: string(domain.RoleOperator)},
+			user: user, wantChanged: true},
+		{name: "demoted admin", session: SessionData{LoggedIn: true, IsAdmin: true, Role: string(domain.RoleAdmin)},
+			user: user, wantChanged: true},
+		{name: "promoted user", session: SessionData{LoggedIn: true, Role: string(domain.RoleUser)}, user: operator,
+			wantChanged: true, wantScopes: true},
+		{name: "managed interfaces changed", session: SessionData{LoggedIn: true, Role: string(domain.RoleUser)},
+			user: delegatedAdmin, wantChanged: true, wantScopes: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed := sessionWithUserRole(tt.session, tt.user)
+			if changed != tt.wantChanged {
+				t.Errorf("sessionWithUserRole() changed = %t, want %t", changed, tt.wantChanged)
+			}
+			if got.IsAdmin != tt.user.IsAdmin || got.Role != string(tt.user.EffectiveRole()) ||
+				len(got.ManagedInterfaces) != len(tt.user.ManagedInterfaces) {
+				t.Errorf("sessionWithUserRole() = %+v, does not match user %+v", got, tt.user)
+			}
+			if UserHasScopes(got, ScopePeersWrite) != tt.wantScopes {
+				t.Errorf("UserHasScopes() = %t, want %t", !tt.wantScopes, tt.wantScopes)
+			}
+		})
+	}
+}
@@ -3,9 +3,15 @@ package handlers
 import (
 	"encoding/gob"
 	"fmt"
+	"time"
 
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	evbus "github.com/vardius/message-bus"
+
+	"github.com/h44z/wg-portal/internal/app"
+	"github.com/h44z/wg-portal/internal/domain"
 )
 
 func init() {
@@ -15,6 +21,9 @@ func init() {
 type SessionData struct {
 	LoggedIn bool
 	IsAdmin  bool
+	Role     string
+
+	ManagedInterfaces []string // interfaces the user administers as delegated admin
 
 	UserIdentifier string
 
@@ -24,8 +33,33 @@ type SessionData struct {
 
 	OauthState    string
 	OauthNonce    string
+	OauthVerifier string // PKCE code verifier, empty if PKCE is disabled for the provider
 	OauthProvider string
 	OauthReturnTo string
+
+	// set after an OpenID Connect login, used to end the session at the provider
+	OidcProvider  string
+	OidcSubject   string
+	OidcSessionId string
+	OidcIdToken   string
+
+	// set after a successful password login, if a second factor is required to complete the login
+	SecondFactorUser  string
+	SecondFactorState string
+	SecondFactorSince time.Time
+
+	// JSON encoded state of a pending WebAuthn registration or login
+	WebauthnSession string
+}
+
+// secondFactorTimeout is the maximum duration between the password login and the second factor verification.
+const secondFactorTimeout = 5 * time.Minute
+
+// HasPendingSecondFactor returns true if the session waits for the given second factor action.
+func (s SessionData) HasPendingSecondFactor(state domain.SecondFactorState) bool {
+	return s.SecondFactorUser != "" &&
+		s.SecondFactorState == string(state) &&
+		time.Since(s.SecondFactorSince) < secondFactorTimeout
 }
 
 type SessionStore interface {
@@ -35,10 +69,16 @@ type SessionStore interface {
 	SetData(c *gin.Context, data SessionData)
 
 	DestroyData(c *gin.Context)
+
+	// DestroyOidcSessions destroys the sessions of all clients that belong to the given OpenID Connect session.
+	DestroyOidcSessions(provider, subject, sessionId string) int
+	// DestroyUserSessions destroys the sessions of all clients that are logged in as the given user.
+	DestroyUserSessions(userId domain.UserIdentifier) int
 }
 
 type GinSessionStore struct {
 	sessionIdentifier string
+	store             *memorySessionStore
 }
 
 func (g GinSessionStore) GetData(c *gin.Context) SessionData {
@@ -64,14 +104,27 @@ func (g GinSessionStore) DefaultSessionData() SessionData {
 	return SessionData{
 		LoggedIn:       false,
 		IsAdmin:        false,
+		Role:           "",
 		UserIdentifier: "",
 		Firstname:      "",
 		Lastname:       "",
 		Email:          "",
 		OauthState:     "",
 		OauthNonce:     "",
+		OauthVerifier:  "",
 		OauthProvider:  "",
 		OauthReturnTo:  "",
+
+		OidcProvider:  "",
+		OidcSubject:   "",
+		OidcSessionId: "",
+		OidcIdToken:   "",
+
+		SecondFactorUser:  "",
+		SecondFactorState: "",
+		SecondFactorSince: time.Time{},
+
+		WebauthnSession: "",
 	}
 }
 
@@ -90,3 +143,23 @@ func (g GinSessionStore) DestroyData(c *gin.Context) {
 		panic(fmt.Sprintf("failed to store session: %v", err))
 	}
 }
+
+func (g GinSessionStore) DestroyOidcSessions(provider, subject, sessionId string) int {
+	return g.store.DestroyOidcSessions(provider, subject, sessionId)
+}
+
+func (g GinSessionStore) DestroyUserSessions(userId domain.UserIdentifier) int {
+	return g.store.DestroyUserSessions(userId)
+}
+
+// connectToMessageBus ends the sessions of disabled or deleted users.
+func (g GinSessionStore) connectToMessageBus(bus evbus.MessageBus) {
+	_ = bus.Subscribe(app.TopicUserDisabled, g.handleUserDisabledEvent)
+	_ = bus.Subscribe(app.TopicUserDeleted, g.handleUserDisabledEvent)
+}
+
+func (g GinSessionStore) handleUserDisabledEvent(user domain.User) {
+	if destroyed := g.DestroyUserSessions(user.Identifier); destroyed > 0 {
+		logrus.Debugf("destroyed %d sessions of user %s", destroyed, user.Identifier)
+	}
+}
@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"encoding/base32"
+	"maps"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gorilla/securecookie"
+	gorillaSessions "github.com/gorilla/sessions"
+
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// memorySessionStore is an in-memory session store, similar to the memstore of gin-contrib/sessions.
+// In addition, logged-in sessions are indexed by their user and their OpenID Connect session, so that they can be
+// destroyed if the user is disabled or if the provider reports a logout.
+type memorySessionStore struct {
+	codecs  []securecookie.Codec
+	options *gorillaSessions.Options
+
+	// sessionIdentifier is the key of the SessionData value within the session values
+	sessionIdentifier string
+
+	mux         sync.Mutex
+	sessions    map[string]memorySession
+	index       map[string]map[string]struct{} // index key -> session ids
+	destroyed   map[string]time.Time           // session id -> expiry, prevents that requests restore the session
+	lastCleanup time.Time
+}
+
+type memorySession struct {
+	values    map[interface{}]interface{}
+	indexKeys []string
+	expiresAt time.Time
+}
+
+// memorySessionCleanupInterval defines how often expired sessions are removed from the store.
+const memorySessionCleanupInterval = 5 * time.Minute
+
+func newMemorySessionStore(sessionIdentifier string, keyPairs ...[]byte) *memorySessionStore {
+	return &memorySessionStore{
+		codecs: securecookie.CodecsFromPairs(keyPairs...),
+		options: &gorillaSessions.Options{
+			Path:   "/",
+			MaxAge: 86400,
+		},
+		sessionIdentifier: sessionIdentifier,
+		sessions:          make(map[string]memorySession),
+		index:             make(map[string]map[string]struct{}),
+		destroyed:         make(map[string]time.Time),
+		lastCleanup:       time.Now(),
+	}
+}
+
+// Options sets the options for new sessions.
+func (m *memorySessionStore) Options(options sessions.Options) {
+	m.options = options.ToGorillaOptions()
+	for _, codec := range m.codecs {
+		if sc, ok := codec.(*securecookie.SecureCookie); ok {
+			sc.MaxAge(options.MaxAge)
+		}
+	}
+}
+
+// Get returns a session for the given name after adding it to the registry.
+func (m *memorySessionStore) Get(r *http.Request, name string) (*gorillaSessions.Session, error) {
+	return gorillaSessions.GetRegistry(r).Get(m, name)
+}
+
+// New returns a session for the given name without adding it to the registry.
+func (m *memorySessionStore) New(r *http.Request, name string) (*gorillaSessions.Session, error) {
+	session := gorillaSessions.NewSession(m, name)
+	options := *m.options
+	session.Options = &options
+	session.IsNew = true
+
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return session, nil // no session cookie, this is a new session
+	}
+
+	if err = securecookie.DecodeMulti(name, cookie.Value, &session.ID, m.codecs...); err != nil {
+		return session, err
+	}
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	storedSession, ok := m.sessions[session.ID]
+	if !ok || time.Now().After(storedSession.expiresAt) {
+		session.ID = "" // session was destroyed or has expired, a new identifier is generated on save
+		return session, nil
+	}
+
+	session.Values = maps.Clone(storedSession.values)
+	session.IsNew = false
+
+	return session, nil
+}
+
+// Save stores the session values and adds the session cookie to the response.
+func (m *memorySessionStore) Save(_ *http.Request, w http.ResponseWriter, session *gorillaSessions.Session) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	m.cleanup()
+
+	if session.Options.MaxAge < 0 {
+		m.destroy(session.ID)
+	}
+	if _, destroyed := m.destroyed[session.ID]; destroyed || session.Options.MaxAge < 0 {
+		// the session was destroyed while the request was processed, it must not be restored
+		clear(session.Values)
+		options := *session.Options
+		options.MaxAge = -1
+		http.SetCookie(w, gorillaSessions.NewCookie(session.Name(), "", &options))
+		return nil
+	}
+
+	if session.ID == "" {
+		session.ID = strings.TrimRight(
+			base32.StdEncoding.EncodeToString(securecookie.GenerateRandomKey(32)), "=")
+	}
+	encoded, err := securecookie.EncodeMulti(session.Name(), session.ID, m.codecs...)
+	if err != nil {
+		return err
+	}
+
+	m.delete(session.ID) // remove outdated index entries
+	storedSession := memorySession{
+		values:    maps.Clone(session.Values),
+		expiresAt: time.Now().Add(time.Duration(session.Options.MaxAge) * time.Second),
+	}
+	if data, ok := session.Values[m.sessionIdentifier].(SessionData); ok {
+		storedSession.indexKeys = sessionIndexKeys(data)
+	}
+	m.sessions[session.ID] = storedSession
+	for _, key := range storedSession.indexKeys {
+		if m.index[key] == nil {
+			m.index[key] = make(map[string]struct{})
+		}
+		m.index[key][session.ID] = struct{}{}
+	}
+
+	http.SetCookie(w, gorillaSessions.NewCookie(session.Name(), encoded, session.Options))
+	return nil
+}
+
+// DestroyOidcSessions destroys all portal sessions that belong to the given session at the OpenID Connect provider.
+// If the session identifier is empty, all sessions of the subject are destroyed. It returns the number of destroyed
+// sessions.
+func (m *memorySessionStore) DestroyOidcSessions(provider, subject, sessionId string) int {
+	key := oidcSubjectIndexKey(provider, subject)
+	if sessionId != "" {
+		key = oidcSessionIndexKey(provider, sessionId)
+	}
+
+	return m.destroyIndexed(key)
+}
+
+// DestroyUserSessions destroys all portal sessions of the given user. It returns the number of destroyed sessions.
+func (m *memorySessionStore) DestroyUserSessions(userId domain.UserIdentifier) int {
+	return m.destroyIndexed(userIndexKey(userId))
+}
+
+// destroyIndexed destroys all sessions that are indexed by the given key.
+func (m *memorySessionStore) destroyIndexed(key string) int {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	destroyed := 0
+	for id := range m.index[key] {
+		m.destroy(id)
+		destroyed++
+	}
+
+	return destroyed
+}
+
+// destroy removes the session and remembers its identifier until it expires, the caller must hold the lock.
+func (m *memorySessionStore) destroy(id string) {
+	storedSession, ok := m.sessions[id]
+	if !ok {
+		return
+	}
+
+	m.destroyed[id] = storedSession.expiresAt
+	m.delete(id)
+}
+
+// delete removes the session and its index entries, the caller must hold the lock.
+func (m *memorySessionStore) delete(id string) {
+	storedSession, ok := m.sessions[id]
+	if !ok {
+		return
+	}
+
+	for _, key := range storedSession.indexKeys {
+		delete(m.index[key], id)
+		if len(m.index[key]) == 0 {
+			delete(m.index, key)
+		}
+	}
+	delete(m.sessions, id)
+}
+
+// cleanup removes expired sessions, the caller must hold the lock.
+func (m *memorySessionStore) cleanup() {
+	now := time.Now()
+	if now.Sub(m.lastCleanup) < memorySessionCleanupInterval {
+		return
+	}
+	m.lastCleanup = now
+
+	for id, storedSession := range m.sessions {
+		if now.After(storedSession.expiresAt) {
+			m.delete(id)
+		}
+	}
+	for id, expiresAt := range m.destroyed {
+		if now.After(expiresAt) {
+			delete(m.destroyed, id)
+		}
+	}
+}
+
+// sessionIndexKeys returns the keys that are used to find the session of a logged-in user.
+func sessionIndexKeys(data SessionData) []string {
+	if !data.LoggedIn {
+		return nil
+	}
+
+	keys := []string{userIndexKey(domain.UserIdentifier(data.UserIdentifier))}
+	if data.OidcProvider == "" {
+		return keys
+	}
+	if data.OidcSubject != "" {
+		keys = append(keys, oidcSubjectIndexKey(data.OidcProvider, data.OidcSubject))
+	}
+	if data.OidcSessionId != "" {
+		keys = append(keys, oidcSessionIndexKey(data.OidcProvider, data.OidcSessionId))
+	}
+
+	return keys
+}
+
+func userIndexKey(userId domain.UserIdentifier) string {
+	return "user:" + string(userId)
+}
+
+func oidcSubjectIndexKey(provider, subject string) string {
+	return "oidc-sub:" + provider + ":" + subject
+}
+
+func oidcSessionIndexKey(provider, sessionId string) string {
+	return "oidc-sid:" + provider + ":" + sessionId
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	gorillaSessions "github.com/gorilla/sessions"
+)
+
+const testSessionName = "wgPortalSession"
+
+func newTestSessionStore() *memorySessionStore {
+	return newMemorySessionStore("session-data", []byte("0123456789abcdef0123456789abcdef"))
+}
+
+// saveTestSession stores the given session and returns the request that carries the session cookie.
+func saveTestSession(t *testing.T, store *memorySessionStore, session *gorillaSessions.Session) *http.Request {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	if err := store.Save(httptest.NewRequest(http.MethodGet, "/", nil), rec, session); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, cookie := range rec.Result().Cookies() {
+		req.AddCookie(cookie)
+	}
+	return req
+}
+
+// loginTestSession stores a new session with the given session data and returns the request of the client.
+func loginTestSession(t *testing.T, store *memorySessionStore, data SessionData) *http.Request {
+	t.Helper()
+
+	session, err := store.New(httptest.NewRequest(http.MethodGet, "/", nil), testSessionName)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	session.Values[store.sessionIdentifier] = data
+
+	return saveTestSession(t, store, session)
+}
+
+func loadTestSession(t *testing.T, store *memorySessionStore, req *http.Request) *gorillaSessions.Session {
+	t.Helper()
+
+	session, err := store.New(req, testSessionName)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return session
+}
+
+func TestMemorySessionStore_Destroy(t *testing.T) {
+	oidcSession := SessionData{LoggedIn: true, UserIdentifier: "alice", OidcProvider: "idp", OidcSubject: "sub-alice",
+		OidcSessionId: "sid-1"}
+	localSession := SessionData{LoggedIn: true, UserIdentifier: "alice"}
+
+	tests := []struct {
+		name          string
+		data          SessionData
+		destroy       func(store *memorySessionStore) int
+		wantDestroyed int
+	}{
+		{name: "user", data: localSession, wantDestroyed: 1,
+			destroy: func(store *memorySessionStore) int { return store.DestroyUserSessions("alice") }},
+		{name: "other user", data: localSession, wantDestroyed: 0,
+			destroy: func(store *memorySessionStore) int { return store.DestroyUserSessions("bob") }},
+		{name: "oidc user", data: oidcSession, wantDestroyed: 1,
+			destroy: func(store *memorySessionStore) int { return store.DestroyUserSessions("alice") }},
+		{name: "oidc session", data: oidcSession, wantDestroyed: 1,
+			destroy: func(store *memorySessionStore) int { return store.DestroyOidcSessions("idp", "sub-alice", "sid-1") }},
+		{name: "oidc subject", data: oidcSession, wantDestroyed: 1,
+			destroy: func(store *memorySessionStore) int { return store.DestroyOidcSessions("idp", "sub-alice", "") }},
+		{name: "other oidc session", data: oidcSession, wantDestroyed: 0,
+			destroy: func(store *memorySessionStore) int { return store.DestroyOidcSessions("idp", "sub-alice", "sid-2") }},
+		{name: "other oidc provider", data: oidcSession, wantDestroyed: 0,
+			destroy: func(store *memorySessionStore) int { return store.DestroyOidcSessions("other", "sub-alice", "") }},
+		{name: "anonymous", data: SessionData{UserIdentifier: "alice"}, wantDestroyed: 0,
+			destroy: func(store *memorySessionStore) int { return store.DestroyUserSessions("alice") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newTestSessionStore()
+			req := loginTestSession(t, store, tt.data)
+
+			if destroyed := tt.destroy(store); destroyed != tt.wantDestroyed {
+				t.Errorf("destroyed %d sessions, want %d", destroyed, tt.wantDestroyed)
+			}
+
+			session := loadTestSession(t, store, req)
+			if isNew := session.IsNew; isNew != (tt.wantDestroyed > 0) {
+				t.Errorf("session is new = %t, want %t", isNew, tt.wantDestroyed > 0)
+			}
+		})
+	}
+}
+
+func TestMemorySessionStore_SaveDestroyedSession(t *testing.T) {
+	store := newTestSessionStore()
+	req := loginTestSession(t, store, SessionData{LoggedIn: true, UserIdentifier: "alice"})
+
+	// a concurrent request loaded the session before it was destroyed
+	session := loadTestSession(t, store, req)
+	if session.IsNew {
+		t.Fatal("session was not restored")
+	}
+	if destroyed := store.DestroyUserSessions("alice"); destroyed != 1 {
+		t.Fatalf("destroyed %d sessions, want 1", destroyed)
+	}
+
+	rec := httptest.NewRecorder()
+	if err := store.Save(req, rec, session); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if cookies := rec.Result().Cookies(); len(cookies) != 1 || cookies[0].MaxAge >= 0 {
+		t.Errorf("Save() did not remove the session cookie: %v", cookies)
+	}
+	if restored := loadTestSession(t, store, req); !restored.IsNew {
+		t.Errorf("Save() restored the destroyed session")
+	}
+	if destroyed := store.DestroyUserSessions("alice"); destroyed != 0 {
+		t.Errorf("Save() re-indexed the destroyed session")
+	}
+
+	// the client gets a new session with a new identifier
+	newSession := loadTestSession(t, store, req)
+	newSession.Values[store.sessionIdentifier] = SessionData{LoggedIn: true, UserIdentifier: "alice"}
+	saveTestSession(t, store, newSession)
+	if newSession.ID == "" || newSession.ID == session.ID {
+		t.Errorf("new session reuses the destroyed identifier %q", newSession.ID)
+	}
+	if destroyed := store.DestroyUserSessions("alice"); destroyed != 1 {
+		t.Errorf("destroyed %d sessions, want 1", destroyed)
+	}
+}
+
+func TestMemorySessionStore_Logout(t *testing.T) {
+	store := newTestSessionStore()
+	req := loginTestSession(t, store, SessionData{LoggedIn: true, UserIdentifier: "alice"})
+
+	session := loadTestSession(t, store, req)
+	concurrentSession := loadTestSession(t, store, req)
+
+	session.Options.MaxAge = -1
+	saveTestSession(t, store, session)
+	saveTestSession(t, store, concurrentSession)
+
+	if restored := loadTestSession(t, store, req); !restored.IsNew {
+		t.Errorf("Save() restored the session after logout")
+	}
+}
@@ -10,4 +10,7 @@ type Settings struct {
 	PersistentConfigSupported bool `json:"PersistentConfigSupported"`
 	SelfProvisioning          bool `json:"SelfProvisioning"`
 	ApiAdminOnly              bool `json:"ApiAdminOnly"`
+	WebauthnEnabled           bool `json:"WebauthnEnabled"`
+	PasswordResetEnabled      bool `json:"PasswordResetEnabled"`
+	SignupEnabled             bool `json:"SignupEnabled"`
 }
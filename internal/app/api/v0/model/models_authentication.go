@@ -1,6 +1,11 @@
 package model
 
-import "github.com/h44z/wg-portal/internal/domain"
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/h44z/wg-portal/internal/domain"
+)
 
 type LoginProviderInfo struct {
 	Identifier  string `json:"Identifier" example:"google"`
@@ -27,15 +32,132 @@ func NewLoginProviderInfos(src []domain.LoginProviderInfo) []LoginProviderInfo {
 }
 
 type SessionInfo struct {
-	LoggedIn       bool    `json:"LoggedIn"`
-	IsAdmin        bool    `json:"IsAdmin,omitempty"`
-	UserIdentifier *string `json:"UserIdentifier,omitempty"`
-	UserFirstname  *string `json:"UserFirstname,omitempty"`
-	UserLastname   *string `json:"UserLastname,omitempty"`
-	UserEmail      *string `json:"UserEmail,omitempty"`
+	LoggedIn          bool     `json:"LoggedIn"`
+	IsAdmin           bool     `json:"IsAdmin,omitempty"`
+	Role              string   `json:"Role,omitempty"`
+	Permissions       []string `json:"Permissions,omitempty"`       // permissions granted by the role of the user
+	ManagedInterfaces []string `json:"ManagedInterfaces,omitempty"` // interfaces the user administers as delegated admin
+	UserIdentifier    *string  `json:"UserIdentifier,omitempty"`
+	UserFirstname     *string  `json:"UserFirstname,omitempty"`
+	UserLastname      *string  `json:"UserLastname,omitempty"`
+	UserEmail         *string  `json:"UserEmail,omitempty"`
+
+	SecondFactorPending    bool `json:"SecondFactorPending,omitempty"`    // a TOTP code is required to complete the login
+	SecondFactorEnrollment bool `json:"SecondFactorEnrollment,omitempty"` // TOTP must be set up to complete the login
+}
+
+// LoginChallenge is returned if the password login requires a second factor.
+type LoginChallenge struct {
+	SecondFactorRequired bool `json:"SecondFactorRequired"`
+	EnrollmentRequired   bool `json:"EnrollmentRequired"`
+}
+
+// LogoutResult is returned by the logout endpoint. If the session at the OpenID Connect provider has to be ended as
+// well, the client is expected to redirect to the RedirectUrl.
+type LogoutResult struct {
+	Code        int    `json:"Code"`
+	Message     string `json:"Message"`
+	RedirectUrl string `json:"RedirectUrl,omitempty"`
+}
+
+type PasswordResetRequest struct {
+	Identifier string `json:"Identifier" binding:"required"` // the username or email address
+}
+
+type PasswordReset struct {
+	Token    string `json:"Token" binding:"required"`
+	Password string `json:"Password" binding:"required"`
+}
+
+type InvitationActivation struct {
+	Token    string `json:"Token" binding:"required"`
+	Password string `json:"Password" binding:"required"`
+}
+
+type SignupVerification struct {
+	Token string `json:"Token" binding:"required"`
+}
+
+type TotpCode struct {
+	Code string `json:"Code" binding:"required"`
+}
+
+type TotpEnrollment struct {
+	Secret          string `json:"Secret"`
+	ProvisioningUri string `json:"ProvisioningUri"`
+	QrCode          string `json:"QrCode"` // base64 encoded PNG image as data URI
+}
+
+func NewTotpEnrollment(src *domain.TotpEnrollment, qrCode string) *TotpEnrollment {
+	return &TotpEnrollment{
+		Secret:          src.Secret,
+		ProvisioningUri: src.ProvisioningUri,
+		QrCode:          qrCode,
+	}
+}
+
+type TotpActivation struct {
+	RecoveryCodes []string `json:"RecoveryCodes"` // only returned once
 }
 
 type OauthInitiationResponse struct {
 	RedirectUrl string
 	State       string
 }
+
+type WebauthnRegistration struct {
+	Name       string          `json:"Name"`                          // a display name for the new credential
+	Credential json.RawMessage `json:"Credential" binding:"required"` // the result of navigator.credentials.create()
+}
+
+type WebauthnCredential struct {
+	Identifier string     `json:"Identifier"`
+	Name       string     `json:"Name"`
+	CreatedAt  time.Time  `json:"CreatedAt"`
+	LastUsed   *time.Time `json:"LastUsed,omitempty"`
+}
+
+func NewWebauthnCredential(src *domain.WebauthnCredential) *WebauthnCredential {
+	return &WebauthnCredential{
+		Identifier: src.CredentialIdentifier,
+		Name:       src.Name,
+		CreatedAt:  src.CreatedAt,
+		LastUsed:   src.LastUsed,
+	}
+}
+
+func NewWebauthnCredentials(src []domain.WebauthnCredential) []WebauthnCredential {
+	results := make([]WebauthnCredential, len(src))
+	for i := range src {
+		results[i] = *NewWebauthnCredential(&src[i])
+	}
+	return results
+}
+
+type LoginLock struct {
+	Type           string    `json:"Type"`               // user or ip
+	Identifier     string    `json:"Identifier"`         // the user identifier or the source IP address
+	SourceIp       string    `json:"SourceIp,omitempty"` // set for user locks that only apply to one source IP
+	Reason         string    `json:"Reason"`
+	LockedUntil    time.Time `json:"LockedUntil"`
+	FailedAttempts int       `json:"FailedAttempts"`
+}
+
+func NewLoginLock(src *domain.LoginLock) *LoginLock {
+	return &LoginLock{
+		Type:           string(src.Type),
+		Identifier:     src.Identifier,
+		SourceIp:       src.SourceIp,
+		Reason:         src.Reason,
+		LockedUntil:    src.LockedUntil,
+		FailedAttempts: src.FailedAttempts,
+	}
+}
+
+func NewLoginLocks(src []domain.LoginLock) []LoginLock {
+	results := make([]LoginLock, len(src))
+	for i := range src {
+		results[i] = *NewLoginLock(&src[i])
+	}
+	return results
+}
@@ -26,6 +26,9 @@ type Interface struct {
 	FirewallMark uint32   `json:"FirewallMark"` // a firewall mark
 	RoutingTable string   `json:"RoutingTable"` // the routing table
 
+	StaticRoutes []InterfaceStaticRoute `json:"StaticRoutes"` // additional routes that are installed for the interface
+	PolicyRules  []InterfacePolicyRule  `json:"PolicyRules"`  // additional source based routing rules
+
 	PreUp    string `json:"PreUp"`    // action that is executed before the device is up
 	PostUp   string `json:"PostUp"`   // action that is executed after the device is up
 	PreDown  string `json:"PreDown"`  // action that is executed before the device is down
@@ -69,6 +72,8 @@ func NewInterface(src *domain.Interface, peers []domain.Peer) *Interface {
 		Mtu:                        src.Mtu,
 		FirewallMark:               src.FirewallMark,
 		RoutingTable:               src.RoutingTable,
+		StaticRoutes:               NewInterfaceStaticRoutes(src.StaticRoutes),
+		PolicyRules:                NewInterfacePolicyRules(src.PolicyRules),
 		PreUp:                      src.PreUp,
 		PostUp:                     src.PostUp,
 		PreDown:                    src.PreDown,
@@ -106,6 +111,55 @@ func NewInterface(src *domain.Interface, peers []domain.Peer) *Interface {
 	return iface
 }
 
+type InterfaceStaticRoute struct {
+	Destination string `json:"Destination"` // the destination network in CIDR format
+	Gateway     string `json:"Gateway"`     // an optional gateway address
+	Metric      int    `json:"Metric"`      // an optional route metric
+	Table       int    `json:"Table"`       // an optional routing table, defaults to the interface routing table
+}
+
+type InterfacePolicyRule struct {
+	Source   string `json:"Source"`   // the source network in CIDR format
+	Table    int    `json:"Table"`    // an optional routing table, defaults to the interface routing table
+	Priority int    `json:"Priority"` // an optional rule priority
+}
+
+func NewInterfaceStaticRoutes(src []domain.InterfaceStaticRoute) []InterfaceStaticRoute {
+	results := make([]InterfaceStaticRoute, len(src))
+	for i, r := range src {
+		results[i] = InterfaceStaticRoute(r)
+	}
+
+	return results
+}
+
+func NewInterfacePolicyRules(src []domain.InterfacePolicyRule) []InterfacePolicyRule {
+	results := make([]InterfacePolicyRule, len(src))
+	for i, r := range src {
+		results[i] = InterfacePolicyRule(r)
+	}
+
+	return results
+}
+
+func NewDomainInterfaceStaticRoutes(src []InterfaceStaticRoute) []domain.InterfaceStaticRoute {
+	results := make([]domain.InterfaceStaticRoute, len(src))
+	for i, r := range src {
+		results[i] = domain.InterfaceStaticRoute(r)
+	}
+
+	return results
+}
+
+func NewDomainInterfacePolicyRules(src []InterfacePolicyRule) []domain.InterfacePolicyRule {
+	results := make([]domain.InterfacePolicyRule, len(src))
+	for i, r := range src {
+		results[i] = domain.InterfacePolicyRule(r)
+	}
+
+	return results
+}
+
 func NewInterfaces(src []domain.Interface, srcPeers [][]domain.Peer) []Interface {
 	results := make([]Interface, len(src))
 	for i := range src {
@@ -134,6 +188,8 @@ func NewDomainInterface(src *Interface) *domain.Interface {
 		Mtu:                        src.Mtu,
 		FirewallMark:               src.FirewallMark,
 		RoutingTable:               src.RoutingTable,
+		StaticRoutes:               NewDomainInterfaceStaticRoutes(src.StaticRoutes),
+		PolicyRules:                NewDomainInterfacePolicyRules(src.PolicyRules),
 		PreUp:                      src.PreUp,
 		PostUp:                     src.PostUp,
 		PreDown:                    src.PreDown,
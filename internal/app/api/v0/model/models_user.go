@@ -12,6 +12,9 @@ type User struct {
 	Source       string `json:"Source"`
 	ProviderName string `json:"ProviderName"`
 	IsAdmin      bool   `json:"IsAdmin"`
+	Role         string `json:"Role"` // optional on write, if set it takes precedence over IsAdmin
+
+	ManagedInterfaces []string `json:"ManagedInterfaces"` // optional on write, interfaces administered by the user
 
 	Firstname  string `json:"Firstname"`
 	Lastname   string `json:"Lastname"`
@@ -29,6 +32,12 @@ type User struct {
 	ApiTokenCreated *time.Time `json:"ApiTokenCreated,omitempty"`
 	ApiEnabled      bool       `json:"ApiEnabled"`
 
+	TotpEnabled bool `json:"TotpEnabled"` // readonly, TOTP is managed using the authentication endpoints
+
+	InvitationPending bool       `json:"InvitationPending"`           // readonly, the user has not accepted the invitation yet
+	InvitationExpires *time.Time `json:"InvitationExpires,omitempty"` // readonly, expiry time of the pending invitation
+	RegistrationState string     `json:"RegistrationState,omitempty"` // readonly, pending signup step: verification or approval
+
 	// Calculated
 
 	PeerCount int `json:"PeerCount"`
@@ -36,11 +45,15 @@ type User struct {
 
 func NewUser(src *domain.User, exposeCreds bool) *User {
 	u := &User{
-		Identifier:      string(src.Identifier),
-		Email:           src.Email,
-		Source:          string(src.Source),
-		ProviderName:    src.ProviderName,
-		IsAdmin:         src.IsAdmin,
+		Identifier:   string(src.Identifier),
+		Email:        src.Email,
+		Source:       string(src.Source),
+		ProviderName: src.ProviderName,
+		IsAdmin:      src.IsAdmin,
+		Role:         string(src.EffectiveRole()),
+
+		ManagedInterfaces: newInterfaceIdentifiers(src.ManagedInterfaces),
+
 		Firstname:       src.Firstname,
 		Lastname:        src.Lastname,
 		Phone:           src.Phone,
@@ -54,6 +67,11 @@ func NewUser(src *domain.User, exposeCreds bool) *User {
 		ApiToken:        "", // by default, do not expose API token
 		ApiTokenCreated: src.ApiTokenCreated,
 		ApiEnabled:      src.IsApiEnabled(),
+		TotpEnabled:     src.SecondFactor.IsTotpEnabled(),
+
+		InvitationPending: src.Invitation.IsPending(),
+		InvitationExpires: src.Invitation.ExpiresAt,
+		RegistrationState: src.Registration.State(),
 
 		PeerCount: src.LinkedPeerCount,
 	}
@@ -95,6 +113,14 @@ func NewDomainUser(src *User) *domain.User {
 		LinkedPeerCount: src.PeerCount,
 	}
 
+	if src.Role != "" {
+		res.SetRole(domain.RoleName(src.Role))
+	}
+
+	if src.ManagedInterfaces != nil {
+		res.ManagedInterfaces = newDomainInterfaceIdentifiers(src.ManagedInterfaces)
+	}
+
 	if src.Disabled {
 		res.Disabled = &now
 	}
@@ -105,3 +131,75 @@ func NewDomainUser(src *User) *domain.User {
 
 	return res
 }
+
+type UserInvitation struct {
+	Identifier        string `json:"Identifier"` // optional, the email address is used if empty
+	Email             string `json:"Email" binding:"required,email"`
+	IsAdmin           bool   `json:"IsAdmin"`
+	Role              string `json:"Role"` // optional, if set it takes precedence over IsAdmin
+	Firstname         string `json:"Firstname"`
+	Lastname          string `json:"Lastname"`
+	Phone             string `json:"Phone"`
+	Department        string `json:"Department"`
+	Notes             string `json:"Notes"`
+	CreateDefaultPeer bool   `json:"CreateDefaultPeer"` // create a default peer once the invitation is accepted
+}
+
+func NewDomainInvitedUser(src *UserInvitation) *domain.User {
+	res := &domain.User{
+		Identifier: domain.UserIdentifier(src.Identifier),
+		Email:      src.Email,
+		Source:     domain.UserSourceDatabase,
+		IsAdmin:    src.IsAdmin,
+		Firstname:  src.Firstname,
+		Lastname:   src.Lastname,
+		Phone:      src.Phone,
+		Department: src.Department,
+		Notes:      src.Notes,
+	}
+
+	if src.Role != "" {
+		res.SetRole(domain.RoleName(src.Role))
+	}
+
+	return res
+}
+
+type UserSignup struct {
+	Email      string `json:"Email" binding:"required,email"`
+	Password   string `json:"Password" binding:"required"`
+	Firstname  string `json:"Firstname"`
+	Lastname   string `json:"Lastname"`
+	Phone      string `json:"Phone"`
+	Department string `json:"Department"`
+}
+
+func NewDomainSignupUser(src *UserSignup) *domain.User {
+	return &domain.User{
+		Email:      src.Email,
+		Source:     domain.UserSourceDatabase,
+		Password:   domain.PrivateString(src.Password),
+		Firstname:  src.Firstname,
+		Lastname:   src.Lastname,
+		Phone:      src.Phone,
+		Department: src.Department,
+	}
+}
+
+func newInterfaceIdentifiers(src []domain.InterfaceIdentifier) []string {
+	results := make([]string, len(src))
+	for i := range src {
+		results[i] = string(src[i])
+	}
+
+	return results
+}
+
+func newDomainInterfaceIdentifiers(src []string) []domain.InterfaceIdentifier {
+	results := make([]domain.InterfaceIdentifier, len(src))
+	for i := range src {
+		results[i] = domain.InterfaceIdentifier(src[i])
+	}
+
+	return results
+}
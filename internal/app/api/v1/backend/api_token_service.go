@@ -0,0 +1,57 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+type ApiTokenServiceUserManagerRepo interface {
+	GetApiTokens(ctx context.Context, id domain.UserIdentifier) ([]domain.ApiToken, error)
+	CreateApiToken(
+		ctx context.Context,
+		id domain.UserIdentifier,
+		name string,
+		scopes []domain.ApiTokenScope,
+		expiresAt *time.Time,
+	) (*domain.ApiToken, string, error)
+	DeleteApiToken(ctx context.Context, id domain.UserIdentifier, tokenId string) error
+}
+
+type ApiTokenService struct {
+	cfg *config.Config
+
+	users ApiTokenServiceUserManagerRepo
+}
+
+func NewApiTokenService(cfg *config.Config, users ApiTokenServiceUserManagerRepo) *ApiTokenService {
+	return &ApiTokenService{
+		cfg:   cfg,
+		users: users,
+	}
+}
+
+func (s ApiTokenService) GetAllForUser(ctx context.Context, id domain.UserIdentifier) ([]domain.ApiToken, error) {
+	return s.users.GetApiTokens(ctx, id)
+}
+
+func (s ApiTokenService) Create(
+	ctx context.Context,
+	id domain.UserIdentifier,
+	name string,
+	scopes []domain.ApiTokenScope,
+	expiresAt *time.Time,
+) (*domain.ApiToken, string, error) {
+	if s.cfg.Advanced.ApiAdminOnly && !domain.GetUserInfo(ctx).IsAdmin {
+		return nil, "", errors.Join(errors.New("only admins can create api tokens"), domain.ErrNoPermission)
+	}
+
+	return s.users.CreateApiToken(ctx, id, name, scopes, expiresAt)
+}
+
+func (s ApiTokenService) Delete(ctx context.Context, id domain.UserIdentifier, tokenId string) error {
+	return s.users.DeleteApiToken(ctx, id, tokenId)
+}
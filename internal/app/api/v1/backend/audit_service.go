@@ -0,0 +1,145 @@
+package backend
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/h44z/wg-portal/internal/app/api/v1/models"
+	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// auditExportPageSize is the number of entries that are loaded at once during an export.
+const auditExportPageSize = 500
+
+type AuditServiceAuditRecorderRepo interface {
+	GetEntries(ctx context.Context, filter domain.AuditEntryFilter) ([]domain.AuditEntry, error)
+	VerifyChain(ctx context.Context) (*domain.AuditVerificationResult, error)
+}
+
+type AuditService struct {
+	cfg *config.Config
+
+	audit AuditServiceAuditRecorderRepo
+}
+
+func NewAuditService(cfg *config.Config, audit AuditServiceAuditRecorderRepo) *AuditService {
+	return &AuditService{
+		cfg:   cfg,
+		audit: audit,
+	}
+}
+
+// GetEntries returns a single page of audit entries and the cursor for the next page.
+// The cursor is empty if there are no more entries.
+func (s AuditService) GetEntries(ctx context.Context, filter domain.AuditEntryFilter) (
+	[]domain.AuditEntry,
+	string,
+	error,
+) {
+	entries, err := s.audit.GetEntries(ctx, filter)
+	if err != nil {
+		return nil, "", err
+	}
+
+	nextCursor := ""
+	if filter.Limit > 0 && len(entries) == filter.Limit {
+		nextCursor = strconv.FormatUint(entries[len(entries)-1].UniqueId, 10)
+	}
+
+	return entries, nextCursor, nil
+}
+
+// Verify walks the audit hash chain and reports the first broken link.
+func (s AuditService) Verify(ctx context.Context) (*domain.AuditVerificationResult, error) {
+	return s.audit.VerifyChain(ctx)
+}
+
+// ExportCsv writes all audit entries that match the filter as CSV to the given writer.
+func (s AuditService) ExportCsv(ctx context.Context, filter domain.AuditEntryFilter, w io.Writer) error {
+	writer := csv.NewWriter(w)
+	headerWritten := false
+
+	err := s.forEachEntry(ctx, filter, func(entry *domain.AuditEntry) error {
+		if !headerWritten {
+			headerWritten = true
+			if err := writer.Write([]string{"Id", "CreatedAt", "Severity", "Origin", "Actor", "ClientIp",
+				"ObjectType", "ObjectId", "Message", "Details"}); err != nil {
+				return err
+			}
+		}
+
+		details, err := json.Marshal(entry.Details)
+		if err != nil {
+			return fmt.Errorf("failed to encode details of entry %d: %w", entry.UniqueId, err)
+		}
+
+		return writer.Write([]string{
+			strconv.FormatUint(entry.UniqueId, 10),
+			entry.CreatedAt.Format(time.RFC3339),
+			string(entry.Severity),
+			csvSafe(entry.Origin),
+			csvSafe(string(entry.Actor)),
+			csvSafe(entry.ClientIp),
+			string(entry.ObjectType),
+			entry.ObjectId,
+			csvSafe(entry.Message),
+			string(details),
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// ExportJsonLines writes all audit entries that match the filter as JSON Lines to the given writer.
+func (s AuditService) ExportJsonLines(ctx context.Context, filter domain.AuditEntryFilter, w io.Writer) error {
+	encoder := json.NewEncoder(w)
+
+	return s.forEachEntry(ctx, filter, func(entry *domain.AuditEntry) error {
+		return encoder.Encode(models.NewAuditEntry(entry))
+	})
+}
+
+// forEachEntry calls fn for all audit entries that match the filter. The limit of the filter is ignored.
+func (s AuditService) forEachEntry(
+	ctx context.Context,
+	filter domain.AuditEntryFilter,
+	fn func(entry *domain.AuditEntry) error,
+) error {
+	filter.Limit = auditExportPageSize
+	for {
+		entries, err := s.audit.GetEntries(ctx, filter)
+		if err != nil {
+			return err
+		}
+
+		for i := range entries {
+			if err := fn(&entries[i]); err != nil {
+				return err
+			}
+		}
+
+		if len(entries) < filter.Limit {
+			return nil
+		}
+		filter.Cursor = entries[len(entries)-1].UniqueId
+	}
+}
+
+// csvSafe prevents formula injection if the exported file is opened in a spreadsheet application.
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
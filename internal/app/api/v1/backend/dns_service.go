@@ -0,0 +1,49 @@
+package backend
+
+import (
+	"context"
+	"io"
+
+	"github.com/h44z/wg-portal/internal/app/dnsexport"
+	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+type DnsServiceDnsExportManagerRepo interface {
+	GetInterfaceRecords(ctx context.Context, id domain.InterfaceIdentifier, format dnsexport.Format) (io.Reader, error)
+}
+
+type DnsService struct {
+	cfg *config.Config
+
+	dnsExport DnsServiceDnsExportManagerRepo
+}
+
+func NewDnsService(cfg *config.Config, dnsExport DnsServiceDnsExportManagerRepo) *DnsService {
+	return &DnsService{
+		cfg:       cfg,
+		dnsExport: dnsExport,
+	}
+}
+
+func (s DnsService) GetInterfaceRecords(
+	ctx context.Context,
+	id domain.InterfaceIdentifier,
+	format dnsexport.Format,
+) ([]byte, error) {
+	if err := domain.ValidateAdminAccessRights(ctx, domain.PermissionSystemRead); err != nil {
+		return nil, err
+	}
+
+	recordReader, err := s.dnsExport.GetInterfaceRecords(ctx, id, format)
+	if err != nil {
+		return nil, err
+	}
+
+	recordData, err := io.ReadAll(recordReader)
+	if err != nil {
+		return nil, err
+	}
+
+	return recordData, nil
+}
@@ -0,0 +1,29 @@
+package backend
+
+import (
+	"context"
+
+	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+type EventServiceEventStreamManagerRepo interface {
+	Subscribe(ctx context.Context, lastEventId string) (<-chan domain.StreamEvent, error)
+}
+
+type EventService struct {
+	cfg *config.Config
+
+	stream EventServiceEventStreamManagerRepo
+}
+
+func NewEventService(cfg *config.Config, stream EventServiceEventStreamManagerRepo) *EventService {
+	return &EventService{
+		cfg:    cfg,
+		stream: stream,
+	}
+}
+
+func (s EventService) Subscribe(ctx context.Context, lastEventId string) (<-chan domain.StreamEvent, error) {
+	return s.stream.Subscribe(ctx, lastEventId)
+}
@@ -31,7 +31,8 @@ func NewInterfaceService(cfg *config.Config, interfaces InterfaceServiceInterfac
 }
 
 func (s InterfaceService) GetAll(ctx context.Context) ([]domain.Interface, [][]domain.Peer, error) {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	if err := domain.ValidateInterfaceAdminAccessRights(ctx, domain.PermissionInterfacesRead,
+		domain.PermissionPeersRead); err != nil {
 		return nil, nil, err
 	}
 
@@ -48,7 +49,8 @@ func (s InterfaceService) GetById(ctx context.Context, id domain.InterfaceIdenti
 	[]domain.Peer,
 	error,
 ) {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	if err := domain.ValidateInterfaceAccessRights(ctx, id, domain.PermissionInterfacesRead,
+		domain.PermissionPeersRead); err != nil {
 		return nil, nil, err
 	}
 
@@ -61,7 +63,7 @@ func (s InterfaceService) GetById(ctx context.Context, id domain.InterfaceIdenti
 }
 
 func (s InterfaceService) Create(ctx context.Context, iface *domain.Interface) (*domain.Interface, error) {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	if err := domain.ValidateAdminAccessRights(ctx, domain.PermissionInterfacesWrite); err != nil {
 		return nil, err
 	}
 
@@ -78,7 +80,7 @@ func (s InterfaceService) Update(ctx context.Context, id domain.InterfaceIdentif
 	[]domain.Peer,
 	error,
 ) {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	if err := domain.ValidateInterfaceAccessRights(ctx, id, domain.PermissionInterfacesWrite); err != nil {
 		return nil, nil, err
 	}
 
@@ -96,7 +98,7 @@ func (s InterfaceService) Update(ctx context.Context, id domain.InterfaceIdentif
 }
 
 func (s InterfaceService) Delete(ctx context.Context, id domain.InterfaceIdentifier) error {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	if err := domain.ValidateAdminAccessRights(ctx, domain.PermissionInterfacesWrite); err != nil {
 		return err
 	}
 
@@ -55,8 +55,9 @@ func (m MetricsService) GetForInterface(ctx context.Context, id domain.Interface
 		return nil, fmt.Errorf("interface statistics collection is disabled")
 	}
 
-	// validate admin rights
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	// validate admin rights, delegated admins can access their interfaces
+	if err := domain.ValidateInterfaceAccessRights(ctx, id, domain.PermissionInterfacesRead,
+		domain.PermissionPeersRead); err != nil {
 		return nil, err
 	}
 
@@ -77,11 +78,7 @@ func (m MetricsService) GetForUser(ctx context.Context, id domain.UserIdentifier
 		return nil, nil, fmt.Errorf("statistics collection is disabled")
 	}
 
-	if err := domain.ValidateUserAccessRights(ctx, id); err != nil {
-		return nil, nil, err
-	}
-
-	user, err := m.users.GetUser(ctx, id)
+	user, err := m.users.GetUser(ctx, id) // also grants delegated admins access to the users of their interfaces
 	if err != nil {
 		return nil, nil, err
 	}
@@ -91,9 +88,11 @@ func (m MetricsService) GetForUser(ctx context.Context, id domain.UserIdentifier
 		return nil, nil, fmt.Errorf("failed to fetch peers for user %s: %w", user.Identifier, err)
 	}
 
-	peerIds := make([]domain.PeerIdentifier, len(peers))
-	for i, peer := range peers {
-		peerIds[i] = peer.Identifier
+	peerIds := make([]domain.PeerIdentifier, 0, len(peers))
+	for _, peer := range peers {
+		if domain.HasPeerAccessRights(ctx, &peer, domain.PermissionPeersRead) {
+			peerIds = append(peerIds, peer.Identifier)
+		}
 	}
 
 	peerStats, err := m.db.GetPeersStats(ctx, peerIds...)
@@ -114,7 +113,7 @@ func (m MetricsService) GetForPeer(ctx context.Context, id domain.PeerIdentifier
 		return nil, err
 	}
 
-	if err := domain.ValidateUserAccessRights(ctx, peer.UserIdentifier); err != nil {
+	if err := domain.ValidatePeerAccessRights(ctx, peer, domain.PermissionPeersRead); err != nil {
 		return nil, err
 	}
 
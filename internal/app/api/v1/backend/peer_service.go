@@ -42,7 +42,7 @@ func NewPeerService(
 }
 
 func (s PeerService) GetForInterface(ctx context.Context, id domain.InterfaceIdentifier) ([]domain.Peer, error) {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	if err := domain.ValidateInterfaceAccessRights(ctx, id, domain.PermissionPeersRead); err != nil {
 		return nil, err
 	}
 
@@ -55,10 +55,6 @@ func (s PeerService) GetForInterface(ctx context.Context, id domain.InterfaceIde
 }
 
 func (s PeerService) GetForUser(ctx context.Context, id domain.UserIdentifier) ([]domain.Peer, error) {
-	if err := domain.ValidateUserAccessRights(ctx, id); err != nil {
-		return nil, err
-	}
-
 	if s.cfg.Advanced.ApiAdminOnly && !domain.GetUserInfo(ctx).IsAdmin {
 		return nil, errors.Join(errors.New("only admins can access this endpoint"), domain.ErrNoPermission)
 	}
@@ -87,8 +83,8 @@ func (s PeerService) GetById(ctx context.Context, id domain.PeerIdentifier) (*do
 	}
 
 	// Check if the user has access rights to the requested peer.
-	// If the peer is not linked to any user, access is granted only for admins.
-	if err := domain.ValidateUserAccessRights(ctx, peer.UserIdentifier); err != nil {
+	// If the peer is not linked to any user, access is granted only for admins and delegated interface admins.
+	if err := domain.ValidatePeerAccessRights(ctx, peer, domain.PermissionPeersRead); err != nil {
 		return nil, err
 	}
 
@@ -96,7 +92,7 @@ func (s PeerService) GetById(ctx context.Context, id domain.PeerIdentifier) (*do
 }
 
 func (s PeerService) Create(ctx context.Context, peer *domain.Peer) (*domain.Peer, error) {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	if err := domain.ValidatePeerAccessRights(ctx, peer, domain.PermissionPeersWrite); err != nil {
 		return nil, err
 	}
 
@@ -117,7 +113,8 @@ func (s PeerService) Update(ctx context.Context, _ domain.PeerIdentifier, peer *
 	*domain.Peer,
 	error,
 ) {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	if err := domain.ValidateInterfaceAccessRights(ctx, peer.InterfaceIdentifier,
+		domain.PermissionPeersWrite); err != nil {
 		return nil, err
 	}
 
@@ -130,7 +127,7 @@ func (s PeerService) Update(ctx context.Context, _ domain.PeerIdentifier, peer *
 }
 
 func (s PeerService) Delete(ctx context.Context, id domain.PeerIdentifier) error {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	if err := domain.ValidateInterfaceAdminAccessRights(ctx, domain.PermissionPeersWrite); err != nil {
 		return err
 	}
 
@@ -74,7 +74,7 @@ func (p ProvisioningService) GetUserAndPeers(
 		return nil, nil, fmt.Errorf("either UserId or Email must be set: %w", domain.ErrInvalidData)
 	}
 
-	if err := domain.ValidateUserAccessRights(ctx, user.Identifier); err != nil {
+	if err := domain.ValidateUserAccessRights(ctx, user.Identifier, domain.PermissionUsersRead, domain.PermissionPeersRead); err != nil {
 		return nil, nil, err
 	}
 
@@ -92,7 +92,7 @@ func (p ProvisioningService) GetPeerConfig(ctx context.Context, peerId domain.Pe
 		return nil, err
 	}
 
-	if err := domain.ValidateUserAccessRights(ctx, peer.UserIdentifier); err != nil {
+	if err := domain.ValidateUserAccessRights(ctx, peer.UserIdentifier, domain.PermissionPeersWrite); err != nil {
 		return nil, err
 	}
 
@@ -115,7 +115,7 @@ func (p ProvisioningService) GetPeerQrPng(ctx context.Context, peerId domain.Pee
 		return nil, err
 	}
 
-	if err := domain.ValidateUserAccessRights(ctx, peer.UserIdentifier); err != nil {
+	if err := domain.ValidateUserAccessRights(ctx, peer.UserIdentifier, domain.PermissionPeersWrite); err != nil {
 		return nil, err
 	}
 
@@ -138,12 +138,12 @@ func (p ProvisioningService) NewPeer(ctx context.Context, req models.Provisionin
 	}
 
 	// check permissions
-	if err := domain.ValidateUserAccessRights(ctx, domain.UserIdentifier(req.UserIdentifier)); err != nil {
+	if err := domain.ValidateUserAccessRights(ctx, domain.UserIdentifier(req.UserIdentifier), domain.PermissionPeersWrite); err != nil {
 		return nil, err
 	}
 	if !p.cfg.Core.SelfProvisioningAllowed {
 		// only admins can create new peers if self-provisioning is disabled
-		if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+		if err := domain.ValidateAdminAccessRights(ctx, domain.PermissionPeersWrite); err != nil {
 			return nil, err
 		}
 	}
@@ -0,0 +1,45 @@
+package backend
+
+import (
+	"context"
+
+	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+type RoutingServiceRouteManagerRepo interface {
+	GetAllRoutingStatus(ctx context.Context) ([]domain.RoutingStatus, error)
+	GetRoutingStatus(ctx context.Context, id domain.InterfaceIdentifier) (*domain.RoutingStatus, error)
+}
+
+type RoutingService struct {
+	cfg *config.Config
+
+	routes RoutingServiceRouteManagerRepo
+}
+
+func NewRoutingService(cfg *config.Config, routes RoutingServiceRouteManagerRepo) *RoutingService {
+	return &RoutingService{
+		cfg:    cfg,
+		routes: routes,
+	}
+}
+
+func (s RoutingService) GetAll(ctx context.Context) ([]domain.RoutingStatus, error) {
+	if err := domain.ValidateAdminAccessRights(ctx, domain.PermissionSystemRead); err != nil {
+		return nil, err
+	}
+
+	return s.routes.GetAllRoutingStatus(ctx)
+}
+
+func (s RoutingService) GetByInterface(ctx context.Context, id domain.InterfaceIdentifier) (
+	*domain.RoutingStatus,
+	error,
+) {
+	if err := domain.ValidateAdminAccessRights(ctx, domain.PermissionSystemRead); err != nil {
+		return nil, err
+	}
+
+	return s.routes.GetRoutingStatus(ctx, id)
+}
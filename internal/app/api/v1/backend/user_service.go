@@ -15,6 +15,7 @@ type UserManagerRepo interface {
 	CreateUser(ctx context.Context, user *domain.User) (*domain.User, error)
 	UpdateUser(ctx context.Context, user *domain.User) (*domain.User, error)
 	DeleteUser(ctx context.Context, id domain.UserIdentifier) error
+	ResetTotp(ctx context.Context, id domain.UserIdentifier) error
 }
 
 type UserService struct {
@@ -31,7 +32,7 @@ func NewUserService(cfg *config.Config, users UserManagerRepo) *UserService {
 }
 
 func (s UserService) GetAll(ctx context.Context) ([]domain.User, error) {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	if err := domain.ValidateInterfaceAdminAccessRights(ctx, domain.PermissionUsersRead); err != nil {
 		return nil, err
 	}
 
@@ -44,10 +45,6 @@ func (s UserService) GetAll(ctx context.Context) ([]domain.User, error) {
 }
 
 func (s UserService) GetById(ctx context.Context, id domain.UserIdentifier) (*domain.User, error) {
-	if err := domain.ValidateUserAccessRights(ctx, id); err != nil {
-		return nil, err
-	}
-
 	if s.cfg.Advanced.ApiAdminOnly && !domain.GetUserInfo(ctx).IsAdmin {
 		return nil, errors.Join(errors.New("only admins can access this endpoint"), domain.ErrNoPermission)
 	}
@@ -61,7 +58,7 @@ func (s UserService) GetById(ctx context.Context, id domain.UserIdentifier) (*do
 }
 
 func (s UserService) Create(ctx context.Context, user *domain.User) (*domain.User, error) {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	if err := domain.ValidateAdminAccessRights(ctx, domain.PermissionUsersWrite); err != nil {
 		return nil, err
 	}
 
@@ -77,7 +74,7 @@ func (s UserService) Update(ctx context.Context, id domain.UserIdentifier, user
 	*domain.User,
 	error,
 ) {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	if err := domain.ValidateInterfaceAdminAccessRights(ctx, domain.PermissionUsersWrite); err != nil {
 		return nil, err
 	}
 
@@ -94,7 +91,7 @@ func (s UserService) Update(ctx context.Context, id domain.UserIdentifier, user
 }
 
 func (s UserService) Delete(ctx context.Context, id domain.UserIdentifier) error {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	if err := domain.ValidateAdminAccessRights(ctx, domain.PermissionUsersWrite); err != nil {
 		return err
 	}
 
@@ -105,3 +102,16 @@ func (s UserService) Delete(ctx context.Context, id domain.UserIdentifier) error
 
 	return nil
 }
+
+func (s UserService) ResetTotp(ctx context.Context, id domain.UserIdentifier) error {
+	if err := domain.ValidateAdminAccessRights(ctx, domain.PermissionUsersWrite); err != nil {
+		return err
+	}
+
+	err := s.users.ResetTotp(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package backend
+
+import (
+	"context"
+
+	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+type WebhookServiceWebhookManagerRepo interface {
+	GetWebhooks(ctx context.Context) ([]config.WebhookConfig, error)
+	GetDeliveries(ctx context.Context, state domain.WebhookDeliveryState, limit int) ([]domain.WebhookDelivery, error)
+	ReplayDelivery(ctx context.Context, id string) (*domain.WebhookDelivery, error)
+}
+
+type WebhookService struct {
+	cfg *config.Config
+
+	webhooks WebhookServiceWebhookManagerRepo
+}
+
+func NewWebhookService(cfg *config.Config, webhooks WebhookServiceWebhookManagerRepo) *WebhookService {
+	return &WebhookService{
+		cfg:      cfg,
+		webhooks: webhooks,
+	}
+}
+
+func (s WebhookService) GetAll(ctx context.Context) ([]config.WebhookConfig, error) {
+	return s.webhooks.GetWebhooks(ctx)
+}
+
+func (s WebhookService) GetDeliveries(ctx context.Context, state domain.WebhookDeliveryState, limit int) (
+	[]domain.WebhookDelivery,
+	error,
+) {
+	return s.webhooks.GetDeliveries(ctx, state, limit)
+}
+
+func (s WebhookService) Replay(ctx context.Context, id string) (*domain.WebhookDelivery, error) {
+	return s.webhooks.ReplayDelivery(ctx, id)
+}
@@ -37,9 +37,10 @@ type Handler interface {
 // @BasePath /api/v1
 // @query.collection.format multi
 
-func NewRestApi(userSource UserSource, handlers ...Handler) core.ApiEndpointSetupFunc {
+func NewRestApi(userSource UserSource, loginGuard LoginGuard, handlers ...Handler) core.ApiEndpointSetupFunc {
 	authenticator := &authenticationHandler{
 		userSource: userSource,
+		loginGuard: loginGuard,
 	}
 
 	return func() (core.ApiVersion, core.GroupSetupFn) {
@@ -68,6 +69,8 @@ func ParseServiceError(err error) (int, models.Error) {
 		code = http.StatusNotFound
 	case errors.Is(err, domain.ErrNoPermission):
 		code = http.StatusForbidden
+	case errors.Is(err, domain.ErrTooManyAttempts):
+		code = http.StatusTooManyRequests
 	case errors.Is(err, domain.ErrDuplicateEntry):
 		code = http.StatusConflict
 	case errors.Is(err, domain.ErrInvalidData):
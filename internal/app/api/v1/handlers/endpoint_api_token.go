@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/h44z/wg-portal/internal/app/api/v1/models"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+type ApiTokenEndpointApiTokenService interface {
+	GetAllForUser(ctx context.Context, id domain.UserIdentifier) ([]domain.ApiToken, error)
+	Create(
+		ctx context.Context,
+		id domain.UserIdentifier,
+		name string,
+		scopes []domain.ApiTokenScope,
+		expiresAt *time.Time,
+	) (*domain.ApiToken, string, error)
+	Delete(ctx context.Context, id domain.UserIdentifier, tokenId string) error
+}
+
+type ApiTokenEndpoint struct {
+	tokens ApiTokenEndpointApiTokenService
+}
+
+func NewApiTokenEndpoint(apiTokenService ApiTokenEndpointApiTokenService) *ApiTokenEndpoint {
+	return &ApiTokenEndpoint{
+		tokens: apiTokenService,
+	}
+}
+
+func (e ApiTokenEndpoint) GetName() string {
+	return "ApiTokenEndpoint"
+}
+
+func (e ApiTokenEndpoint) RegisterRoutes(g *gin.RouterGroup, authenticator *authenticationHandler) {
+	apiGroup := g.Group("/api-token")
+
+	apiGroup.GET("/by-user/:id", authenticator.LoggedIn(ScopeTokens), e.handleAllForUserGet())
+	apiGroup.POST("/by-user/:id", authenticator.LoggedIn(ScopeTokens), e.handleCreatePost())
+	apiGroup.DELETE("/by-user/:id/:tokenId", authenticator.LoggedIn(ScopeTokens), e.handleDelete())
+}
+
+// handleAllForUserGet returns a gorm Handler function.
+//
+// @ID apiTokens_handleAllForUserGet
+// @Tags API Tokens
+// @Summary Get all named API tokens of a user.
+// @Description Normal users can only access their own tokens. The tokens themselves are never returned.
+// @Param id path string true "The user identifier."
+// @Produce json
+// @Success 200 {object} []models.ApiToken
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /api-token/by-user/{id} [get]
+// @Security BasicAuth
+func (e ApiTokenEndpoint) handleAllForUserGet() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := domain.SetUserInfoFromGin(c)
+
+		id := c.Param("id")
+		if id == "" {
+			c.JSON(http.StatusBadRequest, models.Error{Code: http.StatusBadRequest, Message: "missing user id"})
+			return
+		}
+
+		tokens, err := e.tokens.GetAllForUser(ctx, domain.UserIdentifier(id))
+		if err != nil {
+			c.JSON(ParseServiceError(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, models.NewApiTokens(tokens))
+	}
+}
+
+// handleCreatePost returns a gorm handler function.
+//
+// @ID apiTokens_handleCreatePost
+// @Tags API Tokens
+// @Summary Create a new named API token for a user.
+// @Description Normal users can only create tokens for themselves. The token is only returned once.
+// @Description If the request is authenticated with a named API token, only scopes of that token can be granted.
+// @Param id path string true "The user identifier."
+// @Param request body models.ApiTokenRequest true "The token data."
+// @Produce json
+// @Success 200 {object} models.CreatedApiToken
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /api-token/by-user/{id} [post]
+// @Security BasicAuth
+func (e ApiTokenEndpoint) handleCreatePost() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := domain.SetUserInfoFromGin(c)
+
+		id := c.Param("id")
+		if id == "" {
+			c.JSON(http.StatusBadRequest, models.Error{Code: http.StatusBadRequest, Message: "missing user id"})
+			return
+		}
+
+		var req models.ApiTokenRequest
+		err := c.BindJSON(&req)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+
+		scopes := req.DomainScopes()
+		if callerToken := GetApiToken(c); callerToken != nil {
+			for _, scope := range scopes {
+				if !callerToken.HasScope(scope) {
+					c.JSON(http.StatusForbidden, models.Error{
+						Code: http.StatusForbidden, Message: "scope " + string(scope) + " not granted to current token",
+					})
+					return
+				}
+			}
+		}
+
+		token, plainToken, err := e.tokens.Create(ctx, domain.UserIdentifier(id), req.Name, scopes, req.ExpiresAt)
+		if err != nil {
+			c.JSON(ParseServiceError(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, models.NewCreatedApiToken(token, plainToken))
+	}
+}
+
+// handleDelete returns a gorm handler function.
+//
+// @ID apiTokens_handleDelete
+// @Tags API Tokens
+// @Summary Revoke a named API token.
+// @Description Normal users can only revoke their own tokens.
+// @Param id path string true "The user identifier."
+// @Param tokenId path string true "The token identifier."
+// @Produce json
+// @Success 204 "No content if deletion was successful."
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /api-token/by-user/{id}/{tokenId} [delete]
+// @Security BasicAuth
+func (e ApiTokenEndpoint) handleDelete() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := domain.SetUserInfoFromGin(c)
+
+		id := c.Param("id")
+		tokenId := c.Param("tokenId")
+		if id == "" || tokenId == "" {
+			c.JSON(http.StatusBadRequest, models.Error{Code: http.StatusBadRequest, Message: "missing user or token id"})
+			return
+		}
+
+		err := e.tokens.Delete(ctx, domain.UserIdentifier(id), tokenId)
+		if err != nil {
+			c.JSON(ParseServiceError(err))
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
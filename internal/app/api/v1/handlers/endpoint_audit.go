@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/h44z/wg-portal/internal/app/api/v1/models"
+	"github.com/h44z/wg-portal/internal/domain"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultAuditLimit is the number of entries that is returned if no limit was requested.
+	defaultAuditLimit = 100
+	// maxAuditLimit is the maximum number of entries that can be requested at once.
+	maxAuditLimit = 1000
+)
+
+type AuditEndpointAuditService interface {
+	GetEntries(ctx context.Context, filter domain.AuditEntryFilter) ([]domain.AuditEntry, string, error)
+	ExportCsv(ctx context.Context, filter domain.AuditEntryFilter, w io.Writer) error
+	ExportJsonLines(ctx context.Context, filter domain.AuditEntryFilter, w io.Writer) error
+	Verify(ctx context.Context) (*domain.AuditVerificationResult, error)
+}
+
+type AuditEndpoint struct {
+	audit AuditEndpointAuditService
+}
+
+func NewAuditEndpoint(auditService AuditEndpointAuditService) *AuditEndpoint {
+	return &AuditEndpoint{
+		audit: auditService,
+	}
+}
+
+func (e AuditEndpoint) GetName() string {
+	return "AuditEndpoint"
+}
+
+func (e AuditEndpoint) RegisterRoutes(g *gin.RouterGroup, authenticator *authenticationHandler) {
+	apiGroup := g.Group("/audit")
+
+	apiGroup.GET("/entries", authenticator.LoggedIn(ScopeAdmin, ScopeAuditRead), e.handleEntriesGet())
+	apiGroup.GET("/export", authenticator.LoggedIn(ScopeAdmin, ScopeAuditRead), e.handleExportGet())
+	apiGroup.GET("/verify", authenticator.LoggedIn(ScopeAdmin, ScopeAuditRead), e.handleVerifyGet())
+}
+
+// handleEntriesGet returns a gorm Handler function.
+//
+// @ID audit_handleEntriesGet
+// @Tags Audit
+// @Summary Get audit log entries.
+// @Description The newest entries are returned first. Use the returned NextCursor as Cursor to fetch the next page.
+// @Param From query string false "Only return entries created at or after this time (RFC3339)."
+// @Param To query string false "Only return entries created before this time (RFC3339)."
+// @Param Severity query string false "Only return entries with the given severity (low, medium or high)."
+// @Param Origin query string false "Only return entries with the given origin."
+// @Param Actor query string false "Only return entries triggered by the given user identifier."
+// @Param ObjectType query string false "Only return entries for the given object type (user, interface or peer)."
+// @Param ObjectId query string false "Only return entries for the given object identifier."
+// @Param Cursor query string false "The cursor returned by the previous page."
+// @Param Limit query int false "The maximum number of entries that should be returned. Defaults to 100, at most 1000."
+// @Produce json
+// @Success 200 {object} models.AuditEntryPage
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /audit/entries [get]
+// @Security BasicAuth
+func (e AuditEndpoint) handleEntriesGet() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := domain.SetUserInfoFromGin(c)
+
+		filter, err := parseAuditFilter(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+
+		entries, nextCursor, err := e.audit.GetEntries(ctx, filter)
+		if err != nil {
+			c.JSON(ParseServiceError(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, models.AuditEntryPage{
+			Entries:    models.NewAuditEntries(entries),
+			NextCursor: nextCursor,
+		})
+	}
+}
+
+// handleExportGet returns a gorm Handler function.
+//
+// @ID audit_handleExportGet
+// @Tags Audit
+// @Summary Export all audit log entries that match the given filter.
+// @Description The export is streamed as CSV or JSON Lines, newest entries first. Cursor and Limit are ignored.
+// @Param Format query string false "The export format, either csv or jsonl. Defaults to jsonl."
+// @Param From query string false "Only export entries created at or after this time (RFC3339)."
+// @Param To query string false "Only export entries created before this time (RFC3339)."
+// @Param Severity query string false "Only export entries with the given severity (low, medium or high)."
+// @Param Origin query string false "Only export entries with the given origin."
+// @Param Actor query string false "Only export entries triggered by the given user identifier."
+// @Param ObjectType query string false "Only export entries for the given object type (user, interface or peer)."
+// @Param ObjectId query string false "Only export entries for the given object identifier."
+// @Produce text/csv
+// @Produce application/jsonl
+// @Success 200 {string} string
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /audit/export [get]
+// @Security BasicAuth
+func (e AuditEndpoint) handleExportGet() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := domain.SetUserInfoFromGin(c)
+
+		filter, err := parseAuditFilter(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+		filter.Cursor = 0
+
+		var contentType, extension string
+		var export func(ctx context.Context, filter domain.AuditEntryFilter, w io.Writer) error
+		switch strings.ToLower(strings.TrimSpace(c.DefaultQuery("Format", "jsonl"))) {
+		case "csv":
+			contentType, extension, export = "text/csv", "csv", e.audit.ExportCsv
+		case "jsonl":
+			contentType, extension, export = "application/jsonl", "jsonl", e.audit.ExportJsonLines
+		default:
+			c.JSON(http.StatusBadRequest, models.Error{Code: http.StatusBadRequest, Message: "invalid format"})
+			return
+		}
+
+		c.Header("Content-Type", contentType)
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=audit-%s.%s",
+			time.Now().Format("20060102-150405"), extension))
+
+		err = export(ctx, filter, c.Writer)
+		if err != nil {
+			if !c.Writer.Written() {
+				c.JSON(ParseServiceError(err))
+				return
+			}
+			logrus.Errorf("failed to export audit entries: %v", err)
+			_ = c.Error(err)
+		}
+	}
+}
+
+// handleVerifyGet returns a gorm Handler function.
+//
+// @ID audit_handleVerifyGet
+// @Tags Audit
+// @Summary Verify the audit log hash chain.
+// @Description The whole chain is walked, the result contains the first broken link if the chain is not intact.
+// @Produce json
+// @Success 200 {object} models.AuditVerification
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /audit/verify [get]
+// @Security BasicAuth
+func (e AuditEndpoint) handleVerifyGet() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := domain.SetUserInfoFromGin(c)
+
+		result, err := e.audit.Verify(ctx)
+		if err != nil {
+			c.JSON(ParseServiceError(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, models.NewAuditVerification(result))
+	}
+}
+
+func parseAuditFilter(c *gin.Context) (domain.AuditEntryFilter, error) {
+	filter := domain.AuditEntryFilter{
+		Severity:   domain.AuditSeverityLevel(strings.TrimSpace(c.Query("Severity"))),
+		Origin:     strings.TrimSpace(c.Query("Origin")),
+		Actor:      domain.UserIdentifier(strings.TrimSpace(c.Query("Actor"))),
+		ObjectType: domain.AuditObjectType(strings.TrimSpace(c.Query("ObjectType"))),
+		ObjectId:   strings.TrimSpace(c.Query("ObjectId")),
+		Limit:      defaultAuditLimit,
+	}
+
+	switch filter.Severity {
+	case "", domain.AuditSeverityLevelLow, domain.AuditSeverityLevelMedium, domain.AuditSeverityLevelHigh:
+	default:
+		return filter, errors.New("invalid severity")
+	}
+
+	switch filter.ObjectType {
+	case "", domain.AuditObjectTypeUser, domain.AuditObjectTypeInterface, domain.AuditObjectTypePeer:
+	default:
+		return filter, errors.New("invalid object type")
+	}
+
+	for _, param := range []struct {
+		name   string
+		target **time.Time
+	}{{"From", &filter.From}, {"To", &filter.To}} {
+		value := strings.TrimSpace(c.Query(param.name))
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid %s time, expected RFC3339", strings.ToLower(param.name))
+		}
+		*param.target = &t
+	}
+
+	if cursor := strings.TrimSpace(c.Query("Cursor")); cursor != "" {
+		id, err := strconv.ParseUint(cursor, 10, 64)
+		if err != nil {
+			return filter, errors.New("invalid cursor")
+		}
+		filter.Cursor = id
+	}
+
+	if limitStr := strings.TrimSpace(c.Query("Limit")); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 || limit > maxAuditLimit {
+			return filter, errors.New("invalid limit")
+		}
+		filter.Limit = limit
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/h44z/wg-portal/internal/app/api/v1/models"
+	"github.com/h44z/wg-portal/internal/app/dnsexport"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+type DnsEndpointDnsService interface {
+	GetInterfaceRecords(ctx context.Context, id domain.InterfaceIdentifier, format dnsexport.Format) ([]byte, error)
+}
+
+type DnsEndpoint struct {
+	dns DnsEndpointDnsService
+}
+
+func NewDnsEndpoint(dnsService DnsEndpointDnsService) *DnsEndpoint {
+	return &DnsEndpoint{
+		dns: dnsService,
+	}
+}
+
+func (e DnsEndpoint) GetName() string {
+	return "DnsEndpoint"
+}
+
+func (e DnsEndpoint) RegisterRoutes(g *gin.RouterGroup, authenticator *authenticationHandler) {
+	apiGroup := g.Group("/dns")
+
+	apiGroup.GET("/by-interface/:id/hosts", authenticator.LoggedIn(ScopeAdmin, ScopeSystemRead), e.handleHostsGet())
+	apiGroup.GET("/by-interface/:id/dnsmasq", authenticator.LoggedIn(ScopeAdmin, ScopeSystemRead), e.handleDnsmasqGet())
+	apiGroup.GET("/by-interface/:id/zone", authenticator.LoggedIn(ScopeAdmin, ScopeSystemRead), e.handleZoneGet())
+}
+
+// handleHostsGet returns a gorm Handler function.
+//
+// @ID dns_handleHostsGet
+// @Tags DNS
+// @Summary Get the peer address records of an interface in /etc/hosts format.
+// @Param id path string true "The interface identifier."
+// @Produce plain
+// @Produce json
+// @Success 200 {string} string "The hosts file"
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /dns/by-interface/{id}/hosts [get]
+// @Security BasicAuth
+func (e DnsEndpoint) handleHostsGet() gin.HandlerFunc {
+	return e.handleRecordsGet(dnsexport.FormatHosts)
+}
+
+// handleDnsmasqGet returns a gorm Handler function.
+//
+// @ID dns_handleDnsmasqGet
+// @Tags DNS
+// @Summary Get the peer address records of an interface as dnsmasq configuration.
+// @Param id path string true "The interface identifier."
+// @Produce plain
+// @Produce json
+// @Success 200 {string} string "The dnsmasq configuration file"
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /dns/by-interface/{id}/dnsmasq [get]
+// @Security BasicAuth
+func (e DnsEndpoint) handleDnsmasqGet() gin.HandlerFunc {
+	return e.handleRecordsGet(dnsexport.FormatDnsmasq)
+}
+
+// handleZoneGet returns a gorm Handler function.
+//
+// @ID dns_handleZoneGet
+// @Tags DNS
+// @Summary Get the peer address records of an interface as BIND zone file.
+// @Param id path string true "The interface identifier."
+// @Produce plain
+// @Produce json
+// @Success 200 {string} string "The BIND zone file"
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /dns/by-interface/{id}/zone [get]
+// @Security BasicAuth
+func (e DnsEndpoint) handleZoneGet() gin.HandlerFunc {
+	return e.handleRecordsGet(dnsexport.FormatZone)
+}
+
+func (e DnsEndpoint) handleRecordsGet(format dnsexport.Format) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := domain.SetUserInfoFromGin(c)
+
+		id := c.Param("id")
+		if id == "" {
+			c.JSON(http.StatusBadRequest, models.Error{Code: http.StatusBadRequest, Message: "missing interface id"})
+			return
+		}
+
+		records, err := e.dns.GetInterfaceRecords(ctx, domain.InterfaceIdentifier(id), format)
+		if err != nil {
+			c.JSON(ParseServiceError(err))
+			return
+		}
+
+		c.Data(http.StatusOK, "text/plain", records)
+	}
+}
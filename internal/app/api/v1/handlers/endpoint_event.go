@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"github.com/h44z/wg-portal/internal/app/api/v1/models"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// streamKeepAliveInterval defines how often a comment is sent to keep idle connections open.
+const streamKeepAliveInterval = 30 * time.Second
+
+type EventEndpointEventService interface {
+	Subscribe(ctx context.Context, lastEventId string) (<-chan domain.StreamEvent, error)
+}
+
+type EventEndpoint struct {
+	events EventEndpointEventService
+}
+
+func NewEventEndpoint(eventService EventEndpointEventService) *EventEndpoint {
+	return &EventEndpoint{
+		events: eventService,
+	}
+}
+
+func (e EventEndpoint) GetName() string {
+	return "EventEndpoint"
+}
+
+func (e EventEndpoint) RegisterRoutes(g *gin.RouterGroup, authenticator *authenticationHandler) {
+	apiGroup := g.Group("/event")
+
+	apiGroup.GET("/stream", authenticator.LoggedIn(ScopeEventsRead), e.handleStreamGet())
+}
+
+// handleStreamGet returns a gorm Handler function.
+//
+// @ID event_handleStreamGet
+// @Tags Events
+// @Summary Subscribe to the live event stream (Server-Sent Events).
+// @Description Pushes peer connection changes, handshakes, traffic updates and user, interface and peer changes.
+// @Description Normal users only receive events for their own account and peers.
+// @Description Use the Last-Event-ID header (or the LastEventId query parameter) to resume the stream after a reconnect.
+// @Param Last-Event-ID header string false "The identifier of the last received event."
+// @Param LastEventId query string false "The identifier of the last received event. Ignored if the Last-Event-ID header is set."
+// @Produce text/event-stream
+// @Success 200 {object} models.StreamEvent
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /event/stream [get]
+// @Security BasicAuth
+func (e EventEndpoint) handleStreamGet() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := domain.SetUserInfoFromGin(c)
+
+		lastEventId := strings.TrimSpace(c.GetHeader("Last-Event-ID"))
+		if lastEventId == "" {
+			lastEventId = strings.TrimSpace(c.Query("LastEventId"))
+		}
+
+		events, err := e.events.Subscribe(ctx, lastEventId)
+		if err != nil {
+			c.JSON(ParseServiceError(err))
+			return
+		}
+
+		c.Header("Content-Type", sse.ContentType)
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Header("X-Accel-Buffering", "no") // disable response buffering in nginx
+		c.Status(http.StatusOK)
+
+		keepAlive := time.NewTicker(streamKeepAliveInterval)
+		defer keepAlive.Stop()
+
+		c.Stream(func(w io.Writer) bool {
+			select {
+			case <-ctx.Done():
+				return false
+			case event, ok := <-events:
+				if !ok {
+					return false
+				}
+				c.Render(-1, sse.Event{
+					Id:    event.Id,
+					Event: string(event.Type),
+					Data:  models.NewStreamEvent(&event),
+				})
+				return true
+			case <-keepAlive.C:
+				_, _ = io.WriteString(w, ": keep-alive\n\n")
+				return true
+			}
+		})
+	}
+}
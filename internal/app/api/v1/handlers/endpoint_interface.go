@@ -32,14 +32,14 @@ func (e InterfaceEndpoint) GetName() string {
 }
 
 func (e InterfaceEndpoint) RegisterRoutes(g *gin.RouterGroup, authenticator *authenticationHandler) {
-	apiGroup := g.Group("/interface", authenticator.LoggedIn())
+	apiGroup := g.Group("/interface")
 
-	apiGroup.GET("/all", authenticator.LoggedIn(ScopeAdmin), e.handleAllGet())
-	apiGroup.GET("/by-id/:id", authenticator.LoggedIn(ScopeAdmin), e.handleByIdGet())
+	apiGroup.GET("/all", authenticator.LoggedIn(ScopeAdmin, ScopeInterfacesRead), e.handleAllGet())
+	apiGroup.GET("/by-id/:id", authenticator.LoggedIn(ScopeAdmin, ScopeInterfacesRead), e.handleByIdGet())
 
-	apiGroup.POST("/new", authenticator.LoggedIn(ScopeAdmin), e.handleCreatePost())
-	apiGroup.PUT("/by-id/:id", authenticator.LoggedIn(ScopeAdmin), e.handleUpdatePut())
-	apiGroup.DELETE("/by-id/:id", authenticator.LoggedIn(ScopeAdmin), e.handleDelete())
+	apiGroup.POST("/new", authenticator.LoggedIn(ScopeAdmin, ScopeInterfacesWrite), e.handleCreatePost())
+	apiGroup.PUT("/by-id/:id", authenticator.LoggedIn(ScopeAdmin, ScopeInterfacesWrite), e.handleUpdatePut())
+	apiGroup.DELETE("/by-id/:id", authenticator.LoggedIn(ScopeAdmin, ScopeInterfacesWrite), e.handleDelete())
 }
 
 // handleAllGet returns a gorm Handler function.
@@ -30,11 +30,11 @@ func (e MetricsEndpoint) GetName() string {
 }
 
 func (e MetricsEndpoint) RegisterRoutes(g *gin.RouterGroup, authenticator *authenticationHandler) {
-	apiGroup := g.Group("/metrics", authenticator.LoggedIn())
+	apiGroup := g.Group("/metrics")
 
-	apiGroup.GET("/by-interface/:id", authenticator.LoggedIn(ScopeAdmin), e.handleMetricsForInterfaceGet())
-	apiGroup.GET("/by-user/:id", authenticator.LoggedIn(), e.handleMetricsForUserGet())
-	apiGroup.GET("/by-peer/:id", authenticator.LoggedIn(), e.handleMetricsForPeerGet())
+	apiGroup.GET("/by-interface/:id", authenticator.LoggedIn(ScopeAdmin, ScopeMetricsRead), e.handleMetricsForInterfaceGet())
+	apiGroup.GET("/by-user/:id", authenticator.LoggedIn(ScopeMetricsRead), e.handleMetricsForUserGet())
+	apiGroup.GET("/by-peer/:id", authenticator.LoggedIn(ScopeMetricsRead), e.handleMetricsForPeerGet())
 }
 
 // handleMetricsForInterfaceGet returns a gorm Handler function.
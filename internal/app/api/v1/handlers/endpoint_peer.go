@@ -33,15 +33,15 @@ func (e PeerEndpoint) GetName() string {
 }
 
 func (e PeerEndpoint) RegisterRoutes(g *gin.RouterGroup, authenticator *authenticationHandler) {
-	apiGroup := g.Group("/peer", authenticator.LoggedIn())
+	apiGroup := g.Group("/peer")
 
-	apiGroup.GET("/by-interface/:id", authenticator.LoggedIn(ScopeAdmin), e.handleAllForInterfaceGet())
-	apiGroup.GET("/by-user/:id", authenticator.LoggedIn(), e.handleAllForUserGet())
-	apiGroup.GET("/by-id/:id", authenticator.LoggedIn(), e.handleByIdGet())
+	apiGroup.GET("/by-interface/:id", authenticator.LoggedIn(ScopeAdmin, ScopePeersRead), e.handleAllForInterfaceGet())
+	apiGroup.GET("/by-user/:id", authenticator.LoggedIn(ScopePeersRead), e.handleAllForUserGet())
+	apiGroup.GET("/by-id/:id", authenticator.LoggedIn(ScopePeersRead), e.handleByIdGet())
 
-	apiGroup.POST("/new", authenticator.LoggedIn(ScopeAdmin), e.handleCreatePost())
-	apiGroup.PUT("/by-id/:id", authenticator.LoggedIn(ScopeAdmin), e.handleUpdatePut())
-	apiGroup.DELETE("/by-id/:id", authenticator.LoggedIn(ScopeAdmin), e.handleDelete())
+	apiGroup.POST("/new", authenticator.LoggedIn(ScopeAdmin, ScopePeersWrite), e.handleCreatePost())
+	apiGroup.PUT("/by-id/:id", authenticator.LoggedIn(ScopeAdmin, ScopePeersWrite), e.handleUpdatePut())
+	apiGroup.DELETE("/by-id/:id", authenticator.LoggedIn(ScopeAdmin, ScopePeersWrite), e.handleDelete())
 }
 
 // handleAllForInterfaceGet returns a gorm Handler function.
@@ -36,13 +36,13 @@ func (e ProvisioningEndpoint) GetName() string {
 }
 
 func (e ProvisioningEndpoint) RegisterRoutes(g *gin.RouterGroup, authenticator *authenticationHandler) {
-	apiGroup := g.Group("/provisioning", authenticator.LoggedIn())
+	apiGroup := g.Group("/provisioning")
 
-	apiGroup.GET("/data/user-info", authenticator.LoggedIn(), e.handleUserInfoGet())
-	apiGroup.GET("/data/peer-config", authenticator.LoggedIn(), e.handlePeerConfigGet())
-	apiGroup.GET("/data/peer-qr", authenticator.LoggedIn(), e.handlePeerQrGet())
+	apiGroup.GET("/data/user-info", authenticator.LoggedIn(ScopeProvisioning), e.handleUserInfoGet())
+	apiGroup.GET("/data/peer-config", authenticator.LoggedIn(ScopeProvisioning), e.handlePeerConfigGet())
+	apiGroup.GET("/data/peer-qr", authenticator.LoggedIn(ScopeProvisioning), e.handlePeerQrGet())
 
-	apiGroup.POST("/new-peer", authenticator.LoggedIn(), e.handleNewPeerPost())
+	apiGroup.POST("/new-peer", authenticator.LoggedIn(ScopeProvisioning), e.handleNewPeerPost())
 }
 
 // handleUserInfoGet returns a gorm Handler function.
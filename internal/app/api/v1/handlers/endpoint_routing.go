@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/h44z/wg-portal/internal/app/api/v1/models"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+type RoutingEndpointRoutingService interface {
+	GetAll(ctx context.Context) ([]domain.RoutingStatus, error)
+	GetByInterface(ctx context.Context, id domain.InterfaceIdentifier) (*domain.RoutingStatus, error)
+}
+
+type RoutingEndpoint struct {
+	routing RoutingEndpointRoutingService
+}
+
+func NewRoutingEndpoint(routingService RoutingEndpointRoutingService) *RoutingEndpoint {
+	return &RoutingEndpoint{
+		routing: routingService,
+	}
+}
+
+func (e RoutingEndpoint) GetName() string {
+	return "RoutingEndpoint"
+}
+
+func (e RoutingEndpoint) RegisterRoutes(g *gin.RouterGroup, authenticator *authenticationHandler) {
+	apiGroup := g.Group("/routing")
+
+	apiGroup.GET("/all", authenticator.LoggedIn(ScopeAdmin, ScopeSystemRead), e.handleAllGet())
+	apiGroup.GET("/by-interface/:id", authenticator.LoggedIn(ScopeAdmin, ScopeSystemRead), e.handleByInterfaceGet())
+}
+
+// handleAllGet returns a gorm Handler function.
+//
+// @ID routing_handleAllGet
+// @Tags Routing
+// @Summary Get the routing status of all interfaces.
+// @Description Compares the expected routes and rules with the ones installed on the host.
+// @Produce json
+// @Success 200 {object} []models.RoutingStatus
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /routing/all [get]
+// @Security BasicAuth
+func (e RoutingEndpoint) handleAllGet() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := domain.SetUserInfoFromGin(c)
+
+		allStatus, err := e.routing.GetAll(ctx)
+		if err != nil {
+			c.JSON(ParseServiceError(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, models.NewRoutingStatuses(allStatus))
+	}
+}
+
+// handleByInterfaceGet returns a gorm Handler function.
+//
+// @ID routing_handleByInterfaceGet
+// @Tags Routing
+// @Summary Get the routing status of a specific interface.
+// @Description Compares the expected routes and rules with the ones installed on the host.
+// @Param id path string true "The interface identifier."
+// @Produce json
+// @Success 200 {object} models.RoutingStatus
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /routing/by-interface/{id} [get]
+// @Security BasicAuth
+func (e RoutingEndpoint) handleByInterfaceGet() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := domain.SetUserInfoFromGin(c)
+
+		id := c.Param("id")
+		if id == "" {
+			c.JSON(http.StatusBadRequest, models.Error{Code: http.StatusBadRequest, Message: "missing interface id"})
+			return
+		}
+
+		status, err := e.routing.GetByInterface(ctx, domain.InterfaceIdentifier(id))
+		if err != nil {
+			c.JSON(ParseServiceError(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, models.NewRoutingStatus(status))
+	}
+}
@@ -15,6 +15,7 @@ type UserService interface {
 	Create(ctx context.Context, user *domain.User) (*domain.User, error)
 	Update(ctx context.Context, id domain.UserIdentifier, user *domain.User) (*domain.User, error)
 	Delete(ctx context.Context, id domain.UserIdentifier) error
+	ResetTotp(ctx context.Context, id domain.UserIdentifier) error
 }
 
 type UserEndpoint struct {
@@ -32,13 +33,14 @@ func (e UserEndpoint) GetName() string {
 }
 
 func (e UserEndpoint) RegisterRoutes(g *gin.RouterGroup, authenticator *authenticationHandler) {
-	apiGroup := g.Group("/user", authenticator.LoggedIn())
+	apiGroup := g.Group("/user")
 
-	apiGroup.GET("/all", authenticator.LoggedIn(ScopeAdmin), e.handleAllGet())
-	apiGroup.GET("/by-id/:id", authenticator.LoggedIn(), e.handleByIdGet())
-	apiGroup.POST("/new", authenticator.LoggedIn(ScopeAdmin), e.handleCreatePost())
-	apiGroup.PUT("/by-id/:id", authenticator.LoggedIn(ScopeAdmin), e.handleUpdatePut())
-	apiGroup.DELETE("/by-id/:id", authenticator.LoggedIn(ScopeAdmin), e.handleDelete())
+	apiGroup.GET("/all", authenticator.LoggedIn(ScopeAdmin, ScopeUsersRead), e.handleAllGet())
+	apiGroup.GET("/by-id/:id", authenticator.LoggedIn(ScopeSelf), e.handleByIdGet())
+	apiGroup.POST("/new", authenticator.LoggedIn(ScopeAdmin, ScopeUsersWrite), e.handleCreatePost())
+	apiGroup.PUT("/by-id/:id", authenticator.LoggedIn(ScopeAdmin, ScopeUsersWrite), e.handleUpdatePut())
+	apiGroup.DELETE("/by-id/:id", authenticator.LoggedIn(ScopeAdmin, ScopeUsersWrite), e.handleDelete())
+	apiGroup.DELETE("/by-id/:id/totp", authenticator.LoggedIn(ScopeAdmin, ScopeUsersWrite), e.handleTotpDelete())
 }
 
 // handleAllGet returns a gorm Handler function.
@@ -72,6 +74,7 @@ func (e UserEndpoint) handleAllGet() gin.HandlerFunc {
 // @Tags Users
 // @Summary Get a specific user record by its internal identifier.
 // @Description Normal users can only access their own record. Admins can access all records.
+// @Description Named API tokens require the self scope, the users:read scope is required to access other records.
 // @Param id path string true "The user identifier."
 // @Produce json
 // @Success 200 {object} models.User
@@ -91,6 +94,13 @@ func (e UserEndpoint) handleByIdGet() gin.HandlerFunc {
 			return
 		}
 
+		token := GetApiToken(c)
+		if token != nil && token.UserIdentifier != domain.UserIdentifier(id) &&
+			!token.HasScope(domain.ApiTokenScopeUsersRead) {
+			c.JSON(http.StatusForbidden, models.Error{Code: http.StatusForbidden, Message: "not enough permissions"})
+			return
+		}
+
 		user, err := e.users.GetById(ctx, domain.UserIdentifier(id))
 		if err != nil {
 			c.JSON(ParseServiceError(err))
@@ -216,3 +226,39 @@ func (e UserEndpoint) handleDelete() gin.HandlerFunc {
 		c.Status(http.StatusNoContent)
 	}
 }
+
+// handleTotpDelete returns a gorm handler function.
+//
+// @ID users_handleTotpDelete
+// @Tags Users
+// @Summary Reset the TOTP second factor of the user.
+// @Description The user has to set up TOTP again on the next login if the TOTP policy requires a second factor.
+// @Param id path string true "The user identifier."
+// @Produce json
+// @Success 204 "No content if the second factor was reset successfully."
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /user/by-id/{id}/totp [delete]
+// @Security BasicAuth
+func (e UserEndpoint) handleTotpDelete() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := domain.SetUserInfoFromGin(c)
+
+		id := c.Param("id")
+		if id == "" {
+			c.JSON(http.StatusBadRequest, models.Error{Code: http.StatusBadRequest, Message: "missing user id"})
+			return
+		}
+
+		err := e.users.ResetTotp(ctx, domain.UserIdentifier(id))
+		if err != nil {
+			c.JSON(ParseServiceError(err))
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
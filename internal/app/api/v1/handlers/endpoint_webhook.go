@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/h44z/wg-portal/internal/app/api/v1/models"
+	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// defaultDeliveryLimit is the number of deliveries that is returned if no limit was requested.
+const defaultDeliveryLimit = 100
+
+type WebhookEndpointWebhookService interface {
+	GetAll(ctx context.Context) ([]config.WebhookConfig, error)
+	GetDeliveries(ctx context.Context, state domain.WebhookDeliveryState, limit int) ([]domain.WebhookDelivery, error)
+	Replay(ctx context.Context, id string) (*domain.WebhookDelivery, error)
+}
+
+type WebhookEndpoint struct {
+	webhooks WebhookEndpointWebhookService
+}
+
+func NewWebhookEndpoint(webhookService WebhookEndpointWebhookService) *WebhookEndpoint {
+	return &WebhookEndpoint{
+		webhooks: webhookService,
+	}
+}
+
+func (e WebhookEndpoint) GetName() string {
+	return "WebhookEndpoint"
+}
+
+func (e WebhookEndpoint) RegisterRoutes(g *gin.RouterGroup, authenticator *authenticationHandler) {
+	apiGroup := g.Group("/webhook")
+
+	apiGroup.GET("/all", authenticator.LoggedIn(ScopeAdmin, ScopeSystemRead), e.handleAllGet())
+	apiGroup.GET("/deliveries", authenticator.LoggedIn(ScopeAdmin, ScopeSystemRead), e.handleDeliveriesGet())
+	apiGroup.GET("/dead-letters", authenticator.LoggedIn(ScopeAdmin, ScopeSystemRead), e.handleDeadLettersGet())
+	apiGroup.POST("/dead-letters/:id/replay", authenticator.LoggedIn(ScopeAdmin, ScopeSystemWrite), e.handleReplayPost())
+}
+
+// handleAllGet returns a gorm Handler function.
+//
+// @ID webhook_handleAllGet
+// @Tags Webhooks
+// @Summary Get all configured webhook subscriptions.
+// @Produce json
+// @Success 200 {object} []models.Webhook
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /webhook/all [get]
+// @Security BasicAuth
+func (e WebhookEndpoint) handleAllGet() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := domain.SetUserInfoFromGin(c)
+
+		webhooks, err := e.webhooks.GetAll(ctx)
+		if err != nil {
+			c.JSON(ParseServiceError(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, models.NewWebhooks(webhooks))
+	}
+}
+
+// handleDeliveriesGet returns a gorm Handler function.
+//
+// @ID webhook_handleDeliveriesGet
+// @Tags Webhooks
+// @Summary Get the webhook delivery log.
+// @Description The most recent deliveries are returned first.
+// @Param State query string false "Only return deliveries with the given state (pending, delivered or dead-letter)."
+// @Param Limit query int false "The maximum number of deliveries that should be returned. Defaults to 100."
+// @Produce json
+// @Success 200 {object} []models.WebhookDelivery
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /webhook/deliveries [get]
+// @Security BasicAuth
+func (e WebhookEndpoint) handleDeliveriesGet() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := domain.SetUserInfoFromGin(c)
+
+		state := domain.WebhookDeliveryState(strings.TrimSpace(c.Query("State")))
+		switch state {
+		case "", domain.WebhookDeliveryStatePending, domain.WebhookDeliveryStateDelivered,
+			domain.WebhookDeliveryStateDeadLetter:
+		default:
+			c.JSON(http.StatusBadRequest, models.Error{Code: http.StatusBadRequest, Message: "invalid state"})
+			return
+		}
+
+		limit, err := parseDeliveryLimit(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+
+		deliveries, err := e.webhooks.GetDeliveries(ctx, state, limit)
+		if err != nil {
+			c.JSON(ParseServiceError(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, models.NewWebhookDeliveries(deliveries))
+	}
+}
+
+// handleDeadLettersGet returns a gorm Handler function.
+//
+// @ID webhook_handleDeadLettersGet
+// @Tags Webhooks
+// @Summary Get all webhook deliveries that failed permanently.
+// @Param Limit query int false "The maximum number of deliveries that should be returned. Defaults to 100."
+// @Produce json
+// @Success 200 {object} []models.WebhookDelivery
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /webhook/dead-letters [get]
+// @Security BasicAuth
+func (e WebhookEndpoint) handleDeadLettersGet() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := domain.SetUserInfoFromGin(c)
+
+		limit, err := parseDeliveryLimit(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+
+		deliveries, err := e.webhooks.GetDeliveries(ctx, domain.WebhookDeliveryStateDeadLetter, limit)
+		if err != nil {
+			c.JSON(ParseServiceError(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, models.NewWebhookDeliveries(deliveries))
+	}
+}
+
+// handleReplayPost returns a gorm Handler function.
+//
+// @ID webhook_handleReplayPost
+// @Tags Webhooks
+// @Summary Replay a dead-letter delivery.
+// @Description The delivery is sent again in the background, using the retry policy of the webhook.
+// @Param id path string true "The delivery identifier."
+// @Produce json
+// @Success 202 {object} models.WebhookDelivery
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /webhook/dead-letters/{id}/replay [post]
+// @Security BasicAuth
+func (e WebhookEndpoint) handleReplayPost() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := domain.SetUserInfoFromGin(c)
+
+		id := c.Param("id")
+		if id == "" {
+			c.JSON(http.StatusBadRequest, models.Error{Code: http.StatusBadRequest, Message: "missing delivery id"})
+			return
+		}
+
+		delivery, err := e.webhooks.Replay(ctx, id)
+		if err != nil {
+			c.JSON(ParseServiceError(err))
+			return
+		}
+
+		c.JSON(http.StatusAccepted, models.NewWebhookDelivery(delivery))
+	}
+}
+
+func parseDeliveryLimit(c *gin.Context) (int, error) {
+	limitStr := strings.TrimSpace(c.Query("Limit"))
+	if limitStr == "" {
+		return defaultDeliveryLimit, nil
+	}
+
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit <= 0 {
+		return 0, errors.New("invalid limit")
+	}
+
+	return limit, nil
+}
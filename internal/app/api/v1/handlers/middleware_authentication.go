@@ -2,7 +2,10 @@ package handlers
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"slices"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/h44z/wg-portal/internal/app/api/v0/model"
@@ -11,68 +14,184 @@ import (
 
 type Scope string
 
+// ScopeAdmin requires an admin user, or a user whose role grants the permissions of the other scopes of the route.
+// All other scopes must be granted to the named API token that is used for the request. Legacy user API tokens are
+// not restricted by scopes. Every route must require at least one scope, named API tokens are rejected otherwise.
 const (
-	ScopeAdmin Scope = "ADMIN" // Admin scope contains all other scopes
+	ScopeAdmin           Scope = "ADMIN" // Admin scope contains all other scopes
+	ScopePeersRead       Scope = Scope(domain.ApiTokenScopePeersRead)
+	ScopePeersWrite      Scope = Scope(domain.ApiTokenScopePeersWrite)
+	ScopeProvisioning    Scope = Scope(domain.ApiTokenScopeProvisioning)
+	ScopeMetricsRead     Scope = Scope(domain.ApiTokenScopeMetricsRead)
+	ScopeTokens          Scope = Scope(domain.ApiTokenScopeTokens)
+	ScopeSelf            Scope = Scope(domain.ApiTokenScopeSelf)
+	ScopeEventsRead      Scope = Scope(domain.ApiTokenScopeEventsRead)
+	ScopeUsersRead       Scope = Scope(domain.ApiTokenScopeUsersRead)
+	ScopeUsersWrite      Scope = Scope(domain.ApiTokenScopeUsersWrite)
+	ScopeInterfacesRead  Scope = Scope(domain.ApiTokenScopeInterfacesRead)
+	ScopeInterfacesWrite Scope = Scope(domain.ApiTokenScopeInterfacesWrite)
+	ScopeAuditRead       Scope = Scope(domain.ApiTokenScopeAuditRead)
+	ScopeSystemRead      Scope = Scope(domain.ApiTokenScopeSystemRead)
+	ScopeSystemWrite     Scope = Scope(domain.ApiTokenScopeSystemWrite)
 )
 
+// scopePermissions contains the role permissions that are required for a scope on routes that require ScopeAdmin.
+var scopePermissions = map[Scope][]domain.Permission{
+	ScopePeersRead:       {domain.PermissionPeersRead},
+	ScopePeersWrite:      {domain.PermissionPeersWrite},
+	ScopeMetricsRead:     {domain.PermissionInterfacesRead, domain.PermissionPeersRead},
+	ScopeUsersRead:       {domain.PermissionUsersRead},
+	ScopeUsersWrite:      {domain.PermissionUsersWrite},
+	ScopeInterfacesRead:  {domain.PermissionInterfacesRead},
+	ScopeInterfacesWrite: {domain.PermissionInterfacesWrite},
+	ScopeAuditRead:       {domain.PermissionAuditRead},
+	ScopeSystemRead:      {domain.PermissionSystemRead},
+	ScopeSystemWrite:     {domain.PermissionSystemWrite},
+}
+
+// ctxApiToken is the gin context key of the named API token that authenticated the request.
+const ctxApiToken = "apiToken"
+
 type UserSource interface {
 	GetUser(ctx context.Context, id domain.UserIdentifier) (*domain.User, error)
+	AuthenticateApiToken(ctx context.Context, token string) (*domain.User, *domain.ApiToken, error)
+}
+
+// LoginGuard delays and blocks repeated failed API logins.
+type LoginGuard interface {
+	CheckLoginAttempt(ctx context.Context, id domain.UserIdentifier) error
+	RegisterFailedLogin(ctx context.Context, id domain.UserIdentifier, origin string, err error)
+	RegisterSuccessfulLogin(ctx context.Context, id domain.UserIdentifier)
 }
 
 type authenticationHandler struct {
 	userSource UserSource
+	loginGuard LoginGuard
 }
 
 // LoggedIn checks if a user is logged in. If scopes are given, they are validated as well.
+// Named API tokens can be passed as bearer token or as basic auth password, legacy API tokens require basic auth.
 func (h authenticationHandler) LoggedIn(scopes ...Scope) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		username, password, ok := c.Request.BasicAuth()
-		if !ok || username == "" || password == "" {
+		if bearer, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); !ok && found {
+			password = strings.TrimSpace(bearer)
+			ok = domain.IsApiToken(password)
+		}
+		if !ok || (username == "" && !domain.IsApiToken(password)) || password == "" {
 			// Abort the request with the appropriate error code
 			c.Abort()
 			c.JSON(http.StatusUnauthorized, model.Error{Code: http.StatusUnauthorized, Message: "missing credentials"})
 			return
 		}
 
-		// check if user exists in DB
+		loginCtx := domain.SetUserInfo(c.Request.Context(), &domain.ContextUserInfo{
+			Id:       domain.UserIdentifier(username),
+			ClientIp: c.ClientIP(),
+		})
+		if err := h.loginGuard.CheckLoginAttempt(loginCtx, domain.UserIdentifier(username)); err != nil {
+			// Abort the request with the appropriate error code
+			c.Abort()
+			c.JSON(http.StatusTooManyRequests, model.Error{Code: http.StatusTooManyRequests, Message: err.Error()})
+			return
+		}
+
+		// validate the API token, this also checks if the user exists in DB
 
-		ctx := domain.SetUserInfo(c.Request.Context(), domain.SystemAdminContextUserInfo())
-		user, err := h.userSource.GetUser(ctx, domain.UserIdentifier(username))
+		user, token, err := h.authenticate(loginCtx, domain.UserIdentifier(username), password)
 		if err != nil {
+			h.loginGuard.RegisterFailedLogin(loginCtx, domain.UserIdentifier(username), "apiLoginFailed", err)
 			// Abort the request with the appropriate error code
 			c.Abort()
 			c.JSON(http.StatusUnauthorized, model.Error{Code: http.StatusUnauthorized, Message: "invalid credentials"})
 			return
 		}
 
-		// validate API token
-		if err := user.CheckApiToken(password); err != nil {
+		if user.IsDisabled() {
 			// Abort the request with the appropriate error code
 			c.Abort()
-			c.JSON(http.StatusUnauthorized, model.Error{Code: http.StatusUnauthorized, Message: "invalid credentials"})
+			c.JSON(http.StatusUnauthorized, model.Error{Code: http.StatusUnauthorized, Message: "user is disabled"})
 			return
 		}
 
-		if !UserHasScopes(user, scopes...) {
+		if user.IsLocked() {
+			// Abort the request with the appropriate error code
+			c.Abort()
+			c.JSON(http.StatusUnauthorized, model.Error{Code: http.StatusUnauthorized, Message: "user is locked"})
+			return
+		}
+		h.loginGuard.RegisterSuccessfulLogin(loginCtx, user.Identifier)
+
+		if !UserHasScopes(user, scopes...) || !TokenHasScopes(token, scopes...) {
+			userInfo := domain.NewContextUserInfo(user)
+			userInfo.ClientIp = c.ClientIP()
+			domain.NotifyAccessDenied(domain.SetUserInfo(c.Request.Context(), userInfo), fmt.Sprintf("missing scopes %v for %s", scopes, c.FullPath()))
 			// Abort the request with the appropriate error code
 			c.Abort()
 			c.JSON(http.StatusForbidden, model.Error{Code: http.StatusForbidden, Message: "not enough permissions"})
 			return
 		}
 
-		c.Set(domain.CtxUserInfo, &domain.ContextUserInfo{
-			Id:      user.Identifier,
-			IsAdmin: user.IsAdmin,
-		})
+		c.Set(domain.CtxUserInfo, domain.NewContextUserInfo(user))
+		if token != nil {
+			c.Set(ctxApiToken, token)
+		}
 
 		// Continue down the chain to Handler etc
 		c.Next()
 	}
 }
 
+// authenticate validates a named API token or the legacy API token of the given user. For legacy tokens, the returned
+// named token is nil.
+func (h authenticationHandler) authenticate(ctx context.Context, id domain.UserIdentifier, password string) (
+	*domain.User,
+	*domain.ApiToken,
+	error,
+) {
+	systemUser := domain.SystemAdminContextUserInfo()
+	systemUser.ClientIp = domain.GetUserInfo(ctx).ClientIp // used to record the last usage of named tokens
+	systemCtx := domain.SetUserInfo(ctx, systemUser)
+
+	if domain.IsApiToken(password) {
+		user, token, err := h.userSource.AuthenticateApiToken(systemCtx, password)
+		if err != nil {
+			return nil, nil, err
+		}
+		if id != "" && id != user.Identifier {
+			return nil, nil, fmt.Errorf("token does not belong to user %s", id)
+		}
+
+		return user, token, nil
+	}
+
+	user, err := h.userSource.GetUser(systemCtx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := user.CheckApiToken(password); err != nil {
+		return nil, nil, err
+	}
+
+	return user, nil, nil
+}
+
+// GetApiToken returns the named API token that authenticated the request, or nil for legacy API tokens.
+func GetApiToken(c *gin.Context) *domain.ApiToken {
+	if token, ok := c.Get(ctxApiToken); ok {
+		return token.(*domain.ApiToken)
+	}
+
+	return nil
+}
+
+// UserHasScopes checks if the given user is allowed to use a route with the given scopes. Routes that require
+// ScopeAdmin are available to admins, to users whose role grants the permissions of all other scopes and to
+// delegated interface admins if the permissions can be delegated.
 func UserHasScopes(user *domain.User, scopes ...Scope) bool {
-	// No scopes give, so the check should succeed
-	if len(scopes) == 0 {
+	// No scopes give or admin scope not required, so the check should succeed
+	if len(scopes) == 0 || !slices.Contains(scopes, ScopeAdmin) {
 		return true
 	}
 
@@ -81,12 +200,46 @@ func UserHasScopes(user *domain.User, scopes ...Scope) bool {
 		return true
 	}
 
-	// Check if admin scope is required
+	// Check if the role of the user grants the required permissions
+	var permissions []domain.Permission
+	for _, scope := range scopes {
+		permissions = append(permissions, scopePermissions[scope]...)
+	}
+	if len(permissions) == 0 {
+		return false // only ScopeAdmin is required
+	}
+
+	// Delegated interface admins pass the check, the services limit their access to the managed interfaces
+	userInfo := domain.NewContextUserInfo(user)
+	return userInfo.HasPermissions(permissions...) || userInfo.HasDelegatedPermissions(permissions...)
+}
+
+// TokenHasScopes checks if the required scopes have been granted to the given named API token.
+// Routes that only require ScopeAdmin can only be used with tokens that contain the admin scope. Routes without
+// scopes cannot be used with named API tokens at all.
+func TokenHasScopes(token *domain.ApiToken, scopes ...Scope) bool {
+	// Legacy API tokens are not restricted by scopes
+	if token == nil {
+		return true
+	}
+	if len(scopes) == 0 {
+		return false
+	}
+
+	tokenScopes := 0
 	for _, scope := range scopes {
 		if scope == ScopeAdmin {
+			continue
+		}
+		tokenScopes++
+		if !token.HasScope(domain.ApiTokenScope(scope)) {
 			return false
 		}
 	}
 
+	if tokenScopes == 0 {
+		return token.HasScope(domain.ApiTokenScopeAdmin)
+	}
+
 	return true
 }
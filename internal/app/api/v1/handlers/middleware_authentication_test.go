@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+func TestTokenHasScopes(t *testing.T) {
+	peersToken := &domain.ApiToken{Scopes: []domain.ApiTokenScope{domain.ApiTokenScopePeersRead}}
+	adminToken := &domain.ApiToken{Scopes: []domain.ApiTokenScope{domain.ApiTokenScopeAdmin}}
+
+	tests := []struct {
+		name   string
+		token  *domain.ApiToken
+		scopes []Scope
+		want   bool
+	}{
+		{name: "legacy token", token: nil, want: true},
+		{name: "legacy token admin route", token: nil, scopes: []Scope{ScopeAdmin}, want: true},
+		{name: "route without scopes", token: peersToken, want: false},
+		{name: "route without scopes admin token", token: adminToken, want: false},
+		{name: "granted", token: peersToken, scopes: []Scope{ScopeAdmin, ScopePeersRead}, want: true},
+		{name: "not granted", token: peersToken, scopes: []Scope{ScopeSelf}, want: false},
+		{name: "admin only route", token: peersToken, scopes: []Scope{ScopeAdmin}, want: false},
+		{name: "admin only route admin token", token: adminToken, scopes: []Scope{ScopeAdmin}, want: true},
+		{name: "admin token", token: adminToken, scopes: []Scope{ScopeEventsRead}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TokenHasScopes(tt.token, tt.scopes...); got != tt.want {
+				t.Errorf("TokenHasScopes() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package models
+
+import (
+	"time"
+
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// ApiToken represents a named API token of a user. The token itself is never returned after creation.
+type ApiToken struct {
+	// The unique identifier of the token.
+	Identifier string `json:"Identifier" example:"0f9d3c3c1d7e4b6a8c2f5e1a9b7d3c11"`
+	// The identifier of the user that owns the token.
+	UserIdentifier string `json:"UserIdentifier" example:"uid-1234567"`
+	// The name of the token.
+	Name string `json:"Name" example:"monitoring"`
+	// The scopes that have been granted to the token.
+	Scopes []string `json:"Scopes" example:"peers:read,metrics:read"`
+	// The creation date of the token.
+	CreatedAt time.Time `json:"CreatedAt" example:"2025-01-01T10:00:00Z"`
+	// The date after which the token can no longer be used.
+	ExpiresAt time.Time `json:"ExpiresAt" example:"2025-04-01T10:00:00Z"`
+	// If this field is set, the token has expired.
+	Expired bool `json:"Expired" example:"false"`
+	// The last time the token has been used.
+	LastUsed *time.Time `json:"LastUsed,omitempty" example:"2025-01-02T10:00:00Z"`
+	// The IP address of the client that used the token last.
+	LastUsedIp string `json:"LastUsedIp,omitempty" example:"10.0.0.1"`
+}
+
+func NewApiToken(src *domain.ApiToken) *ApiToken {
+	scopes := make([]string, len(src.Scopes))
+	for i := range src.Scopes {
+		scopes[i] = string(src.Scopes[i])
+	}
+
+	return &ApiToken{
+		Identifier:     src.Identifier,
+		UserIdentifier: string(src.UserIdentifier),
+		Name:           src.Name,
+		Scopes:         scopes,
+		CreatedAt:      src.CreatedAt,
+		ExpiresAt:      src.ExpiresAt,
+		Expired:        src.IsExpired(),
+		LastUsed:       src.LastUsed,
+		LastUsedIp:     src.LastUsedIp,
+	}
+}
+
+func NewApiTokens(src []domain.ApiToken) []ApiToken {
+	results := make([]ApiToken, len(src))
+	for i := range src {
+		results[i] = *NewApiToken(&src[i])
+	}
+
+	return results
+}
+
+// ApiTokenRequest contains the data that is required to create a new API token.
+type ApiTokenRequest struct {
+	// The name of the token.
+	Name string `json:"Name" binding:"required,max=64" example:"monitoring"`
+	// The scopes that should be granted to the token: admin, peers:read, peers:write, provisioning, metrics:read or
+	// tokens. The admin scope contains all other scopes.
+	Scopes []string `json:"Scopes" binding:"required,min=1" example:"peers:read,metrics:read"`
+	// The date after which the token can no longer be used. Defaults to 90 days after creation.
+	ExpiresAt *time.Time `json:"ExpiresAt,omitempty" example:"2025-04-01T10:00:00Z"`
+}
+
+// DomainScopes returns the requested scopes.
+func (r ApiTokenRequest) DomainScopes() []domain.ApiTokenScope {
+	scopes := make([]domain.ApiTokenScope, len(r.Scopes))
+	for i := range r.Scopes {
+		scopes[i] = domain.ApiTokenScope(r.Scopes[i])
+	}
+
+	return scopes
+}
+
+// CreatedApiToken is returned once after a new API token has been created.
+type CreatedApiToken struct {
+	ApiToken
+	// The API token. It is only shown once and can not be retrieved later.
+	Token string `json:"Token" example:"wgp_0f9d3c3c1d7e4b6a8c2f5e1a9b7d3c11.secret"`
+}
+
+func NewCreatedApiToken(src *domain.ApiToken, token string) *CreatedApiToken {
+	return &CreatedApiToken{
+		ApiToken: *NewApiToken(src),
+		Token:    token,
+	}
+}
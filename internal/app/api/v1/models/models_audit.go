@@ -0,0 +1,124 @@
+package models
+
+import (
+	"time"
+
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// AuditEntry represents a single entry of the audit log.
+type AuditEntry struct {
+	// The unique identifier of the entry, it is also used as pagination cursor.
+	Id uint64 `json:"Id" example:"42"`
+	// The time when the entry was created.
+	CreatedAt time.Time `json:"CreatedAt" example:"2024-01-01T12:00:00Z"`
+	// The severity of the entry.
+	Severity string `json:"Severity" example:"medium" enums:"low,medium,high"`
+	// The origin of the entry, for example the action that was performed.
+	Origin string `json:"Origin" example:"peerUpdated"`
+	// The user that triggered the event, empty for system events.
+	Actor string `json:"Actor" example:"admin@wgportal.local"`
+	// The IP address of the actor.
+	ClientIp string `json:"ClientIp" example:"192.168.1.10"`
+	// The type of the affected object.
+	ObjectType string `json:"ObjectType" example:"peer" enums:"user,interface,peer"`
+	// The identifier of the affected object.
+	ObjectId string `json:"ObjectId" example:"xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg="`
+	// A human-readable description of the event.
+	Message string `json:"Message" example:"peer xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg= updated"`
+	// The field-level changes of the affected object. Sensitive values are masked.
+	Changes []AuditFieldChange `json:"Changes,omitempty"`
+	// Additional information about the event.
+	Info map[string]string `json:"Info,omitempty"`
+}
+
+// AuditFieldChange describes the change of a single field.
+type AuditFieldChange struct {
+	// The name of the field, nested fields are separated by a dot.
+	Field string `json:"Field" example:"Interface.Mtu"`
+	// The old value.
+	Old string `json:"Old" example:"1420"`
+	// The new value.
+	New string `json:"New" example:"1380"`
+}
+
+// AuditEntryPage contains a single page of audit entries.
+type AuditEntryPage struct {
+	// The audit entries, newest entries first.
+	Entries []AuditEntry `json:"Entries"`
+	// The cursor that must be used to fetch the next page. It is empty if there are no more entries.
+	NextCursor string `json:"NextCursor,omitempty" example:"41"`
+}
+
+func NewAuditEntry(src *domain.AuditEntry) AuditEntry {
+	changes := make([]AuditFieldChange, len(src.Details.Changes))
+	for i, change := range src.Details.Changes {
+		changes[i] = AuditFieldChange{
+			Field: change.Field,
+			Old:   change.Old,
+			New:   change.New,
+		}
+	}
+
+	return AuditEntry{
+		Id:         src.UniqueId,
+		CreatedAt:  src.CreatedAt,
+		Severity:   string(src.Severity),
+		Origin:     src.Origin,
+		Actor:      string(src.Actor),
+		ClientIp:   src.ClientIp,
+		ObjectType: string(src.ObjectType),
+		ObjectId:   src.ObjectId,
+		Message:    src.Message,
+		Changes:    changes,
+		Info:       src.Details.Info,
+	}
+}
+
+func NewAuditEntries(src []domain.AuditEntry) []AuditEntry {
+	results := make([]AuditEntry, len(src))
+	for i := range src {
+		results[i] = NewAuditEntry(&src[i])
+	}
+
+	return results
+}
+
+// AuditVerification is the result of an audit hash chain verification.
+type AuditVerification struct {
+	// If this field is true, the audit chain is intact.
+	Valid bool `json:"Valid" example:"true"`
+	// The number of verified audit entries.
+	CheckedEntries int `json:"CheckedEntries" example:"1200"`
+	// The number of entries that were recorded before hash chaining was enabled.
+	UnchainedEntries int `json:"UnchainedEntries" example:"0"`
+	// The number of verified checkpoints.
+	CheckedCheckpoints int `json:"CheckedCheckpoints" example:"24"`
+	// The number of checkpoints without signature.
+	UnsignedCheckpoints int `json:"UnsignedCheckpoints" example:"0"`
+	// The id of the first chained entry.
+	FirstEntryId uint64 `json:"FirstEntryId" example:"1"`
+	// The id of the last verified entry.
+	LastEntryId uint64 `json:"LastEntryId" example:"1200"`
+	// If this field is true, the first entry is anchored by a checkpoint or is the start of the chain.
+	Anchored bool `json:"Anchored" example:"true"`
+	// The id of the entry where the chain is broken.
+	BrokenEntryId uint64 `json:"BrokenEntryId,omitempty" example:"0"`
+	// The reason why the chain is broken.
+	Reason string `json:"Reason,omitempty" example:""`
+}
+
+func NewAuditVerification(src *domain.AuditVerificationResult) *AuditVerification {
+	return &AuditVerification{
+		Valid:               src.Valid,
+		CheckedEntries:      src.CheckedEntries,
+		UnchainedEntries:    src.UnchainedEntries,
+		CheckedCheckpoints:  src.CheckedCheckpoints,
+		UnsignedCheckpoints: src.UnsignedCheckpoints,
+		FirstEntryId:        src.FirstEntryId,
+		LastEntryId:         src.LastEntryId,
+		Anchored:            src.Anchored,
+		BrokenEntryId:       src.BrokenEntryId,
+		Reason:              src.Reason,
+	}
+}
@@ -0,0 +1,117 @@
+package models
+
+import (
+	"time"
+
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// StreamEvent represents a single event of the live event stream.
+type StreamEvent struct {
+	// The unique identifier of the event. It can be used as Last-Event-ID to resume the stream.
+	Id string `json:"Id" example:"sab3k1-42"`
+	// The event type, for example peer:connected, peer:traffic or user:created.
+	Type string `json:"Type" example:"peer:connected"`
+	// The time when the event occurred.
+	Time time.Time `json:"Time" example:"2024-01-01T12:00:00Z"`
+	// The event data. For connection changes, this is a PeerConnectionEvent, for other peer events a PeerStatusEvent. For user events, this is a User.
+	// For interface events, this is an Interface. Other events contain the affected identifier.
+	Data any `json:"Data"`
+}
+
+func NewStreamEvent(src *domain.StreamEvent) *StreamEvent {
+	e := &StreamEvent{
+		Id:   src.Id,
+		Type: string(src.Type),
+		Time: src.Time,
+	}
+
+	switch data := src.Data.(type) {
+	case domain.PeerStatusUpdate:
+		e.Data = NewPeerStatusEvent(&data)
+	case domain.PeerConnectionEvent:
+		e.Data = NewPeerConnectionEvent(&data)
+	case domain.User:
+		e.Data = NewUser(&data, false)
+	case domain.Interface:
+		e.Data = NewInterface(&data, nil)
+	case domain.UserIdentifier:
+		e.Data = string(data)
+	case domain.InterfaceIdentifier:
+		e.Data = string(data)
+	case domain.PeerIdentifier:
+		e.Data = string(data)
+	default:
+		e.Data = data
+	}
+
+	return e
+}
+
+// PeerStatusEvent contains the status changes of a WireGuard peer.
+type PeerStatusEvent struct {
+	// The unique identifier of the peer.
+	PeerIdentifier string `json:"PeerIdentifier" example:"xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg="`
+	// The unique identifier of the interface the peer belongs to.
+	InterfaceIdentifier string `json:"InterfaceIdentifier" example:"wg0"`
+
+	// If this field is set, the peer is currently connected.
+	IsConnected bool `json:"IsConnected" example:"true"`
+	// The current endpoint address of the peer.
+	Endpoint string `json:"Endpoint" example:"12.34.56.78"`
+	// The last time the peer initiated a handshake.
+	LastHandshake *time.Time `json:"LastHandshake" example:"2021-01-01T12:00:00Z"`
+	// The last time the peer initiated a session.
+	LastSessionStart *time.Time `json:"LastSessionStart" example:"2021-01-01T12:00:00Z"`
+
+	// The total number of bytes received by the peer.
+	BytesReceived uint64 `json:"BytesReceived" example:"123456789"`
+	// The total number of bytes transmitted by the peer.
+	BytesTransmitted uint64 `json:"BytesTransmitted" example:"123456789"`
+	// The number of bytes received since the previous update.
+	BytesReceivedDelta uint64 `json:"BytesReceivedDelta" example:"1024"`
+	// The number of bytes transmitted since the previous update.
+	BytesTransmittedDelta uint64 `json:"BytesTransmittedDelta" example:"2048"`
+}
+
+func NewPeerStatusEvent(src *domain.PeerStatusUpdate) *PeerStatusEvent {
+	return &PeerStatusEvent{
+		PeerIdentifier:        string(src.Current.PeerId),
+		InterfaceIdentifier:   string(src.InterfaceId),
+		IsConnected:           src.Current.IsConnected(),
+		Endpoint:              src.Current.Endpoint,
+		LastHandshake:         src.Current.LastHandshake,
+		LastSessionStart:      src.Current.LastSessionStart,
+		BytesReceived:         src.Current.BytesReceived,
+		BytesTransmitted:      src.Current.BytesTransmitted,
+		BytesReceivedDelta:    src.ReceivedDelta(),
+		BytesTransmittedDelta: src.TransmittedDelta(),
+	}
+}
+
+// PeerConnectionEvent describes a change of the connection state of a WireGuard peer.
+type PeerConnectionEvent struct {
+	// The unique identifier of the peer.
+	PeerIdentifier string `json:"PeerIdentifier" example:"xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg="`
+	// The unique identifier of the interface the peer belongs to.
+	InterfaceIdentifier string `json:"InterfaceIdentifier" example:"wg0"`
+	// The display name of the peer.
+	DisplayName string `json:"DisplayName" example:"My Peer"`
+	// The user that owns the peer.
+	UserIdentifier string `json:"UserIdentifier" example:"uid-1234567"`
+	// The current endpoint address of the peer.
+	Endpoint string `json:"Endpoint" example:"12.34.56.78"`
+	// The start time of the current (or last) session.
+	SessionStart *time.Time `json:"SessionStart" example:"2021-01-01T12:00:00Z"`
+}
+
+func NewPeerConnectionEvent(src *domain.PeerConnectionEvent) *PeerConnectionEvent {
+	return &PeerConnectionEvent{
+		PeerIdentifier:      string(src.Peer.Identifier),
+		InterfaceIdentifier: string(src.Peer.InterfaceIdentifier),
+		DisplayName:         src.Peer.DisplayName,
+		UserIdentifier:      string(src.Peer.UserIdentifier),
+		Endpoint:            src.Endpoint,
+		SessionStart:        src.SessionStart,
+	}
+}
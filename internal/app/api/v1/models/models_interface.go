@@ -40,6 +40,10 @@ type Interface struct {
 	FirewallMark uint32 `json:"FirewallMark"`
 	// RoutingTable is an optional routing table which is used to route interface traffic.
 	RoutingTable string `json:"RoutingTable"`
+	// StaticRoutes is a list of custom routes that are installed in addition to the peer routes.
+	StaticRoutes []InterfaceStaticRoute `json:"StaticRoutes" binding:"omitempty,dive"`
+	// PolicyRules is a list of custom source based routing rules.
+	PolicyRules []InterfacePolicyRule `json:"PolicyRules" binding:"omitempty,dive"`
 
 	// PreUp is an optional action that is executed before the device is up.
 	PreUp string `json:"PreUp" example:"echo 'Interface is up'"`
@@ -103,6 +107,8 @@ func NewInterface(src *domain.Interface, peers []domain.Peer) *Interface {
 		Mtu:                        src.Mtu,
 		FirewallMark:               src.FirewallMark,
 		RoutingTable:               src.RoutingTable,
+		StaticRoutes:               NewInterfaceStaticRoutes(src.StaticRoutes),
+		PolicyRules:                NewInterfacePolicyRules(src.PolicyRules),
 		PreUp:                      src.PreUp,
 		PostUp:                     src.PostUp,
 		PreDown:                    src.PreDown,
@@ -140,6 +146,64 @@ func NewInterface(src *domain.Interface, peers []domain.Peer) *Interface {
 	return iface
 }
 
+// InterfaceStaticRoute represents a custom route of a WireGuard interface.
+type InterfaceStaticRoute struct {
+	// Destination is the destination network of the route in CIDR format.
+	Destination string `json:"Destination" binding:"required,cidr" example:"192.168.100.0/24"`
+	// Gateway is an optional gateway address. If empty, the route is a link scoped route.
+	Gateway string `json:"Gateway" binding:"omitempty,ip" example:"10.11.12.10"`
+	// Metric is an optional route metric.
+	Metric int `json:"Metric" binding:"omitempty,min=0" example:"100"`
+	// Table is an optional routing table. If zero, the routing table of the interface is used.
+	Table int `json:"Table" binding:"omitempty,min=0" example:"0"`
+}
+
+// InterfacePolicyRule represents a custom source based routing rule of a WireGuard interface.
+type InterfacePolicyRule struct {
+	// Source is the source network of the rule in CIDR format.
+	Source string `json:"Source" binding:"required,cidr" example:"192.168.200.0/24"`
+	// Table is an optional routing table. If zero, the routing table of the interface is used.
+	Table int `json:"Table" binding:"omitempty,min=0" example:"0"`
+	// Priority is an optional rule priority. If zero, a free priority is chosen automatically.
+	Priority int `json:"Priority" binding:"omitempty,min=0" example:"0"`
+}
+
+func NewInterfaceStaticRoutes(src []domain.InterfaceStaticRoute) []InterfaceStaticRoute {
+	results := make([]InterfaceStaticRoute, len(src))
+	for i, r := range src {
+		results[i] = InterfaceStaticRoute(r)
+	}
+
+	return results
+}
+
+func NewInterfacePolicyRules(src []domain.InterfacePolicyRule) []InterfacePolicyRule {
+	results := make([]InterfacePolicyRule, len(src))
+	for i, r := range src {
+		results[i] = InterfacePolicyRule(r)
+	}
+
+	return results
+}
+
+func NewDomainInterfaceStaticRoutes(src []InterfaceStaticRoute) []domain.InterfaceStaticRoute {
+	results := make([]domain.InterfaceStaticRoute, len(src))
+	for i, r := range src {
+		results[i] = domain.InterfaceStaticRoute(r)
+	}
+
+	return results
+}
+
+func NewDomainInterfacePolicyRules(src []InterfacePolicyRule) []domain.InterfacePolicyRule {
+	results := make([]domain.InterfacePolicyRule, len(src))
+	for i, r := range src {
+		results[i] = domain.InterfacePolicyRule(r)
+	}
+
+	return results
+}
+
 func NewInterfaces(src []domain.Interface, srcPeers [][]domain.Peer) []Interface {
 	results := make([]Interface, len(src))
 	for i := range src {
@@ -168,6 +232,8 @@ func NewDomainInterface(src *Interface) *domain.Interface {
 		Mtu:                        src.Mtu,
 		FirewallMark:               src.FirewallMark,
 		RoutingTable:               src.RoutingTable,
+		StaticRoutes:               NewDomainInterfaceStaticRoutes(src.StaticRoutes),
+		PolicyRules:                NewDomainInterfacePolicyRules(src.PolicyRules),
 		PreUp:                      src.PreUp,
 		PostUp:                     src.PostUp,
 		PreDown:                    src.PreDown,
@@ -0,0 +1,132 @@
+package models
+
+import (
+	"github.com/h44z/wg-portal/internal/domain"
+	"github.com/vishvananda/netlink"
+)
+
+// RoutingStatus compares the expected routes and rules of a WireGuard interface with the ones installed on the host.
+type RoutingStatus struct {
+	// The unique identifier of the interface.
+	InterfaceIdentifier string `json:"InterfaceIdentifier" example:"wg0"`
+	// If this field is false, the routing table of the interface is not managed by WireGuard Portal.
+	ManagementEnabled bool `json:"ManagementEnabled" example:"true"`
+	// The routing table that is used for the interface routes.
+	Table int `json:"Table" example:"20001"`
+	// The firewall mark that is used for the interface rules.
+	FwMark uint32 `json:"FwMark" example:"20001"`
+	// If this field is true, the expected and actual state are equal.
+	InSync bool `json:"InSync" example:"true"`
+
+	// The routes that should be installed, based on the allowed IPs of all peers.
+	ExpectedRoutes []RouteEntry `json:"ExpectedRoutes"`
+	// The routes that are currently installed on the host.
+	ActualRoutes []RouteEntry `json:"ActualRoutes"`
+	// The routing policy rules that should be installed.
+	ExpectedRules []RuleEntry `json:"ExpectedRules"`
+	// The routing policy rules that are currently installed on the host.
+	ActualRules []RuleEntry `json:"ActualRules"`
+
+	// Routes that are expected, but not installed.
+	MissingRoutes []RouteEntry `json:"MissingRoutes"`
+	// Routes that are installed, but not expected.
+	UnexpectedRoutes []RouteEntry `json:"UnexpectedRoutes"`
+	// Rules that are expected, but not installed.
+	MissingRules []RuleEntry `json:"MissingRules"`
+	// Rules that are installed, but not expected.
+	UnexpectedRules []RuleEntry `json:"UnexpectedRules"`
+
+	// If this field is set, the routing state of the interface could not be inspected.
+	Error string `json:"Error,omitempty" example:"failed to find physical link for wg0"`
+}
+
+// RouteEntry represents a single route.
+type RouteEntry struct {
+	// The destination network of the route.
+	Destination string `json:"Destination" example:"10.11.12.0/24"`
+	// The routing table of the route.
+	Table int `json:"Table" example:"20001"`
+}
+
+// RuleEntry represents a single routing policy rule.
+type RuleEntry struct {
+	// The address family of the rule, either IPv4 or IPv6.
+	Family string `json:"Family" example:"IPv4" enums:"IPv4,IPv6"`
+	// The source network of the rule. Only set for source based policy rules.
+	Source string `json:"Source,omitempty" example:"10.11.12.0/24"`
+	// The priority of the rule. Only set for installed rules.
+	Priority int `json:"Priority,omitempty" example:"32700"`
+	// The routing table that the rule points to.
+	Table int `json:"Table" example:"20001"`
+	// The firewall mark of the rule.
+	FwMark uint32 `json:"FwMark" example:"20001"`
+	// If this field is set, the rule matches packets that do NOT carry the firewall mark.
+	Invert bool `json:"Invert" example:"true"`
+	// The suppress prefix length of the rule, -1 if not set.
+	SuppressPrefixlen int `json:"SuppressPrefixlen" example:"-1"`
+}
+
+func NewRoutingStatus(src *domain.RoutingStatus) *RoutingStatus {
+	return &RoutingStatus{
+		InterfaceIdentifier: string(src.InterfaceId),
+		ManagementEnabled:   src.ManagementEnabled,
+		Table:               src.Table,
+		FwMark:              src.FwMark,
+		InSync:              src.InSync(),
+		ExpectedRoutes:      NewRouteEntries(src.ExpectedRoutes),
+		ActualRoutes:        NewRouteEntries(src.ActualRoutes),
+		ExpectedRules:       NewRuleEntries(src.ExpectedRules),
+		ActualRules:         NewRuleEntries(src.ActualRules),
+		MissingRoutes:       NewRouteEntries(src.MissingRoutes),
+		UnexpectedRoutes:    NewRouteEntries(src.UnexpectedRoutes),
+		MissingRules:        NewRuleEntries(src.MissingRules),
+		UnexpectedRules:     NewRuleEntries(src.UnexpectedRules),
+		Error:               src.Error,
+	}
+}
+
+func NewRoutingStatuses(src []domain.RoutingStatus) []RoutingStatus {
+	results := make([]RoutingStatus, len(src))
+	for i := range src {
+		results[i] = *NewRoutingStatus(&src[i])
+	}
+
+	return results
+}
+
+func NewRouteEntries(src []domain.RouteEntry) []RouteEntry {
+	results := make([]RouteEntry, len(src))
+	for i, r := range src {
+		results[i] = RouteEntry{
+			Destination: r.Destination.String(),
+			Table:       r.Table,
+		}
+	}
+
+	return results
+}
+
+func NewRuleEntries(src []domain.RuleEntry) []RuleEntry {
+	results := make([]RuleEntry, len(src))
+	for i, r := range src {
+		family := "IPv4"
+		if r.Family == netlink.FAMILY_V6 {
+			family = "IPv6"
+		}
+		source := ""
+		if r.Source != nil {
+			source = r.Source.String()
+		}
+		results[i] = RuleEntry{
+			Family:            family,
+			Source:            source,
+			Priority:          r.Priority,
+			Table:             r.Table,
+			FwMark:            r.FwMark,
+			Invert:            r.Invert,
+			SuppressPrefixlen: r.SuppressPrefixlen,
+		}
+	}
+
+	return results
+}
@@ -18,6 +18,12 @@ type User struct {
 	ProviderName string `json:"ProviderName,omitempty" readonly:"true" example:""`
 	// If this field is set, the user is an admin.
 	IsAdmin bool `json:"IsAdmin" binding:"required" example:"false"`
+	// The role of the user, for example admin, operator, auditor, user or a custom role. If set, it takes precedence
+	// over the IsAdmin flag. This field is optional.
+	Role string `json:"Role,omitempty" example:"user"`
+	// The interfaces that are administered by the user as delegated admin, including their peers and the users that
+	// own those peers. If this field is omitted, the current assignments are kept. This field is optional.
+	ManagedInterfaces []string `json:"ManagedInterfaces,omitempty" example:"wg-sales"`
 
 	// The first name of the user. This field is optional.
 	Firstname string `json:"Firstname" example:"Max"`
@@ -45,6 +51,12 @@ type User struct {
 	ApiToken string `json:"ApiToken,omitempty" binding:"omitempty,min=32,max=64" example:""`
 	// If this field is set, the user is allowed to use the RESTful API. This field is read-only.
 	ApiEnabled bool `json:"ApiEnabled" readonly:"true" example:"false"`
+	// If this field is set, the user has to enter a TOTP code on login. This field is read-only.
+	TotpEnabled bool `json:"TotpEnabled" readonly:"true" example:"false"`
+	// If this field is set, the user has been invited and has not chosen a password yet. This field is read-only.
+	InvitationPending bool `json:"InvitationPending" readonly:"true" example:"false"`
+	// The pending step of a self-registration: verification or approval. This field is read-only.
+	RegistrationState string `json:"RegistrationState,omitempty" readonly:"true" example:""`
 
 	// The number of peers linked to the user. This field is read-only.
 	PeerCount int `json:"PeerCount" readonly:"true" example:"2"`
@@ -52,24 +64,29 @@ type User struct {
 
 func NewUser(src *domain.User, exposeCredentials bool) *User {
 	u := &User{
-		Identifier:     string(src.Identifier),
-		Email:          src.Email,
-		Source:         string(src.Source),
-		ProviderName:   src.ProviderName,
-		IsAdmin:        src.IsAdmin,
-		Firstname:      src.Firstname,
-		Lastname:       src.Lastname,
-		Phone:          src.Phone,
-		Department:     src.Department,
-		Notes:          src.Notes,
-		Password:       "", // never fill password
-		Disabled:       src.IsDisabled(),
-		DisabledReason: src.DisabledReason,
-		Locked:         src.IsLocked(),
-		LockedReason:   src.LockedReason,
-		ApiToken:       "", // by default, do not expose API token
-		ApiEnabled:     src.IsApiEnabled(),
-		PeerCount:      src.LinkedPeerCount,
+		Identifier:        string(src.Identifier),
+		Email:             src.Email,
+		Source:            string(src.Source),
+		ProviderName:      src.ProviderName,
+		IsAdmin:           src.IsAdmin,
+		Role:              string(src.EffectiveRole()),
+		ManagedInterfaces: newInterfaceIdentifiers(src.ManagedInterfaces),
+		Firstname:         src.Firstname,
+		Lastname:          src.Lastname,
+		Phone:             src.Phone,
+		Department:        src.Department,
+		Notes:             src.Notes,
+		Password:          "", // never fill password
+		Disabled:          src.IsDisabled(),
+		DisabledReason:    src.DisabledReason,
+		Locked:            src.IsLocked(),
+		LockedReason:      src.LockedReason,
+		ApiToken:          "", // by default, do not expose API token
+		ApiEnabled:        src.IsApiEnabled(),
+		TotpEnabled:       src.SecondFactor.IsTotpEnabled(),
+		InvitationPending: src.Invitation.IsPending(),
+		RegistrationState: src.Registration.State(),
+		PeerCount:         src.LinkedPeerCount,
 	}
 
 	if exposeCredentials {
@@ -108,6 +125,14 @@ func NewDomainUser(src *User) *domain.User {
 		LockedReason:   src.LockedReason,
 	}
 
+	if src.Role != "" {
+		res.SetRole(domain.RoleName(src.Role))
+	}
+
+	if src.ManagedInterfaces != nil {
+		res.ManagedInterfaces = newDomainInterfaceIdentifiers(src.ManagedInterfaces)
+	}
+
 	if src.ApiToken != "" {
 		res.ApiToken = src.ApiToken
 		res.ApiTokenCreated = &now
@@ -123,3 +148,21 @@ func NewDomainUser(src *User) *domain.User {
 
 	return res
 }
+
+func newInterfaceIdentifiers(src []domain.InterfaceIdentifier) []string {
+	results := make([]string, len(src))
+	for i := range src {
+		results[i] = string(src[i])
+	}
+
+	return results
+}
+
+func newDomainInterfaceIdentifiers(src []string) []domain.InterfaceIdentifier {
+	results := make([]domain.InterfaceIdentifier, len(src))
+	for i := range src {
+		results[i] = domain.InterfaceIdentifier(src[i])
+	}
+
+	return results
+}
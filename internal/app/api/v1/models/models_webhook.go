@@ -0,0 +1,92 @@
+package models
+
+import (
+	"time"
+
+	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// Webhook represents a configured webhook subscription.
+type Webhook struct {
+	// The unique name of the webhook subscription.
+	Name string `json:"Name" example:"monitoring"`
+	// The URL that receives the event payloads.
+	Url string `json:"Url" example:"https://hooks.example.com/wg-portal"`
+	// The event topics that are delivered to the receiver.
+	Topics []string `json:"Topics" example:"user:created,peer:*"`
+	// If this field is true, payloads are signed with HMAC-SHA256.
+	Signed bool `json:"Signed" example:"true"`
+	// The number of retries after a failed delivery attempt.
+	MaxRetries int `json:"MaxRetries" example:"3"`
+}
+
+func NewWebhook(src config.WebhookConfig) Webhook {
+	return Webhook{
+		Name:       src.Name,
+		Url:        src.Url,
+		Topics:     src.Topics,
+		Signed:     src.Secret != "",
+		MaxRetries: src.MaxRetries,
+	}
+}
+
+func NewWebhooks(src []config.WebhookConfig) []Webhook {
+	results := make([]Webhook, len(src))
+	for i := range src {
+		results[i] = NewWebhook(src[i])
+	}
+
+	return results
+}
+
+// WebhookDelivery represents a single delivery of an event to a webhook receiver.
+type WebhookDelivery struct {
+	// The unique identifier of the delivery.
+	Identifier string `json:"Identifier" example:"5d6f9a3c-0c5b-4bd3-8d6b-0a6e4b1b2c3d"`
+	// The time when the delivery was created.
+	CreatedAt time.Time `json:"CreatedAt" example:"2024-01-01T12:00:00Z"`
+	// The name of the webhook subscription.
+	Webhook string `json:"Webhook" example:"monitoring"`
+	// The event topic.
+	Topic string `json:"Topic" example:"user:created"`
+	// The delivery state, either pending, delivered or dead-letter.
+	State string `json:"State" example:"delivered" enums:"pending,delivered,dead-letter"`
+	// The JSON payload that is sent to the receiver.
+	Payload string `json:"Payload"`
+	// The number of delivery attempts.
+	Attempts int `json:"Attempts" example:"1"`
+	// The time of the last delivery attempt.
+	LastAttempt *time.Time `json:"LastAttempt,omitempty" example:"2024-01-01T12:00:01Z"`
+	// The HTTP status code of the last delivery attempt, 0 if no response was received.
+	LastStatusCode int `json:"LastStatusCode" example:"200"`
+	// The error message of the last delivery attempt.
+	LastError string `json:"LastError,omitempty" example:""`
+	// The time of the next scheduled retry of a pending delivery.
+	NextAttempt *time.Time `json:"NextAttempt,omitempty" example:"2024-01-01T12:00:06Z"`
+}
+
+func NewWebhookDelivery(src *domain.WebhookDelivery) *WebhookDelivery {
+	return &WebhookDelivery{
+		Identifier:     src.Identifier,
+		CreatedAt:      src.CreatedAt,
+		Webhook:        src.Webhook,
+		Topic:          src.Topic,
+		State:          string(src.State),
+		Payload:        src.Payload,
+		Attempts:       src.Attempts,
+		LastAttempt:    src.LastAttempt,
+		LastStatusCode: src.LastStatusCode,
+		LastError:      src.LastError,
+		NextAttempt:    src.NextAttempt,
+	}
+}
+
+func NewWebhookDeliveries(src []domain.WebhookDelivery) []WebhookDelivery {
+	results := make([]WebhookDelivery, len(src))
+	for i := range src {
+		results[i] = *NewWebhookDelivery(&src[i])
+	}
+
+	return results
+}
@@ -2,31 +2,55 @@ package audit
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
 	"github.com/h44z/wg-portal/internal/app"
 	"github.com/h44z/wg-portal/internal/config"
 	"github.com/h44z/wg-portal/internal/domain"
 	"github.com/sirupsen/logrus"
 	evbus "github.com/vardius/message-bus"
-	"time"
 )
 
 type Recorder struct {
-	cfg *config.Config
-	bus evbus.MessageBus
+	cfg      *config.Config
+	bus      evbus.MessageBus
+	verifier *Verifier
 
 	db DatabaseRepo
+
+	chainMu          sync.Mutex // serializes writes, so that each entry is chained to its predecessor
+	lastEntryId      uint64
+	lastHash         string
+	lastCheckpointId uint64 // the entry id of the last checkpoint
+
+	sinkMux sync.RWMutex
+	sinks   []*sinkWorker
 }
 
 func NewAuditRecorder(cfg *config.Config, bus evbus.MessageBus, db DatabaseRepo) (*Recorder, error) {
 	r := &Recorder{
-		cfg: cfg,
-		bus: bus,
+		cfg:      cfg,
+		bus:      bus,
+		verifier: NewAuditVerifier(cfg, db),
 
 		db: db,
 	}
 
-	err := r.connectToMessageBus()
+	lastEntries, err := r.db.GetAuditEntries(context.Background(), domain.AuditEntryFilter{Limit: 1})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load last audit entry: %w", err)
+	}
+	if len(lastEntries) > 0 {
+		r.lastEntryId = lastEntries[0].UniqueId
+		r.lastHash = lastEntries[0].Hash
+	}
+
+	err = r.connectToMessageBus()
 	if err != nil {
 		return nil, fmt.Errorf("failed to setup message bus: %w", err)
 	}
@@ -39,6 +63,8 @@ func (r *Recorder) StartBackgroundJobs(ctx context.Context) {
 		return // noting to do
 	}
 
+	r.startSinks(ctx)
+
 	go func() {
 		running := true
 		for running {
@@ -50,11 +76,179 @@ func (r *Recorder) StartBackgroundJobs(ctx context.Context) {
 				// select blocks until one of the cases evaluate to true
 			}
 
-			logrus.Tracef("registered %d audit message within the last hour", 0) // TODO: implement
+			r.createCheckpoint(ctx)
+			r.purgeExpiredEntries(ctx)
+			r.reportDroppedSinkEntries()
 		}
 	}()
 }
 
+// VerifyChain walks the audit hash chain and reports the first broken link.
+func (r *Recorder) VerifyChain(ctx context.Context) (*domain.AuditVerificationResult, error) {
+	if err := domain.ValidateAdminAccessRights(ctx, domain.PermissionAuditRead); err != nil {
+		return nil, err
+	}
+
+	return r.verifier.Verify(ctx)
+}
+
+// saveEntry chains the entry to the last recorded entry and stores it. Afterwards, it is forwarded to all sinks.
+func (r *Recorder) saveEntry(entry *domain.AuditEntry) error {
+	r.chainMu.Lock()
+	defer r.chainMu.Unlock()
+
+	entry.CreatedAt = time.Now().Truncate(time.Millisecond) // the hash uses millisecond precision
+	entry.PrevHash = r.lastHash
+	entry.Hash = entry.CalculateHash()
+
+	if err := r.db.SaveAuditEntry(context.Background(), entry); err != nil {
+		return err
+	}
+
+	r.lastEntryId = entry.UniqueId
+	r.lastHash = entry.Hash
+
+	r.forwardToSinks(*entry)
+
+	return nil
+}
+
+// createCheckpoint stores a checkpoint for the last recorded entry, if there were new entries since the last run.
+func (r *Recorder) createCheckpoint(ctx context.Context) {
+	r.chainMu.Lock()
+	entryId, entryHash := r.lastEntryId, r.lastHash
+	r.chainMu.Unlock()
+
+	if entryId == 0 || entryHash == "" || entryId == r.lastCheckpointId {
+		return // nothing new to checkpoint
+	}
+
+	if err := r.saveCheckpoint(ctx, entryId, entryHash); err != nil {
+		logrus.Errorf("failed to create audit checkpoint for entry %d: %v", entryId, err)
+		return
+	}
+
+	r.lastCheckpointId = entryId
+}
+
+func (r *Recorder) saveCheckpoint(ctx context.Context, entryId uint64, entryHash string) error {
+	checkpoint := &domain.AuditCheckpoint{
+		CreatedAt: time.Now().Truncate(time.Millisecond),
+		EntryId:   entryId,
+		EntryHash: entryHash,
+	}
+	if r.cfg.Statistics.AuditCheckpointKey != "" {
+		checkpoint.Signature = checkpoint.CalculateSignature(r.cfg.Statistics.AuditCheckpointKey)
+	}
+
+	return r.db.SaveAuditCheckpoint(ctx, checkpoint)
+}
+
+// GetEntries returns the audit entries that match the given filter, newest entries first.
+func (r *Recorder) GetEntries(ctx context.Context, filter domain.AuditEntryFilter) ([]domain.AuditEntry, error) {
+	if err := domain.ValidateAdminAccessRights(ctx, domain.PermissionAuditRead); err != nil {
+		return nil, err
+	}
+
+	entries, err := r.db.GetAuditEntries(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load audit entries: %w", err)
+	}
+
+	return entries, nil
+}
+
+// purgeExpiredEntries deletes all audit entries that are older than the configured retention time.
+// If an archive path is configured, the entries are archived before they get deleted.
+func (r *Recorder) purgeExpiredEntries(ctx context.Context) {
+	if r.cfg.Statistics.AuditRetention <= 0 {
+		return // entries are kept forever
+	}
+
+	before := time.Now().Add(-r.cfg.Statistics.AuditRetention)
+
+	newest, err := r.db.GetAuditEntries(ctx, domain.AuditEntryFilter{To: &before, Limit: 1})
+	if err != nil {
+		logrus.Errorf("failed to load expired audit entries: %v", err)
+		return
+	}
+	if len(newest) == 0 {
+		return // nothing to purge
+	}
+	maxId := newest[0].UniqueId
+
+	if r.cfg.Statistics.AuditArchivePath != "" {
+		if err := r.archiveEntries(ctx, before, maxId); err != nil {
+			logrus.Errorf("failed to archive audit entries, skipping purge: %v", err)
+			return
+		}
+	}
+
+	// the checkpoint anchors the remaining chain, its first entry references the hash of the purged entry
+	if newest[0].Hash != "" {
+		if err := r.saveCheckpoint(ctx, maxId, newest[0].Hash); err != nil {
+			logrus.Errorf("failed to create audit checkpoint, skipping purge: %v", err)
+			return
+		}
+	}
+
+	deleted, err := r.db.DeleteAuditEntries(ctx, before, maxId)
+	if err != nil {
+		logrus.Errorf("failed to purge audit entries: %v", err)
+		return
+	}
+
+	logrus.Tracef("purged %d audit entries older than %s", deleted, before.Format(time.RFC3339))
+}
+
+// archiveEntries appends all audit entries created before the given time, up to the given id,
+// to a monthly JSON Lines file.
+func (r *Recorder) archiveEntries(ctx context.Context, before time.Time, maxId uint64) error {
+	const pageSize = 500
+
+	if err := os.MkdirAll(r.cfg.Statistics.AuditArchivePath, os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create archive path: %w", err)
+	}
+
+	fileName := filepath.Join(r.cfg.Statistics.AuditArchivePath,
+		fmt.Sprintf("audit-%s.jsonl", time.Now().Format("2006-01")))
+	file, err := os.OpenFile(fileName, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open archive file %s: %w", fileName, err)
+	}
+	defer file.Close()
+
+	// the entries are written oldest first, so that the archived chain can be verified in order
+	encoder := json.NewEncoder(file)
+	filter := domain.AuditEntryFilter{To: &before, Limit: pageSize, Ascending: true}
+	for {
+		entries, err := r.db.GetAuditEntries(ctx, filter)
+		if err != nil {
+			return fmt.Errorf("failed to load audit entries: %w", err)
+		}
+
+		for i := range entries {
+			if entries[i].UniqueId > maxId {
+				break
+			}
+			if err := encoder.Encode(entries[i]); err != nil {
+				return fmt.Errorf("failed to write archive file %s: %w", fileName, err)
+			}
+		}
+
+		if len(entries) < pageSize || entries[len(entries)-1].UniqueId >= maxId {
+			break
+		}
+		filter.Cursor = entries[len(entries)-1].UniqueId
+	}
+
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync archive file %s: %w", fileName, err)
+	}
+
+	return nil
+}
+
 func (r *Recorder) connectToMessageBus() error {
 	if !r.cfg.Statistics.CollectAuditData {
 		return nil // noting to do
@@ -63,19 +257,50 @@ func (r *Recorder) connectToMessageBus() error {
 	if err := r.bus.Subscribe(app.TopicAuthLogin, r.authLoginEvent); err != nil {
 		return fmt.Errorf("failed to subscribe to %s: %w", app.TopicAuthLogin, err)
 	}
+	if err := r.bus.Subscribe(app.TopicAuditEvent, r.auditEvent); err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", app.TopicAuditEvent, err)
+	}
+
+	domain.SetAccessDeniedHandler(r.accessDenied)
 
 	return nil
 }
 
 func (r *Recorder) authLoginEvent(userIdentifier domain.UserIdentifier) {
-	err := r.db.SaveAuditEntry(context.Background(), &domain.AuditEntry{
-		CreatedAt: time.Time{},
-		Severity:  domain.AuditSeverityLevelLow,
-		Origin:    "authLoginEvent",
-		Message:   fmt.Sprintf("user %s logged in", userIdentifier),
+	err := r.saveEntry(&domain.AuditEntry{
+		Severity:   domain.AuditSeverityLevelLow,
+		Origin:     "authLoginEvent",
+		Actor:      userIdentifier,
+		ObjectType: domain.AuditObjectTypeUser,
+		ObjectId:   string(userIdentifier),
+		Message:    fmt.Sprintf("user %s logged in", userIdentifier),
 	})
 	if err != nil {
 		logrus.Errorf("failed to create audit entry for handleAuthLoginEvent: %v", err)
 		return
 	}
 }
+
+func (r *Recorder) auditEvent(event domain.AuditEvent) {
+	err := r.saveEntry(&domain.AuditEntry{
+		Severity:   event.Severity,
+		Origin:     event.Origin,
+		Actor:      event.Actor,
+		ClientIp:   event.ClientIp,
+		ObjectType: event.ObjectType,
+		ObjectId:   event.ObjectId,
+		Message:    event.Message,
+		Details:    event.Details,
+	})
+	if err != nil {
+		logrus.Errorf("failed to create audit entry for %s: %v", event.Origin, err)
+		return
+	}
+}
+
+// accessDenied is called for failed access rights checks. The event is forwarded via the message bus,
+// so that the request is not blocked by the database write.
+func (r *Recorder) accessDenied(ctx context.Context, message string) {
+	r.bus.Publish(app.TopicAuditEvent,
+		domain.NewAuditEvent(ctx, domain.AuditSeverityLevelMedium, "accessDenied", "", "", message))
+}
@@ -0,0 +1,60 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+func TestRecorder_archiveEntries(t *testing.T) {
+	entries := newTestAuditChain(1200, 0)
+	before := entries[1100].CreatedAt // entries 1 to 1100 are expired
+
+	cfg := &config.Config{}
+	cfg.Statistics.AuditArchivePath = t.TempDir()
+	r := &Recorder{cfg: cfg, db: &testDatabaseRepo{entries: entries}}
+
+	if err := r.archiveEntries(context.Background(), before, 1100); err != nil {
+		t.Fatalf("archiveEntries() error = %v", err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(cfg.Statistics.AuditArchivePath, "audit-*.jsonl"))
+	if len(files) != 1 {
+		t.Fatalf("archiveEntries() wrote %d files, want 1", len(files))
+	}
+	file, err := os.Open(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var archived []domain.AuditEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry domain.AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("invalid archive line: %v", err)
+		}
+		archived = append(archived, entry)
+	}
+
+	if len(archived) != 1100 {
+		t.Fatalf("archiveEntries() archived %d entries, want 1100", len(archived))
+	}
+	prevHash := ""
+	for i, entry := range archived {
+		if entry.UniqueId != uint64(i+1) {
+			t.Fatalf("archived entry %d has id %d, want ascending ids", i, entry.UniqueId)
+		}
+		if entry.PrevHash != prevHash {
+			t.Fatalf("archived entry %d does not continue the chain", entry.UniqueId)
+		}
+		prevHash = entry.Hash
+	}
+}
@@ -2,9 +2,21 @@ package audit
 
 import (
 	"context"
+	"time"
+
 	"github.com/h44z/wg-portal/internal/domain"
 )
 
 type DatabaseRepo interface {
 	SaveAuditEntry(ctx context.Context, entry *domain.AuditEntry) error
+	GetAuditEntries(ctx context.Context, filter domain.AuditEntryFilter) ([]domain.AuditEntry, error)
+	DeleteAuditEntries(ctx context.Context, before time.Time, maxId uint64) (int64, error)
+	GetAuditCheckpoints(ctx context.Context) ([]domain.AuditCheckpoint, error)
+	SaveAuditCheckpoint(ctx context.Context, checkpoint *domain.AuditCheckpoint) error
+}
+
+// Sink forwards audit entries to an external system, like a syslog server.
+type Sink interface {
+	Write(entry *domain.AuditEntry) error
+	Close() error
 }
@@ -0,0 +1,143 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
+	"github.com/sirupsen/logrus"
+)
+
+// sinkDrainTimeout defines how long queued entries are forwarded after the application was stopped.
+const sinkDrainTimeout = 3 * time.Second
+
+// sinkWorker forwards entries to a sink in the background. The queue is buffered, so that a slow sink
+// never blocks the recorder. If the queue is full, entries are dropped.
+type sinkWorker struct {
+	name        string
+	minSeverity domain.AuditSeverityLevel
+	sink        Sink
+
+	queue   chan domain.AuditEntry
+	dropped atomic.Uint64
+}
+
+func newSinkWorker(cfg config.AuditSinkConfig, sink Sink) (*sinkWorker, error) {
+	minSeverity := domain.AuditSeverityLevel(cfg.MinSeverity)
+	switch minSeverity {
+	case "":
+		minSeverity = domain.AuditSeverityLevelLow
+	case domain.AuditSeverityLevelLow, domain.AuditSeverityLevelMedium, domain.AuditSeverityLevelHigh:
+	default:
+		return nil, fmt.Errorf("invalid minimum severity %q for audit sink %s", cfg.MinSeverity, cfg.Name)
+	}
+
+	return &sinkWorker{
+		name:        cfg.Name,
+		minSeverity: minSeverity,
+		sink:        sink,
+		queue:       make(chan domain.AuditEntry, cfg.GetBufferSize()),
+	}, nil
+}
+
+// enqueue adds the entry to the queue if it reaches the severity threshold. It never blocks.
+func (w *sinkWorker) enqueue(entry domain.AuditEntry) {
+	if !entry.Severity.AtLeast(w.minSeverity) {
+		return
+	}
+
+	select {
+	case w.queue <- entry:
+	default:
+		w.dropped.Add(1)
+	}
+}
+
+func (w *sinkWorker) run(ctx context.Context) {
+	defer func() {
+		if err := w.sink.Close(); err != nil {
+			logrus.Warnf("failed to close audit sink %s: %v", w.name, err)
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.drain(sinkDrainTimeout)
+			return
+		case entry := <-w.queue:
+			w.write(entry)
+		}
+	}
+}
+
+// drain forwards the remaining queued entries until the queue is empty or the timeout is reached.
+func (w *sinkWorker) drain(timeout time.Duration) {
+	deadline := time.After(timeout)
+	for {
+		select {
+		case <-deadline:
+			if remaining := len(w.queue); remaining > 0 {
+				logrus.Warnf("audit sink %s dropped %d entries on shutdown", w.name, remaining)
+			}
+			return
+		case entry := <-w.queue:
+			w.write(entry)
+		default:
+			return // queue is empty
+		}
+	}
+}
+
+func (w *sinkWorker) write(entry domain.AuditEntry) {
+	if err := w.sink.Write(&entry); err != nil {
+		logrus.Warnf("failed to forward audit entry %d to sink %s: %v", entry.UniqueId, w.name, err)
+	}
+}
+
+// RegisterSink adds a sink that receives all new audit entries that reach the configured severity threshold.
+func (r *Recorder) RegisterSink(cfg config.AuditSinkConfig, sink Sink) error {
+	worker, err := newSinkWorker(cfg, sink)
+	if err != nil {
+		return err
+	}
+
+	r.sinkMux.Lock()
+	r.sinks = append(r.sinks, worker)
+	r.sinkMux.Unlock()
+
+	return nil
+}
+
+func (r *Recorder) forwardToSinks(entry domain.AuditEntry) {
+	r.sinkMux.RLock()
+	defer r.sinkMux.RUnlock()
+
+	for _, worker := range r.sinks {
+		worker.enqueue(entry)
+	}
+}
+
+func (r *Recorder) startSinks(ctx context.Context) {
+	r.sinkMux.RLock()
+	defer r.sinkMux.RUnlock()
+
+	for _, worker := range r.sinks {
+		go worker.run(ctx)
+	}
+}
+
+// reportDroppedSinkEntries logs the number of entries that were dropped since the last report.
+func (r *Recorder) reportDroppedSinkEntries() {
+	r.sinkMux.RLock()
+	defer r.sinkMux.RUnlock()
+
+	for _, worker := range r.sinks {
+		if dropped := worker.dropped.Swap(0); dropped > 0 {
+			logrus.Warnf("audit sink %s dropped %d entries, the sink is too slow or unavailable", worker.name, dropped)
+		}
+	}
+}
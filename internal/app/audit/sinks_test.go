@@ -0,0 +1,82 @@
+package audit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+type testSink struct {
+	mux     sync.Mutex
+	delay   time.Duration
+	written int
+	closed  bool
+}
+
+func (s *testSink) Write(*domain.AuditEntry) error {
+	time.Sleep(s.delay)
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.written++
+	return nil
+}
+
+func (s *testSink) Close() error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.closed = true
+	return nil
+}
+
+func TestSinkWorker_DrainsQueueOnShutdown(t *testing.T) {
+	tests := []struct {
+		name        string
+		delay       time.Duration
+		timeout     time.Duration
+		wantWritten func(written int) bool
+	}{
+		{name: "all entries", timeout: time.Second, wantWritten: func(written int) bool { return written == 10 }},
+		{name: "timeout", delay: 20 * time.Millisecond, timeout: 50 * time.Millisecond,
+			wantWritten: func(written int) bool { return written > 0 && written < 10 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := &testSink{delay: tt.delay}
+			worker, err := newSinkWorker(config.AuditSinkConfig{Name: "test"}, sink)
+			if err != nil {
+				t.Fatalf("newSinkWorker() error = %v", err)
+			}
+			for i := 0; i < 10; i++ {
+				worker.enqueue(domain.AuditEntry{UniqueId: uint64(i + 1), Severity: domain.AuditSeverityLevelLow})
+			}
+
+			worker.drain(tt.timeout)
+			_ = worker.sink.Close()
+
+			if !tt.wantWritten(sink.written) || !sink.closed {
+				t.Errorf("drain() written = %d, closed = %t", sink.written, sink.closed)
+			}
+		})
+	}
+}
+
+func TestSinkWorker_RunDrainsQueue(t *testing.T) {
+	sink := &testSink{}
+	worker, _ := newSinkWorker(config.AuditSinkConfig{Name: "test"}, sink)
+	for i := 0; i < 10; i++ {
+		worker.enqueue(domain.AuditEntry{UniqueId: uint64(i + 1), Severity: domain.AuditSeverityLevelHigh})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel() // stopped before the worker processed any entry
+	worker.run(ctx)
+
+	if sink.written != 10 || !sink.closed {
+		t.Errorf("run() written = %d, closed = %t, want 10, true", sink.written, sink.closed)
+	}
+}
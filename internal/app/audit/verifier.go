@@ -0,0 +1,122 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// verifyPageSize is the number of entries that are loaded at once during a verification.
+const verifyPageSize = 500
+
+// Verifier walks the audit hash chain and checks it against the stored checkpoints.
+type Verifier struct {
+	cfg *config.Config
+
+	db DatabaseRepo
+}
+
+func NewAuditVerifier(cfg *config.Config, db DatabaseRepo) *Verifier {
+	return &Verifier{
+		cfg: cfg,
+		db:  db,
+	}
+}
+
+// Verify checks the whole audit chain. It stops at the first broken link.
+func (v *Verifier) Verify(ctx context.Context) (*domain.AuditVerificationResult, error) {
+	result := &domain.AuditVerificationResult{Valid: true}
+
+	checkpoints, err := v.db.GetAuditCheckpoints(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load audit checkpoints: %w", err)
+	}
+
+	checkpointsByEntry := make(map[uint64][]domain.AuditCheckpoint, len(checkpoints))
+	for _, checkpoint := range checkpoints {
+		if checkpoint.Signature == "" {
+			result.UnsignedCheckpoints++
+		} else if v.cfg.Statistics.AuditCheckpointKey != "" &&
+			!checkpoint.VerifySignature(v.cfg.Statistics.AuditCheckpointKey) {
+			result.Fail(checkpoint.EntryId, fmt.Sprintf("invalid signature of checkpoint %d", checkpoint.Id))
+			return result, nil
+		}
+		checkpointsByEntry[checkpoint.EntryId] = append(checkpointsByEntry[checkpoint.EntryId], checkpoint)
+		result.CheckedCheckpoints++
+	}
+
+	prevHash := ""
+	filter := domain.AuditEntryFilter{Limit: verifyPageSize, Ascending: true}
+	for {
+		entries, err := v.db.GetAuditEntries(ctx, filter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load audit entries: %w", err)
+		}
+
+		for i := range entries {
+			entry := &entries[i]
+
+			if result.FirstEntryId == 0 {
+				if entry.Hash == "" {
+					result.UnchainedEntries++ // recorded before hash chaining was enabled
+					continue
+				}
+				result.FirstEntryId = entry.UniqueId
+				if entry.PrevHash != "" && !v.isAnchored(checkpoints, entry) {
+					result.Fail(entry.UniqueId, "previous entry is missing and no checkpoint anchors the chain, "+
+						"oldest entries were deleted")
+					return result, nil
+				}
+				result.Anchored = true
+			} else if entry.PrevHash != prevHash {
+				result.Fail(entry.UniqueId, "previous hash does not match, entries were modified or deleted")
+				return result, nil
+			}
+
+			if entry.CalculateHash() != entry.Hash {
+				result.Fail(entry.UniqueId, "content hash does not match, entry was modified")
+				return result, nil
+			}
+
+			for _, checkpoint := range checkpointsByEntry[entry.UniqueId] {
+				if checkpoint.EntryHash != entry.Hash {
+					result.Fail(entry.UniqueId, fmt.Sprintf("hash does not match checkpoint %d", checkpoint.Id))
+					return result, nil
+				}
+			}
+
+			prevHash = entry.Hash
+			result.LastEntryId = entry.UniqueId
+			result.CheckedEntries++
+		}
+
+		if len(entries) < filter.Limit {
+			break
+		}
+		filter.Cursor = entries[len(entries)-1].UniqueId
+	}
+
+	// checkpoints of entries newer than the last entry indicate that the newest entries were deleted
+	if len(checkpoints) > 0 {
+		latest := checkpoints[len(checkpoints)-1]
+		if latest.EntryId > result.LastEntryId {
+			result.Fail(latest.EntryId, fmt.Sprintf("entry of checkpoint %d is missing, newest entries were deleted",
+				latest.Id))
+			return result, nil
+		}
+	}
+
+	return result, nil
+}
+
+// isAnchored checks if the previous hash of the first chained entry belongs to a checkpoint of a purged entry.
+func (v *Verifier) isAnchored(checkpoints []domain.AuditCheckpoint, entry *domain.AuditEntry) bool {
+	for _, checkpoint := range checkpoints {
+		if checkpoint.EntryId < entry.UniqueId && checkpoint.EntryHash == entry.PrevHash {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,173 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+type testDatabaseRepo struct {
+	DatabaseRepo
+	entries     []domain.AuditEntry // sorted by id, oldest first
+	checkpoints []domain.AuditCheckpoint
+}
+
+func (r *testDatabaseRepo) GetAuditEntries(_ context.Context, filter domain.AuditEntryFilter) (
+	[]domain.AuditEntry,
+	error,
+) {
+	var entries []domain.AuditEntry
+	for _, entry := range r.entries {
+		if filter.To != nil && !entry.CreatedAt.Before(*filter.To) {
+			continue
+		}
+		if filter.Cursor > 0 && filter.Ascending && entry.UniqueId <= filter.Cursor {
+			continue
+		}
+		if filter.Cursor > 0 && !filter.Ascending && entry.UniqueId >= filter.Cursor {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if !filter.Ascending {
+		slices.Reverse(entries)
+	}
+	if filter.Limit > 0 && len(entries) > filter.Limit {
+		entries = entries[:filter.Limit]
+	}
+	return entries, nil
+}
+
+func (r *testDatabaseRepo) GetAuditCheckpoints(context.Context) ([]domain.AuditCheckpoint, error) {
+	return r.checkpoints, nil
+}
+
+// newTestAuditChain returns a chain of the given length, the first entries are recorded without hash.
+func newTestAuditChain(length, unchained int) []domain.AuditEntry {
+	created := time.Now().Add(-time.Hour)
+	entries := make([]domain.AuditEntry, length)
+	prevHash := ""
+	for i := range entries {
+		entry := &entries[i]
+		entry.UniqueId = uint64(i + 1)
+		entry.CreatedAt = created.Add(time.Duration(i) * time.Second)
+		entry.Message = fmt.Sprintf("entry %d", i+1)
+		if i < unchained {
+			continue
+		}
+		entry.PrevHash = prevHash
+		entry.Hash = entry.CalculateHash()
+		prevHash = entry.Hash
+	}
+	return entries
+}
+
+func newTestCheckpoint(id uint64, entry domain.AuditEntry) domain.AuditCheckpoint {
+	return domain.AuditCheckpoint{Id: id, EntryId: entry.UniqueId, EntryHash: entry.Hash}
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	tests := []struct {
+		name          string
+		prepare       func(entries []domain.AuditEntry) ([]domain.AuditEntry, []domain.AuditCheckpoint)
+		wantValid     bool
+		wantBrokenId  uint64
+		wantChecked   int
+		wantUnchained int
+	}{
+		{
+			name: "valid chain",
+			prepare: func(entries []domain.AuditEntry) ([]domain.AuditEntry, []domain.AuditCheckpoint) {
+				return entries, []domain.AuditCheckpoint{newTestCheckpoint(1, entries[9])}
+			},
+			wantValid: true, wantChecked: 10,
+		},
+		{
+			name: "legacy entries before the chain",
+			prepare: func([]domain.AuditEntry) ([]domain.AuditEntry, []domain.AuditCheckpoint) {
+				return newTestAuditChain(10, 3), nil
+			},
+			wantValid: true, wantChecked: 7, wantUnchained: 3,
+		},
+		{
+			name: "tampered content",
+			prepare: func(entries []domain.AuditEntry) ([]domain.AuditEntry, []domain.AuditCheckpoint) {
+				entries[4].Message = "changed"
+				return entries, nil
+			},
+			wantBrokenId: 5, wantChecked: 4,
+		},
+		{
+			name: "tampered and rehashed content",
+			prepare: func(entries []domain.AuditEntry) ([]domain.AuditEntry, []domain.AuditCheckpoint) {
+				entries[4].Message = "changed"
+				entries[4].Hash = entries[4].CalculateHash()
+				return entries, nil
+			},
+			wantBrokenId: 6, wantChecked: 5,
+		},
+		{
+			name: "deleted middle entry",
+			prepare: func(entries []domain.AuditEntry) ([]domain.AuditEntry, []domain.AuditCheckpoint) {
+				return append(entries[:4], entries[5:]...), nil
+			},
+			wantBrokenId: 6, wantChecked: 4,
+		},
+		{
+			name: "deleted prefix",
+			prepare: func(entries []domain.AuditEntry) ([]domain.AuditEntry, []domain.AuditCheckpoint) {
+				return entries[3:], nil
+			},
+			wantBrokenId: 4,
+		},
+		{
+			name: "purged prefix with checkpoint",
+			prepare: func(entries []domain.AuditEntry) ([]domain.AuditEntry, []domain.AuditCheckpoint) {
+				return entries[3:], []domain.AuditCheckpoint{newTestCheckpoint(1, entries[2])}
+			},
+			wantValid: true, wantChecked: 7,
+		},
+		{
+			name: "deleted tail",
+			prepare: func(entries []domain.AuditEntry) ([]domain.AuditEntry, []domain.AuditCheckpoint) {
+				return entries[:7], []domain.AuditCheckpoint{newTestCheckpoint(1, entries[9])}
+			},
+			wantBrokenId: 10, wantChecked: 7,
+		},
+		{
+			name: "checkpoint hash mismatch",
+			prepare: func(entries []domain.AuditEntry) ([]domain.AuditEntry, []domain.AuditCheckpoint) {
+				return entries, []domain.AuditCheckpoint{{Id: 1, EntryId: 8, EntryHash: entries[6].Hash}}
+			},
+			wantBrokenId: 8, wantChecked: 7,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, checkpoints := tt.prepare(newTestAuditChain(10, 0))
+			db := &testDatabaseRepo{entries: entries, checkpoints: checkpoints}
+			v := NewAuditVerifier(&config.Config{}, db)
+
+			result, err := v.Verify(context.Background())
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if result.Valid != tt.wantValid || result.BrokenEntryId != tt.wantBrokenId {
+				t.Errorf("Verify() valid = %t, broken = %d (%s), want %t, %d",
+					result.Valid, result.BrokenEntryId, result.Reason, tt.wantValid, tt.wantBrokenId)
+			}
+			if result.CheckedEntries != tt.wantChecked || result.UnchainedEntries != tt.wantUnchained {
+				t.Errorf("Verify() checked = %d, unchained = %d, want %d, %d",
+					result.CheckedEntries, result.UnchainedEntries, tt.wantChecked, tt.wantUnchained)
+			}
+			if tt.wantValid && !result.Anchored {
+				t.Errorf("Verify() anchored = false for a valid chain")
+			}
+		})
+	}
+}
@@ -9,21 +9,33 @@ import (
 	"io"
 	"net/url"
 	"path"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/go-webauthn/webauthn/webauthn"
 	"github.com/h44z/wg-portal/internal/app"
 	"github.com/h44z/wg-portal/internal/config"
 	"github.com/h44z/wg-portal/internal/domain"
 	"github.com/sirupsen/logrus"
 	evbus "github.com/vardius/message-bus"
+	"golang.org/x/oauth2"
 )
 
 type UserManager interface {
 	GetUser(context.Context, domain.UserIdentifier) (*domain.User, error)
 	RegisterUser(ctx context.Context, user *domain.User) error
 	UpdateUser(ctx context.Context, user *domain.User) (*domain.User, error)
+	VerifySecondFactor(ctx context.Context, id domain.UserIdentifier, code string) (*domain.User, error)
+	LockUserTemporarily(ctx context.Context, id domain.UserIdentifier, until time.Time, reason string) error
+	GetTemporarilyLockedUsers(ctx context.Context) ([]domain.User, error)
+	UnlockUser(ctx context.Context, id domain.UserIdentifier) error
+	RequestPasswordReset(ctx context.Context, identifier string) error
+	ResetPassword(ctx context.Context, token, password string) (*domain.User, error)
+	AcceptInvitation(ctx context.Context, token, password string) (*domain.User, error)
+	SignupUser(ctx context.Context, user *domain.User) error
+	VerifySignup(ctx context.Context, token string) (*domain.User, error)
 }
 
 type Authenticator struct {
@@ -36,18 +48,31 @@ type Authenticator struct {
 	// URL prefix for the callback endpoints, this is a combination of the external URL and the API prefix
 	callbackUrlPrefix string
 
-	users UserManager
+	// webauthn is nil if passkey login is disabled
+	webauthn *webauthn.WebAuthn
+
+	throttle      *loginThrottle
+	resetThrottle *loginThrottle // limits the number of password reset and signup verification mails
+
+	users       UserManager
+	credentials WebauthnCredentialRepo
 }
 
-func NewAuthenticator(cfg *config.Auth, extUrl string, bus evbus.MessageBus, users UserManager) (
-	*Authenticator,
-	error,
-) {
+func NewAuthenticator(
+	cfg *config.Auth,
+	extUrl string,
+	bus evbus.MessageBus,
+	users UserManager,
+	credentials WebauthnCredentialRepo,
+) (*Authenticator, error) {
 	a := &Authenticator{
 		cfg:               cfg,
 		bus:               bus,
 		users:             users,
+		credentials:       credentials,
 		callbackUrlPrefix: fmt.Sprintf("%s/api/v0", extUrl),
+		throttle:          newLoginThrottle(cfg.LoginLockDuration),
+		resetThrottle:     newLoginThrottle(cfg.LoginLockDuration),
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -58,6 +83,13 @@ func NewAuthenticator(cfg *config.Auth, extUrl string, bus evbus.MessageBus, use
 		return nil, err
 	}
 
+	if cfg.WebauthnEnabled {
+		a.webauthn, err = newWebauthn(extUrl)
+		if err != nil {
+			return nil, fmt.Errorf("failed to setup webauthn: %w", err)
+		}
+	}
+
 	return a, nil
 }
 
@@ -156,24 +188,223 @@ func (a *Authenticator) GetExternalLoginProviders(_ context.Context) []domain.Lo
 	return authProviders
 }
 
-func (a *Authenticator) IsUserValid(ctx context.Context, id domain.UserIdentifier) bool {
+// GetValidUser returns the current state of the user. An error is returned if the user no longer exists or is
+// disabled or locked.
+func (a *Authenticator) GetValidUser(ctx context.Context, id domain.UserIdentifier) (*domain.User, error) {
 	ctx = domain.SetUserInfo(ctx, domain.SystemAdminContextUserInfo()) // switch to admin user context
 	user, err := a.users.GetUser(ctx, id)
 	if err != nil {
-		return false
+		return nil, err
 	}
 
 	if user.IsDisabled() {
-		return false
+		return nil, errors.New("user is disabled")
 	}
 
 	if user.IsLocked() {
-		return false
+		return nil, errors.New("user is locked")
+	}
+
+	return user, nil
+}
+
+// publishLoginFailure records a failed login attempt in the audit log. The actor is the user that tried to log in.
+func (a *Authenticator) publishLoginFailure(ctx context.Context, id domain.UserIdentifier, origin string, err error) {
+	a.bus.Publish(app.TopicAuditEvent, loginFailureEvent(ctx, id, origin, err))
+}
+
+func loginFailureEvent(ctx context.Context, id domain.UserIdentifier, origin string, err error) domain.AuditEvent {
+	info := domain.GetUserInfo(ctx)
+	return domain.AuditEvent{
+		Severity:   domain.AuditSeverityLevelMedium,
+		Origin:     origin,
+		Actor:      id,
+		ClientIp:   info.ClientIp,
+		ObjectType: domain.AuditObjectTypeUser,
+		ObjectId:   string(id),
+		Message:    fmt.Sprintf("login of user %s failed", id),
+		Details:    domain.AuditDetails{Info: map[string]string{"error": err.Error()}},
+	}
+}
+
+// region login throttling
+
+// userThrottleKey returns the throttle key of login attempts of the user from the given source IP. Failed attempts
+// are tracked per source IP, so that nobody can lock out a user from other places by guessing passwords.
+func userThrottleKey(id domain.UserIdentifier, clientIp string) string {
+	return throttleKey(domain.LoginLockTypeUser, string(id)+"|"+clientIp)
+}
+
+// CheckLoginAttempt returns an error if login attempts for the user or the source IP of the request are currently
+// delayed or blocked because of previous failures.
+func (a *Authenticator) CheckLoginAttempt(ctx context.Context, id domain.UserIdentifier) error {
+	now := time.Now()
+	clientIp := domain.GetUserInfo(ctx).ClientIp
+
+	var delay time.Duration
+	if id != "" {
+		delay = a.throttle.delay(userThrottleKey(id, clientIp), now)
+	}
+	if clientIp != "" {
+		delay = max(delay, a.throttle.delay(throttleKey(domain.LoginLockTypeSourceIp, clientIp), now))
+	}
+
+	if delay > 0 {
+		return fmt.Errorf("retry in %s: %w", delay.Round(time.Second), domain.ErrTooManyAttempts)
+	}
+
+	return nil
+}
+
+// RegisterFailedLogin tracks a failed login attempt and records it in the audit log. Users and source IPs that
+// exceed the configured number of failed attempts are blocked temporarily. Users are only blocked for the source IP
+// of the failed attempts, unless account locks are enabled.
+func (a *Authenticator) RegisterFailedLogin(ctx context.Context, id domain.UserIdentifier, origin string, err error) {
+	now := time.Now()
+	until := now.Add(a.cfg.LoginLockDuration)
+	clientIp := domain.GetUserInfo(ctx).ClientIp
+	userKey := userThrottleKey(id, clientIp)
+	accountKey := throttleKey(domain.LoginLockTypeUser, string(id))
+	ipKey := throttleKey(domain.LoginLockTypeSourceIp, clientIp)
+
+	userAttempts := 0
+	accountAttempts := 0
+	if id != "" && id != domain.CtxUnknownUserId {
+		userAttempts = a.throttle.fail(userKey, now)
+		if a.cfg.LoginLockAccounts {
+			accountAttempts = a.throttle.fail(accountKey, now)
+		}
+	}
+	ipAttempts := 0
+	if clientIp != "" {
+		ipAttempts = a.throttle.fail(ipKey, now)
+	}
+
+	a.bus.Publish(app.TopicAuditEvent, loginFailureEvent(ctx, id, origin, err).
+		WithInfo("failedAttempts", strconv.Itoa(userAttempts)))
+
+	if a.cfg.MaxLoginAttemptsPerIp > 0 && ipAttempts >= a.cfg.MaxLoginAttemptsPerIp {
+		a.throttle.block(ipKey, until)
+		a.bus.Publish(app.TopicAuditEvent, domain.AuditEvent{
+			Severity:   domain.AuditSeverityLevelHigh,
+			Origin:     "sourceIpBlocked",
+			Actor:      id,
+			ClientIp:   clientIp,
+			ObjectType: domain.AuditObjectTypeSourceIp,
+			ObjectId:   clientIp,
+			Message:    fmt.Sprintf("source ip %s blocked after %d failed login attempts", clientIp, ipAttempts),
+		})
+	}
+
+	if a.cfg.MaxLoginAttempts > 0 && userAttempts >= a.cfg.MaxLoginAttempts && !a.cfg.LoginLockAccounts {
+		a.throttle.block(userKey, until)
+		a.bus.Publish(app.TopicAuditEvent, domain.AuditEvent{
+			Severity:   domain.AuditSeverityLevelHigh,
+			Origin:     "userSourceIpBlocked",
+			Actor:      id,
+			ClientIp:   clientIp,
+			ObjectType: domain.AuditObjectTypeUser,
+			ObjectId:   string(id),
+			Message: fmt.Sprintf("logins of user %s from source ip %s blocked after %d failed login attempts",
+				id, clientIp, userAttempts),
+		})
+	}
+
+	if a.cfg.MaxLoginAttempts > 0 && accountAttempts >= a.cfg.MaxLoginAttempts {
+		adminCtx := domain.SetUserInfo(ctx, domain.SystemAdminContextUserInfo())
+		err := a.users.LockUserTemporarily(adminCtx, id, until, "too many failed login attempts")
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			// unknown users are only throttled
+		case err != nil:
+			logrus.Warnf("failed to lock user %s: %v", id, err)
+		default:
+			a.bus.Publish(app.TopicAuditEvent, domain.AuditEvent{
+				Severity:   domain.AuditSeverityLevelHigh,
+				Origin:     "userLockedOut",
+				Actor:      id,
+				ClientIp:   clientIp,
+				ObjectType: domain.AuditObjectTypeUser,
+				ObjectId:   string(id),
+				Message:    fmt.Sprintf("user %s locked after %d failed login attempts", id, accountAttempts),
+			})
+		}
+	}
+}
+
+// RegisterSuccessfulLogin removes the failed login attempts of the user.
+// Failures of the source IP are kept, so attackers cannot reset them using their own account.
+func (a *Authenticator) RegisterSuccessfulLogin(ctx context.Context, id domain.UserIdentifier) {
+	a.throttle.reset(userThrottleKey(id, domain.GetUserInfo(ctx).ClientIp))
+	a.throttle.reset(throttleKey(domain.LoginLockTypeUser, string(id)))
+}
+
+// GetLoginLocks returns all users and source IPs that are locked because of too many failed login attempts.
+func (a *Authenticator) GetLoginLocks(ctx context.Context) ([]domain.LoginLock, error) {
+	if err := domain.ValidateAdminAccessRights(ctx, domain.PermissionUsersRead); err != nil {
+		return nil, err
+	}
+
+	lockedUsers, err := a.users.GetTemporarilyLockedUsers(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	return true
+	now := time.Now()
+	locks := make([]domain.LoginLock, 0, len(lockedUsers))
+	for _, user := range lockedUsers {
+		locks = append(locks, domain.LoginLock{
+			Type:           domain.LoginLockTypeUser,
+			Identifier:     string(user.Identifier),
+			Reason:         user.LockedReason,
+			LockedUntil:    *user.LockedUntil,
+			FailedAttempts: a.throttle.attempts(throttleKey(domain.LoginLockTypeUser, string(user.Identifier)), now),
+		})
+	}
+	for _, lock := range a.throttle.blocked(domain.LoginLockTypeUser, now) {
+		// the throttle identifier of blocked users is the user identifier combined with the source IP
+		separator := strings.LastIndex(lock.Identifier, "|")
+		if separator < 0 {
+			continue
+		}
+		lock.Identifier, lock.SourceIp = lock.Identifier[:separator], lock.Identifier[separator+1:]
+		locks = append(locks, lock)
+	}
+	locks = append(locks, a.throttle.blocked(domain.LoginLockTypeSourceIp, now)...)
+
+	return locks, nil
 }
 
+// ClearLoginLock unlocks a user or source IP and removes the failed login attempts.
+func (a *Authenticator) ClearLoginLock(ctx context.Context, lockType domain.LoginLockType, identifier string) error {
+	if err := domain.ValidateAdminAccessRights(ctx, domain.PermissionUsersWrite); err != nil {
+		return err
+	}
+
+	objectType := domain.AuditObjectTypeUser
+	switch lockType {
+	case domain.LoginLockTypeUser:
+		if err := a.users.UnlockUser(ctx, domain.UserIdentifier(identifier)); err != nil {
+			return err
+		}
+	case domain.LoginLockTypeSourceIp:
+		objectType = domain.AuditObjectTypeSourceIp
+	default:
+		return fmt.Errorf("unknown lock type %s: %w", lockType, domain.ErrInvalidData)
+	}
+	a.throttle.reset(throttleKey(lockType, identifier))
+	if lockType == domain.LoginLockTypeUser {
+		a.throttle.resetPrefix(userThrottleKey(domain.UserIdentifier(identifier), "")) // blocks for all source IPs
+	}
+
+	a.bus.Publish(app.TopicAuditEvent, domain.NewAuditEvent(ctx, domain.AuditSeverityLevelMedium, "loginLockCleared",
+		objectType, identifier, fmt.Sprintf("login lock of %s %s cleared", lockType, identifier)))
+
+	return nil
+}
+
+// endregion login throttling
+
 // region password authentication
 
 func (a *Authenticator) PlainLogin(ctx context.Context, username, password string) (*domain.User, error) {
@@ -184,11 +415,90 @@ func (a *Authenticator) PlainLogin(ctx context.Context, username, password strin
 		return nil, fmt.Errorf("missing username or password")
 	}
 
+	if err := a.CheckLoginAttempt(ctx, domain.UserIdentifier(username)); err != nil {
+		return nil, fmt.Errorf("login failed: %w", err)
+	}
+
 	user, err := a.passwordAuthentication(ctx, domain.UserIdentifier(username), password)
 	if err != nil {
+		a.RegisterFailedLogin(ctx, domain.UserIdentifier(username), "passwordLoginFailed", err)
 		return nil, fmt.Errorf("login failed: %w", err)
 	}
 
+	if a.SecondFactorState(user) != domain.SecondFactorStateNone {
+		return user, nil // the login is completed after the second factor has been verified
+	}
+
+	a.RegisterSuccessfulLogin(ctx, user.Identifier)
+	a.bus.Publish(app.TopicAuthLogin, user.Identifier)
+
+	return user, nil
+}
+
+// SecondFactorState returns the second factor action that is required to complete the login of the user.
+// A second factor is only supported for database users.
+func (a *Authenticator) SecondFactorState(user *domain.User) domain.SecondFactorState {
+	switch {
+	case user.Source != domain.UserSourceDatabase:
+		return domain.SecondFactorStateNone
+	case user.SecondFactor.IsTotpEnabled():
+		return domain.SecondFactorStateVerify
+	case a.cfg.IsTotpRequired(user.IsAdmin):
+		return domain.SecondFactorStateEnrollment
+	default:
+		return domain.SecondFactorStateNone
+	}
+}
+
+// SecondFactorLogin completes a password login by verifying the TOTP or recovery code of the user.
+func (a *Authenticator) SecondFactorLogin(ctx context.Context, id domain.UserIdentifier, code string) (
+	*domain.User,
+	error,
+) {
+	if err := a.CheckLoginAttempt(ctx, id); err != nil {
+		return nil, fmt.Errorf("login failed: %w", err)
+	}
+
+	loginCtx := ctx
+	ctx = domain.SetUserInfo(ctx, domain.SystemAdminContextUserInfo()) // switch to admin user context to verify the code
+
+	user, err := a.users.VerifySecondFactor(ctx, id, code)
+	if err != nil {
+		a.RegisterFailedLogin(loginCtx, id, "totpLoginFailed", err)
+		return nil, fmt.Errorf("login failed: %w", err)
+	}
+
+	if user.IsLocked() || user.IsDisabled() {
+		a.publishLoginFailure(loginCtx, id, "totpLoginFailed", errors.New("user is locked"))
+		return nil, errors.New("user is locked")
+	}
+
+	a.RegisterSuccessfulLogin(ctx, user.Identifier)
+	a.bus.Publish(app.TopicAuthLogin, user.Identifier)
+
+	return user, nil
+}
+
+// CompleteEnrollmentLogin completes a password login after the user enrolled the mandatory second factor.
+func (a *Authenticator) CompleteEnrollmentLogin(ctx context.Context, id domain.UserIdentifier) (
+	*domain.User,
+	error,
+) {
+	ctx = domain.SetUserInfo(ctx, domain.SystemAdminContextUserInfo()) // switch to admin user context to load the user
+
+	user, err := a.users.GetUser(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user: %w", err)
+	}
+
+	if !user.SecondFactor.IsTotpEnabled() {
+		return nil, errors.New("second factor enrollment not completed")
+	}
+	if user.IsLocked() || user.IsDisabled() {
+		return nil, errors.New("user is locked")
+	}
+
+	a.RegisterSuccessfulLogin(ctx, user.Identifier)
 	a.bus.Publish(app.TopicAuthLogin, user.Identifier)
 
 	return user, nil
@@ -269,41 +579,188 @@ func (a *Authenticator) passwordAuthentication(
 		if err != nil {
 			return nil, fmt.Errorf("unable to process user information: %w", err)
 		}
+		a.publishInterfaceMemberships(user.Identifier, ldapUserInfo)
 		return user, nil
 	} else {
+		if userSource == domain.UserSourceLdap {
+			a.publishInterfaceMemberships(existingUser.Identifier, ldapUserInfo)
+		}
 		return existingUser, nil
 	}
 }
 
+// publishInterfaceMemberships re-evaluates the peers of the user on the interfaces that are mapped to LDAP groups.
+func (a *Authenticator) publishInterfaceMemberships(id domain.UserIdentifier, userInfo *domain.AuthenticatorUserInfo) {
+	if len(userInfo.InterfaceMemberships) == 0 {
+		return
+	}
+
+	a.bus.Publish(app.TopicUserInterfacesAssigned, domain.UserInterfaceMemberships{
+		UserIdentifier: id,
+		Memberships:    userInfo.InterfaceMemberships,
+	})
+}
+
 // endregion password authentication
 
+// region password reset and invitations
+
+// RequestPasswordReset sends a password reset link to the database user with the given identifier or email address.
+// To not reveal which accounts exist, the function succeeds even if no reset mail is sent. Repeated requests for the
+// same account or from the same source IP are delayed exponentially.
+func (a *Authenticator) RequestPasswordReset(ctx context.Context, identifier string) error {
+	if !a.cfg.PasswordResetEnabled {
+		return fmt.Errorf("password reset is disabled: %w", domain.ErrNoPermission)
+	}
+
+	identifier = strings.TrimSpace(identifier)
+	if identifier == "" {
+		return fmt.Errorf("missing user identifier: %w", domain.ErrInvalidData)
+	}
+
+	now := time.Now()
+	userKey := throttleKey(domain.LoginLockTypeUser, strings.ToLower(identifier))
+	ipKey := throttleKey(domain.LoginLockTypeSourceIp, domain.GetUserInfo(ctx).ClientIp)
+	if delay := max(a.resetThrottle.delay(userKey, now), a.resetThrottle.delay(ipKey, now)); delay > 0 {
+		return fmt.Errorf("retry in %s: %w", delay.Round(time.Second), domain.ErrTooManyAttempts)
+	}
+	if err := a.CheckLoginAttempt(ctx, ""); err != nil {
+		return err
+	}
+	a.resetThrottle.fail(userKey, now)
+	a.resetThrottle.fail(ipKey, now)
+
+	if err := a.users.RequestPasswordReset(ctx, identifier); err != nil {
+		logrus.Errorf("failed to process password reset request for %s: %v", identifier, err)
+	}
+
+	return nil
+}
+
+// ResetPassword sets a new password using the token from a password reset mail. Invalid tokens count as failed
+// login attempts.
+func (a *Authenticator) ResetPassword(ctx context.Context, token, password string) error {
+	if !a.cfg.PasswordResetEnabled {
+		return fmt.Errorf("password reset is disabled: %w", domain.ErrNoPermission)
+	}
+
+	id, _, err := domain.ParseUserToken(token)
+	if err != nil {
+		return errors.Join(err, domain.ErrInvalidData)
+	}
+
+	if err := a.CheckLoginAttempt(ctx, id); err != nil {
+		return err
+	}
+
+	user, err := a.users.ResetPassword(ctx, token, password)
+	if err != nil {
+		a.RegisterFailedLogin(ctx, id, "passwordResetFailed", err)
+		return err
+	}
+
+	a.RegisterSuccessfulLogin(ctx, user.Identifier)
+
+	return nil
+}
+
+// AcceptInvitation activates an invited user with the chosen password. Invalid tokens count as failed login attempts.
+func (a *Authenticator) AcceptInvitation(ctx context.Context, token, password string) error {
+	id, _, err := domain.ParseUserToken(token)
+	if err != nil {
+		return errors.Join(err, domain.ErrInvalidData)
+	}
+
+	if err := a.CheckLoginAttempt(ctx, id); err != nil {
+		return err
+	}
+
+	if _, err := a.users.AcceptInvitation(ctx, token, password); err != nil {
+		a.RegisterFailedLogin(ctx, id, "invitationAcceptFailed", err)
+		return err
+	}
+
+	return nil
+}
+
+// SignupUser registers a new database user. The user has to verify the email address before the login is possible.
+// Repeated registrations for the same email address or from the same source IP are delayed exponentially.
+func (a *Authenticator) SignupUser(ctx context.Context, user *domain.User) error {
+	if !a.cfg.SignupEnabled {
+		return fmt.Errorf("registration is disabled: %w", domain.ErrNoPermission)
+	}
+
+	now := time.Now()
+	userKey := throttleKey(domain.LoginLockTypeUser, strings.ToLower(strings.TrimSpace(user.Email)))
+	ipKey := throttleKey(domain.LoginLockTypeSourceIp, domain.GetUserInfo(ctx).ClientIp)
+	if delay := max(a.resetThrottle.delay(userKey, now), a.resetThrottle.delay(ipKey, now)); delay > 0 {
+		return fmt.Errorf("retry in %s: %w", delay.Round(time.Second), domain.ErrTooManyAttempts)
+	}
+	if err := a.CheckLoginAttempt(ctx, ""); err != nil {
+		return err
+	}
+	a.resetThrottle.fail(userKey, now)
+	a.resetThrottle.fail(ipKey, now)
+
+	return a.users.SignupUser(ctx, user)
+}
+
+// VerifySignup verifies the email address of a new registration. Invalid tokens count as failed login attempts.
+func (a *Authenticator) VerifySignup(ctx context.Context, token string) error {
+	id, _, err := domain.ParseUserToken(token)
+	if err != nil {
+		return errors.Join(err, domain.ErrInvalidData)
+	}
+
+	if err := a.CheckLoginAttempt(ctx, id); err != nil {
+		return err
+	}
+
+	if _, err := a.users.VerifySignup(ctx, token); err != nil {
+		a.RegisterFailedLogin(ctx, id, "signupVerificationFailed", err)
+		return err
+	}
+
+	return nil
+}
+
+// endregion password reset and invitations
+
 // region oauth authentication
 
+// OauthLoginStep1 prepares the OAuth login. The returned state, nonce and PKCE code verifier have to be passed to
+// OauthLoginStep2. The nonce is empty for plain OAuth providers, the verifier is empty if PKCE is disabled.
 func (a *Authenticator) OauthLoginStep1(_ context.Context, providerId string) (
-	authCodeUrl, state, nonce string,
+	authCodeUrl, state, nonce, verifier string,
 	err error,
 ) {
 	oauthProvider, ok := a.oauthAuthenticators[providerId]
 	if !ok {
-		return "", "", "", fmt.Errorf("missing oauth provider %s", providerId)
+		return "", "", "", "", fmt.Errorf("missing oauth provider %s", providerId)
 	}
 
 	// Prepare authentication flow, set state cookies
 	state, err = a.randString(16)
 	if err != nil {
-		return "", "", "", fmt.Errorf("failed to generate state: %w", err)
+		return "", "", "", "", fmt.Errorf("failed to generate state: %w", err)
+	}
+
+	var opts []oauth2.AuthCodeOption
+	if oauthProvider.PkceEnabled() {
+		verifier = oauth2.GenerateVerifier()
+		opts = append(opts, oauth2.S256ChallengeOption(verifier))
 	}
 
 	switch oauthProvider.GetType() {
 	case domain.AuthenticatorTypeOAuth:
-		authCodeUrl = oauthProvider.AuthCodeURL(state)
+		authCodeUrl = oauthProvider.AuthCodeURL(state, opts...)
 	case domain.AuthenticatorTypeOidc:
 		nonce, err = a.randString(16)
 		if err != nil {
-			return "", "", "", fmt.Errorf("failed to generate nonce: %w", err)
+			return "", "", "", "", fmt.Errorf("failed to generate nonce: %w", err)
 		}
 
-		authCodeUrl = oauthProvider.AuthCodeURL(state, oidc.Nonce(nonce))
+		authCodeUrl = oauthProvider.AuthCodeURL(state, append(opts, oidc.Nonce(nonce))...)
 	}
 
 	return
@@ -317,42 +774,96 @@ func (a *Authenticator) randString(nByte int) (string, error) {
 	return base64.RawURLEncoding.EncodeToString(b), nil
 }
 
-func (a *Authenticator) OauthLoginStep2(ctx context.Context, providerId, nonce, code string) (*domain.User, error) {
+// OauthLoginStep2 completes the OAuth login. For OpenID Connect providers, the session at the provider is returned as
+// well, it is nil for plain OAuth providers.
+func (a *Authenticator) OauthLoginStep2(ctx context.Context, providerId, nonce, verifier, code string) (
+	*domain.User,
+	*domain.OidcSession,
+	error,
+) {
 	oauthProvider, ok := a.oauthAuthenticators[providerId]
 	if !ok {
-		return nil, fmt.Errorf("missing oauth provider %s", providerId)
+		return nil, nil, fmt.Errorf("missing oauth provider %s", providerId)
 	}
 
-	oauth2Token, err := oauthProvider.Exchange(ctx, code)
+	var opts []oauth2.AuthCodeOption
+	if verifier != "" {
+		opts = append(opts, oauth2.VerifierOption(verifier))
+	}
+
+	oauth2Token, err := oauthProvider.Exchange(ctx, code, opts...)
 	if err != nil {
-		return nil, fmt.Errorf("unable to exchange code: %w", err)
+		return nil, nil, fmt.Errorf("unable to exchange code: %w", err)
 	}
 
 	rawUserInfo, err := oauthProvider.GetUserInfo(ctx, oauth2Token, nonce)
 	if err != nil {
-		return nil, fmt.Errorf("unable to fetch user information: %w", err)
+		return nil, nil, fmt.Errorf("unable to fetch user information: %w", err)
 	}
 
 	userInfo, err := oauthProvider.ParseUserInfo(rawUserInfo)
 	if err != nil {
-		return nil, fmt.Errorf("unable to parse user information: %w", err)
+		return nil, nil, fmt.Errorf("unable to parse user information: %w", err)
 	}
 
+	loginCtx := ctx
 	ctx = domain.SetUserInfo(ctx,
 		domain.SystemAdminContextUserInfo()) // switch to admin user context to check if user exists
 	user, err := a.processUserInfo(ctx, userInfo, domain.UserSourceOauth, oauthProvider.GetName(),
 		oauthProvider.RegistrationEnabled())
 	if err != nil {
-		return nil, fmt.Errorf("unable to process user information: %w", err)
+		a.publishLoginFailure(loginCtx, userInfo.Identifier, "oauthLoginFailed", err)
+		return nil, nil, fmt.Errorf("unable to process user information: %w", err)
 	}
 
 	if user.IsLocked() || user.IsDisabled() {
-		return nil, errors.New("user is locked")
+		a.publishLoginFailure(loginCtx, user.Identifier, "oauthLoginFailed", errors.New("user is locked"))
+		return nil, nil, errors.New("user is locked")
 	}
 
 	a.bus.Publish(app.TopicAuthLogin, user.Identifier)
 
-	return user, nil
+	session := oauthProvider.GetSession(oauth2Token, rawUserInfo)
+	if session != nil {
+		session.Provider = providerId
+	}
+
+	return user, session, nil
+}
+
+// OidcLogoutUrl returns the URL that ends the given session at the OpenID Connect provider. The URL is empty if the
+// provider does not support RP-initiated logout.
+func (a *Authenticator) OidcLogoutUrl(_ context.Context, session *domain.OidcSession, postLogoutRedirectUrl string) string {
+	if session == nil {
+		return ""
+	}
+
+	oauthProvider, ok := a.oauthAuthenticators[session.Provider]
+	if !ok {
+		return ""
+	}
+
+	return oauthProvider.EndSessionURL(session, postLogoutRedirectUrl)
+}
+
+// OidcBackChannelLogout validates the logout token that was sent by the OpenID Connect provider and returns the
+// session that has been ended at the provider.
+func (a *Authenticator) OidcBackChannelLogout(ctx context.Context, providerId, logoutToken string) (
+	*domain.OidcSession,
+	error,
+) {
+	oauthProvider, ok := a.oauthAuthenticators[providerId]
+	if !ok {
+		return nil, fmt.Errorf("missing oauth provider %s", providerId)
+	}
+
+	session, err := oauthProvider.VerifyLogoutToken(ctx, logoutToken)
+	if err != nil {
+		return nil, fmt.Errorf("invalid logout token: %w", err)
+	}
+	session.Provider = providerId
+
+	return session, nil
 }
 
 func (a *Authenticator) processUserInfo(
@@ -362,6 +873,11 @@ func (a *Authenticator) processUserInfo(
 	provider string,
 	withReg bool,
 ) (*domain.User, error) {
+	if userInfo.LoginDenied {
+		return nil, fmt.Errorf("user %s is not a member of an allowed group: %w", userInfo.Identifier,
+			domain.ErrNoPermission)
+	}
+
 	// Search user in backend
 	user, err := a.users.GetUser(ctx, userInfo.Identifier)
 	switch {
@@ -395,6 +911,7 @@ func (a *Authenticator) registerNewUser(
 		Source:       source,
 		ProviderName: provider,
 		IsAdmin:      userInfo.IsAdmin,
+		Role:         userInfo.Role,
 		Firstname:    userInfo.Firstname,
 		Lastname:     userInfo.Lastname,
 		Phone:        userInfo.Phone,
@@ -462,6 +979,10 @@ func (a *Authenticator) updateExternalUser(
 		existingUser.IsAdmin = userInfo.IsAdmin
 		isChanged = true
 	}
+	if userInfo.Role != "" && existingUser.EffectiveRole() != userInfo.Role { // the role is managed by the provider
+		existingUser.SetRole(userInfo.Role)
+		isChanged = true
+	}
 
 	if !isChanged {
 		return nil // nothing to update
@@ -27,6 +27,14 @@ func newLdapAuthenticator(_ context.Context, cfg *config.LdapProvider) (*LdapAut
 	provider.cfg.FieldMap = provider.getLdapFieldMapping(cfg.FieldMap)
 	provider.cfg.ParsedAdminGroupDN = dn
 
+	for i, rule := range provider.cfg.GroupInterfaces {
+		dn, err := ldap.ParseDN(rule.GroupDN)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse group DN of interface %s: %w", rule.Interface, err)
+		}
+		provider.cfg.GroupInterfaces[i].ParsedGroupDN = dn
+	}
+
 	return provider, nil
 }
 
@@ -109,14 +117,36 @@ func (l LdapAuthenticator) GetUserInfo(_ context.Context, userId domain.UserIden
 
 	users := internal.LdapConvertEntries(sr, &l.cfg.FieldMap)
 
+	if l.cfg.NestedGroups {
+		err = internal.LdapAddNestedGroupMemberships(conn, l.cfg.BaseDN, loginFilter, &l.cfg.FieldMap, users,
+			l.cfg.GroupDNs())
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve nested groups: %w", err)
+		}
+	}
+
 	return users[0], nil
 }
 
 func (l LdapAuthenticator) ParseUserInfo(raw map[string]interface{}) (*domain.AuthenticatorUserInfo, error) {
-	isAdmin, err := internal.LdapIsMemberOf(raw[l.cfg.FieldMap.GroupMembership].([][]byte), l.cfg.ParsedAdminGroupDN)
+	groupData := raw[l.cfg.FieldMap.GroupMembership].([][]byte)
+	isAdmin, err := internal.LdapIsMemberOf(groupData, l.cfg.ParsedAdminGroupDN)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check admin group: %w", err)
 	}
+
+	memberships := make([]domain.InterfaceMembership, len(l.cfg.GroupInterfaces))
+	for i, rule := range l.cfg.GroupInterfaces {
+		isMember, err := internal.LdapIsMemberOf(groupData, rule.ParsedGroupDN)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check group of interface %s: %w", rule.Interface, err)
+		}
+		memberships[i] = domain.InterfaceMembership{
+			Interface:   domain.InterfaceIdentifier(rule.Interface),
+			IsMember:    isMember,
+			DeletePeers: rule.DeletePeers,
+		}
+	}
 	userInfo := &domain.AuthenticatorUserInfo{
 		Identifier: domain.UserIdentifier(internal.MapDefaultString(raw, l.cfg.FieldMap.UserIdentifier, "")),
 		Email:      internal.MapDefaultString(raw, l.cfg.FieldMap.Email, ""),
@@ -125,6 +155,8 @@ func (l LdapAuthenticator) ParseUserInfo(raw map[string]interface{}) (*domain.Au
 		Phone:      internal.MapDefaultString(raw, l.cfg.FieldMap.Phone, ""),
 		Department: internal.MapDefaultString(raw, l.cfg.FieldMap.Department, ""),
 		IsAdmin:    isAdmin,
+
+		InterfaceMemberships: memberships,
 	}
 
 	return userInfo, nil
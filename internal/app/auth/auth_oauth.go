@@ -3,13 +3,15 @@ package auth
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
-	"github.com/h44z/wg-portal/internal"
 	"github.com/h44z/wg-portal/internal/config"
 	"github.com/h44z/wg-portal/internal/domain"
 	"golang.org/x/oauth2"
@@ -21,7 +23,9 @@ type PlainOauthAuthenticator struct {
 	userInfoEndpoint    string
 	client              *http.Client
 	userInfoMapping     config.OauthFields
+	groupMapping        config.OauthGroupMapping
 	registrationEnabled bool
+	pkceEnabled         bool
 }
 
 func newPlainOauthAuthenticator(_ context.Context, callbackUrl string, cfg *config.OAuthProvider) (*PlainOauthAuthenticator, error) {
@@ -44,7 +48,9 @@ func newPlainOauthAuthenticator(_ context.Context, callbackUrl string, cfg *conf
 	}
 	provider.userInfoEndpoint = cfg.UserInfoURL
 	provider.userInfoMapping = getOauthFieldMapping(cfg.FieldMap)
+	provider.groupMapping = cfg.GroupMapping
 	provider.registrationEnabled = cfg.RegistrationEnabled
+	provider.pkceEnabled = cfg.UsePkce
 
 	return provider, nil
 }
@@ -57,6 +63,10 @@ func (p PlainOauthAuthenticator) RegistrationEnabled() bool {
 	return p.registrationEnabled
 }
 
+func (p PlainOauthAuthenticator) PkceEnabled() bool {
+	return p.pkceEnabled
+}
+
 func (p PlainOauthAuthenticator) GetType() domain.AuthenticatorType {
 	return domain.AuthenticatorTypeOAuth
 }
@@ -97,18 +107,134 @@ func (p PlainOauthAuthenticator) GetUserInfo(ctx context.Context, token *oauth2.
 }
 
 func (p PlainOauthAuthenticator) ParseUserInfo(raw map[string]interface{}) (*domain.AuthenticatorUserInfo, error) {
-	isAdmin, _ := strconv.ParseBool(internal.MapDefaultString(raw, p.userInfoMapping.IsAdmin, ""))
+	return parseOauthUserInfo(p.userInfoMapping, p.groupMapping, raw), nil
+}
+
+// GetSession returns nil, sessions of plain OAuth providers cannot be ended by WireGuard Portal.
+func (p PlainOauthAuthenticator) GetSession(_ *oauth2.Token, _ map[string]interface{}) *domain.OidcSession {
+	return nil
+}
+
+// EndSessionURL returns an empty URL, plain OAuth providers do not support RP-initiated logout.
+func (p PlainOauthAuthenticator) EndSessionURL(_ *domain.OidcSession, _ string) string {
+	return ""
+}
+
+func (p PlainOauthAuthenticator) VerifyLogoutToken(_ context.Context, _ string) (*domain.OidcSession, error) {
+	return nil, errors.New("back-channel logout is not supported by oauth providers")
+}
+
+// parseOauthUserInfo maps the claims of an OAuth or OpenID Connect user to WireGuard Portal user fields.
+// Admin rights, the role and the login permission are derived from the is_admin claim and the group memberships.
+func parseOauthUserInfo(
+	mapping config.OauthFields,
+	groupMapping config.OauthGroupMapping,
+	raw map[string]interface{},
+) *domain.AuthenticatorUserInfo {
+	isAdmin, _ := strconv.ParseBool(getOauthClaimString(raw, mapping.IsAdmin))
 	userInfo := &domain.AuthenticatorUserInfo{
-		Identifier: domain.UserIdentifier(internal.MapDefaultString(raw, p.userInfoMapping.UserIdentifier, "")),
-		Email:      internal.MapDefaultString(raw, p.userInfoMapping.Email, ""),
-		Firstname:  internal.MapDefaultString(raw, p.userInfoMapping.Firstname, ""),
-		Lastname:   internal.MapDefaultString(raw, p.userInfoMapping.Lastname, ""),
-		Phone:      internal.MapDefaultString(raw, p.userInfoMapping.Phone, ""),
-		Department: internal.MapDefaultString(raw, p.userInfoMapping.Department, ""),
+		Identifier: domain.UserIdentifier(getOauthClaimString(raw, mapping.UserIdentifier)),
+		Email:      getOauthClaimString(raw, mapping.Email),
+		Firstname:  getOauthClaimString(raw, mapping.Firstname),
+		Lastname:   getOauthClaimString(raw, mapping.Lastname),
+		Phone:      getOauthClaimString(raw, mapping.Phone),
+		Department: getOauthClaimString(raw, mapping.Department),
 		IsAdmin:    isAdmin,
 	}
 
-	return userInfo, nil
+	groups := getOauthClaimValues(raw, mapping.UserGroups)
+	isMember := func(group string) bool {
+		return slices.Contains(groups, group)
+	}
+
+	if slices.ContainsFunc(groupMapping.AdminGroups, isMember) {
+		userInfo.IsAdmin = true
+	}
+
+	if len(groupMapping.Roles) > 0 {
+		userInfo.Role = domain.RoleUser
+		for _, roleMapping := range groupMapping.Roles {
+			if isMember(roleMapping.Group) {
+				userInfo.Role = domain.RoleName(roleMapping.Role)
+				break
+			}
+		}
+	}
+	if userInfo.IsAdmin {
+		userInfo.Role = domain.RoleAdmin
+	}
+
+	if len(groupMapping.AllowedGroups) > 0 && !userInfo.IsAdmin &&
+		!slices.ContainsFunc(groupMapping.AllowedGroups, isMember) {
+		userInfo.LoginDenied = true
+	}
+
+	return userInfo
+}
+
+// getOauthClaim returns the value of the given claim. Nested claims can be addressed using dots,
+// for example "organization.department".
+func getOauthClaim(raw map[string]interface{}, claim string) interface{} {
+	if claim == "" {
+		return nil
+	}
+	if value, ok := raw[claim]; ok {
+		return value
+	}
+
+	var value interface{} = raw
+	for _, key := range strings.Split(claim, ".") {
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = fields[key]
+	}
+
+	return value
+}
+
+// getOauthClaimString returns the value of the given claim as string. For list claims, the first entry is returned.
+func getOauthClaimString(raw map[string]interface{}, claim string) string {
+	value := getOauthClaim(raw, claim)
+	if values, ok := value.([]interface{}); ok {
+		if len(values) == 0 {
+			return ""
+		}
+		value = values[0]
+	}
+
+	return oauthClaimValueString(value)
+}
+
+// getOauthClaimValues returns the values of a list claim. String claims are split at commas.
+func getOauthClaimValues(raw map[string]interface{}, claim string) []string {
+	var values []string
+	switch value := getOauthClaim(raw, claim).(type) {
+	case []interface{}:
+		for _, v := range value {
+			values = append(values, oauthClaimValueString(v))
+		}
+	case string:
+		values = strings.Split(value, ",")
+	}
+
+	for i := range values {
+		values[i] = strings.TrimSpace(values[i])
+	}
+
+	return slices.DeleteFunc(values, func(v string) bool { return v == "" })
+}
+
+func oauthClaimValueString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case nil:
+		return ""
+	default:
+		return fmt.Sprintf("%v", v)
+	}
 }
 
 func getOauthFieldMapping(f config.OauthFields) config.OauthFields {
@@ -121,7 +247,8 @@ func getOauthFieldMapping(f config.OauthFields) config.OauthFields {
 			Phone:          "phone",
 			Department:     "department",
 		},
-		IsAdmin: "admin_flag",
+		IsAdmin:    "admin_flag",
+		UserGroups: "groups",
 	}
 	if f.UserIdentifier != "" {
 		defaultMap.UserIdentifier = f.UserIdentifier
@@ -144,6 +271,9 @@ func getOauthFieldMapping(f config.OauthFields) config.OauthFields {
 	if f.IsAdmin != "" {
 		defaultMap.IsAdmin = f.IsAdmin
 	}
+	if f.UserGroups != "" {
+		defaultMap.UserGroups = f.UserGroups
+	}
 
 	return defaultMap
 }
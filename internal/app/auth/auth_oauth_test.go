@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
+	"golang.org/x/oauth2"
+)
+
+func TestParseOauthUserInfo(t *testing.T) {
+	mapping := getOauthFieldMapping(config.OauthFields{BaseFields: config.BaseFields{Department: "org.department"}})
+	groupMapping := config.OauthGroupMapping{
+		AdminGroups: []string{"wg-admins"},
+		Roles: []config.OauthRoleMapping{
+			{Group: "wg-operators", Role: string(domain.RoleOperator)},
+			{Group: "wg-auditors", Role: string(domain.RoleAuditor)},
+		},
+		AllowedGroups: []string{"wg-users", "wg-operators", "wg-auditors"},
+	}
+
+	tests := []struct {
+		name       string
+		groups     interface{}
+		wantAdmin  bool
+		wantRole   domain.RoleName
+		wantDenied bool
+	}{
+		{name: "admin group", groups: []interface{}{"wg-admins"}, wantAdmin: true, wantRole: domain.RoleAdmin},
+		{name: "first role wins", groups: []interface{}{"wg-auditors", "wg-operators"}, wantRole: domain.RoleOperator},
+		{name: "default role", groups: []interface{}{"wg-users"}, wantRole: domain.RoleUser},
+		{name: "not allowed", groups: []interface{}{"staff"}, wantRole: domain.RoleUser, wantDenied: true},
+		{name: "no groups", groups: nil, wantRole: domain.RoleUser, wantDenied: true},
+		{name: "comma separated", groups: "staff, wg-auditors", wantRole: domain.RoleAuditor},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw := map[string]interface{}{
+				"sub":    "alice",
+				"groups": tt.groups,
+				"org":    map[string]interface{}{"department": "Sales"},
+			}
+			got := parseOauthUserInfo(mapping, groupMapping, raw)
+			if got.Identifier != "alice" || got.Department != "Sales" {
+				t.Errorf("parseOauthUserInfo() identifier = %q, department = %q", got.Identifier, got.Department)
+			}
+			if got.IsAdmin != tt.wantAdmin || got.Role != tt.wantRole || got.LoginDenied != tt.wantDenied {
+				t.Errorf("parseOauthUserInfo() admin = %t, role = %q, denied = %t, want %t, %q, %t",
+					got.IsAdmin, got.Role, got.LoginDenied, tt.wantAdmin, tt.wantRole, tt.wantDenied)
+			}
+		})
+	}
+}
+
+func TestOauthLoginStep1Pkce(t *testing.T) {
+	tests := []struct {
+		name    string
+		usePkce bool
+	}{
+		{name: "disabled", usePkce: false},
+		{name: "enabled", usePkce: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, _ := newPlainOauthAuthenticator(context.Background(), "http://localhost/callback",
+				&config.OAuthProvider{ProviderName: "test", AuthURL: "http://idp/auth", UsePkce: tt.usePkce})
+			a := &Authenticator{oauthAuthenticators: map[string]domain.OauthAuthenticator{"test": provider}}
+
+			authCodeUrl, _, _, verifier, err := a.OauthLoginStep1(context.Background(), "test")
+			if err != nil {
+				t.Fatalf("OauthLoginStep1() error = %v", err)
+			}
+			parsedUrl, _ := url.Parse(authCodeUrl)
+			challenge := parsedUrl.Query().Get("code_challenge")
+			if tt.usePkce && (verifier == "" || challenge != oauth2.S256ChallengeFromVerifier(verifier)) {
+				t.Errorf("OauthLoginStep1() verifier = %q, challenge = %q", verifier, challenge)
+			}
+			if !tt.usePkce && (verifier != "" || challenge != "") {
+				t.Errorf("OauthLoginStep1() verifier = %q, challenge = %q, want none", verifier, challenge)
+			}
+		})
+	}
+}
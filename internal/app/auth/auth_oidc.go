@@ -2,12 +2,12 @@ package auth
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"strconv"
+	"net/url"
 
 	"github.com/coreos/go-oidc/v3/oidc"
-	"github.com/h44z/wg-portal/internal"
 	"github.com/h44z/wg-portal/internal/config"
 	"github.com/h44z/wg-portal/internal/domain"
 	"golang.org/x/oauth2"
@@ -19,9 +19,17 @@ type OidcAuthenticator struct {
 	verifier            *oidc.IDTokenVerifier
 	cfg                 *oauth2.Config
 	userInfoMapping     config.OauthFields
+	groupMapping        config.OauthGroupMapping
 	registrationEnabled bool
+	pkceEnabled         bool
+
+	// endSessionUrl is the end_session_endpoint of the provider, empty if RP-initiated logout is not supported
+	endSessionUrl string
 }
 
+// oidcBackChannelLogoutEvent is the event that must be contained in back-channel logout tokens.
+const oidcBackChannelLogoutEvent = "http://schemas.openid.net/event/backchannel-logout"
+
 func newOidcAuthenticator(ctx context.Context, callbackUrl string, cfg *config.OpenIDConnectProvider) (*OidcAuthenticator, error) {
 	var err error
 	var provider = &OidcAuthenticator{}
@@ -35,6 +43,14 @@ func newOidcAuthenticator(ctx context.Context, callbackUrl string, cfg *config.O
 		ClientID: cfg.ClientID,
 	})
 
+	var discovery struct {
+		EndSessionEndpoint string `json:"end_session_endpoint"`
+	}
+	if err := provider.provider.Claims(&discovery); err != nil {
+		return nil, fmt.Errorf("failed to parse oidc discovery document: %w", err)
+	}
+	provider.endSessionUrl = discovery.EndSessionEndpoint
+
 	scopes := []string{oidc.ScopeOpenID}
 	scopes = append(scopes, cfg.ExtraScopes...)
 	provider.cfg = &oauth2.Config{
@@ -45,7 +61,9 @@ func newOidcAuthenticator(ctx context.Context, callbackUrl string, cfg *config.O
 		Scopes:       scopes,
 	}
 	provider.userInfoMapping = getOauthFieldMapping(cfg.FieldMap)
+	provider.groupMapping = cfg.GroupMapping
 	provider.registrationEnabled = cfg.RegistrationEnabled
+	provider.pkceEnabled = cfg.UsePkce
 
 	return provider, nil
 }
@@ -58,6 +76,10 @@ func (o OidcAuthenticator) RegistrationEnabled() bool {
 	return o.registrationEnabled
 }
 
+func (o OidcAuthenticator) PkceEnabled() bool {
+	return o.pkceEnabled
+}
+
 func (o OidcAuthenticator) GetType() domain.AuthenticatorType {
 	return domain.AuthenticatorTypeOidc
 }
@@ -92,16 +114,72 @@ func (o OidcAuthenticator) GetUserInfo(ctx context.Context, token *oauth2.Token,
 }
 
 func (o OidcAuthenticator) ParseUserInfo(raw map[string]interface{}) (*domain.AuthenticatorUserInfo, error) {
-	isAdmin, _ := strconv.ParseBool(internal.MapDefaultString(raw, o.userInfoMapping.IsAdmin, ""))
-	userInfo := &domain.AuthenticatorUserInfo{
-		Identifier: domain.UserIdentifier(internal.MapDefaultString(raw, o.userInfoMapping.UserIdentifier, "")),
-		Email:      internal.MapDefaultString(raw, o.userInfoMapping.Email, ""),
-		Firstname:  internal.MapDefaultString(raw, o.userInfoMapping.Firstname, ""),
-		Lastname:   internal.MapDefaultString(raw, o.userInfoMapping.Lastname, ""),
-		Phone:      internal.MapDefaultString(raw, o.userInfoMapping.Phone, ""),
-		Department: internal.MapDefaultString(raw, o.userInfoMapping.Department, ""),
-		IsAdmin:    isAdmin,
-	}
-
-	return userInfo, nil
+	return parseOauthUserInfo(o.userInfoMapping, o.groupMapping, raw), nil
+}
+
+func (o OidcAuthenticator) GetSession(token *oauth2.Token, raw map[string]interface{}) *domain.OidcSession {
+	rawIDToken, _ := token.Extra("id_token").(string)
+
+	return &domain.OidcSession{
+		Subject:   getOauthClaimString(raw, "sub"),
+		SessionId: getOauthClaimString(raw, "sid"),
+		IdToken:   rawIDToken,
+	}
+}
+
+// EndSessionURL returns the URL that ends the session at the provider. It is empty if the provider does not support
+// RP-initiated logout.
+func (o OidcAuthenticator) EndSessionURL(session *domain.OidcSession, postLogoutRedirectUrl string) string {
+	if o.endSessionUrl == "" {
+		return ""
+	}
+
+	endSessionUrl, err := url.Parse(o.endSessionUrl)
+	if err != nil {
+		return ""
+	}
+
+	params := endSessionUrl.Query()
+	params.Set("client_id", o.cfg.ClientID)
+	if session != nil && session.IdToken != "" {
+		params.Set("id_token_hint", session.IdToken)
+	}
+	if postLogoutRedirectUrl != "" {
+		params.Set("post_logout_redirect_uri", postLogoutRedirectUrl)
+	}
+	endSessionUrl.RawQuery = params.Encode()
+
+	return endSessionUrl.String()
+}
+
+// VerifyLogoutToken validates a back-channel logout token as defined by the OpenID Connect Back-Channel Logout
+// specification and returns the session that has been ended at the provider.
+func (o OidcAuthenticator) VerifyLogoutToken(ctx context.Context, rawToken string) (*domain.OidcSession, error) {
+	logoutToken, err := o.verifier.Verify(ctx, rawToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate logout token: %w", err)
+	}
+
+	var claims struct {
+		SessionId string                     `json:"sid"`
+		Events    map[string]json.RawMessage `json:"events"`
+	}
+	if err = logoutToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("failed to parse logout token claims: %w", err)
+	}
+
+	if _, ok := claims.Events[oidcBackChannelLogoutEvent]; !ok {
+		return nil, errors.New("logout token does not contain a back-channel logout event")
+	}
+	if logoutToken.Nonce != "" {
+		return nil, errors.New("logout token must not contain a nonce")
+	}
+	if logoutToken.Subject == "" && claims.SessionId == "" {
+		return nil, errors.New("logout token contains neither sub nor sid")
+	}
+
+	return &domain.OidcSession{
+		Subject:   logoutToken.Subject,
+		SessionId: claims.SessionId,
+	}, nil
 }
@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+const testOidcIssuer = "https://idp.example.com"
+
+// signTestJwt creates an ES256 signed JWT with the given claims.
+func signTestJwt(t *testing.T, key *ecdsa.PrivateKey, claims map[string]interface{}) string {
+	t.Helper()
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"ES256","typ":"logout+jwt"}`)) + "." +
+		base64.RawURLEncoding.EncodeToString(payload)
+
+	digest := sha256.Sum256([]byte(signingInput))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestOidcAuthenticator_VerifyLogoutToken(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authenticator := OidcAuthenticator{
+		verifier: oidc.NewVerifier(testOidcIssuer, &oidc.StaticKeySet{PublicKeys: []crypto.PublicKey{&key.PublicKey}},
+			&oidc.Config{ClientID: "wg-portal", SupportedSigningAlgs: []string{oidc.ES256}}),
+	}
+
+	claims := func(modify func(c map[string]interface{})) map[string]interface{} {
+		c := map[string]interface{}{
+			"iss":    testOidcIssuer,
+			"aud":    "wg-portal",
+			"iat":    time.Now().Unix(),
+			"exp":    time.Now().Add(time.Minute).Unix(),
+			"jti":    "logout-1",
+			"sub":    "alice",
+			"sid":    "session-1",
+			"events": map[string]interface{}{oidcBackChannelLogoutEvent: map[string]interface{}{}},
+		}
+		if modify != nil {
+			modify(c)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name    string
+		key     *ecdsa.PrivateKey
+		claims  map[string]interface{}
+		want    *domain.OidcSession
+		wantErr bool
+	}{
+		{name: "session", key: key, claims: claims(nil),
+			want: &domain.OidcSession{Subject: "alice", SessionId: "session-1"}},
+		{name: "subject only", key: key, claims: claims(func(c map[string]interface{}) { delete(c, "sid") }),
+			want: &domain.OidcSession{Subject: "alice"}},
+		{name: "session only", key: key, claims: claims(func(c map[string]interface{}) { delete(c, "sub") }),
+			want: &domain.OidcSession{SessionId: "session-1"}},
+		{name: "neither subject nor session", key: key, wantErr: true,
+			claims: claims(func(c map[string]interface{}) { delete(c, "sub"); delete(c, "sid") })},
+		{name: "missing event", key: key, claims: claims(func(c map[string]interface{}) { delete(c, "events") }),
+			wantErr: true},
+		{name: "nonce", key: key, claims: claims(func(c map[string]interface{}) { c["nonce"] = "n" }),
+			wantErr: true},
+		{name: "foreign audience", key: key, claims: claims(func(c map[string]interface{}) { c["aud"] = "other" }),
+			wantErr: true},
+		{name: "foreign issuer", key: key,
+			claims: claims(func(c map[string]interface{}) { c["iss"] = "https://evil.example.com" }), wantErr: true},
+		{name: "expired", key: key,
+			claims:  claims(func(c map[string]interface{}) { c["exp"] = time.Now().Add(-time.Minute).Unix() }),
+			wantErr: true},
+		{name: "invalid signature", key: otherKey, claims: claims(nil), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := authenticator.VerifyLogoutToken(context.Background(), signTestJwt(t, tt.key, tt.claims))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("VerifyLogoutToken() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Subject != tt.want.Subject || got.SessionId != tt.want.SessionId {
+				t.Errorf("VerifyLogoutToken() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,451 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+
+	"github.com/h44z/wg-portal/internal/app"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+type WebauthnCredentialRepo interface {
+	GetWebauthnCredentials(ctx context.Context, id domain.UserIdentifier) ([]domain.WebauthnCredential, error)
+	GetWebauthnCredential(ctx context.Context, credentialId string) (*domain.WebauthnCredential, error)
+	SaveWebauthnCredential(ctx context.Context, credential *domain.WebauthnCredential) error
+	DeleteWebauthnCredential(ctx context.Context, id domain.UserIdentifier, credentialId string) error
+}
+
+// webauthnUser wraps a user and the registered credentials for the WebAuthn library.
+type webauthnUser struct {
+	user        *domain.User
+	credentials []webauthn.Credential
+}
+
+func (u *webauthnUser) WebAuthnID() []byte {
+	return domain.WebauthnUserHandle(u.user.Identifier)
+}
+
+func (u *webauthnUser) WebAuthnName() string {
+	return string(u.user.Identifier)
+}
+
+func (u *webauthnUser) WebAuthnDisplayName() string {
+	if name := strings.TrimSpace(u.user.Firstname + " " + u.user.Lastname); name != "" {
+		return name
+	}
+	return string(u.user.Identifier)
+}
+
+func (u *webauthnUser) WebAuthnCredentials() []webauthn.Credential {
+	return u.credentials
+}
+
+func newWebauthn(extUrl string) (*webauthn.WebAuthn, error) {
+	u, err := url.Parse(extUrl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse external url: %w", err)
+	}
+
+	return webauthn.New(&webauthn.Config{
+		RPID:          u.Hostname(),
+		RPDisplayName: "WireGuard Portal",
+		RPOrigins:     []string{u.Scheme + "://" + u.Host},
+	})
+}
+
+// region webauthn credentials
+
+// IsWebauthnEnabled returns true if passkeys and security keys can be used to log in.
+func (a *Authenticator) IsWebauthnEnabled() bool {
+	return a.webauthn != nil
+}
+
+// StartWebauthnRegistration starts the registration of a new passkey or security key for the given user.
+func (a *Authenticator) StartWebauthnRegistration(ctx context.Context, id domain.UserIdentifier) (
+	*domain.WebauthnChallenge,
+	error,
+) {
+	if !a.IsWebauthnEnabled() {
+		return nil, errors.Join(errors.New("webauthn is disabled"), domain.ErrInvalidData)
+	}
+	if err := domain.ValidateUserAccessRights(ctx, id); err != nil {
+		return nil, err
+	}
+
+	user, err := a.loadWebauthnUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	exclusions := make([]protocol.CredentialDescriptor, len(user.credentials))
+	for i := range user.credentials {
+		exclusions[i] = user.credentials[i].Descriptor()
+	}
+
+	options, session, err := a.webauthn.BeginRegistration(user,
+		webauthn.WithExclusions(exclusions),
+		webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementPreferred))
+	if err != nil {
+		return nil, fmt.Errorf("failed to start registration: %w", err)
+	}
+
+	return newWebauthnChallenge(options, session)
+}
+
+// FinishWebauthnRegistration verifies the response of the authenticator and stores the new credential.
+func (a *Authenticator) FinishWebauthnRegistration(
+	ctx context.Context,
+	id domain.UserIdentifier,
+	name, session string,
+	response []byte,
+) (*domain.WebauthnCredential, error) {
+	if !a.IsWebauthnEnabled() {
+		return nil, errors.Join(errors.New("webauthn is disabled"), domain.ErrInvalidData)
+	}
+	if err := domain.ValidateUserAccessRights(ctx, id); err != nil {
+		return nil, err
+	}
+
+	sessionData, err := parseWebauthnSession(session)
+	if err != nil {
+		return nil, err
+	}
+
+	parsedResponse, err := protocol.ParseCredentialCreationResponseBytes(response)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("invalid registration response: %w", err), domain.ErrInvalidData)
+	}
+
+	user, err := a.loadWebauthnUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	credential, err := a.webauthn.CreateCredential(user, *sessionData, parsedResponse)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("registration failed: %w", err), domain.ErrInvalidData)
+	}
+
+	credentialId := base64.RawURLEncoding.EncodeToString(credential.ID)
+	if _, err := a.credentials.GetWebauthnCredential(ctx, credentialId); err == nil {
+		return nil, errors.Join(errors.New("credential is already registered"), domain.ErrDuplicateEntry)
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = "Passkey"
+	}
+
+	newCredential := &domain.WebauthnCredential{
+		CredentialIdentifier: credentialId,
+		UserIdentifier:       id,
+		Name:                 name,
+		CreatedAt:            time.Now(),
+	}
+	if err := a.updateWebauthnCredential(ctx, newCredential, credential); err != nil {
+		return nil, err
+	}
+
+	a.bus.Publish(app.TopicAuditEvent, domain.NewAuditEvent(ctx, domain.AuditSeverityLevelMedium,
+		"webauthnCredentialAdded", domain.AuditObjectTypeUser, string(id),
+		fmt.Sprintf("webauthn credential %s added for user %s", name, id)))
+
+	return newCredential, nil
+}
+
+// GetWebauthnCredentials returns all passkeys and security keys of the given user.
+func (a *Authenticator) GetWebauthnCredentials(ctx context.Context, id domain.UserIdentifier) (
+	[]domain.WebauthnCredential,
+	error,
+) {
+	if err := domain.ValidateUserAccessRights(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return a.credentials.GetWebauthnCredentials(ctx, id)
+}
+
+// DeleteWebauthnCredential revokes a passkey or security key of the given user.
+func (a *Authenticator) DeleteWebauthnCredential(ctx context.Context, id domain.UserIdentifier, credentialId string) error {
+	if err := domain.ValidateUserAccessRights(ctx, id); err != nil {
+		return err
+	}
+
+	if err := a.credentials.DeleteWebauthnCredential(ctx, id, credentialId); err != nil {
+		return fmt.Errorf("failed to delete credential: %w", err)
+	}
+
+	a.bus.Publish(app.TopicAuditEvent, domain.NewAuditEvent(ctx, domain.AuditSeverityLevelHigh,
+		"webauthnCredentialRemoved", domain.AuditObjectTypeUser, string(id),
+		fmt.Sprintf("webauthn credential %s removed from user %s", credentialId, id)))
+
+	return nil
+}
+
+// endregion webauthn credentials
+
+// region webauthn login
+
+// StartWebauthnLogin starts a passwordless login. The user is identified by the discoverable credential.
+func (a *Authenticator) StartWebauthnLogin(_ context.Context) (*domain.WebauthnChallenge, error) {
+	if !a.IsWebauthnEnabled() {
+		return nil, errors.Join(errors.New("webauthn is disabled"), domain.ErrInvalidData)
+	}
+
+	options, session, err := a.webauthn.BeginDiscoverableLogin(
+		webauthn.WithUserVerification(protocol.VerificationRequired))
+	if err != nil {
+		return nil, fmt.Errorf("failed to start login: %w", err)
+	}
+
+	return newWebauthnChallenge(options, session)
+}
+
+// WebauthnLogin completes a passwordless login.
+func (a *Authenticator) WebauthnLogin(ctx context.Context, session string, response []byte) (*domain.User, error) {
+	if !a.IsWebauthnEnabled() {
+		return nil, errors.Join(errors.New("webauthn is disabled"), domain.ErrInvalidData)
+	}
+	if err := a.CheckLoginAttempt(ctx, ""); err != nil { // the user is not known yet, only the source IP is checked
+		return nil, fmt.Errorf("login failed: %w", err)
+	}
+
+	loginCtx := ctx
+	ctx = domain.SetUserInfo(ctx, domain.SystemAdminContextUserInfo()) // switch to admin user context to load the user
+
+	var user *webauthnUser
+	userHandler := func(rawId, userHandle []byte) (webauthn.User, error) {
+		storedCredential, err := a.credentials.GetWebauthnCredential(ctx, base64.RawURLEncoding.EncodeToString(rawId))
+		if err != nil {
+			return nil, fmt.Errorf("unknown credential: %w", err)
+		}
+		if !bytes.Equal(userHandle, domain.WebauthnUserHandle(storedCredential.UserIdentifier)) {
+			return nil, errors.New("user handle mismatch")
+		}
+
+		user, err = a.loadWebauthnUser(ctx, storedCredential.UserIdentifier)
+		if err != nil {
+			return nil, err
+		}
+		return user, nil
+	}
+
+	credential, err := a.validateWebauthnAssertion(session, response,
+		func(sessionData webauthn.SessionData, parsed *protocol.ParsedCredentialAssertionData) (
+			*webauthn.Credential,
+			error,
+		) {
+			return a.webauthn.ValidateDiscoverableLogin(userHandler, sessionData, parsed)
+		})
+	if err != nil {
+		failedId := domain.UserIdentifier(domain.CtxUnknownUserId)
+		if user != nil {
+			failedId = user.user.Identifier
+		}
+		a.RegisterFailedLogin(loginCtx, failedId, "webauthnLoginFailed", err)
+		return nil, fmt.Errorf("login failed: %w", err)
+	}
+
+	return a.finishWebauthnLogin(ctx, loginCtx, user, credential)
+}
+
+// StartWebauthnSecondFactor starts the verification of a passkey or security key after a password login.
+func (a *Authenticator) StartWebauthnSecondFactor(ctx context.Context, id domain.UserIdentifier) (
+	*domain.WebauthnChallenge,
+	error,
+) {
+	if !a.IsWebauthnEnabled() {
+		return nil, errors.Join(errors.New("webauthn is disabled"), domain.ErrInvalidData)
+	}
+
+	ctx = domain.SetUserInfo(ctx, domain.SystemAdminContextUserInfo()) // switch to admin user context to load the user
+
+	user, err := a.loadWebauthnUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(user.credentials) == 0 {
+		return nil, errors.Join(errors.New("no webauthn credentials registered"), domain.ErrInvalidData)
+	}
+
+	options, session, err := a.webauthn.BeginLogin(user,
+		webauthn.WithUserVerification(protocol.VerificationDiscouraged))
+	if err != nil {
+		return nil, fmt.Errorf("failed to start login: %w", err)
+	}
+
+	return newWebauthnChallenge(options, session)
+}
+
+// WebauthnSecondFactorLogin completes a password login by verifying a passkey or security key of the user.
+func (a *Authenticator) WebauthnSecondFactorLogin(
+	ctx context.Context,
+	id domain.UserIdentifier,
+	session string,
+	response []byte,
+) (*domain.User, error) {
+	if !a.IsWebauthnEnabled() {
+		return nil, errors.Join(errors.New("webauthn is disabled"), domain.ErrInvalidData)
+	}
+	if err := a.CheckLoginAttempt(ctx, id); err != nil {
+		return nil, fmt.Errorf("login failed: %w", err)
+	}
+
+	loginCtx := ctx
+	ctx = domain.SetUserInfo(ctx, domain.SystemAdminContextUserInfo()) // switch to admin user context to load the user
+
+	user, err := a.loadWebauthnUser(ctx, id)
+	if err != nil {
+		a.RegisterFailedLogin(loginCtx, id, "webauthnLoginFailed", err)
+		return nil, fmt.Errorf("login failed: %w", err)
+	}
+
+	credential, err := a.validateWebauthnAssertion(session, response,
+		func(sessionData webauthn.SessionData, parsed *protocol.ParsedCredentialAssertionData) (
+			*webauthn.Credential,
+			error,
+		) {
+			return a.webauthn.ValidateLogin(user, sessionData, parsed)
+		})
+	if err != nil {
+		a.RegisterFailedLogin(loginCtx, id, "webauthnLoginFailed", err)
+		return nil, fmt.Errorf("login failed: %w", err)
+	}
+
+	return a.finishWebauthnLogin(ctx, loginCtx, user, credential)
+}
+
+func (a *Authenticator) validateWebauthnAssertion(
+	session string,
+	response []byte,
+	validate func(webauthn.SessionData, *protocol.ParsedCredentialAssertionData) (*webauthn.Credential, error),
+) (*webauthn.Credential, error) {
+	sessionData, err := parseWebauthnSession(session)
+	if err != nil {
+		return nil, err
+	}
+
+	parsedResponse, err := protocol.ParseCredentialRequestResponseBytes(response)
+	if err != nil {
+		return nil, fmt.Errorf("invalid login response: %w", err)
+	}
+
+	credential, err := validate(*sessionData, parsedResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	if credential.Authenticator.CloneWarning {
+		return nil, errors.New("signature counter mismatch, the authenticator might be cloned")
+	}
+
+	return credential, nil
+}
+
+func (a *Authenticator) finishWebauthnLogin(
+	ctx, loginCtx context.Context,
+	user *webauthnUser,
+	credential *webauthn.Credential,
+) (*domain.User, error) {
+	if user.user.IsLocked() || user.user.IsDisabled() {
+		a.publishLoginFailure(loginCtx, user.user.Identifier, "webauthnLoginFailed", errors.New("user is locked"))
+		return nil, errors.New("user is locked")
+	}
+
+	storedCredential, err := a.credentials.GetWebauthnCredential(ctx,
+		base64.RawURLEncoding.EncodeToString(credential.ID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load credential: %w", err)
+	}
+
+	now := time.Now()
+	storedCredential.LastUsed = &now
+	if err := a.updateWebauthnCredential(ctx, storedCredential, credential); err != nil {
+		return nil, err
+	}
+
+	a.RegisterSuccessfulLogin(ctx, user.user.Identifier)
+	a.bus.Publish(app.TopicAuthLogin, user.user.Identifier)
+
+	return user.user, nil
+}
+
+// endregion webauthn login
+
+func (a *Authenticator) loadWebauthnUser(ctx context.Context, id domain.UserIdentifier) (*webauthnUser, error) {
+	user, err := a.users.GetUser(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user: %w", err)
+	}
+
+	storedCredentials, err := a.credentials.GetWebauthnCredentials(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load credentials: %w", err)
+	}
+
+	credentials := make([]webauthn.Credential, len(storedCredentials))
+	for i := range storedCredentials {
+		if err := json.Unmarshal([]byte(storedCredentials[i].Data), &credentials[i]); err != nil {
+			return nil, fmt.Errorf("failed to decode credential %s: %w", storedCredentials[i].CredentialIdentifier, err)
+		}
+	}
+
+	return &webauthnUser{user: user, credentials: credentials}, nil
+}
+
+func (a *Authenticator) updateWebauthnCredential(
+	ctx context.Context,
+	storedCredential *domain.WebauthnCredential,
+	credential *webauthn.Credential,
+) error {
+	data, err := json.Marshal(credential)
+	if err != nil {
+		return fmt.Errorf("failed to encode credential: %w", err)
+	}
+	storedCredential.Data = string(data)
+
+	if err := a.credentials.SaveWebauthnCredential(ctx, storedCredential); err != nil {
+		return fmt.Errorf("failed to store credential: %w", err)
+	}
+
+	return nil
+}
+
+func newWebauthnChallenge(options any, session *webauthn.SessionData) (*domain.WebauthnChallenge, error) {
+	rawOptions, err := json.Marshal(options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode options: %w", err)
+	}
+	rawSession, err := json.Marshal(session)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode session: %w", err)
+	}
+
+	return &domain.WebauthnChallenge{
+		Options: rawOptions,
+		Session: string(rawSession),
+	}, nil
+}
+
+func parseWebauthnSession(session string) (*webauthn.SessionData, error) {
+	if session == "" {
+		return nil, errors.Join(errors.New("no pending webauthn ceremony"), domain.ErrInvalidData)
+	}
+
+	var sessionData webauthn.SessionData
+	if err := json.Unmarshal([]byte(session), &sessionData); err != nil {
+		return nil, errors.Join(fmt.Errorf("invalid webauthn session: %w", err), domain.ErrInvalidData)
+	}
+
+	return &sessionData, nil
+}
@@ -0,0 +1,413 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/go-webauthn/webauthn/webauthn"
+	evbus "github.com/vardius/message-bus"
+
+	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+const testWebauthnOrigin = "https://vpn.example.com"
+
+type testMessageBus struct {
+	evbus.MessageBus
+}
+
+func (b testMessageBus) Publish(string, ...interface{}) {}
+
+type testUserManager struct {
+	UserManager
+	users map[domain.UserIdentifier]*domain.User
+}
+
+func (m testUserManager) GetUser(_ context.Context, id domain.UserIdentifier) (*domain.User, error) {
+	user, ok := m.users[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return user, nil
+}
+
+// testCredentialRepo mirrors the behaviour of the database repository.
+type testCredentialRepo struct {
+	credentials map[string]domain.WebauthnCredential
+}
+
+func (r *testCredentialRepo) GetWebauthnCredentials(_ context.Context, id domain.UserIdentifier) (
+	[]domain.WebauthnCredential,
+	error,
+) {
+	var credentials []domain.WebauthnCredential
+	for _, credential := range r.credentials {
+		if credential.UserIdentifier == id {
+			credentials = append(credentials, credential)
+		}
+	}
+	return credentials, nil
+}
+
+func (r *testCredentialRepo) GetWebauthnCredential(_ context.Context, credentialId string) (
+	*domain.WebauthnCredential,
+	error,
+) {
+	credential, ok := r.credentials[credentialId]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return &credential, nil
+}
+
+func (r *testCredentialRepo) SaveWebauthnCredential(_ context.Context, credential *domain.WebauthnCredential) error {
+	r.credentials[credential.CredentialIdentifier] = *credential
+	return nil
+}
+
+func (r *testCredentialRepo) DeleteWebauthnCredential(
+	_ context.Context,
+	id domain.UserIdentifier,
+	credentialId string,
+) error {
+	credential, ok := r.credentials[credentialId]
+	if !ok || credential.UserIdentifier != id {
+		return domain.ErrNotFound
+	}
+	delete(r.credentials, credentialId)
+	return nil
+}
+
+// testAuthenticatorDevice is a software authenticator that creates "none" attestations and ES256 assertions.
+type testAuthenticatorDevice struct {
+	t          *testing.T
+	key        *ecdsa.PrivateKey
+	id         []byte
+	userHandle []byte
+	signCount  uint32
+}
+
+func newTestAuthenticatorDevice(t *testing.T, user domain.UserIdentifier) *testAuthenticatorDevice {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+
+	return &testAuthenticatorDevice{t: t, key: key, id: id, userHandle: domain.WebauthnUserHandle(user)}
+}
+
+func (d *testAuthenticatorDevice) credentialId() string {
+	return base64.RawURLEncoding.EncodeToString(d.id)
+}
+
+func (d *testAuthenticatorDevice) clientData(ceremony string, options json.RawMessage) []byte {
+	var parsed struct {
+		PublicKey struct {
+			Challenge string `json:"challenge"`
+		} `json:"publicKey"`
+	}
+	if err := json.Unmarshal(options, &parsed); err != nil {
+		d.t.Fatal(err)
+	}
+
+	clientData, _ := json.Marshal(map[string]string{
+		"type":      ceremony,
+		"challenge": parsed.PublicKey.Challenge,
+		"origin":    testWebauthnOrigin,
+	})
+	return clientData
+}
+
+func (d *testAuthenticatorDevice) authData(flags byte, attestedData []byte) []byte {
+	rpIdHash := sha256.Sum256([]byte("vpn.example.com"))
+	data := append(rpIdHash[:], flags)
+	data = binary.BigEndian.AppendUint32(data, d.signCount)
+	return append(data, attestedData...)
+}
+
+func (d *testAuthenticatorDevice) create(options json.RawMessage) []byte {
+	publicKey, err := cbor.Marshal(map[int]interface{}{
+		1:  2,  // key type: EC2
+		3:  -7, // algorithm: ES256
+		-1: 1,  // curve: P-256
+		-2: d.key.X.FillBytes(make([]byte, 32)),
+		-3: d.key.Y.FillBytes(make([]byte, 32)),
+	})
+	if err != nil {
+		d.t.Fatal(err)
+	}
+
+	attestedData := make([]byte, 16) // zero AAGUID
+	attestedData = binary.BigEndian.AppendUint16(attestedData, uint16(len(d.id)))
+	attestedData = append(attestedData, d.id...)
+	attestedData = append(attestedData, publicKey...)
+
+	attestationObject, err := cbor.Marshal(map[string]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[string]interface{}{},
+		"authData": d.authData(0x45, attestedData), // user present, user verified, attested data
+	})
+	if err != nil {
+		d.t.Fatal(err)
+	}
+
+	response, _ := json.Marshal(map[string]interface{}{
+		"id":    d.credentialId(),
+		"rawId": d.credentialId(),
+		"type":  "public-key",
+		"response": map[string]string{
+			"clientDataJSON":    base64.RawURLEncoding.EncodeToString(d.clientData("webauthn.create", options)),
+			"attestationObject": base64.RawURLEncoding.EncodeToString(attestationObject),
+		},
+	})
+	return response
+}
+
+func (d *testAuthenticatorDevice) get(options json.RawMessage) []byte {
+	clientData := d.clientData("webauthn.get", options)
+	authData := d.authData(0x05, nil) // user present, user verified
+
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(authData, clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, d.key, digest[:])
+	if err != nil {
+		d.t.Fatal(err)
+	}
+
+	response, _ := json.Marshal(map[string]interface{}{
+		"id":    d.credentialId(),
+		"rawId": d.credentialId(),
+		"type":  "public-key",
+		"response": map[string]string{
+			"clientDataJSON":    base64.RawURLEncoding.EncodeToString(clientData),
+			"authenticatorData": base64.RawURLEncoding.EncodeToString(authData),
+			"signature":         base64.RawURLEncoding.EncodeToString(signature),
+			"userHandle":        base64.RawURLEncoding.EncodeToString(d.userHandle),
+		},
+	})
+	return response
+}
+
+func newTestWebauthnAuthenticator(t *testing.T) (*Authenticator, *testCredentialRepo) {
+	users := testUserManager{users: map[domain.UserIdentifier]*domain.User{
+		"alice": {Identifier: "alice", Firstname: "Alice"},
+		"bob":   {Identifier: "bob"},
+	}}
+	credentials := &testCredentialRepo{credentials: map[string]domain.WebauthnCredential{}}
+
+	a, err := NewAuthenticator(&config.Auth{WebauthnEnabled: true, LoginLockDuration: time.Minute},
+		testWebauthnOrigin, testMessageBus{}, users, credentials)
+	if err != nil {
+		t.Fatalf("NewAuthenticator() error = %v", err)
+	}
+	return a, credentials
+}
+
+func userContext(id domain.UserIdentifier) context.Context {
+	return domain.SetUserInfo(context.Background(),
+		&domain.ContextUserInfo{Id: id, Role: domain.RoleUser, ClientIp: "192.0.2.1"})
+}
+
+func registerTestDevice(t *testing.T, a *Authenticator, device *testAuthenticatorDevice, id domain.UserIdentifier) {
+	ctx := userContext(id)
+	challenge, err := a.StartWebauthnRegistration(ctx, id)
+	if err != nil {
+		t.Fatalf("StartWebauthnRegistration() error = %v", err)
+	}
+	if _, err := a.FinishWebauthnRegistration(ctx, id, "", challenge.Session,
+		device.create(challenge.Options)); err != nil {
+		t.Fatalf("FinishWebauthnRegistration() error = %v", err)
+	}
+}
+
+func TestAuthenticator_WebauthnRegistration(t *testing.T) {
+	a, credentials := newTestWebauthnAuthenticator(t)
+	device := newTestAuthenticatorDevice(t, "alice")
+	ctx := userContext("alice")
+
+	challenge, err := a.StartWebauthnRegistration(ctx, "alice")
+	if err != nil {
+		t.Fatalf("StartWebauthnRegistration() error = %v", err)
+	}
+	otherChallenge, err := a.StartWebauthnRegistration(ctx, "alice")
+	if err != nil {
+		t.Fatalf("StartWebauthnRegistration() error = %v", err)
+	}
+
+	_, err = a.FinishWebauthnRegistration(ctx, "alice", "", otherChallenge.Session, device.create(challenge.Options))
+	if !errors.Is(err, domain.ErrInvalidData) {
+		t.Errorf("FinishWebauthnRegistration() with foreign challenge error = %v, want %v", err, domain.ErrInvalidData)
+	}
+
+	_, err = a.FinishWebauthnRegistration(userContext("bob"), "alice", "", challenge.Session,
+		device.create(challenge.Options))
+	if !errors.Is(err, domain.ErrNoPermission) {
+		t.Errorf("FinishWebauthnRegistration() for other user error = %v, want %v", err, domain.ErrNoPermission)
+	}
+
+	credential, err := a.FinishWebauthnRegistration(ctx, "alice", " ", challenge.Session,
+		device.create(challenge.Options))
+	if err != nil {
+		t.Fatalf("FinishWebauthnRegistration() error = %v", err)
+	}
+	if credential.CredentialIdentifier != device.credentialId() || credential.UserIdentifier != "alice" ||
+		credential.Name != "Passkey" {
+		t.Errorf("FinishWebauthnRegistration() = %+v", credential)
+	}
+	if _, ok := credentials.credentials[device.credentialId()]; !ok {
+		t.Errorf("credential was not stored")
+	}
+
+	challenge, err = a.StartWebauthnRegistration(ctx, "alice")
+	if err != nil {
+		t.Fatalf("StartWebauthnRegistration() error = %v", err)
+	}
+	_, err = a.FinishWebauthnRegistration(ctx, "alice", "", challenge.Session, device.create(challenge.Options))
+	if !errors.Is(err, domain.ErrDuplicateEntry) {
+		t.Errorf("FinishWebauthnRegistration() of registered credential error = %v, want %v",
+			err, domain.ErrDuplicateEntry)
+	}
+}
+
+func TestAuthenticator_WebauthnCredentialOwnership(t *testing.T) {
+	a, credentials := newTestWebauthnAuthenticator(t)
+	device := newTestAuthenticatorDevice(t, "alice")
+	registerTestDevice(t, a, device, "alice")
+
+	tests := []struct {
+		name          string
+		ctx           context.Context
+		owner         domain.UserIdentifier
+		wantAccessErr error
+		wantDeleteErr error
+	}{
+		{name: "other user", ctx: userContext("bob"), owner: "alice",
+			wantAccessErr: domain.ErrNoPermission, wantDeleteErr: domain.ErrNoPermission},
+		{name: "foreign credential", ctx: userContext("bob"), owner: "bob", wantDeleteErr: domain.ErrNotFound},
+		{name: "owner", ctx: userContext("alice"), owner: "alice"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := a.StartWebauthnRegistration(tt.ctx, tt.owner); !errors.Is(err, tt.wantAccessErr) {
+				t.Errorf("StartWebauthnRegistration() error = %v, want %v", err, tt.wantAccessErr)
+			}
+			if _, err := a.GetWebauthnCredentials(tt.ctx, tt.owner); !errors.Is(err, tt.wantAccessErr) {
+				t.Errorf("GetWebauthnCredentials() error = %v, want %v", err, tt.wantAccessErr)
+			}
+
+			err := a.DeleteWebauthnCredential(tt.ctx, tt.owner, device.credentialId())
+			if !errors.Is(err, tt.wantDeleteErr) {
+				t.Errorf("DeleteWebauthnCredential() error = %v, want %v", err, tt.wantDeleteErr)
+			}
+			_, stored := credentials.credentials[device.credentialId()]
+			if stored != (tt.wantDeleteErr != nil) {
+				t.Errorf("credential stored = %t after delete", stored)
+			}
+		})
+	}
+}
+
+func TestAuthenticator_WebauthnLogin(t *testing.T) {
+	a, credentials := newTestWebauthnAuthenticator(t)
+	device := newTestAuthenticatorDevice(t, "alice")
+	registerTestDevice(t, a, device, "alice")
+
+	storedSignCount := func() uint32 {
+		var credential webauthn.Credential
+		if err := json.Unmarshal([]byte(credentials.credentials[device.credentialId()].Data), &credential); err != nil {
+			t.Fatal(err)
+		}
+		return credential.Authenticator.SignCount
+	}
+
+	tests := []struct {
+		name       string
+		signCount  uint32
+		userHandle domain.UserIdentifier
+		wantErr    bool
+		wantCount  uint32
+	}{
+		{name: "valid", signCount: 1, userHandle: "alice", wantCount: 1},
+		{name: "user handle of other user", signCount: 2, userHandle: "bob", wantErr: true, wantCount: 1},
+		{name: "replayed sign count", signCount: 1, userHandle: "alice", wantErr: true, wantCount: 1},
+		{name: "lower sign count", signCount: 0, userHandle: "alice", wantErr: true, wantCount: 1},
+		{name: "increased sign count", signCount: 5, userHandle: "alice", wantCount: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a.throttle.reset(throttleKey(domain.LoginLockTypeSourceIp, "192.0.2.1"))
+
+			device.signCount = tt.signCount
+			device.userHandle = domain.WebauthnUserHandle(tt.userHandle)
+
+			challenge, err := a.StartWebauthnLogin(context.Background())
+			if err != nil {
+				t.Fatalf("StartWebauthnLogin() error = %v", err)
+			}
+			user, err := a.WebauthnLogin(userContext(""), challenge.Session, device.get(challenge.Options))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("WebauthnLogin() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if err == nil && user.Identifier != "alice" {
+				t.Errorf("WebauthnLogin() user = %s, want alice", user.Identifier)
+			}
+			if got := storedSignCount(); got != tt.wantCount {
+				t.Errorf("stored sign count = %d, want %d", got, tt.wantCount)
+			}
+		})
+	}
+
+	if credentials.credentials[device.credentialId()].LastUsed == nil {
+		t.Errorf("last usage of the credential was not recorded")
+	}
+}
+
+func TestAuthenticator_WebauthnSecondFactorLogin(t *testing.T) {
+	a, _ := newTestWebauthnAuthenticator(t)
+	aliceDevice := newTestAuthenticatorDevice(t, "alice")
+	registerTestDevice(t, a, aliceDevice, "alice")
+	bobDevice := newTestAuthenticatorDevice(t, "bob")
+	registerTestDevice(t, a, bobDevice, "bob")
+
+	tests := []struct {
+		name    string
+		device  *testAuthenticatorDevice
+		wantErr bool
+	}{
+		{name: "credential of other user", device: bobDevice, wantErr: true},
+		{name: "own credential", device: aliceDevice},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a.throttle.reset(userThrottleKey("alice", "192.0.2.1"))
+			a.throttle.reset(throttleKey(domain.LoginLockTypeSourceIp, "192.0.2.1"))
+			tt.device.signCount++
+
+			challenge, err := a.StartWebauthnSecondFactor(context.Background(), "alice")
+			if err != nil {
+				t.Fatalf("StartWebauthnSecondFactor() error = %v", err)
+			}
+			user, err := a.WebauthnSecondFactorLogin(userContext(""), "alice", challenge.Session,
+				tt.device.get(challenge.Options))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("WebauthnSecondFactorLogin() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if err == nil && user.Identifier != "alice" {
+				t.Errorf("WebauthnSecondFactorLogin() user = %s, want alice", user.Identifier)
+			}
+		})
+	}
+}
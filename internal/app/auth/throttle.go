@@ -0,0 +1,179 @@
+package auth
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+const (
+	throttleBaseDelay = 1 * time.Second
+	throttleMaxDelay  = 1 * time.Minute
+)
+
+// failedLogins keeps track of the failed login attempts for a single user identifier or source IP.
+type failedLogins struct {
+	count        int
+	last         time.Time
+	blockedUntil time.Time
+}
+
+// loginThrottle tracks failed login attempts in memory. After each failure, further attempts are delayed
+// exponentially. Records are forgotten after the configured window without new failures.
+type loginThrottle struct {
+	mux         sync.Mutex
+	window      time.Duration
+	records     map[string]*failedLogins
+	lastCleanup time.Time
+}
+
+func newLoginThrottle(window time.Duration) *loginThrottle {
+	return &loginThrottle{
+		window:      window,
+		records:     make(map[string]*failedLogins),
+		lastCleanup: time.Now(),
+	}
+}
+
+func throttleKey(lockType domain.LoginLockType, identifier string) string {
+	return string(lockType) + ":" + identifier
+}
+
+// delay returns the time that must pass before the next login attempt is allowed.
+func (t *loginThrottle) delay(key string, now time.Time) time.Duration {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	r, ok := t.records[key]
+	if !ok || t.isExpired(r, now) {
+		return 0
+	}
+
+	if now.Before(r.blockedUntil) {
+		return r.blockedUntil.Sub(now)
+	}
+
+	if next := r.last.Add(throttleDelay(r.count)); now.Before(next) {
+		return next.Sub(now)
+	}
+
+	return 0
+}
+
+// fail records a failed attempt and returns the number of failed attempts within the current window.
+func (t *loginThrottle) fail(key string, now time.Time) int {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	if now.Sub(t.lastCleanup) > t.window {
+		t.cleanup(now)
+	}
+
+	r, ok := t.records[key]
+	if !ok || t.isExpired(r, now) {
+		r = &failedLogins{}
+		t.records[key] = r
+	}
+	r.count++
+	r.last = now
+
+	return r.count
+}
+
+// block rejects all attempts until the given time.
+func (t *loginThrottle) block(key string, until time.Time) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	if r, ok := t.records[key]; ok {
+		r.blockedUntil = until
+	}
+}
+
+// reset removes all failed attempts of the given key.
+func (t *loginThrottle) reset(key string) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	delete(t.records, key)
+}
+
+// resetPrefix removes all failed attempts of keys with the given prefix.
+func (t *loginThrottle) resetPrefix(prefix string) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	for key := range t.records {
+		if strings.HasPrefix(key, prefix) {
+			delete(t.records, key)
+		}
+	}
+}
+
+// attempts returns the number of failed attempts within the current window.
+func (t *loginThrottle) attempts(key string, now time.Time) int {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	r, ok := t.records[key]
+	if !ok || t.isExpired(r, now) {
+		return 0
+	}
+	return r.count
+}
+
+// blocked returns all blocked records of the given type.
+func (t *loginThrottle) blocked(lockType domain.LoginLockType, now time.Time) []domain.LoginLock {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	prefix := throttleKey(lockType, "")
+	locks := make([]domain.LoginLock, 0)
+	for key, r := range t.records {
+		if !strings.HasPrefix(key, prefix) || !now.Before(r.blockedUntil) {
+			continue
+		}
+		locks = append(locks, domain.LoginLock{
+			Type:           lockType,
+			Identifier:     strings.TrimPrefix(key, prefix),
+			Reason:         "too many failed login attempts",
+			LockedUntil:    r.blockedUntil,
+			FailedAttempts: r.count,
+		})
+	}
+	sort.Slice(locks, func(i, j int) bool { return locks[i].Identifier < locks[j].Identifier })
+
+	return locks
+}
+
+func (t *loginThrottle) isExpired(r *failedLogins, now time.Time) bool {
+	return now.Sub(r.last) > t.window && !now.Before(r.blockedUntil)
+}
+
+func (t *loginThrottle) cleanup(now time.Time) {
+	for key, r := range t.records {
+		if t.isExpired(r, now) {
+			delete(t.records, key)
+		}
+	}
+	t.lastCleanup = now
+}
+
+// throttleDelay calculates the exponential delay after the given number of failed attempts.
+func throttleDelay(failures int) time.Duration {
+	if failures <= 0 {
+		return 0
+	}
+	if failures > 7 { // 2^6 seconds already exceed the maximum delay
+		return throttleMaxDelay
+	}
+
+	delay := throttleBaseDelay << (failures - 1)
+	if delay > throttleMaxDelay {
+		return throttleMaxDelay
+	}
+	return delay
+}
@@ -0,0 +1,155 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+func TestThrottleDelay(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{failures: 0, want: 0},
+		{failures: 1, want: 1 * time.Second},
+		{failures: 2, want: 2 * time.Second},
+		{failures: 6, want: 32 * time.Second},
+		{failures: 7, want: throttleMaxDelay},
+		{failures: 100, want: throttleMaxDelay},
+	}
+	for _, tt := range tests {
+		if got := throttleDelay(tt.failures); got != tt.want {
+			t.Errorf("throttleDelay(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestLoginThrottle(t *testing.T) {
+	now := time.Unix(1000, 0)
+	throttle := newLoginThrottle(15 * time.Minute)
+	key := throttleKey("user", "alice")
+
+	if d := throttle.delay(key, now); d != 0 {
+		t.Fatalf("delay without failures = %v, want 0", d)
+	}
+
+	throttle.fail(key, now)
+	if n := throttle.fail(key, now); n != 2 {
+		t.Fatalf("fail() = %d, want 2", n)
+	}
+	if d := throttle.delay(key, now.Add(time.Second)); d != time.Second {
+		t.Errorf("delay after two failures = %v, want 1s", d)
+	}
+
+	throttle.block(key, now.Add(time.Hour))
+	if locks := throttle.blocked("user", now); len(locks) != 1 || locks[0].Identifier != "alice" {
+		t.Errorf("blocked() = %v, want lock for alice", locks)
+	}
+	if d := throttle.delay(key, now.Add(30*time.Minute)); d != 30*time.Minute {
+		t.Errorf("delay while blocked = %v, want 30m", d)
+	}
+
+	if n := throttle.attempts(key, now.Add(2*time.Hour)); n != 0 {
+		t.Errorf("attempts after window = %d, want 0", n)
+	}
+
+	throttle.fail(key, now)
+	throttle.reset(key)
+	if n := throttle.attempts(key, now); n != 0 {
+		t.Errorf("attempts after reset = %d, want 0", n)
+	}
+}
+
+type testLockingUserManager struct {
+	UserManager
+	locked map[domain.UserIdentifier]time.Time
+}
+
+func (m *testLockingUserManager) LockUserTemporarily(
+	_ context.Context,
+	id domain.UserIdentifier,
+	until time.Time,
+	_ string,
+) error {
+	m.locked[id] = until
+	return nil
+}
+
+func (m *testLockingUserManager) GetTemporarilyLockedUsers(context.Context) ([]domain.User, error) {
+	return nil, nil
+}
+
+func (m *testLockingUserManager) UnlockUser(_ context.Context, id domain.UserIdentifier) error {
+	delete(m.locked, id)
+	return nil
+}
+
+func TestAuthenticator_RegisterFailedLogin(t *testing.T) {
+	ipCtx := func(ip string) context.Context {
+		return domain.SetUserInfo(context.Background(), &domain.ContextUserInfo{Id: "admin", ClientIp: ip})
+	}
+	adminCtx := domain.SetUserInfo(context.Background(), domain.SystemAdminContextUserInfo())
+
+	tests := []struct {
+		name          string
+		lockAccounts  bool
+		failures      []string // the source IPs of the failed attempts
+		wantLocks     int      // blocked users in the lock list
+		wantLocked    bool     // the user account is locked
+		wantOtherIpOk bool     // a login from another source IP is possible
+	}{
+		{name: "below limit", failures: []string{"10.0.0.1", "10.0.0.1"}, wantOtherIpOk: true},
+		{name: "blocked for one source ip", failures: []string{"10.0.0.1", "10.0.0.1", "10.0.0.1"},
+			wantLocks: 1, wantOtherIpOk: true},
+		{name: "failures from multiple source ips", failures: []string{"10.0.0.1", "10.0.0.2", "10.0.0.1"},
+			wantOtherIpOk: true},
+		{name: "account lock", lockAccounts: true, failures: []string{"10.0.0.1", "10.0.0.2", "10.0.0.1"},
+			wantLocked: true, wantOtherIpOk: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := &testLockingUserManager{locked: map[domain.UserIdentifier]time.Time{}}
+			a := &Authenticator{
+				cfg: &config.Auth{MaxLoginAttempts: 3, LoginLockDuration: 15 * time.Minute,
+					LoginLockAccounts: tt.lockAccounts},
+				bus:      testMessageBus{},
+				throttle: newLoginThrottle(15 * time.Minute),
+				users:    users,
+			}
+
+			for _, ip := range tt.failures {
+				a.RegisterFailedLogin(ipCtx(ip), "admin", "test", errors.New("invalid password"))
+			}
+
+			if _, locked := users.locked["admin"]; locked != tt.wantLocked {
+				t.Errorf("account locked = %t, want %t", locked, tt.wantLocked)
+			}
+			if err := a.CheckLoginAttempt(ipCtx("10.0.0.3"), "admin"); (err == nil) != tt.wantOtherIpOk {
+				t.Errorf("CheckLoginAttempt() from other source ip error = %v", err)
+			}
+
+			locks, err := a.GetLoginLocks(adminCtx)
+			if err != nil {
+				t.Fatalf("GetLoginLocks() error = %v", err)
+			}
+			if len(locks) != tt.wantLocks {
+				t.Fatalf("GetLoginLocks() = %v, want %d locks", locks, tt.wantLocks)
+			}
+			if tt.wantLocks > 0 && (locks[0].Identifier != "admin" || locks[0].SourceIp != "10.0.0.1") {
+				t.Errorf("GetLoginLocks() = %+v, want lock of admin from 10.0.0.1", locks[0])
+			}
+
+			if err := a.ClearLoginLock(adminCtx, domain.LoginLockTypeUser, "admin"); err != nil {
+				t.Fatalf("ClearLoginLock() error = %v", err)
+			}
+			if n := a.throttle.attempts(userThrottleKey("admin", "10.0.0.1"), time.Now()); n != 0 {
+				t.Errorf("failed attempts after clearing the lock = %d, want 0", n)
+			}
+		})
+	}
+}
@@ -1,14 +1,24 @@
 package app
 
 import (
+	"context"
 	"flag"
+	"fmt"
+
 	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
+	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
 
-func HandleProgramArgs(cfg *config.Config, db *gorm.DB) (exit bool, err error) {
+type AuditVerifier interface {
+	Verify(ctx context.Context) (*domain.AuditVerificationResult, error)
+}
+
+func HandleProgramArgs(cfg *config.Config, db *gorm.DB, auditVerifier AuditVerifier) (exit bool, err error) {
 	migrationSource := flag.String("migrateFrom", "", "path to v1 database file or DSN")
 	migrationDbType := flag.String("migrateFromType", string(config.DatabaseSQLite), "old database type, either mysql, mssql, postgres or sqlite")
+	verifyAudit := flag.Bool("verifyAudit", false, "verify the audit log hash chain and exit")
 	flag.Parse()
 
 	if *migrationSource != "" {
@@ -16,5 +26,32 @@ func HandleProgramArgs(cfg *config.Config, db *gorm.DB) (exit bool, err error) {
 		exit = true
 	}
 
+	if *verifyAudit {
+		err = verifyAuditChain(auditVerifier)
+		exit = true
+	}
+
 	return
 }
+
+func verifyAuditChain(auditVerifier AuditVerifier) error {
+	result, err := auditVerifier.Verify(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to verify audit log: %w", err)
+	}
+
+	logrus.Infof("checked %d audit entries (%d-%d) and %d checkpoints (%d unsigned), %d entries without hash",
+		result.CheckedEntries, result.FirstEntryId, result.LastEntryId, result.CheckedCheckpoints,
+		result.UnsignedCheckpoints, result.UnchainedEntries)
+	if result.UnchainedEntries > 0 {
+		logrus.Warnf("%d audit entries were recorded before hash chaining was enabled and can not be verified",
+			result.UnchainedEntries)
+	}
+
+	if !result.Valid {
+		return fmt.Errorf("audit chain broken at entry %d: %s", result.BrokenEntryId, result.Reason)
+	}
+
+	logrus.Infof("audit chain is valid")
+	return nil
+}
@@ -102,7 +102,7 @@ func (m Manager) handlePeerInterfaceUpdatedEvent(id domain.InterfaceIdentifier)
 }
 
 func (m Manager) GetInterfaceConfig(ctx context.Context, id domain.InterfaceIdentifier) (io.Reader, error) {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	if err := domain.ValidateInterfaceAccessRights(ctx, id, domain.PermissionInterfacesWrite); err != nil {
 		return nil, err
 	}
 
@@ -111,6 +111,9 @@ func (m Manager) GetInterfaceConfig(ctx context.Context, id domain.InterfaceIden
 		return nil, fmt.Errorf("failed to fetch interface %s: %w", id, err)
 	}
 
+	m.bus.Publish(app.TopicAuditEvent, domain.NewAuditEvent(ctx, domain.AuditSeverityLevelLow, "interfaceConfigDownload",
+		domain.AuditObjectTypeInterface, string(id), fmt.Sprintf("configuration of interface %s downloaded", id)))
+
 	return m.tplHandler.GetInterfaceConfig(iface, peers)
 }
 
@@ -120,10 +123,13 @@ func (m Manager) GetPeerConfig(ctx context.Context, id domain.PeerIdentifier) (i
 		return nil, fmt.Errorf("failed to fetch peer %s: %w", id, err)
 	}
 
-	if err := domain.ValidateUserAccessRights(ctx, peer.UserIdentifier); err != nil {
+	if err := domain.ValidatePeerAccessRights(ctx, peer, domain.PermissionPeersWrite); err != nil {
 		return nil, err
 	}
 
+	m.bus.Publish(app.TopicAuditEvent, domain.NewAuditEvent(ctx, domain.AuditSeverityLevelLow, "peerConfigDownload",
+		domain.AuditObjectTypePeer, string(id), fmt.Sprintf("configuration of peer %s downloaded", id)))
+
 	return m.tplHandler.GetPeerConfig(peer)
 }
 
@@ -133,10 +139,13 @@ func (m Manager) GetPeerConfigQrCode(ctx context.Context, id domain.PeerIdentifi
 		return nil, fmt.Errorf("failed to fetch peer %s: %w", id, err)
 	}
 
-	if err := domain.ValidateUserAccessRights(ctx, peer.UserIdentifier); err != nil {
+	if err := domain.ValidatePeerAccessRights(ctx, peer, domain.PermissionPeersWrite); err != nil {
 		return nil, err
 	}
 
+	m.bus.Publish(app.TopicAuditEvent, domain.NewAuditEvent(ctx, domain.AuditSeverityLevelLow, "peerConfigQrCodeDownload",
+		domain.AuditObjectTypePeer, string(id), fmt.Sprintf("qr code of peer %s downloaded", id)))
+
 	cfgData, err := m.tplHandler.GetPeerConfig(peer)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get peer config for %s: %w", id, err)
@@ -156,9 +165,19 @@ func (m Manager) GetPeerConfigQrCode(ctx context.Context, id domain.PeerIdentifi
 		return nil, fmt.Errorf("failed to read peer config for %s: %w", id, err)
 	}
 
-	code, err := qrcode.New(sb.String())
+	code, err := m.GetQrCode(sb.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create qr code for %s: %w", id, err)
+	}
+
+	return code, nil
+}
+
+// GetQrCode returns the given content as PNG encoded QR code.
+func (m Manager) GetQrCode(content string) (io.Reader, error) {
+	code, err := qrcode.New(content)
 	if err != nil {
-		return nil, fmt.Errorf("failed to initializeqr code for %s: %w", id, err)
+		return nil, fmt.Errorf("failed to initialize qr code: %w", err)
 	}
 
 	buf := bytes.NewBuffer(nil)
@@ -170,7 +189,7 @@ func (m Manager) GetPeerConfigQrCode(ctx context.Context, id domain.PeerIdentifi
 	qrWriter := NewCompressedWriter(wr, &option)
 	err = code.Save(qrWriter)
 	if err != nil {
-		return nil, fmt.Errorf("failed to write code for %s: %w", id, err)
+		return nil, fmt.Errorf("failed to write qr code: %w", err)
 	}
 
 	return buf, nil
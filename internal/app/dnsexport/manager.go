@@ -0,0 +1,193 @@
+package dnsexport
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/h44z/wg-portal/internal/app"
+	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
+	"github.com/sirupsen/logrus"
+	evbus "github.com/vardius/message-bus"
+)
+
+// exportDirectory is the subdirectory of the config storage path where DNS records will be stored.
+const exportDirectory = "dns"
+
+// Manager exports the addresses of all peers as DNS records (hosts file, dnsmasq config and BIND zone file).
+type Manager struct {
+	cfg *config.Config
+	bus evbus.MessageBus
+
+	fsRepo FileSystemRepo
+	wg     WireguardDatabaseRepo
+
+	serialMux *sync.Mutex
+	serials   map[domain.InterfaceIdentifier]uint32
+}
+
+func NewDnsExportManager(cfg *config.Config, bus evbus.MessageBus, wg WireguardDatabaseRepo, fsRepo FileSystemRepo) (*Manager, error) {
+	m := &Manager{
+		cfg: cfg,
+		bus: bus,
+
+		fsRepo: fsRepo,
+		wg:     wg,
+
+		serialMux: &sync.Mutex{},
+		serials:   make(map[domain.InterfaceIdentifier]uint32),
+	}
+
+	if m.cfg.Advanced.ConfigStoragePath != "" && m.cfg.Advanced.DnsExport {
+		m.connectToMessageBus()
+	}
+
+	return m, nil
+}
+
+func (m Manager) connectToMessageBus() {
+	_ = m.bus.Subscribe(app.TopicInterfaceUpdated, m.handleInterfaceUpdatedEvent)
+	_ = m.bus.Subscribe(app.TopicInterfaceDeleted, m.handleInterfaceDeletedEvent)
+	_ = m.bus.Subscribe(app.TopicPeerInterfaceUpdated, m.handlePeerInterfaceUpdatedEvent)
+}
+
+func (m Manager) handleInterfaceUpdatedEvent(iface *domain.Interface) {
+	logrus.Debugf("handling interface updated event for DNS export of %s", iface.Identifier)
+
+	err := m.PersistInterfaceRecords(context.Background(), iface.Identifier)
+	if err != nil {
+		logrus.Errorf("failed to automatically persist DNS records for %s: %v", iface.Identifier, err)
+	}
+}
+
+func (m Manager) handleInterfaceDeletedEvent(id domain.InterfaceIdentifier) {
+	logrus.Debugf("handling interface deleted event for DNS export of %s", id)
+
+	err := m.RemoveInterfaceRecords(id)
+	if err != nil {
+		logrus.Errorf("failed to automatically remove DNS records for %s: %v", id, err)
+	}
+}
+
+func (m Manager) handlePeerInterfaceUpdatedEvent(id domain.InterfaceIdentifier) {
+	logrus.Debugf("handling peer interface updated event for DNS export of %s", id)
+
+	err := m.PersistInterfaceRecords(context.Background(), id)
+	if err != nil {
+		logrus.Errorf("failed to automatically persist DNS records for %s: %v", id, err)
+	}
+}
+
+// GetInterfaceRecords returns the DNS records of all peers of the given interface in the requested format.
+func (m Manager) GetInterfaceRecords(ctx context.Context, id domain.InterfaceIdentifier, format Format) (
+	io.Reader,
+	error,
+) {
+	if err := domain.ValidateAdminAccessRights(ctx, domain.PermissionSystemRead); err != nil {
+		return nil, err
+	}
+
+	iface, peers, err := m.wg.GetInterfaceAndPeers(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch interface %s: %w", id, err)
+	}
+
+	records := buildRecords(m.cfg.Advanced.DnsExportDomain, iface, peers)
+
+	switch format {
+	case FormatHosts:
+		return renderHosts(records), nil
+	case FormatDnsmasq:
+		return renderDnsmasq(records), nil
+	case FormatZone:
+		return renderZone(records, m.currentSerial(id)), nil
+	default:
+		return nil, fmt.Errorf("unsupported DNS export format %s: %w", format, domain.ErrInvalidData)
+	}
+}
+
+// PersistInterfaceRecords writes the DNS records of all peers of the given interface to the config storage path.
+func (m Manager) PersistInterfaceRecords(ctx context.Context, id domain.InterfaceIdentifier) error {
+	iface, peers, err := m.wg.GetInterfaceAndPeers(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to fetch interface %s: %w", id, err)
+	}
+
+	records := buildRecords(m.cfg.Advanced.DnsExportDomain, iface, peers)
+	baseName := exportFileBaseName(id)
+
+	files := map[string]io.Reader{
+		baseName + ".hosts":        renderHosts(records),
+		baseName + ".dnsmasq.conf": renderDnsmasq(records),
+		baseName + ".zone":         renderZone(records, m.nextSerial(id)),
+	}
+	for fileName, contents := range files {
+		if err := m.fsRepo.WriteFile(exportDirectory+"/"+fileName, contents); err != nil {
+			return fmt.Errorf("failed to write DNS records %s: %w", fileName, err)
+		}
+	}
+
+	return nil
+}
+
+// RemoveInterfaceRecords deletes the exported DNS records of the given interface from the config storage path.
+func (m Manager) RemoveInterfaceRecords(id domain.InterfaceIdentifier) error {
+	baseName := exportFileBaseName(id)
+
+	for _, fileName := range []string{baseName + ".hosts", baseName + ".dnsmasq.conf", baseName + ".zone"} {
+		if err := m.fsRepo.DeleteFile(exportDirectory + "/" + fileName); err != nil {
+			return fmt.Errorf("failed to delete DNS records %s: %w", fileName, err)
+		}
+	}
+
+	return nil
+}
+
+// nextSerial increments and returns the zone serial of the given interface.
+// The serial is derived from the current time, so it also increases across restarts.
+func (m Manager) nextSerial(id domain.InterfaceIdentifier) uint32 {
+	m.serialMux.Lock()
+	defer m.serialMux.Unlock()
+
+	serial := zoneSerial(time.Now())
+	if prev, ok := m.serials[id]; ok && serial <= prev {
+		serial = prev + 1
+	}
+	m.serials[id] = serial
+
+	return serial
+}
+
+// currentSerial returns the last zone serial of the given interface, or a new one if no zone was exported yet.
+func (m Manager) currentSerial(id domain.InterfaceIdentifier) uint32 {
+	m.serialMux.Lock()
+	serial, ok := m.serials[id]
+	m.serialMux.Unlock()
+
+	if !ok {
+		return m.nextSerial(id)
+	}
+
+	return serial
+}
+
+// zoneSerial returns the zone serial for the given time in the common YYYYMMDDnn format. The revision nn is derived
+// from the time of day and advances about every 15 minutes.
+func zoneSerial(t time.Time) uint32 {
+	t = t.UTC()
+	revision := (t.Hour()*60 + t.Minute()) * 100 / (24 * 60)
+
+	return uint32(t.Year()*1000000 + int(t.Month())*10000 + t.Day()*100 + revision)
+}
+
+var invalidFileNameChars = regexp.MustCompile("[^a-zA-Z0-9-_]+")
+
+// exportFileBaseName returns the file name prefix of the exported records. The full interface identifier is used,
+// so that interfaces with a common prefix do not overwrite each other's files.
+func exportFileBaseName(id domain.InterfaceIdentifier) string {
+	return invalidFileNameChars.ReplaceAllString(string(id), "")
+}
@@ -0,0 +1,81 @@
+package dnsexport
+
+import (
+	"context"
+	"io"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+type testWireguardDatabaseRepo struct{}
+
+func (r testWireguardDatabaseRepo) GetInterfaceAndPeers(_ context.Context, id domain.InterfaceIdentifier) (
+	*domain.Interface,
+	[]domain.Peer,
+	error,
+) {
+	return &domain.Interface{Identifier: id}, nil, nil
+}
+
+type testFileSystemRepo struct {
+	files map[string]string
+}
+
+func (r *testFileSystemRepo) WriteFile(path string, contents io.Reader) error {
+	data, err := io.ReadAll(contents)
+	if err != nil {
+		return err
+	}
+	r.files[path] = string(data)
+	return nil
+}
+
+func (r *testFileSystemRepo) DeleteFile(path string) error {
+	delete(r.files, path)
+	return nil
+}
+
+func TestManager_PersistInterfaceRecords(t *testing.T) {
+	fsRepo := &testFileSystemRepo{files: map[string]string{}}
+	m, _ := NewDnsExportManager(&config.Config{}, nil, testWireguardDatabaseRepo{}, fsRepo)
+
+	for _, id := range []domain.InterfaceIdentifier{"wg-office-1", "wg-office-2"} {
+		if err := m.PersistInterfaceRecords(context.Background(), id); err != nil {
+			t.Fatalf("PersistInterfaceRecords() error = %v", err)
+		}
+	}
+	if err := m.RemoveInterfaceRecords("wg-office-1"); err != nil {
+		t.Fatalf("RemoveInterfaceRecords() error = %v", err)
+	}
+
+	want := []string{"dns/wg-office-2.dnsmasq.conf", "dns/wg-office-2.hosts", "dns/wg-office-2.zone"}
+	if got := slices.Sorted(maps.Keys(fsRepo.files)); !slices.Equal(got, want) {
+		t.Errorf("exported files = %v, want %v", got, want)
+	}
+}
+
+func Test_zoneSerial(t *testing.T) {
+	tests := []struct {
+		name string
+		time time.Time
+		want uint32
+	}{
+		{name: "midnight", time: time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), want: 2026101900},
+		{name: "noon", time: time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC), want: 2026101950},
+		{name: "end of day", time: time.Date(2026, 12, 31, 23, 59, 59, 0, time.UTC), want: 2026123199},
+		{name: "other time zone", time: time.Date(2026, 10, 20, 1, 0, 0, 0, time.FixedZone("CEST", 2*60*60)),
+			want: 2026101995},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := zoneSerial(tt.time); got != tt.want {
+				t.Errorf("zoneSerial() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
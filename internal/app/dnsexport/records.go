@@ -0,0 +1,139 @@
+package dnsexport
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/h44z/wg-portal/internal"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// Format describes the output format of an exported DNS record set.
+type Format string
+
+const (
+	FormatHosts   Format = "hosts"   // /etc/hosts style
+	FormatDnsmasq Format = "dnsmasq" // dnsmasq address=/name/ip lines
+	FormatZone    Format = "zone"    // BIND zone file
+)
+
+// zoneTtl is the default TTL (in seconds) used for all records of a zone file.
+const zoneTtl = 300
+
+// Record is a single DNS address record for a peer.
+type Record struct {
+	Name    string // the fully qualified name, without trailing dot
+	Host    string // the name relative to the zone origin
+	Address domain.Cidr
+}
+
+// Records is a set of address records that belong to one interface.
+type Records struct {
+	Origin  string // the zone origin, without trailing dot
+	Entries []Record
+}
+
+var invalidLabelChars = regexp.MustCompile("[^a-z0-9-]+")
+
+// hostLabel converts an arbitrary string to a valid DNS label (RFC 1123).
+func hostLabel(name string) string {
+	label := strings.ToLower(strings.TrimSpace(name))
+	label = invalidLabelChars.ReplaceAllString(label, "-")
+	label = strings.Trim(label, "-")
+	label = internal.TruncateString(label, 63)
+	label = strings.TrimRight(label, "-")
+
+	return label
+}
+
+// peerLabel returns the DNS label for the given peer. If the display name does not produce a valid label,
+// a label is derived from the peer's public key.
+func peerLabel(peer *domain.Peer) string {
+	if label := hostLabel(peer.DisplayName); label != "" {
+		return label
+	}
+
+	return "peer-" + internal.TruncateString(hostLabel(string(peer.Identifier)), 8)
+}
+
+// buildRecords creates the address records for all enabled peers of the given interface.
+func buildRecords(domainName string, iface *domain.Interface, peers []domain.Peer) Records {
+	origin := strings.Trim(domainName, ".")
+	if ifaceLabel := hostLabel(string(iface.Identifier)); ifaceLabel != "" {
+		origin = ifaceLabel + "." + origin
+	}
+
+	records := Records{Origin: origin}
+	usedLabels := make(map[string]int)
+	for i := range peers {
+		peer := &peers[i]
+		if peer.IsDisabled() {
+			continue
+		}
+
+		label := peerLabel(peer)
+		usedLabels[label]++
+		if cnt := usedLabels[label]; cnt > 1 {
+			label = fmt.Sprintf("%s-%d", label, cnt)
+		}
+
+		for _, addr := range peer.Interface.Addresses {
+			records.Entries = append(records.Entries, Record{
+				Name:    label + "." + origin,
+				Host:    label,
+				Address: addr.HostAddr(),
+			})
+		}
+	}
+
+	return records
+}
+
+// renderHosts writes the records in /etc/hosts format.
+func renderHosts(records Records) io.Reader {
+	var buf bytes.Buffer
+	buf.WriteString("# WireGuard Portal peer records for " + records.Origin + "\n")
+	for _, r := range records.Entries {
+		buf.WriteString(fmt.Sprintf("%s\t%s %s\n", r.Address.Addr, r.Name, r.Host))
+	}
+
+	return &buf
+}
+
+// renderDnsmasq writes the records as dnsmasq address directives.
+func renderDnsmasq(records Records) io.Reader {
+	var buf bytes.Buffer
+	buf.WriteString("# WireGuard Portal peer records for " + records.Origin + "\n")
+	for _, r := range records.Entries {
+		buf.WriteString(fmt.Sprintf("address=/%s/%s\n", r.Name, r.Address.Addr))
+	}
+
+	return &buf
+}
+
+// renderZone writes the records as BIND zone file using the given SOA serial.
+func renderZone(records Records, serial uint32) io.Reader {
+	var buf bytes.Buffer
+	buf.WriteString("; WireGuard Portal peer records for " + records.Origin + "\n")
+	buf.WriteString(fmt.Sprintf("$ORIGIN %s.\n", records.Origin))
+	buf.WriteString(fmt.Sprintf("$TTL %d\n", zoneTtl))
+	buf.WriteString(fmt.Sprintf("@\tIN\tSOA\tlocalhost. hostmaster.%s. (\n", records.Origin))
+	buf.WriteString(fmt.Sprintf("\t\t%d\t; serial\n", serial))
+	buf.WriteString("\t\t3600\t; refresh\n")
+	buf.WriteString("\t\t600\t; retry\n")
+	buf.WriteString("\t\t86400\t; expire\n")
+	buf.WriteString(fmt.Sprintf("\t\t%d )\t; minimum\n", zoneTtl))
+	buf.WriteString("@\tIN\tNS\tlocalhost.\n") // the zone is meant to be served locally, so no glue records are needed
+	for _, r := range records.Entries {
+		recordType := "AAAA"
+		if r.Address.IsV4() {
+			recordType = "A"
+		}
+		buf.WriteString(fmt.Sprintf("%s\tIN\t%s\t%s\n", r.Host, recordType, r.Address.Addr))
+	}
+
+	return &buf
+}
@@ -0,0 +1,129 @@
+package dnsexport
+
+import (
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+func Test_hostLabel(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "simple", in: "laptop", want: "laptop"},
+		{name: "spaces and case", in: "My Laptop", want: "my-laptop"},
+		{name: "special characters", in: "  _Phone (Work)! ", want: "phone-work"},
+		{name: "empty", in: "", want: ""},
+		{name: "only invalid", in: "+/=", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hostLabel(tt.in); got != tt.want {
+				t.Errorf("hostLabel() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_buildRecords(t *testing.T) {
+	now := time.Now()
+	iface := &domain.Interface{Identifier: "wg0"}
+	peers := []domain.Peer{
+		{
+			Identifier:  "peer1",
+			DisplayName: "Laptop",
+			Interface: domain.PeerInterfaceConfig{
+				Addresses: domain.CidrsMust(domain.CidrsFromString("10.0.0.2/32,fd00::2/128")),
+			},
+		},
+		{
+			Identifier:  "peer2",
+			DisplayName: "laptop",
+			Interface: domain.PeerInterfaceConfig{
+				Addresses: domain.CidrsMust(domain.CidrsFromString("10.0.0.3/32")),
+			},
+		},
+		{
+			Identifier:  "peer3",
+			DisplayName: "disabled",
+			Disabled:    &now,
+			Interface: domain.PeerInterfaceConfig{
+				Addresses: domain.CidrsMust(domain.CidrsFromString("10.0.0.4/32")),
+			},
+		},
+	}
+
+	records := buildRecords("wg.local.", iface, peers)
+
+	want := []string{"laptop.wg0.wg.local", "laptop.wg0.wg.local", "laptop-2.wg0.wg.local"}
+	var got []string
+	for _, r := range records.Entries {
+		got = append(got, r.Name)
+	}
+	if records.Origin != "wg0.wg.local" {
+		t.Errorf("buildRecords() origin = %v, want %v", records.Origin, "wg0.wg.local")
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("buildRecords() names = %v, want %v", got, want)
+	}
+}
+
+func Test_renderers(t *testing.T) {
+	records := Records{
+		Origin: "wg0.wg.local",
+		Entries: []Record{
+			{Name: "laptop.wg0.wg.local", Host: "laptop", Address: domain.Cidr{Addr: "10.0.0.2", NetLength: 32}},
+			{Name: "laptop.wg0.wg.local", Host: "laptop", Address: domain.Cidr{Addr: "fd00::2", NetLength: 128}},
+		},
+	}
+
+	tests := []struct {
+		name string
+		in   io.Reader
+		want string
+	}{
+		{
+			name: "hosts",
+			in:   renderHosts(records),
+			want: "# WireGuard Portal peer records for wg0.wg.local\n" +
+				"10.0.0.2\tlaptop.wg0.wg.local laptop\n" +
+				"fd00::2\tlaptop.wg0.wg.local laptop\n",
+		},
+		{
+			name: "dnsmasq",
+			in:   renderDnsmasq(records),
+			want: "# WireGuard Portal peer records for wg0.wg.local\n" +
+				"address=/laptop.wg0.wg.local/10.0.0.2\n" +
+				"address=/laptop.wg0.wg.local/fd00::2\n",
+		},
+		{
+			name: "zone",
+			in:   renderZone(records, 42),
+			want: "; WireGuard Portal peer records for wg0.wg.local\n" +
+				"$ORIGIN wg0.wg.local.\n" +
+				"$TTL 300\n" +
+				"@\tIN\tSOA\tlocalhost. hostmaster.wg0.wg.local. (\n" +
+				"\t\t42\t; serial\n" +
+				"\t\t3600\t; refresh\n" +
+				"\t\t600\t; retry\n" +
+				"\t\t86400\t; expire\n" +
+				"\t\t300 )\t; minimum\n" +
+				"@\tIN\tNS\tlocalhost.\n" +
+				"laptop\tIN\tA\t10.0.0.2\n" +
+				"laptop\tIN\tAAAA\tfd00::2\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := io.ReadAll(tt.in)
+			if string(got) != tt.want {
+				t.Errorf("render() = %q, want %q", string(got), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package dnsexport
+
+import (
+	"context"
+	"io"
+
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+type WireguardDatabaseRepo interface {
+	GetInterfaceAndPeers(ctx context.Context, id domain.InterfaceIdentifier) (*domain.Interface, []domain.Peer, error)
+}
+
+type FileSystemRepo interface {
+	WriteFile(path string, contents io.Reader) error
+	DeleteFile(path string) error
+}
@@ -7,9 +7,19 @@ const TopicUserRegistered = "user:registered"
 const TopicUserDisabled = "user:disabled"
 const TopicUserEnabled = "user:enabled"
 const TopicUserDeleted = "user:deleted"
+const TopicUserPasswordReset = "user:password:reset"
+const TopicUserInvited = "user:invited"
+const TopicUserInvitationAccepted = "user:invitation:accepted"
+const TopicUserVerificationRequested = "user:verification:requested"
+const TopicUserInterfacesAssigned = "user:interfaces:assigned"
 const TopicAuthLogin = "auth:login"
 const TopicRouteUpdate = "route:update"
 const TopicRouteRemove = "route:remove"
 const TopicInterfaceUpdated = "interface:updated"
+const TopicInterfaceDeleted = "interface:deleted"
 const TopicPeerInterfaceUpdated = "peer:interface:updated"
 const TopicPeerIdentifierUpdated = "peer:identifier:updated"
+const TopicPeerStatusUpdated = "peer:status:updated"
+const TopicPeerConnected = "peer:connected"
+const TopicPeerDisconnected = "peer:disconnected"
+const TopicAuditEvent = "audit:event"
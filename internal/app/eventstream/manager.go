@@ -0,0 +1,337 @@
+package eventstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/h44z/wg-portal/internal/app"
+	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
+	"github.com/sirupsen/logrus"
+	evbus "github.com/vardius/message-bus"
+)
+
+const (
+	historySize          = 1000 // number of events that are kept for resuming streams
+	subscriberBufferSize = 100  // number of events that are buffered for slow subscribers
+)
+
+// busTopics contains all message bus topics that are forwarded to the event stream.
+var busTopics = []string{
+	app.TopicUserCreated,
+	app.TopicUserApiEnabled,
+	app.TopicUserApiDisabled,
+	app.TopicUserRegistered,
+	app.TopicUserDisabled,
+	app.TopicUserEnabled,
+	app.TopicUserDeleted,
+	app.TopicAuthLogin,
+	app.TopicInterfaceUpdated,
+	app.TopicPeerInterfaceUpdated,
+	app.TopicPeerIdentifierUpdated,
+}
+
+type subscriber struct {
+	ctx    context.Context
+	events chan domain.StreamEvent
+}
+
+type Manager struct {
+	cfg *config.Config
+	bus evbus.MessageBus
+
+	db DatabaseRepo
+
+	streamId string // identifies the current process, events of previous runs can not be resumed
+
+	mux         sync.Mutex
+	sequence    uint64
+	history     []domain.StreamEvent
+	subscribers map[*subscriber]struct{}
+
+	ownerMux sync.Mutex
+	owners   map[domain.InterfaceIdentifier]map[domain.PeerIdentifier]domain.UserIdentifier // peer owners per interface
+}
+
+func NewEventStreamManager(cfg *config.Config, bus evbus.MessageBus, db DatabaseRepo) (*Manager, error) {
+	m := &Manager{
+		cfg: cfg,
+		bus: bus,
+
+		db: db,
+
+		streamId:    strconv.FormatInt(time.Now().Unix(), 36),
+		history:     make([]domain.StreamEvent, 0, historySize),
+		subscribers: make(map[*subscriber]struct{}),
+		owners:      make(map[domain.InterfaceIdentifier]map[domain.PeerIdentifier]domain.UserIdentifier),
+	}
+
+	if err := m.connectToMessageBus(); err != nil {
+		return nil, fmt.Errorf("failed to setup message bus: %w", err)
+	}
+
+	return m, nil
+}
+
+func (m *Manager) connectToMessageBus() error {
+	if err := m.bus.Subscribe(app.TopicPeerStatusUpdated, m.handlePeerStatusUpdatedEvent); err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", app.TopicPeerStatusUpdated, err)
+	}
+
+	// peer owners might have changed, drop the cached owners of the interface
+	if err := m.bus.Subscribe(app.TopicPeerInterfaceUpdated, m.handlePeerInterfaceUpdatedEvent); err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", app.TopicPeerInterfaceUpdated, err)
+	}
+
+	if err := m.bus.Subscribe(app.TopicPeerConnected, m.handlePeerConnectedEvent); err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", app.TopicPeerConnected, err)
+	}
+	if err := m.bus.Subscribe(app.TopicPeerDisconnected, m.handlePeerDisconnectedEvent); err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", app.TopicPeerDisconnected, err)
+	}
+
+	for _, topic := range busTopics {
+		if err := m.bus.Subscribe(topic, m.busEventHandler(topic)); err != nil {
+			return fmt.Errorf("failed to subscribe to %s: %w", topic, err)
+		}
+	}
+
+	return nil
+}
+
+// Subscribe registers a new stream subscriber. The returned channel receives all events that the current user
+// is allowed to see. It is closed once the context is cancelled or if the subscriber is too slow.
+// If lastEventId is set, all buffered events that happened after the given event are sent first.
+func (m *Manager) Subscribe(ctx context.Context, lastEventId string) (<-chan domain.StreamEvent, error) {
+	if domain.GetUserInfo(ctx).Id == "" {
+		return nil, domain.ErrNoPermission
+	}
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	var backlog []domain.StreamEvent
+	if lastEventId != "" {
+		for _, event := range m.history[m.resumeIndex(lastEventId):] {
+			if isVisible(ctx, event) {
+				backlog = append(backlog, event)
+			}
+		}
+	}
+
+	sub := &subscriber{
+		ctx:    ctx,
+		events: make(chan domain.StreamEvent, len(backlog)+subscriberBufferSize),
+	}
+	for _, event := range backlog {
+		sub.events <- event
+	}
+	m.subscribers[sub] = struct{}{}
+
+	go func() {
+		<-ctx.Done()
+
+		m.mux.Lock()
+		defer m.mux.Unlock()
+		m.removeSubscriber(sub)
+	}()
+
+	return sub.events, nil
+}
+
+// resumeIndex returns the history index of the first event after the given event id.
+// If the event id is unknown, the whole history is replayed.
+func (m *Manager) resumeIndex(lastEventId string) int {
+	streamId, sequence, err := parseEventId(lastEventId)
+	if err != nil || streamId != m.streamId {
+		return 0
+	}
+
+	for i, event := range m.history {
+		_, eventSequence, _ := parseEventId(event.Id)
+		if eventSequence > sequence {
+			return i
+		}
+	}
+
+	return len(m.history)
+}
+
+func (m *Manager) removeSubscriber(sub *subscriber) {
+	if _, ok := m.subscribers[sub]; !ok {
+		return // already removed
+	}
+
+	delete(m.subscribers, sub)
+	close(sub.events)
+}
+
+func (m *Manager) publish(event domain.StreamEvent) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	m.sequence++
+	event.Id = formatEventId(m.streamId, m.sequence)
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	if len(m.history) == historySize {
+		m.history = append(m.history[:0], m.history[1:]...)
+	}
+	m.history = append(m.history, event)
+
+	for sub := range m.subscribers {
+		if !isVisible(sub.ctx, event) {
+			continue
+		}
+
+		select {
+		case sub.events <- event:
+		default:
+			// the subscriber is too slow, it can reconnect and resume using the last received event id
+			logrus.Debugf("dropping slow event stream subscriber %s", domain.GetUserInfo(sub.ctx).Id)
+			m.removeSubscriber(sub)
+		}
+	}
+}
+
+// isVisible checks if the current user is allowed to see the event. Events of an interface are visible to all users
+// that are allowed to read the peers of the interface, including delegated admins.
+func isVisible(ctx context.Context, event domain.StreamEvent) bool {
+	if event.UserIdentifier != "" &&
+		domain.HasUserAccessRights(ctx, event.UserIdentifier, domain.PermissionUsersRead) {
+		return true
+	}
+
+	if event.InterfaceIdentifier != "" {
+		return domain.HasInterfaceAccessRights(ctx, event.InterfaceIdentifier, domain.PermissionPeersRead)
+	}
+
+	return domain.HasAdminAccessRights(ctx, domain.PermissionSystemRead)
+}
+
+func formatEventId(streamId string, sequence uint64) string {
+	return streamId + "-" + strconv.FormatUint(sequence, 10)
+}
+
+func parseEventId(id string) (string, uint64, error) {
+	idx := strings.LastIndex(id, "-")
+	if idx <= 0 {
+		return "", 0, errors.New("invalid event id")
+	}
+
+	sequence, err := strconv.ParseUint(id[idx+1:], 10, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid event sequence: %w", err)
+	}
+
+	return id[:idx], sequence, nil
+}
+
+// peerOwner returns the owner of the peer. The owners are cached per interface, so that status updates do not
+// require a database lookup.
+func (m *Manager) peerOwner(iface domain.InterfaceIdentifier, peer domain.PeerIdentifier) domain.UserIdentifier {
+	m.ownerMux.Lock()
+	defer m.ownerMux.Unlock()
+
+	owners, ok := m.owners[iface]
+	if !ok {
+		ctx := domain.SetUserInfo(context.Background(), domain.SystemAdminContextUserInfo())
+		peers, err := m.db.GetInterfacePeers(ctx, iface)
+		if err != nil {
+			logrus.Warnf("failed to load peers of %s for event stream: %v", iface, err)
+			return "" // do not cache the failure, the next update retries
+		}
+
+		owners = make(map[domain.PeerIdentifier]domain.UserIdentifier, len(peers))
+		for _, p := range peers {
+			owners[p.Identifier] = p.UserIdentifier
+		}
+		m.owners[iface] = owners
+	}
+
+	return owners[peer]
+}
+
+func (m *Manager) handlePeerInterfaceUpdatedEvent(iface domain.InterfaceIdentifier) {
+	m.ownerMux.Lock()
+	defer m.ownerMux.Unlock()
+
+	delete(m.owners, iface)
+}
+
+func (m *Manager) handlePeerStatusUpdatedEvent(update domain.PeerStatusUpdate) {
+	owner := m.peerOwner(update.InterfaceId, update.Current.PeerId)
+
+	if update.HandshakeUpdated() {
+		m.publish(domain.StreamEvent{Type: domain.StreamEventPeerHandshake, UserIdentifier: owner,
+			InterfaceIdentifier: update.InterfaceId, Data: update})
+	}
+
+	if update.ReceivedDelta() > 0 || update.TransmittedDelta() > 0 {
+		m.publish(domain.StreamEvent{Type: domain.StreamEventPeerTraffic, UserIdentifier: owner,
+			InterfaceIdentifier: update.InterfaceId, Data: update})
+	}
+}
+
+func (m *Manager) handlePeerConnectedEvent(event domain.PeerConnectionEvent) {
+	m.publish(domain.StreamEvent{
+		Type:                domain.StreamEventPeerConnected,
+		UserIdentifier:      event.Peer.UserIdentifier,
+		InterfaceIdentifier: event.Peer.InterfaceIdentifier,
+		Data:                event,
+	})
+}
+
+func (m *Manager) handlePeerDisconnectedEvent(event domain.PeerConnectionEvent) {
+	m.publish(domain.StreamEvent{
+		Type:                domain.StreamEventPeerDisconnected,
+		UserIdentifier:      event.Peer.UserIdentifier,
+		InterfaceIdentifier: event.Peer.InterfaceIdentifier,
+		Data:                event,
+	})
+}
+
+func (m *Manager) busEventHandler(topic string) func(args ...any) {
+	return func(args ...any) {
+		m.handleBusEvent(topic, args...)
+	}
+}
+
+func (m *Manager) handleBusEvent(topic string, args ...any) {
+	event := domain.StreamEvent{
+		Type: domain.StreamEventType(topic),
+	}
+
+	for _, arg := range args {
+		switch v := arg.(type) {
+		case domain.User:
+			event.UserIdentifier = v.Identifier
+			event.Data = v
+		case *domain.User:
+			event.UserIdentifier = v.Identifier
+			event.Data = *v
+		case domain.UserIdentifier:
+			event.UserIdentifier = v
+			event.Data = v
+		case *domain.Interface:
+			event.InterfaceIdentifier = v.Identifier
+			event.Data = *v
+		case domain.InterfaceIdentifier:
+			event.InterfaceIdentifier = v
+			event.Data = v
+		case domain.PeerIdentifier:
+			event.Data = v // for identifier changes, the new identifier (last argument) is kept
+		default:
+			event.Data = v
+		}
+	}
+
+	m.publish(event)
+}
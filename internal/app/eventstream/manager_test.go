@@ -0,0 +1,76 @@
+package eventstream
+
+import (
+	"context"
+	"testing"
+
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+type testDatabaseRepo struct {
+	peers   map[domain.InterfaceIdentifier][]domain.Peer
+	lookups int
+}
+
+func (r *testDatabaseRepo) GetInterfacePeers(_ context.Context, id domain.InterfaceIdentifier) ([]domain.Peer, error) {
+	r.lookups++
+	return r.peers[id], nil
+}
+
+func Test_isVisible(t *testing.T) {
+	tests := []struct {
+		name  string
+		user  domain.ContextUserInfo
+		event domain.StreamEvent
+		want  bool
+	}{
+		{name: "owner", user: domain.ContextUserInfo{Id: "u1", Role: domain.RoleUser},
+			event: domain.StreamEvent{UserIdentifier: "u1", InterfaceIdentifier: "wg0"}, want: true},
+		{name: "other user", user: domain.ContextUserInfo{Id: "u2", Role: domain.RoleUser},
+			event: domain.StreamEvent{UserIdentifier: "u1", InterfaceIdentifier: "wg0"}, want: false},
+		{name: "admin", user: domain.ContextUserInfo{Id: "a", IsAdmin: true, Role: domain.RoleAdmin},
+			event: domain.StreamEvent{Type: "user:created"}, want: true},
+		{name: "user without owner", user: domain.ContextUserInfo{Id: "u1", Role: domain.RoleUser},
+			event: domain.StreamEvent{Type: "user:created"}, want: false},
+		{name: "delegated admin of interface",
+			user:  domain.ContextUserInfo{Id: "d", Role: domain.RoleUser, ManagedInterfaces: []domain.InterfaceIdentifier{"wg0"}},
+			event: domain.StreamEvent{UserIdentifier: "u1", InterfaceIdentifier: "wg0"}, want: true},
+		{name: "delegated admin of other interface",
+			user:  domain.ContextUserInfo{Id: "d", Role: domain.RoleUser, ManagedInterfaces: []domain.InterfaceIdentifier{"wg1"}},
+			event: domain.StreamEvent{UserIdentifier: "u1", InterfaceIdentifier: "wg0"}, want: false},
+		{name: "delegated admin without interface",
+			user:  domain.ContextUserInfo{Id: "d", Role: domain.RoleUser, ManagedInterfaces: []domain.InterfaceIdentifier{"wg0"}},
+			event: domain.StreamEvent{Type: "user:created"}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := domain.SetUserInfo(context.Background(), &tt.user)
+			if got := isVisible(ctx, tt.event); got != tt.want {
+				t.Errorf("isVisible() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestManager_peerOwner(t *testing.T) {
+	db := &testDatabaseRepo{peers: map[domain.InterfaceIdentifier][]domain.Peer{
+		"wg0": {{Identifier: "p1", UserIdentifier: "u1"}, {Identifier: "p2", UserIdentifier: "u2"}},
+	}}
+	m := &Manager{db: db, owners: make(map[domain.InterfaceIdentifier]map[domain.PeerIdentifier]domain.UserIdentifier)}
+
+	if owner := m.peerOwner("wg0", "p1"); owner != "u1" {
+		t.Errorf("peerOwner() = %q, want u1", owner)
+	}
+	if owner := m.peerOwner("wg0", "p2"); owner != "u2" {
+		t.Errorf("peerOwner() = %q, want u2", owner)
+	}
+	if db.lookups != 1 {
+		t.Errorf("peerOwner() loaded peers %d times, want 1", db.lookups)
+	}
+
+	db.peers["wg0"][0].UserIdentifier = "u3"
+	m.handlePeerInterfaceUpdatedEvent("wg0")
+	if owner := m.peerOwner("wg0", "p1"); owner != "u3" || db.lookups != 2 {
+		t.Errorf("peerOwner() after update = %q, lookups = %d, want u3, 2", owner, db.lookups)
+	}
+}
@@ -0,0 +1,11 @@
+package eventstream
+
+import (
+	"context"
+
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+type DatabaseRepo interface {
+	GetInterfacePeers(ctx context.Context, id domain.InterfaceIdentifier) ([]domain.Peer, error)
+}
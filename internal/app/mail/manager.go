@@ -3,14 +3,19 @@ package mail
 import (
 	"context"
 	"fmt"
+	"github.com/h44z/wg-portal/internal/app"
 	"github.com/h44z/wg-portal/internal/config"
 	"github.com/h44z/wg-portal/internal/domain"
 	"github.com/sirupsen/logrus"
+	evbus "github.com/vardius/message-bus"
 	"io"
+	"net/url"
+	"time"
 )
 
 type Manager struct {
 	cfg        *config.Config
+	bus        evbus.MessageBus
 	tplHandler *TemplateHandler
 
 	mailer      Mailer
@@ -19,7 +24,7 @@ type Manager struct {
 	wg          WireguardDatabaseRepo
 }
 
-func NewMailManager(cfg *config.Config, mailer Mailer, configFiles ConfigFileManager, users UserDatabaseRepo, wg WireguardDatabaseRepo) (*Manager, error) {
+func NewMailManager(cfg *config.Config, bus evbus.MessageBus, mailer Mailer, configFiles ConfigFileManager, users UserDatabaseRepo, wg WireguardDatabaseRepo) (*Manager, error) {
 	tplHandler, err := newTemplateHandler(cfg.Web.ExternalUrl)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize template handler: %w", err)
@@ -27,6 +32,7 @@ func NewMailManager(cfg *config.Config, mailer Mailer, configFiles ConfigFileMan
 
 	m := &Manager{
 		cfg:         cfg,
+		bus:         bus,
 		tplHandler:  tplHandler,
 		mailer:      mailer,
 		configFiles: configFiles,
@@ -34,9 +40,44 @@ func NewMailManager(cfg *config.Config, mailer Mailer, configFiles ConfigFileMan
 		wg:          wg,
 	}
 
+	m.connectToMessageBus()
+
 	return m, nil
 }
 
+func (m Manager) connectToMessageBus() {
+	_ = m.bus.Subscribe(app.TopicUserPasswordReset, m.handlePasswordResetEvent)
+	_ = m.bus.Subscribe(app.TopicUserInvited, m.handleUserInvitedEvent)
+	_ = m.bus.Subscribe(app.TopicUserVerificationRequested, m.handleVerificationRequestedEvent)
+}
+
+func (m Manager) handlePasswordResetEvent(req domain.PasswordResetRequest) {
+	ctx := domain.SetUserInfo(context.Background(), domain.SystemAdminContextUserInfo())
+
+	err := m.sendPasswordResetEmail(ctx, &req.User, req.Token, req.ExpiresAt)
+	if err != nil {
+		logrus.Errorf("failed to send password reset mail to user %s: %v", req.User.Identifier, err)
+	}
+}
+
+func (m Manager) handleUserInvitedEvent(req domain.UserInvitationRequest) {
+	ctx := domain.SetUserInfo(context.Background(), domain.SystemAdminContextUserInfo())
+
+	err := m.sendInvitationEmail(ctx, &req.User, req.Token, req.ExpiresAt)
+	if err != nil {
+		logrus.Errorf("failed to send invitation mail to user %s: %v", req.User.Identifier, err)
+	}
+}
+
+func (m Manager) handleVerificationRequestedEvent(req domain.RegistrationVerificationRequest) {
+	ctx := domain.SetUserInfo(context.Background(), domain.SystemAdminContextUserInfo())
+
+	err := m.sendVerificationEmail(ctx, &req.User, req.Token, req.ExpiresAt)
+	if err != nil {
+		logrus.Errorf("failed to send verification mail to user %s: %v", req.User.Identifier, err)
+	}
+}
+
 func (m Manager) SendPeerEmail(ctx context.Context, linkOnly bool, peers ...domain.PeerIdentifier) error {
 	for _, peerId := range peers {
 		peer, err := m.wg.GetPeer(ctx, peerId)
@@ -44,7 +85,7 @@ func (m Manager) SendPeerEmail(ctx context.Context, linkOnly bool, peers ...doma
 			return fmt.Errorf("failed to fetch peer %s: %w", peerId, err)
 		}
 
-		if err := domain.ValidateUserAccessRights(ctx, peer.UserIdentifier); err != nil {
+		if err := domain.ValidatePeerAccessRights(ctx, peer, domain.PermissionPeersWrite); err != nil {
 			return err
 		}
 
@@ -127,5 +168,82 @@ func (m Manager) sendPeerEmail(ctx context.Context, linkOnly bool, user *domain.
 		return fmt.Errorf("failed to send mail: %w", err)
 	}
 
+	m.bus.Publish(app.TopicAuditEvent, domain.NewAuditEvent(ctx, domain.AuditSeverityLevelLow, "peerMailSent",
+		domain.AuditObjectTypePeer, string(peer.Identifier),
+		fmt.Sprintf("configuration mail for peer %s sent to %s", peer.Identifier, user.Email)).
+		WithInfo("linkOnly", fmt.Sprintf("%t", linkOnly)))
+
+	return nil
+}
+
+func (m Manager) sendPasswordResetEmail(ctx context.Context, user *domain.User, token string, expiresAt time.Time) error {
+	link := fmt.Sprintf("%s/app/#/password-reset?token=%s", m.cfg.Web.ExternalUrl, url.QueryEscape(token))
+
+	txtMail, htmlMail, err := m.tplHandler.GetPasswordResetMail(user, link, expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to get mail body: %w", err)
+	}
+
+	txtMailStr, _ := io.ReadAll(txtMail)
+	htmlMailStr, _ := io.ReadAll(htmlMail)
+
+	err = m.mailer.Send(ctx, "WireGuard Portal Password Reset", string(txtMailStr), []string{user.Email},
+		&domain.MailOptions{HtmlBody: string(htmlMailStr)})
+	if err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+
+	m.bus.Publish(app.TopicAuditEvent, domain.NewAuditEvent(ctx, domain.AuditSeverityLevelLow, "passwordResetMailSent",
+		domain.AuditObjectTypeUser, string(user.Identifier),
+		fmt.Sprintf("password reset mail for user %s sent to %s", user.Identifier, user.Email)))
+
+	return nil
+}
+
+func (m Manager) sendInvitationEmail(ctx context.Context, user *domain.User, token string, expiresAt time.Time) error {
+	link := fmt.Sprintf("%s/app/#/invitation?token=%s", m.cfg.Web.ExternalUrl, url.QueryEscape(token))
+
+	txtMail, htmlMail, err := m.tplHandler.GetInvitationMail(user, link, expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to get mail body: %w", err)
+	}
+
+	txtMailStr, _ := io.ReadAll(txtMail)
+	htmlMailStr, _ := io.ReadAll(htmlMail)
+
+	err = m.mailer.Send(ctx, "WireGuard Portal Invitation", string(txtMailStr), []string{user.Email},
+		&domain.MailOptions{HtmlBody: string(htmlMailStr)})
+	if err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+
+	m.bus.Publish(app.TopicAuditEvent, domain.NewAuditEvent(ctx, domain.AuditSeverityLevelLow, "invitationMailSent",
+		domain.AuditObjectTypeUser, string(user.Identifier),
+		fmt.Sprintf("invitation mail for user %s sent to %s", user.Identifier, user.Email)))
+
+	return nil
+}
+
+func (m Manager) sendVerificationEmail(ctx context.Context, user *domain.User, token string, expiresAt time.Time) error {
+	link := fmt.Sprintf("%s/app/#/signup/verify?token=%s", m.cfg.Web.ExternalUrl, url.QueryEscape(token))
+
+	txtMail, htmlMail, err := m.tplHandler.GetVerificationMail(user, link, expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to get mail body: %w", err)
+	}
+
+	txtMailStr, _ := io.ReadAll(txtMail)
+	htmlMailStr, _ := io.ReadAll(htmlMail)
+
+	err = m.mailer.Send(ctx, "WireGuard Portal Email Verification", string(txtMailStr), []string{user.Email},
+		&domain.MailOptions{HtmlBody: string(htmlMailStr)})
+	if err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+
+	m.bus.Publish(app.TopicAuditEvent, domain.NewAuditEvent(ctx, domain.AuditSeverityLevelLow, "verificationMailSent",
+		domain.AuditObjectTypeUser, string(user.Identifier),
+		fmt.Sprintf("verification mail for user %s sent to %s", user.Identifier, user.Email)))
+
 	return nil
 }
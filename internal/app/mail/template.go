@@ -7,6 +7,7 @@ import (
 	htmlTemplate "html/template"
 	"io"
 	"text/template"
+	"time"
 
 	"github.com/h44z/wg-portal/internal/domain"
 )
@@ -91,3 +92,96 @@ func (c TemplateHandler) GetConfigMailWithAttachment(user *domain.User, cfgName,
 
 	return &tplBuff, &htmlTplBuff, nil
 }
+
+func (c TemplateHandler) GetPasswordResetMail(user *domain.User, link string, expiresAt time.Time) (
+	io.Reader,
+	io.Reader,
+	error,
+) {
+	var tplBuff bytes.Buffer
+	var htmlTplBuff bytes.Buffer
+
+	err := c.textTemplates.ExecuteTemplate(&tplBuff, "password_reset.gotpl", map[string]interface{}{
+		"User":      user,
+		"Link":      link,
+		"ExpiresAt": expiresAt,
+		"PortalUrl": c.portalUrl,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to execute template password_reset.gotpl: %w", err)
+	}
+
+	err = c.htmlTemplates.ExecuteTemplate(&htmlTplBuff, "password_reset.gohtml", map[string]interface{}{
+		"User":      user,
+		"Link":      link,
+		"ExpiresAt": expiresAt,
+		"PortalUrl": c.portalUrl,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to execute template password_reset.gohtml: %w", err)
+	}
+
+	return &tplBuff, &htmlTplBuff, nil
+}
+
+func (c TemplateHandler) GetInvitationMail(user *domain.User, link string, expiresAt time.Time) (
+	io.Reader,
+	io.Reader,
+	error,
+) {
+	var tplBuff bytes.Buffer
+	var htmlTplBuff bytes.Buffer
+
+	err := c.textTemplates.ExecuteTemplate(&tplBuff, "invitation.gotpl", map[string]interface{}{
+		"User":      user,
+		"Link":      link,
+		"ExpiresAt": expiresAt,
+		"PortalUrl": c.portalUrl,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to execute template invitation.gotpl: %w", err)
+	}
+
+	err = c.htmlTemplates.ExecuteTemplate(&htmlTplBuff, "invitation.gohtml", map[string]interface{}{
+		"User":      user,
+		"Link":      link,
+		"ExpiresAt": expiresAt,
+		"PortalUrl": c.portalUrl,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to execute template invitation.gohtml: %w", err)
+	}
+
+	return &tplBuff, &htmlTplBuff, nil
+}
+
+func (c TemplateHandler) GetVerificationMail(user *domain.User, link string, expiresAt time.Time) (
+	io.Reader,
+	io.Reader,
+	error,
+) {
+	var tplBuff bytes.Buffer
+	var htmlTplBuff bytes.Buffer
+
+	err := c.textTemplates.ExecuteTemplate(&tplBuff, "signup_verification.gotpl", map[string]interface{}{
+		"User":      user,
+		"Link":      link,
+		"ExpiresAt": expiresAt,
+		"PortalUrl": c.portalUrl,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to execute template signup_verification.gotpl: %w", err)
+	}
+
+	err = c.htmlTemplates.ExecuteTemplate(&htmlTplBuff, "signup_verification.gohtml", map[string]interface{}{
+		"User":      user,
+		"Link":      link,
+		"ExpiresAt": expiresAt,
+		"PortalUrl": c.portalUrl,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to execute template signup_verification.gohtml: %w", err)
+	}
+
+	return &tplBuff, &htmlTplBuff, nil
+}
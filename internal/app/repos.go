@@ -9,10 +9,49 @@ import (
 
 type Authenticator interface {
 	GetExternalLoginProviders(_ context.Context) []domain.LoginProviderInfo
-	IsUserValid(ctx context.Context, id domain.UserIdentifier) bool
+	GetValidUser(ctx context.Context, id domain.UserIdentifier) (*domain.User, error)
 	PlainLogin(ctx context.Context, username, password string) (*domain.User, error)
-	OauthLoginStep1(_ context.Context, providerId string) (authCodeUrl, state, nonce string, err error)
-	OauthLoginStep2(ctx context.Context, providerId, nonce, code string) (*domain.User, error)
+	SecondFactorState(user *domain.User) domain.SecondFactorState
+	SecondFactorLogin(ctx context.Context, id domain.UserIdentifier, code string) (*domain.User, error)
+	CompleteEnrollmentLogin(ctx context.Context, id domain.UserIdentifier) (*domain.User, error)
+	OauthLoginStep1(_ context.Context, providerId string) (authCodeUrl, state, nonce, verifier string, err error)
+	OauthLoginStep2(ctx context.Context, providerId, nonce, verifier, code string) (
+		*domain.User,
+		*domain.OidcSession,
+		error,
+	)
+	OidcLogoutUrl(ctx context.Context, session *domain.OidcSession, postLogoutRedirectUrl string) string
+	OidcBackChannelLogout(ctx context.Context, providerId, logoutToken string) (*domain.OidcSession, error)
+
+	IsWebauthnEnabled() bool
+	StartWebauthnRegistration(ctx context.Context, id domain.UserIdentifier) (*domain.WebauthnChallenge, error)
+	FinishWebauthnRegistration(
+		ctx context.Context,
+		id domain.UserIdentifier,
+		name, session string,
+		response []byte,
+	) (*domain.WebauthnCredential, error)
+	GetWebauthnCredentials(ctx context.Context, id domain.UserIdentifier) ([]domain.WebauthnCredential, error)
+	DeleteWebauthnCredential(ctx context.Context, id domain.UserIdentifier, credentialId string) error
+	StartWebauthnLogin(ctx context.Context) (*domain.WebauthnChallenge, error)
+	WebauthnLogin(ctx context.Context, session string, response []byte) (*domain.User, error)
+	StartWebauthnSecondFactor(ctx context.Context, id domain.UserIdentifier) (*domain.WebauthnChallenge, error)
+	WebauthnSecondFactorLogin(
+		ctx context.Context,
+		id domain.UserIdentifier,
+		session string,
+		response []byte,
+	) (*domain.User, error)
+
+	GetLoginLocks(ctx context.Context) ([]domain.LoginLock, error)
+	ClearLoginLock(ctx context.Context, lockType domain.LoginLockType, identifier string) error
+
+	RequestPasswordReset(ctx context.Context, identifier string) error
+	ResetPassword(ctx context.Context, token, password string) error
+	AcceptInvitation(ctx context.Context, token, password string) error
+
+	SignupUser(ctx context.Context, user *domain.User) error
+	VerifySignup(ctx context.Context, token string) error
 }
 
 type UserManager interface {
@@ -26,6 +65,14 @@ type UserManager interface {
 	DeleteUser(ctx context.Context, id domain.UserIdentifier) error
 	ActivateApi(ctx context.Context, id domain.UserIdentifier) (*domain.User, error)
 	DeactivateApi(ctx context.Context, id domain.UserIdentifier) (*domain.User, error)
+	SetupTotp(ctx context.Context, id domain.UserIdentifier) (*domain.TotpEnrollment, error)
+	ActivateTotp(ctx context.Context, id domain.UserIdentifier, code string) ([]string, error)
+	DisableTotp(ctx context.Context, id domain.UserIdentifier, code string) error
+	ResetTotp(ctx context.Context, id domain.UserIdentifier) error
+	InviteUser(ctx context.Context, user *domain.User, createDefaultPeer bool) (*domain.User, error)
+	ResendInvitation(ctx context.Context, id domain.UserIdentifier) error
+	RevokeInvitation(ctx context.Context, id domain.UserIdentifier) error
+	ApproveSignup(ctx context.Context, id domain.UserIdentifier) (*domain.User, error)
 }
 
 type WireGuardManager interface {
@@ -33,7 +80,7 @@ type WireGuardManager interface {
 	GetImportableInterfaces(ctx context.Context) ([]domain.PhysicalInterface, error)
 	ImportNewInterfaces(ctx context.Context, filter ...domain.InterfaceIdentifier) (int, error)
 	RestoreInterfaceState(ctx context.Context, updateDbOnError bool, filter ...domain.InterfaceIdentifier) error
-	CreateDefaultPeer(ctx context.Context, userId domain.UserIdentifier) error
+	CreateDefaultPeer(ctx context.Context, userId domain.UserIdentifier, filter ...domain.InterfaceIdentifier) error
 	GetInterfaceAndPeers(ctx context.Context, id domain.InterfaceIdentifier) (*domain.Interface, []domain.Peer, error)
 	GetPeerStats(ctx context.Context, id domain.InterfaceIdentifier) ([]domain.PeerStatus, error)
 	GetUserPeerStats(ctx context.Context, id domain.UserIdentifier) ([]domain.PeerStatus, error)
@@ -64,6 +111,7 @@ type ConfigFileManager interface {
 	GetInterfaceConfig(ctx context.Context, id domain.InterfaceIdentifier) (io.Reader, error)
 	GetPeerConfig(ctx context.Context, id domain.PeerIdentifier) (io.Reader, error)
 	GetPeerConfigQrCode(ctx context.Context, id domain.PeerIdentifier) (io.Reader, error)
+	GetQrCode(content string) (io.Reader, error)
 	PersistInterfaceConfig(ctx context.Context, id domain.InterfaceIdentifier) error
 }
 
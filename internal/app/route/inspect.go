@@ -0,0 +1,329 @@
+package route
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/h44z/wg-portal/internal/domain"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// GetAllRoutingStatus returns the routing status of all interfaces. If the status of an interface cannot be
+// determined, the error is reported in the status of that interface.
+func (m Manager) GetAllRoutingStatus(ctx context.Context) ([]domain.RoutingStatus, error) {
+	if err := domain.ValidateAdminAccessRights(ctx, domain.PermissionSystemRead); err != nil {
+		return nil, err
+	}
+
+	interfaces, err := m.db.GetAllInterfaces(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find all interfaces: %w", err)
+	}
+
+	result := make([]domain.RoutingStatus, 0, len(interfaces))
+	for _, iface := range interfaces {
+		status, err := m.getRoutingStatus(ctx, &iface)
+		if err != nil {
+			status = &domain.RoutingStatus{
+				InterfaceId:       iface.Identifier,
+				ManagementEnabled: iface.ManageRoutingTable() && !iface.IsDisabled(),
+				Error:             err.Error(),
+			}
+		}
+		result = append(result, *status)
+	}
+
+	return result, nil
+}
+
+// GetRoutingStatus compares the expected routes and rules of the given interface with the ones that are
+// installed on the host system.
+func (m Manager) GetRoutingStatus(ctx context.Context, id domain.InterfaceIdentifier) (*domain.RoutingStatus, error) {
+	if err := domain.ValidateAdminAccessRights(ctx, domain.PermissionSystemRead); err != nil {
+		return nil, err
+	}
+
+	iface, err := m.db.GetInterface(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find interface %s: %w", id, err)
+	}
+
+	return m.getRoutingStatus(ctx, iface)
+}
+
+func (m Manager) getRoutingStatus(ctx context.Context, iface *domain.Interface) (*domain.RoutingStatus, error) {
+	status := &domain.RoutingStatus{
+		InterfaceId:       iface.Identifier,
+		ManagementEnabled: iface.ManageRoutingTable() && !iface.IsDisabled(),
+	}
+	if !status.ManagementEnabled {
+		return status, nil
+	}
+
+	peers, err := m.db.GetInterfacePeers(ctx, iface.Identifier)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find peers for %s: %w", iface.Identifier, err)
+	}
+	allowedIPs := iface.GetAllowedIPs(peers)
+	defRouteV4, defRouteV6 := m.containsDefaultRoute(allowedIPs)
+
+	link, err := m.nl.LinkByName(string(iface.Identifier))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find physical link for %s: %w", iface.Identifier, err)
+	}
+
+	status.Table, status.FwMark, _ = m.calculateRoutingTableAndFwMark(iface, link)
+
+	for _, allowedIP := range allowedIPs {
+		status.ExpectedRoutes = append(status.ExpectedRoutes, domain.RouteEntry{
+			Destination: allowedIP,
+			Table:       status.Table,
+		})
+	}
+	for _, staticRoute := range iface.StaticRoutes {
+		dst, err := domain.CidrFromString(staticRoute.Destination)
+		if err != nil {
+			continue // invalid routes are skipped by syncRoutes as well
+		}
+		routeTable := staticRoute.Table
+		if routeTable == 0 {
+			routeTable = status.Table
+		}
+		status.ExpectedRoutes = append(status.ExpectedRoutes, domain.RouteEntry{
+			Destination: dst.NetworkAddr(),
+			Table:       routeTable,
+		})
+	}
+	var policySources []domain.Cidr
+	for _, policyRule := range iface.PolicyRules {
+		src, err := domain.CidrFromString(policyRule.Source)
+		if err != nil {
+			continue // invalid rules are skipped by syncRoutes as well
+		}
+		src = src.NetworkAddr()
+		policySources = append(policySources, src)
+		ruleTable := policyRule.Table
+		if ruleTable == 0 {
+			ruleTable = status.Table
+		}
+		family := netlink.FAMILY_V6
+		if src.IsV4() {
+			family = netlink.FAMILY_V4
+		}
+		status.ExpectedRules = append(status.ExpectedRules, domain.RuleEntry{
+			Family:            family,
+			Source:            &src,
+			Table:             ruleTable,
+			Priority:          policyRule.Priority,
+			SuppressPrefixlen: -1,
+		})
+	}
+	if status.Table != 0 {
+		familyDefaults := []struct {
+			family     int
+			hasDefault bool
+		}{
+			{netlink.FAMILY_V4, defRouteV4},
+			{netlink.FAMILY_V6, defRouteV6},
+		}
+		for _, fd := range familyDefaults {
+			status.ExpectedRules = append(status.ExpectedRules, domain.RuleEntry{
+				Family:            fd.family,
+				Table:             status.Table,
+				FwMark:            status.FwMark,
+				Invert:            true,
+				SuppressPrefixlen: -1,
+			})
+			if fd.hasDefault {
+				status.ExpectedRules = append(status.ExpectedRules, domain.RuleEntry{
+					Family:            fd.family,
+					Table:             unix.RT_TABLE_MAIN,
+					SuppressPrefixlen: 0,
+				})
+			}
+		}
+	}
+
+	for _, family := range []int{netlink.FAMILY_V4, netlink.FAMILY_V6} {
+		routes, err := m.getInstalledRoutes(link, family)
+		if err != nil {
+			return nil, err
+		}
+		status.ActualRoutes = append(status.ActualRoutes, routes...)
+
+		rules, err := m.getInstalledRules(status.Table, status.FwMark, policySources, family)
+		if err != nil {
+			return nil, err
+		}
+		status.ActualRules = append(status.ActualRules, rules...)
+	}
+
+	status.MissingRoutes, status.UnexpectedRoutes = diffRoutes(status.ExpectedRoutes, status.ActualRoutes)
+	status.MissingRules, status.UnexpectedRules = diffRules(status.ExpectedRules, status.ActualRules)
+
+	return status, nil
+}
+
+// getInstalledRoutes returns all routes of the link, using the same filters as removeDeprecatedRoutes and
+// removeDeprecatedStaticRoutes.
+func (m Manager) getInstalledRoutes(link netlink.Link, family int) ([]domain.RouteEntry, error) {
+	rawRoutes, err := m.nl.RouteListFiltered(family, &netlink.Route{
+		LinkIndex: link.Attrs().Index,
+		Table:     unix.RT_TABLE_UNSPEC, // all tables
+		Scope:     unix.RT_SCOPE_LINK,
+		Type:      unix.RTN_UNICAST,
+	}, netlink.RT_FILTER_TABLE|netlink.RT_FILTER_TYPE|netlink.RT_FILTER_OIF)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch raw routes: %w", err)
+	}
+	rawStaticRoutes, err := m.nl.RouteListFiltered(family, &netlink.Route{
+		LinkIndex: link.Attrs().Index,
+		Table:     unix.RT_TABLE_UNSPEC, // all tables
+		Type:      unix.RTN_UNICAST,
+		Protocol:  routeProtocolWgPortal,
+	}, netlink.RT_FILTER_TABLE|netlink.RT_FILTER_TYPE|netlink.RT_FILTER_OIF|netlink.RT_FILTER_PROTOCOL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch raw static routes: %w", err)
+	}
+	for _, rawStaticRoute := range rawStaticRoutes {
+		if rawStaticRoute.Scope != unix.RT_SCOPE_LINK {
+			rawRoutes = append(rawRoutes, rawStaticRoute) // link scoped routes are already part of the list
+		}
+	}
+
+	routes := make([]domain.RouteEntry, 0, len(rawRoutes))
+	for _, rawRoute := range rawRoutes {
+		var dst domain.Cidr
+		if rawRoute.Dst == nil { // handle default route
+			if family == netlink.FAMILY_V4 {
+				dst, _ = domain.CidrFromString("0.0.0.0/0")
+			} else {
+				dst, _ = domain.CidrFromString("::/0")
+			}
+		} else {
+			dst = domain.CidrFromIpNet(*rawRoute.Dst)
+		}
+
+		routes = append(routes, domain.RouteEntry{
+			Destination: dst,
+			Table:       rawRoute.Table,
+		})
+	}
+
+	return routes, nil
+}
+
+// getInstalledRules returns all rules that point to the given table, that are main rules or that are policy rules
+// for one of the given sources.
+func (m Manager) getInstalledRules(
+	table int,
+	fwmark uint32,
+	policySources []domain.Cidr,
+	family int,
+) ([]domain.RuleEntry, error) {
+	existingRules, err := m.nl.RuleList(family)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get existing rules for family %d: %w", family, err)
+	}
+
+	var rules []domain.RuleEntry
+	for _, existingRule := range existingRules {
+		isTableRule := existingRule.Table == table || (fwmark != 0 && existingRule.Mark == fwmark)
+		isMainRule := existingRule.Table == unix.RT_TABLE_MAIN && existingRule.SuppressPrefixlen == 0
+		var source *domain.Cidr
+		if existingRule.Src != nil {
+			src := domain.CidrFromIpNet(*existingRule.Src)
+			source = &src
+		}
+
+		isPolicyRule := false
+		if existingRule.Protocol == routeProtocolWgPortal && source != nil {
+			for _, policySource := range policySources {
+				if policySource == *source {
+					isPolicyRule = true
+					break
+				}
+			}
+		}
+		if !isTableRule && !isMainRule && !isPolicyRule {
+			continue
+		}
+
+		rules = append(rules, domain.RuleEntry{
+			Family:            family, // somehow the RuleList method does not populate the family field
+			Source:            source,
+			Priority:          existingRule.Priority,
+			Table:             existingRule.Table,
+			FwMark:            existingRule.Mark,
+			Invert:            existingRule.Invert,
+			SuppressPrefixlen: existingRule.SuppressPrefixlen,
+		})
+	}
+
+	return rules, nil
+}
+
+func diffRoutes(expected, actual []domain.RouteEntry) (missing, unexpected []domain.RouteEntry) {
+	for _, e := range expected {
+		found := false
+		for _, a := range actual {
+			if e.Destination == a.Destination && e.Table == a.Table {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, e)
+		}
+	}
+
+	for _, a := range actual {
+		found := false
+		for _, e := range expected {
+			if e.Destination == a.Destination && e.Table == a.Table {
+				found = true
+				break
+			}
+		}
+		if !found {
+			unexpected = append(unexpected, a)
+		}
+	}
+
+	return
+}
+
+func diffRules(expected, actual []domain.RuleEntry) (missing, unexpected []domain.RuleEntry) {
+	for _, e := range expected {
+		found := false
+		for _, a := range actual {
+			if e.Matches(a) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, e)
+		}
+	}
+
+	for _, a := range actual {
+		if a.Table == unix.RT_TABLE_MAIN && a.SuppressPrefixlen == 0 {
+			continue // the main rule is shared by all interfaces with a default route
+		}
+
+		found := false
+		for _, e := range expected {
+			if e.Matches(a) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			unexpected = append(unexpected, a)
+		}
+	}
+
+	return
+}
@@ -0,0 +1,115 @@
+package route
+
+import (
+	"net/netip"
+	"reflect"
+	"testing"
+
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+func TestDiffRoutes(t *testing.T) {
+	route := func(cidr string, table int) domain.RouteEntry {
+		return domain.RouteEntry{Destination: domain.CidrFromPrefix(netip.MustParsePrefix(cidr)), Table: table}
+	}
+
+	tests := []struct {
+		name           string
+		expected       []domain.RouteEntry
+		actual         []domain.RouteEntry
+		wantMissing    []domain.RouteEntry
+		wantUnexpected []domain.RouteEntry
+	}{
+		{name: "empty"},
+		{
+			name:     "in sync",
+			expected: []domain.RouteEntry{route("10.0.0.0/24", 100), route("fd00::/64", 100)},
+			actual:   []domain.RouteEntry{route("fd00::/64", 100), route("10.0.0.0/24", 100)},
+		},
+		{
+			name:        "missing route",
+			expected:    []domain.RouteEntry{route("10.0.0.0/24", 100), route("10.0.1.0/24", 100)},
+			actual:      []domain.RouteEntry{route("10.0.0.0/24", 100)},
+			wantMissing: []domain.RouteEntry{route("10.0.1.0/24", 100)},
+		},
+		{
+			name:           "unexpected route",
+			expected:       []domain.RouteEntry{route("10.0.0.0/24", 100)},
+			actual:         []domain.RouteEntry{route("10.0.0.0/24", 100), route("10.0.2.0/24", 100)},
+			wantUnexpected: []domain.RouteEntry{route("10.0.2.0/24", 100)},
+		},
+		{
+			name:           "wrong table",
+			expected:       []domain.RouteEntry{route("10.0.0.0/24", 100)},
+			actual:         []domain.RouteEntry{route("10.0.0.0/24", 200)},
+			wantMissing:    []domain.RouteEntry{route("10.0.0.0/24", 100)},
+			wantUnexpected: []domain.RouteEntry{route("10.0.0.0/24", 200)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			missing, unexpected := diffRoutes(tt.expected, tt.actual)
+			if !reflect.DeepEqual(missing, tt.wantMissing) {
+				t.Errorf("diffRoutes() missing = %v, want %v", missing, tt.wantMissing)
+			}
+			if !reflect.DeepEqual(unexpected, tt.wantUnexpected) {
+				t.Errorf("diffRoutes() unexpected = %v, want %v", unexpected, tt.wantUnexpected)
+			}
+		})
+	}
+}
+
+func TestDiffRules(t *testing.T) {
+	source := domain.CidrFromPrefix(netip.MustParsePrefix("10.0.5.0/24"))
+	fwMarkRule := domain.RuleEntry{Family: netlink.FAMILY_V4, Table: 100, FwMark: 100, Invert: true,
+		SuppressPrefixlen: -1}
+	policyRule := domain.RuleEntry{Family: netlink.FAMILY_V4, Source: &source, Table: 100, SuppressPrefixlen: -1}
+	mainRule := domain.RuleEntry{Family: netlink.FAMILY_V4, Table: unix.RT_TABLE_MAIN, SuppressPrefixlen: 0}
+	staleRule := domain.RuleEntry{Family: netlink.FAMILY_V4, Table: 100, FwMark: 200, SuppressPrefixlen: -1}
+
+	tests := []struct {
+		name           string
+		expected       []domain.RuleEntry
+		actual         []domain.RuleEntry
+		wantMissing    []domain.RuleEntry
+		wantUnexpected []domain.RuleEntry
+	}{
+		{name: "empty"},
+		{
+			name:     "in sync",
+			expected: []domain.RuleEntry{fwMarkRule, policyRule},
+			actual:   []domain.RuleEntry{policyRule, fwMarkRule},
+		},
+		{
+			name:        "missing rule",
+			expected:    []domain.RuleEntry{fwMarkRule, policyRule},
+			actual:      []domain.RuleEntry{fwMarkRule},
+			wantMissing: []domain.RuleEntry{policyRule},
+		},
+		{
+			name:           "unexpected rule",
+			expected:       []domain.RuleEntry{fwMarkRule},
+			actual:         []domain.RuleEntry{fwMarkRule, staleRule, policyRule},
+			wantUnexpected: []domain.RuleEntry{staleRule, policyRule},
+		},
+		{
+			name:     "shared main rule",
+			expected: []domain.RuleEntry{fwMarkRule},
+			actual:   []domain.RuleEntry{fwMarkRule, mainRule},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			missing, unexpected := diffRules(tt.expected, tt.actual)
+			if !reflect.DeepEqual(missing, tt.wantMissing) {
+				t.Errorf("diffRules() missing = %v, want %v", missing, tt.wantMissing)
+			}
+			if !reflect.DeepEqual(unexpected, tt.wantUnexpected) {
+				t.Errorf("diffRules() unexpected = %v, want %v", unexpected, tt.wantUnexpected)
+			}
+		})
+	}
+}
@@ -2,10 +2,19 @@ package route
 
 import (
 	"context"
+	"time"
+
 	"github.com/h44z/wg-portal/internal/domain"
 )
 
 type InterfaceAndPeerDatabaseRepo interface {
+	GetInterface(ctx context.Context, id domain.InterfaceIdentifier) (*domain.Interface, error)
 	GetAllInterfaces(ctx context.Context) ([]domain.Interface, error)
 	GetInterfacePeers(ctx context.Context, id domain.InterfaceIdentifier) ([]domain.Peer, error)
 }
+
+type MetricsServer interface {
+	UpdateRouteMetrics(id domain.InterfaceIdentifier, routeCount, ruleCount int)
+	RemoveRouteMetrics(id domain.InterfaceIdentifier)
+	UpdateRouteSyncTime(syncTime time.Time)
+}
@@ -3,6 +3,8 @@ package route
 import (
 	"context"
 	"fmt"
+	"net"
+	"slices"
 
 	"github.com/h44z/wg-portal/internal/app"
 	"github.com/h44z/wg-portal/internal/config"
@@ -16,6 +18,17 @@ import (
 	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
 )
 
+// routeProtocolWgPortal is used to tag custom routes and policy rules, so they can be cleaned up later.
+const routeProtocolWgPortal = 0x57
+
+// policyRuleInfo describes a custom source based rule of an interface.
+type policyRuleInfo struct {
+	ifaceId  domain.InterfaceIdentifier
+	source   domain.Cidr
+	table    int
+	priority int
+}
+
 type routeRuleInfo struct {
 	ifaceId    domain.InterfaceIdentifier
 	fwMark     uint32
@@ -30,12 +43,20 @@ type Manager struct {
 	cfg *config.Config
 	bus evbus.MessageBus
 
-	wg lowlevel.WireGuardClient
-	nl lowlevel.NetlinkClient
-	db InterfaceAndPeerDatabaseRepo
+	wg      lowlevel.WireGuardClient
+	nl      lowlevel.NetlinkClient
+	db      InterfaceAndPeerDatabaseRepo
+	metrics MetricsServer
+
+	state *syncState
 }
 
-func NewRouteManager(cfg *config.Config, bus evbus.MessageBus, db InterfaceAndPeerDatabaseRepo) (*Manager, error) {
+func NewRouteManager(
+	cfg *config.Config,
+	bus evbus.MessageBus,
+	db InterfaceAndPeerDatabaseRepo,
+	metrics MetricsServer,
+) (*Manager, error) {
 	wg, err := wgctrl.New()
 	if err != nil {
 		panic("failed to init wgctrl: " + err.Error())
@@ -47,9 +68,12 @@ func NewRouteManager(cfg *config.Config, bus evbus.MessageBus, db InterfaceAndPe
 		cfg: cfg,
 		bus: bus,
 
-		db: db,
-		wg: wg,
-		nl: nl,
+		db:      db,
+		wg:      wg,
+		nl:      nl,
+		metrics: metrics,
+
+		state: newSyncState(),
 	}
 
 	m.connectToMessageBus()
@@ -63,6 +87,7 @@ func (m Manager) connectToMessageBus() {
 }
 
 func (m Manager) StartBackgroundJobs(ctx context.Context) {
+	go m.runNetlinkWatcher(ctx)
 }
 
 func (m Manager) handleRouteUpdateEvent(srcDescription string) {
@@ -83,17 +108,32 @@ func (m Manager) handleRouteRemoveEvent(info domain.RoutingTableInfo) {
 		return // route management disabled
 	}
 
+	m.state.runMux.Lock()
+	defer m.state.runMux.Unlock()
+
 	if err := m.removeFwMarkRules(info.FwMark, info.GetRoutingTable(), netlink.FAMILY_V4); err != nil {
 		logrus.Errorf("failed to remove v4 fwmark rules: %v", err)
 	}
 	if err := m.removeFwMarkRules(info.FwMark, info.GetRoutingTable(), netlink.FAMILY_V6); err != nil {
 		logrus.Errorf("failed to remove v6 fwmark rules: %v", err)
 	}
+	if err := m.removePolicyRules(info.PolicyRules, info.GetRoutingTable()); err != nil {
+		logrus.Errorf("failed to remove policy rules: %v", err)
+	}
 
 	logrus.Debugf("routes removed, table: %s", info.String())
 }
 
 func (m Manager) syncRoutes(ctx context.Context) error {
+	m.state.runMux.Lock()
+	defer m.state.runMux.Unlock()
+
+	m.state.beginSync()
+	defer func() {
+		syncTime := m.state.endSync()
+		m.metrics.UpdateRouteSyncTime(syncTime)
+	}()
+
 	interfaces, err := m.db.GetAllInterfaces(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to find all interfaces: %w", err)
@@ -103,6 +143,12 @@ func (m Manager) syncRoutes(ctx context.Context) error {
 		netlink.FAMILY_V4: nil,
 		netlink.FAMILY_V6: nil,
 	}
+	policyRules := map[int][]policyRuleInfo{
+		netlink.FAMILY_V4: nil,
+		netlink.FAMILY_V6: nil,
+	}
+	managedLinks := make(map[int]domain.InterfaceIdentifier)
+	routeCounts := make(map[domain.InterfaceIdentifier]int)
 	for _, iface := range interfaces {
 		if iface.IsDisabled() {
 			continue // disabled interface does not need route entries
@@ -132,12 +178,49 @@ func (m Manager) syncRoutes(ctx context.Context) error {
 			return fmt.Errorf("failed to set routes for %s: %w", iface.Identifier, err)
 		}
 
-		if err := m.removeDeprecatedRoutes(link, netlink.FAMILY_V4, allowedIPs); err != nil {
+		staticDestinations, err := m.setStaticRoutes(link, table, iface.StaticRoutes)
+		if err != nil {
+			return fmt.Errorf("failed to set static routes for %s: %w", iface.Identifier, err)
+		}
+
+		// static link routes must not be removed by the peer route cleanup
+		expectedRoutes := append(append([]domain.Cidr{}, allowedIPs...), staticDestinations...)
+		if err := m.removeDeprecatedRoutes(link, netlink.FAMILY_V4, expectedRoutes); err != nil {
 			return fmt.Errorf("failed to remove deprecated v4 routes for %s: %w", iface.Identifier, err)
 		}
-		if err := m.removeDeprecatedRoutes(link, netlink.FAMILY_V6, allowedIPs); err != nil {
+		if err := m.removeDeprecatedRoutes(link, netlink.FAMILY_V6, expectedRoutes); err != nil {
 			return fmt.Errorf("failed to remove deprecated v6 routes for %s: %w", iface.Identifier, err)
 		}
+		if err := m.removeDeprecatedStaticRoutes(link, netlink.FAMILY_V4, staticDestinations); err != nil {
+			return fmt.Errorf("failed to remove deprecated v4 static routes for %s: %w", iface.Identifier, err)
+		}
+		if err := m.removeDeprecatedStaticRoutes(link, netlink.FAMILY_V6, staticDestinations); err != nil {
+			return fmt.Errorf("failed to remove deprecated v6 static routes for %s: %w", iface.Identifier, err)
+		}
+
+		for _, rule := range iface.PolicyRules {
+			source, err := domain.CidrFromString(rule.Source)
+			if err != nil {
+				logrus.Warnf("%s: skipping policy rule with invalid source %s", iface.Identifier, rule.Source)
+				continue
+			}
+			family := netlink.FAMILY_V6
+			if source.IsV4() {
+				family = netlink.FAMILY_V4
+			}
+			ruleTable := rule.Table
+			if ruleTable == 0 {
+				ruleTable = table
+			}
+			policyRules[family] = append(policyRules[family], policyRuleInfo{
+				ifaceId:  iface.Identifier,
+				source:   source.NetworkAddr(),
+				table:    ruleTable,
+				priority: rule.Priority,
+			})
+		}
+
+		managedLinks[link.Attrs().Index] = iface.Identifier
 
 		if table != 0 {
 			rules[netlink.FAMILY_V4] = append(rules[netlink.FAMILY_V4], routeRuleInfo{
@@ -157,9 +240,75 @@ func (m Manager) syncRoutes(ctx context.Context) error {
 				hasDefault: defRouteV6,
 			})
 		}
+
+		routeCounts[iface.Identifier] = len(allowedIPs) + len(staticDestinations)
+	}
+
+	err = m.syncPolicyRules(policyRules)
+	if err == nil {
+		err = m.syncRouteRules(rules)
+	}
+	m.updateRouteMetrics(routeCounts, rules, policyRules)
+	if err != nil {
+		return err
+	}
+
+	// the managed links are only replaced after a successful run, otherwise the watcher would ignore links
+	for _, id := range m.state.setManagedLinks(managedLinks) {
+		m.metrics.RemoveRouteMetrics(id)
 	}
 
-	return m.syncRouteRules(rules)
+	return nil
+}
+
+// updateRouteMetrics updates the route and rule metrics of the given interfaces. The rules are counted after the
+// installation, so that rules which could not be installed are not reported.
+func (m Manager) updateRouteMetrics(
+	routeCounts map[domain.InterfaceIdentifier]int,
+	rules map[int][]routeRuleInfo,
+	policyRules map[int][]policyRuleInfo,
+) {
+	ruleCounts, err := m.countInstalledRules(rules, policyRules)
+	if err != nil {
+		logrus.Warnf("failed to count installed rules: %v", err)
+		return
+	}
+
+	for id, routeCount := range routeCounts {
+		m.metrics.UpdateRouteMetrics(id, routeCount, ruleCounts[id])
+	}
+}
+
+// countInstalledRules returns the number of fwmark and policy rules of each interface that are installed.
+func (m Manager) countInstalledRules(
+	rules map[int][]routeRuleInfo,
+	policyRules map[int][]policyRuleInfo,
+) (map[domain.InterfaceIdentifier]int, error) {
+	counts := make(map[domain.InterfaceIdentifier]int)
+	for _, family := range []int{netlink.FAMILY_V4, netlink.FAMILY_V6} {
+		existingRules, err := m.nl.RuleList(family)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get existing rules for family %d: %w", family, err)
+		}
+
+		for _, rule := range rules[family] {
+			if slices.ContainsFunc(existingRules, func(existingRule netlink.Rule) bool {
+				return existingRule.Mark == rule.fwMark && existingRule.Table == rule.table
+			}) {
+				counts[rule.ifaceId]++
+			}
+		}
+		for _, rule := range policyRules[family] {
+			if slices.ContainsFunc(existingRules, func(existingRule netlink.Rule) bool {
+				return existingRule.Src != nil && domain.CidrFromIpNet(*existingRule.Src) == rule.source &&
+					existingRule.Table == rule.table
+			}) {
+				counts[rule.ifaceId]++
+			}
+		}
+	}
+
+	return counts, nil
 }
 
 func (m Manager) syncRouteRules(allRules map[int][]routeRuleInfo) error {
@@ -238,6 +387,46 @@ func (m Manager) removeFwMarkRules(fwmark uint32, table int, family int) error {
 	return nil
 }
 
+// removePolicyRules removes the source based policy rules of an interface. Rules without a custom table point to
+// the routing table of the interface.
+func (m Manager) removePolicyRules(policyRules []domain.InterfacePolicyRule, table int) error {
+	for _, family := range []int{netlink.FAMILY_V4, netlink.FAMILY_V6} {
+		existingRules, err := m.nl.RuleList(family)
+		if err != nil {
+			return fmt.Errorf("failed to get existing rules for family %d: %w", family, err)
+		}
+
+		for _, existingRule := range existingRules {
+			if existingRule.Protocol != routeProtocolWgPortal || existingRule.Src == nil {
+				continue
+			}
+
+			existingSource := domain.CidrFromIpNet(*existingRule.Src)
+			for _, policyRule := range policyRules {
+				src, err := domain.CidrFromString(policyRule.Source)
+				if err != nil {
+					continue // invalid rules have never been installed
+				}
+				ruleTable := policyRule.Table
+				if ruleTable == 0 {
+					ruleTable = table
+				}
+				if src.NetworkAddr() != existingSource || ruleTable != existingRule.Table {
+					continue
+				}
+
+				existingRule.Family = family // set family, somehow the RuleList method does not populate the family field
+				if err := m.nl.RuleDel(&existingRule); err != nil {
+					return fmt.Errorf("failed to delete policy rule for %s: %w", existingSource.String(), err)
+				}
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
 func (m Manager) setMainRule(rules []routeRuleInfo, family int) error {
 	shouldHaveMainRule := false
 	for _, rule := range rules {
@@ -384,12 +573,27 @@ func (m Manager) setInterfaceRoutes(link netlink.Link, table int, allowedIPs []d
 }
 
 func (m Manager) removeDeprecatedRoutes(link netlink.Link, family int, allowedIPs []domain.Cidr) error {
-	rawRoutes, err := m.nl.RouteListFiltered(family, &netlink.Route{
+	return m.removeRoutesNotIn(family, &netlink.Route{
 		LinkIndex: link.Attrs().Index,
 		Table:     unix.RT_TABLE_UNSPEC, // all tables
 		Scope:     unix.RT_SCOPE_LINK,
 		Type:      unix.RTN_UNICAST,
-	}, netlink.RT_FILTER_TABLE|netlink.RT_FILTER_TYPE|netlink.RT_FILTER_OIF)
+	}, netlink.RT_FILTER_TABLE|netlink.RT_FILTER_TYPE|netlink.RT_FILTER_OIF, allowedIPs)
+}
+
+func (m Manager) removeDeprecatedStaticRoutes(link netlink.Link, family int, staticDestinations []domain.Cidr) error {
+	return m.removeRoutesNotIn(family, &netlink.Route{
+		LinkIndex: link.Attrs().Index,
+		Table:     unix.RT_TABLE_UNSPEC, // all tables
+		Type:      unix.RTN_UNICAST,
+		Protocol:  routeProtocolWgPortal,
+	}, netlink.RT_FILTER_TABLE|netlink.RT_FILTER_TYPE|netlink.RT_FILTER_OIF|netlink.RT_FILTER_PROTOCOL,
+		staticDestinations)
+}
+
+// removeRoutesNotIn removes all routes matching the filter whose destination is not part of the keep list.
+func (m Manager) removeRoutesNotIn(family int, filter *netlink.Route, filterMask uint64, keep []domain.Cidr) error {
+	rawRoutes, err := m.nl.RouteListFiltered(family, filter, filterMask)
 	if err != nil {
 		return fmt.Errorf("failed to fetch raw routes: %w", err)
 	}
@@ -406,7 +610,7 @@ func (m Manager) removeDeprecatedRoutes(link netlink.Link, family int, allowedIP
 
 		netlinkAddr := domain.CidrFromIpNet(*rawRoute.Dst)
 		remove := true
-		for _, allowedIP := range allowedIPs {
+		for _, allowedIP := range keep {
 			if netlinkAddr == allowedIP {
 				remove = false
 				break
@@ -425,25 +629,155 @@ func (m Manager) removeDeprecatedRoutes(link netlink.Link, family int, allowedIP
 	return nil
 }
 
+// setStaticRoutes installs the custom routes of an interface. It returns the destinations of all valid routes.
+func (m Manager) setStaticRoutes(
+	link netlink.Link,
+	table int,
+	staticRoutes []domain.InterfaceStaticRoute,
+) ([]domain.Cidr, error) {
+	destinations := make([]domain.Cidr, 0, len(staticRoutes))
+	for _, staticRoute := range staticRoutes {
+		dst, err := domain.CidrFromString(staticRoute.Destination)
+		if err != nil {
+			logrus.Warnf("skipping static route with invalid destination %s", staticRoute.Destination)
+			continue
+		}
+		dst = dst.NetworkAddr()
+
+		route := &netlink.Route{
+			LinkIndex: link.Attrs().Index,
+			Dst:       dst.IpNet(),
+			Table:     table,
+			Priority:  staticRoute.Metric,
+			Protocol:  routeProtocolWgPortal,
+			Scope:     unix.RT_SCOPE_LINK,
+			Type:      unix.RTN_UNICAST,
+		}
+		if staticRoute.Table != 0 {
+			route.Table = staticRoute.Table
+		}
+		if staticRoute.Gateway != "" {
+			route.Gw = net.ParseIP(staticRoute.Gateway)
+			route.Scope = unix.RT_SCOPE_UNIVERSE
+		}
+
+		if err := m.nl.RouteReplace(route); err != nil {
+			return nil, fmt.Errorf("failed to add/update static route %s: %w", dst.String(), err)
+		}
+		destinations = append(destinations, dst)
+	}
+
+	return destinations, nil
+}
+
+// syncPolicyRules installs all custom source based rules and removes the ones that are no longer configured.
+func (m Manager) syncPolicyRules(allRules map[int][]policyRuleInfo) error {
+	for family, rules := range allRules {
+		existingRules, err := m.nl.RuleList(family)
+		if err != nil {
+			return fmt.Errorf("failed to get existing rules for family %d: %w", family, err)
+		}
+
+		// cleanup old rules
+		for _, existingRule := range existingRules {
+			if existingRule.Protocol != routeProtocolWgPortal || existingRule.Src == nil {
+				continue
+			}
+
+			existingSource := domain.CidrFromIpNet(*existingRule.Src)
+			stillConfigured := false
+			for _, rule := range rules {
+				if rule.source == existingSource && rule.table == existingRule.Table {
+					stillConfigured = true
+					break
+				}
+			}
+			if stillConfigured {
+				continue
+			}
+
+			existingRule.Family = family // set family, somehow the RuleList method does not populate the family field
+			if err := m.nl.RuleDel(&existingRule); err != nil {
+				return fmt.Errorf("failed to delete policy rule for %s: %w", existingSource.String(), err)
+			}
+		}
+
+		// create missing rules
+		for _, rule := range rules {
+			ruleExists := false
+			for _, existingRule := range existingRules {
+				if existingRule.Src != nil && domain.CidrFromIpNet(*existingRule.Src) == rule.source &&
+					existingRule.Table == rule.table {
+					ruleExists = true
+					break
+				}
+			}
+			if ruleExists {
+				continue
+			}
+
+			priority := rule.priority
+			if priority == 0 {
+				priority = m.getRulePriority(existingRules)
+			}
+
+			newRule := &netlink.Rule{
+				Family:            family,
+				Src:               rule.source.IpNet(),
+				Table:             rule.table,
+				Priority:          priority,
+				Protocol:          routeProtocolWgPortal,
+				SuppressIfgroup:   -1,
+				SuppressPrefixlen: -1,
+				Mark:              0,
+				Mask:              nil,
+				Goto:              -1,
+				Flow:              -1,
+			}
+			if err := m.nl.RuleAdd(newRule); err != nil {
+				return fmt.Errorf("failed to setup policy rule for %s (%s): %w", rule.source.String(), rule.ifaceId, err)
+			}
+			existingRules = append(existingRules, *newRule)
+		}
+	}
+
+	return nil
+}
+
 func (m Manager) getRoutingTableAndFwMark(
 	iface *domain.Interface,
 	allowedIPs []domain.Cidr,
 	link netlink.Link,
 ) (table int, fwmark uint32, err error) {
+	table, fwmark, generatedFwMark := m.calculateRoutingTableAndFwMark(iface, link)
+
+	if generatedFwMark {
+		logrus.Debugf("%s: using fwmark %d to handle routes", iface.Identifier, fwmark)
+
+		// apply the temporary fwmark to the wireguard interface
+		err = m.setFwMark(iface.Identifier, int(fwmark))
+	}
+	if iface.GetRoutingTable() == 0 {
+		logrus.Debugf("%s: using routing table %d to handle default routes", iface.Identifier, table)
+	}
+	return
+}
+
+// calculateRoutingTableAndFwMark returns the routing table and firewall mark of the interface without applying them.
+func (m Manager) calculateRoutingTableAndFwMark(
+	iface *domain.Interface,
+	link netlink.Link,
+) (table int, fwmark uint32, generatedFwMark bool) {
 	table = iface.GetRoutingTable()
 	fwmark = iface.FirewallMark
 
 	if fwmark == 0 {
 		// generate a new (temporary) firewall mark based on the interface index
 		fwmark = uint32(m.cfg.Advanced.RouteTableOffset + link.Attrs().Index)
-		logrus.Debugf("%s: using fwmark %d to handle routes", iface.Identifier, table)
-
-		// apply the temporary fwmark to the wireguard interface
-		err = m.setFwMark(iface.Identifier, int(fwmark))
+		generatedFwMark = true
 	}
 	if table == 0 {
 		table = int(fwmark) // generate a new routing table base on interface index
-		logrus.Debugf("%s: using routing table %d to handle default routes", iface.Identifier, table)
 	}
 	return
 }
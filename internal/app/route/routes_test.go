@@ -0,0 +1,92 @@
+package route
+
+import (
+	"net/netip"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/h44z/wg-portal/internal/domain"
+	"github.com/h44z/wg-portal/internal/lowlevel"
+)
+
+type testNetlinkClient struct {
+	lowlevel.NetlinkClient
+	rules map[int][]netlink.Rule // family -> rules
+}
+
+func (c testNetlinkClient) RuleList(family int) ([]netlink.Rule, error) {
+	return c.rules[family], nil
+}
+
+func TestManager_countInstalledRules(t *testing.T) {
+	source := domain.CidrFromPrefix(netip.MustParsePrefix("10.0.5.0/24"))
+	rules := map[int][]routeRuleInfo{
+		netlink.FAMILY_V4: {
+			{ifaceId: "wg0", fwMark: 100, table: 100, family: netlink.FAMILY_V4},
+			{ifaceId: "wg1", fwMark: 101, table: 101, family: netlink.FAMILY_V4},
+		},
+		netlink.FAMILY_V6: {
+			{ifaceId: "wg0", fwMark: 100, table: 100, family: netlink.FAMILY_V6},
+			{ifaceId: "wg1", fwMark: 101, table: 101, family: netlink.FAMILY_V6},
+		},
+	}
+	policyRules := map[int][]policyRuleInfo{
+		netlink.FAMILY_V4: {{ifaceId: "wg0", source: source, table: 200}},
+	}
+
+	tests := []struct {
+		name      string
+		installed map[int][]netlink.Rule
+		want      map[domain.InterfaceIdentifier]int
+	}{
+		{name: "nothing installed", want: map[domain.InterfaceIdentifier]int{}},
+		{
+			name: "all installed",
+			installed: map[int][]netlink.Rule{
+				netlink.FAMILY_V4: {{Mark: 100, Table: 100}, {Mark: 101, Table: 101}, {Src: source.IpNet(), Table: 200}},
+				netlink.FAMILY_V6: {{Mark: 100, Table: 100}, {Mark: 101, Table: 101}},
+			},
+			want: map[domain.InterfaceIdentifier]int{"wg0": 3, "wg1": 2},
+		},
+		{
+			name: "partially installed",
+			installed: map[int][]netlink.Rule{
+				netlink.FAMILY_V4: {{Mark: 100, Table: 100}, {Src: source.IpNet(), Table: 100}},
+				netlink.FAMILY_V6: {{Mark: 101, Table: 101}},
+			},
+			want: map[domain.InterfaceIdentifier]int{"wg0": 1, "wg1": 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := Manager{nl: testNetlinkClient{rules: tt.installed}}
+
+			got, err := m.countInstalledRules(rules, policyRules)
+			if err != nil {
+				t.Fatalf("countInstalledRules() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("countInstalledRules() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSyncState_setManagedLinks(t *testing.T) {
+	state := newSyncState()
+
+	if removed := state.setManagedLinks(map[int]domain.InterfaceIdentifier{1: "wg0", 2: "wg1"}); len(removed) != 0 {
+		t.Errorf("setManagedLinks() removed = %v, want none", removed)
+	}
+	// wg1 got a new link index, wg0 is no longer managed
+	removed := state.setManagedLinks(map[int]domain.InterfaceIdentifier{3: "wg1", 4: "wg2"})
+	if !slices.Equal(removed, []domain.InterfaceIdentifier{"wg0"}) {
+		t.Errorf("setManagedLinks() removed = %v, want [wg0]", removed)
+	}
+	if state.isManagedLink(1, "wg0") || !state.isManagedLink(3, "") || !state.isManagedLink(0, "wg2") {
+		t.Errorf("setManagedLinks() did not replace the managed links")
+	}
+}
@@ -0,0 +1,183 @@
+package route
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/h44z/wg-portal/internal/domain"
+	"github.com/h44z/wg-portal/internal/lowlevel"
+	"github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// watchDebounceTime is the time to wait for further netlink updates before the routes get re-synchronized.
+	watchDebounceTime = 2 * time.Second
+	// watchSettleTime is the time after a synchronization run in which netlink updates are ignored,
+	// as they are most likely caused by the synchronization itself.
+	watchSettleTime = 1 * time.Second
+)
+
+// syncState holds the shared state of all route synchronization runs.
+type syncState struct {
+	// runMux serializes all synchronization runs, so that the event handlers and the netlink watcher do not
+	// modify the same routes and rules concurrently.
+	runMux sync.Mutex
+
+	mux          sync.Mutex
+	activeSyncs  int
+	lastSyncEnd  time.Time
+	managedLinks map[int]domain.InterfaceIdentifier // link index -> interface identifier
+}
+
+func newSyncState() *syncState {
+	return &syncState{
+		managedLinks: make(map[int]domain.InterfaceIdentifier),
+	}
+}
+
+func (s *syncState) beginSync() {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.activeSyncs++
+}
+
+func (s *syncState) endSync() time.Time {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.activeSyncs--
+	s.lastSyncEnd = time.Now()
+
+	return s.lastSyncEnd
+}
+
+// setManagedLinks replaces the managed links and returns the interfaces that are no longer managed.
+func (s *syncState) setManagedLinks(links map[int]domain.InterfaceIdentifier) []domain.InterfaceIdentifier {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	stillManaged := make(map[domain.InterfaceIdentifier]struct{}, len(links))
+	for _, id := range links {
+		stillManaged[id] = struct{}{}
+	}
+
+	var removed []domain.InterfaceIdentifier
+	for _, oldId := range s.managedLinks {
+		if _, ok := stillManaged[oldId]; !ok {
+			removed = append(removed, oldId)
+		}
+	}
+	s.managedLinks = links
+
+	return removed
+}
+
+// isManagedLink checks if the link with the given index or name is managed by the route manager.
+func (s *syncState) isManagedLink(index int, name string) bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if _, ok := s.managedLinks[index]; ok {
+		return true
+	}
+	for _, id := range s.managedLinks {
+		if string(id) == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isSettling returns true if a synchronization is running or has just finished.
+func (s *syncState) isSettling() bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	return s.activeSyncs > 0 || time.Since(s.lastSyncEnd) < watchSettleTime
+}
+
+// runNetlinkWatcher listens for link, route and rule changes of managed interfaces and re-synchronizes
+// the routes if something was modified outside of WireGuard Portal.
+func (m Manager) runNetlinkWatcher(ctx context.Context) {
+	linkUpdates := make(chan netlink.LinkUpdate)
+	routeUpdates := make(chan netlink.RouteUpdate)
+	ruleUpdates := make(chan lowlevel.RuleUpdate)
+
+	if err := m.nl.LinkSubscribe(linkUpdates, ctx.Done()); err != nil {
+		logrus.Errorf("failed to subscribe to link updates: %v", err)
+		return
+	}
+	if err := m.nl.RouteSubscribe(routeUpdates, ctx.Done()); err != nil {
+		logrus.Errorf("failed to subscribe to route updates: %v", err)
+		return
+	}
+	if err := m.nl.RuleSubscribe(ruleUpdates, ctx.Done()); err != nil {
+		logrus.Errorf("failed to subscribe to rule updates: %v", err)
+		return
+	}
+
+	logrus.Tracef("started netlink watcher for managed routes")
+
+	var resync <-chan time.Time
+	trigger := func(reason string) {
+		if resync != nil {
+			return // re-synchronization is already pending
+		}
+		if m.state.isSettling() {
+			// most likely caused by our own changes, but it could also be a concurrent modification,
+			// so the routes are checked once more after the synchronization settled
+			logrus.Tracef("netlink watcher deferred change during synchronization: %s", reason)
+			resync = time.After(watchSettleTime)
+			return
+		}
+
+		logrus.Debugf("netlink watcher detected change: %s", reason)
+		resync = time.After(watchDebounceTime)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			logrus.Tracef("stopped netlink watcher for managed routes")
+			return
+		case update, ok := <-linkUpdates:
+			if !ok {
+				linkUpdates = nil
+				continue
+			}
+			if m.state.isManagedLink(int(update.Index), update.Attrs().Name) {
+				trigger("link " + update.Attrs().Name)
+			}
+		case update, ok := <-routeUpdates:
+			if !ok {
+				routeUpdates = nil
+				continue
+			}
+			if m.state.isManagedLink(update.LinkIndex, "") {
+				trigger("route " + update.Route.String())
+			}
+		case update, ok := <-ruleUpdates:
+			if !ok {
+				ruleUpdates = nil
+				continue
+			}
+			if update.Type == unix.RTM_DELRULE {
+				trigger("rule removed")
+			}
+		case <-resync:
+			if m.state.isSettling() {
+				resync = time.After(watchSettleTime) // wait until the running synchronization settled
+				continue
+			}
+			resync = nil
+			if err := m.syncRoutes(ctx); err != nil {
+				logrus.Errorf("failed to re-synchronize routes: %v", err)
+			}
+		}
+	}
+}
@@ -46,6 +46,30 @@ func convertRawLdapUser(
 	}, nil
 }
 
+// getLdapInterfaceMemberships checks the membership of the user in the groups of the group interface rules.
+func getLdapInterfaceMemberships(
+	rawUser map[string]any,
+	fields *config.LdapFields,
+	rules []config.LdapGroupInterfaceRule,
+) ([]domain.InterfaceMembership, error) {
+	groupData, _ := rawUser[fields.GroupMembership].([][]byte)
+
+	memberships := make([]domain.InterfaceMembership, len(rules))
+	for i, rule := range rules {
+		isMember, err := internal.LdapIsMemberOf(groupData, rule.ParsedGroupDN)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check group of interface %s: %w", rule.Interface, err)
+		}
+		memberships[i] = domain.InterfaceMembership{
+			Interface:   domain.InterfaceIdentifier(rule.Interface),
+			IsMember:    isMember,
+			DeletePeers: rule.DeletePeers,
+		}
+	}
+
+	return memberships, nil
+}
+
 func userChangedInLdap(dbUser, ldapUser *domain.User) bool {
 	if dbUser.Firstname != ldapUser.Firstname {
 		return true
@@ -0,0 +1,65 @@
+package users
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/go-ldap/ldap/v3"
+
+	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+func Test_getLdapInterfaceMemberships(t *testing.T) {
+	fields := &config.LdapFields{BaseFields: config.BaseFields{UserIdentifier: "uid"}, GroupMembership: "memberOf"}
+	rule := func(group, iface string, deletePeers bool) config.LdapGroupInterfaceRule {
+		dn, err := ldap.ParseDN(group)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return config.LdapGroupInterfaceRule{GroupDN: group, ParsedGroupDN: dn, Interface: iface,
+			DeletePeers: deletePeers}
+	}
+	rules := []config.LdapGroupInterfaceRule{
+		rule("cn=sales,ou=groups,dc=example,dc=com", "wg-sales", false),
+		rule("cn=dev,ou=groups,dc=example,dc=com", "wg-dev", true),
+	}
+
+	tests := []struct {
+		name    string
+		groups  interface{}
+		want    []domain.InterfaceMembership
+		wantErr bool
+	}{
+		{name: "member", groups: [][]byte{[]byte("cn=sales, ou=groups, dc=example, dc=com")},
+			want: []domain.InterfaceMembership{
+				{Interface: "wg-sales", IsMember: true},
+				{Interface: "wg-dev", DeletePeers: true},
+			}},
+		{name: "member of all groups",
+			groups: [][]byte{[]byte("cn=dev,ou=groups,dc=example,dc=com"), []byte("cn=sales,ou=groups,dc=example,dc=com")},
+			want: []domain.InterfaceMembership{
+				{Interface: "wg-sales", IsMember: true},
+				{Interface: "wg-dev", IsMember: true, DeletePeers: true},
+			}},
+		{name: "no groups", groups: nil,
+			want: []domain.InterfaceMembership{
+				{Interface: "wg-sales"},
+				{Interface: "wg-dev", DeletePeers: true},
+			}},
+		{name: "invalid group", groups: [][]byte{[]byte("not a dn")}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rawUser := map[string]any{"uid": "alice", "memberOf": tt.groups}
+
+			got, err := getLdapInterfaceMemberships(rawUser, fields, rules)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getLdapInterfaceMemberships() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("getLdapInterfaceMemberships() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
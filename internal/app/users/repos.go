@@ -15,6 +15,13 @@ type UserDatabaseRepo interface {
 	DeleteUser(ctx context.Context, id domain.UserIdentifier) error
 }
 
+type ApiTokenDatabaseRepo interface {
+	GetApiTokens(ctx context.Context, id domain.UserIdentifier) ([]domain.ApiToken, error)
+	GetApiToken(ctx context.Context, tokenId string) (*domain.ApiToken, error)
+	SaveApiToken(ctx context.Context, token *domain.ApiToken) error
+	DeleteApiToken(ctx context.Context, id domain.UserIdentifier, tokenId string) error
+}
+
 type PeerDatabaseRepo interface {
 	GetUserPeers(ctx context.Context, id domain.UserIdentifier) ([]domain.Peer, error)
 }
@@ -0,0 +1,144 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/h44z/wg-portal/internal/app"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// apiTokenUsageInterval limits how often the last usage of an API token is written to the database.
+const apiTokenUsageInterval = time.Minute
+
+// GetApiTokens returns all named API tokens of the given user.
+func (m Manager) GetApiTokens(ctx context.Context, id domain.UserIdentifier) ([]domain.ApiToken, error) {
+	if err := domain.ValidateUserAccessRights(ctx, id, domain.PermissionUsersRead); err != nil {
+		return nil, err
+	}
+
+	tokens, err := m.tokens.GetApiTokens(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load api tokens of user %s: %w", id, err)
+	}
+
+	return tokens, nil
+}
+
+// CreateApiToken creates a new named API token for the given user. The returned token string is only available once,
+// the database only stores a hash of it. If no expiry date is given, the token is valid for 90 days.
+func (m Manager) CreateApiToken(
+	ctx context.Context,
+	id domain.UserIdentifier,
+	name string,
+	scopes []domain.ApiTokenScope,
+	expiresAt *time.Time,
+) (*domain.ApiToken, string, error) {
+	if err := domain.ValidateUserAccessRights(ctx, id); err != nil {
+		return nil, "", err
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", fmt.Errorf("missing token name: %w", domain.ErrInvalidData)
+	}
+	if err := domain.ValidateApiTokenScopes(scopes); err != nil {
+		return nil, "", errors.Join(err, domain.ErrInvalidData)
+	}
+
+	expiry := time.Now().Add(domain.ApiTokenDefaultValidity)
+	if expiresAt != nil {
+		expiry = *expiresAt
+	}
+	if !expiry.After(time.Now()) {
+		return nil, "", fmt.Errorf("expiry date must be in the future: %w", domain.ErrInvalidData)
+	}
+
+	if _, err := m.users.GetUser(ctx, id); err != nil {
+		return nil, "", fmt.Errorf("unable to load user %s: %w", id, err)
+	}
+
+	token, plainToken, err := domain.NewApiToken(id, name, scopes, expiry)
+	if err != nil {
+		return nil, "", err
+	}
+	token.CreatedBy = domain.GetUserInfo(ctx).UserId()
+
+	if err := m.tokens.SaveApiToken(ctx, token); err != nil {
+		return nil, "", fmt.Errorf("creation failure: %w", err)
+	}
+
+	m.bus.Publish(app.TopicAuditEvent, domain.NewAuditEvent(ctx, domain.AuditSeverityLevelHigh, "apiTokenCreated",
+		domain.AuditObjectTypeUser, string(id), fmt.Sprintf("API token %s of user %s created", name, id)).
+		WithInfo("token", token.Identifier).
+		WithInfo("scopes", fmt.Sprintf("%v", scopes)).
+		WithInfo("expiresAt", expiry.Format(time.RFC3339)))
+
+	return token, plainToken, nil
+}
+
+// DeleteApiToken revokes a named API token of the given user.
+func (m Manager) DeleteApiToken(ctx context.Context, id domain.UserIdentifier, tokenId string) error {
+	if err := domain.ValidateUserAccessRights(ctx, id, domain.PermissionUsersWrite); err != nil {
+		return err
+	}
+
+	if err := m.tokens.DeleteApiToken(ctx, id, tokenId); err != nil {
+		return fmt.Errorf("unable to delete api token %s: %w", tokenId, err)
+	}
+
+	m.bus.Publish(app.TopicAuditEvent, domain.NewAuditEvent(ctx, domain.AuditSeverityLevelHigh, "apiTokenDeleted",
+		domain.AuditObjectTypeUser, string(id), fmt.Sprintf("API token of user %s revoked", id)).
+		WithInfo("token", tokenId))
+
+	return nil
+}
+
+// AuthenticateApiToken validates a named API token and returns the token together with its owner.
+// The last usage of the token is recorded using the client IP from the context.
+func (m Manager) AuthenticateApiToken(ctx context.Context, plainToken string) (
+	*domain.User,
+	*domain.ApiToken,
+	error,
+) {
+	tokenId, secret, err := domain.ParseApiToken(plainToken)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	token, err := m.tokens.GetApiToken(ctx, tokenId)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to load api token: %w", err)
+	}
+
+	now := time.Now()
+	if err := token.Verify(secret, now); err != nil {
+		return nil, nil, err
+	}
+
+	user, err := m.users.GetUser(ctx, token.UserIdentifier)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to load token owner %s: %w", token.UserIdentifier, err)
+	}
+
+	if user.IsDisabled() || user.IsLocked() {
+		return nil, nil, fmt.Errorf("token owner %s is disabled or locked: %w", token.UserIdentifier,
+			domain.ErrNoPermission)
+	}
+
+	clientIp := domain.GetUserInfo(ctx).ClientIp
+	if token.LastUsed == nil || now.Sub(*token.LastUsed) > apiTokenUsageInterval || token.LastUsedIp != clientIp {
+		token.LastUsed = &now
+		token.LastUsedIp = clientIp
+		if err := m.tokens.SaveApiToken(ctx, token); err != nil {
+			logrus.Warnf("failed to record usage of api token %s: %v", token.Identifier, err)
+		}
+	}
+
+	return user, token, nil
+}
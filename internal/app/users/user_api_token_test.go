@@ -0,0 +1,68 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+type testApiTokenDatabaseRepo struct {
+	ApiTokenDatabaseRepo
+	tokens map[string]*domain.ApiToken
+}
+
+func (r *testApiTokenDatabaseRepo) GetApiToken(_ context.Context, tokenId string) (*domain.ApiToken, error) {
+	token, ok := r.tokens[tokenId]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	tokenCopy := *token
+	return &tokenCopy, nil
+}
+
+func (r *testApiTokenDatabaseRepo) SaveApiToken(_ context.Context, token *domain.ApiToken) error {
+	r.tokens[token.Identifier] = token
+	return nil
+}
+
+func TestManager_AuthenticateApiToken(t *testing.T) {
+	now := time.Now()
+	lockedUntil := now.Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		owner   domain.User
+		wantErr bool
+	}{
+		{name: "active owner", owner: domain.User{Identifier: "bob"}},
+		{name: "disabled owner", owner: domain.User{Identifier: "bob", Disabled: &now}, wantErr: true},
+		{name: "locked owner", owner: domain.User{Identifier: "bob", Locked: &now, LockedUntil: &lockedUntil},
+			wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, plainToken, err := domain.NewApiToken(tt.owner.Identifier, "ci",
+				[]domain.ApiTokenScope{domain.ApiTokenScopePeersRead}, now.Add(time.Hour))
+			if err != nil {
+				t.Fatalf("NewApiToken() error = %v", err)
+			}
+			users := &testUserDatabaseRepo{users: map[domain.UserIdentifier]*domain.User{tt.owner.Identifier: &tt.owner}}
+			tokens := &testApiTokenDatabaseRepo{tokens: map[string]*domain.ApiToken{token.Identifier: token}}
+			m := Manager{users: users, tokens: tokens}
+
+			user, _, err := m.AuthenticateApiToken(context.Background(), plainToken)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AuthenticateApiToken() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, domain.ErrNoPermission) {
+				t.Errorf("AuthenticateApiToken() error = %v, want %v", err, domain.ErrNoPermission)
+			}
+			if !tt.wantErr && user.Identifier != tt.owner.Identifier {
+				t.Errorf("AuthenticateApiToken() user = %s, want %s", user.Identifier, tt.owner.Identifier)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/h44z/wg-portal/internal/app"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// InviteUser creates a database user without password and publishes an invitation, so that the activation link can be
+// mailed to the user. If no identifier is given, the email address is used as identifier.
+func (m Manager) InviteUser(ctx context.Context, user *domain.User, createDefaultPeer bool) (*domain.User, error) {
+	if err := domain.ValidateAdminAccessRights(ctx, domain.PermissionUsersWrite); err != nil {
+		return nil, err
+	}
+
+	user.Email = strings.TrimSpace(user.Email)
+	if user.Email == "" {
+		return nil, fmt.Errorf("missing email address: %w", domain.ErrInvalidData)
+	}
+	if user.Identifier == "" {
+		user.Identifier = domain.UserIdentifier(user.Email)
+	}
+
+	existingUser, err := m.users.GetUser(ctx, user.Identifier)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("unable to load existing user %s: %w", user.Identifier, err)
+	}
+	if existingUser != nil {
+		return nil, errors.Join(fmt.Errorf("user %s already exists", user.Identifier), domain.ErrDuplicateEntry)
+	}
+
+	token, tokenHash, err := domain.GenerateUserToken(user.Identifier)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	expiresAt := now.Add(m.cfg.Auth.InvitationValidity)
+
+	user.Source = domain.UserSourceDatabase
+	user.Password = ""
+	user.SetRole(user.EffectiveRole())
+	user.Invitation = domain.UserInvitation{
+		InvitedAt:   &now,
+		TokenHash:   tokenHash,
+		ExpiresAt:   &expiresAt,
+		DefaultPeer: createDefaultPeer,
+	}
+
+	if err := m.validateCreation(ctx, user); err != nil {
+		return nil, fmt.Errorf("creation not allowed: %w", err)
+	}
+
+	err = m.users.SaveUser(ctx, user.Identifier, func(u *domain.User) (*domain.User, error) {
+		user.CopyCalculatedAttributes(u)
+		return user, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creation failure: %w", err)
+	}
+
+	m.bus.Publish(app.TopicUserInvited, domain.UserInvitationRequest{
+		User:      *user,
+		Token:     token,
+		ExpiresAt: expiresAt,
+	})
+
+	m.bus.Publish(app.TopicAuditEvent, domain.NewAuditEvent(ctx, domain.AuditSeverityLevelMedium, "userInvited",
+		domain.AuditObjectTypeUser, string(user.Identifier),
+		fmt.Sprintf("user %s invited via %s", user.Identifier, user.Email)).
+		WithChanges(nil, user))
+
+	return user, nil
+}
+
+// ResendInvitation issues a new activation link for a pending invitation. Previous links become invalid.
+func (m Manager) ResendInvitation(ctx context.Context, id domain.UserIdentifier) error {
+	if err := domain.ValidateAdminAccessRights(ctx, domain.PermissionUsersWrite); err != nil {
+		return err
+	}
+
+	if _, err := m.users.GetUser(ctx, id); err != nil {
+		return fmt.Errorf("unable to load user %s: %w", id, err)
+	}
+
+	token, tokenHash, err := domain.GenerateUserToken(id)
+	if err != nil {
+		return err
+	}
+	expiresAt := time.Now().Add(m.cfg.Auth.InvitationValidity)
+
+	var user domain.User
+	err = m.users.SaveUser(ctx, id, func(u *domain.User) (*domain.User, error) {
+		if !u.Invitation.IsPending() {
+			return nil, errors.Join(errors.New("no pending invitation"), domain.ErrInvalidData)
+		}
+
+		u.Invitation.TokenHash = tokenHash
+		u.Invitation.ExpiresAt = &expiresAt
+
+		user = *u
+		return u, nil
+	})
+	if err != nil {
+		return fmt.Errorf("update failure: %w", err)
+	}
+
+	m.bus.Publish(app.TopicUserInvited, domain.UserInvitationRequest{
+		User:      user,
+		Token:     token,
+		ExpiresAt: expiresAt,
+	})
+
+	m.bus.Publish(app.TopicAuditEvent, domain.NewAuditEvent(ctx, domain.AuditSeverityLevelLow, "userInvitationResent",
+		domain.AuditObjectTypeUser, string(id), fmt.Sprintf("invitation for user %s resent", id)))
+
+	return nil
+}
+
+// RevokeInvitation deletes a user whose invitation has not been accepted yet.
+func (m Manager) RevokeInvitation(ctx context.Context, id domain.UserIdentifier) error {
+	if err := domain.ValidateAdminAccessRights(ctx, domain.PermissionUsersWrite); err != nil {
+		return err
+	}
+
+	user, err := m.users.GetUser(ctx, id)
+	if err != nil {
+		return fmt.Errorf("unable to load user %s: %w", id, err)
+	}
+
+	if !user.Invitation.IsPending() {
+		return errors.Join(fmt.Errorf("user %s has no pending invitation", id), domain.ErrInvalidData)
+	}
+
+	if err := m.users.DeleteUser(ctx, id); err != nil {
+		return fmt.Errorf("deletion failure: %w", err)
+	}
+
+	m.bus.Publish(app.TopicAuditEvent, domain.NewAuditEvent(ctx, domain.AuditSeverityLevelMedium,
+		"userInvitationRevoked", domain.AuditObjectTypeUser, string(id),
+		fmt.Sprintf("invitation for user %s revoked", id)).
+		WithChanges(user, nil))
+
+	return nil
+}
+
+// AcceptInvitation activates an invited user with the chosen password. The invitation can only be accepted once.
+// The request is authenticated by the token, the returned user is the owner of the token.
+func (m Manager) AcceptInvitation(ctx context.Context, token, password string) (*domain.User, error) {
+	if strings.TrimSpace(password) == "" {
+		return nil, fmt.Errorf("invalid password: %w", domain.ErrInvalidData)
+	}
+
+	id, secret, err := domain.ParseUserToken(token)
+	if err != nil {
+		return nil, errors.Join(err, domain.ErrInvalidData)
+	}
+
+	if _, err := m.users.GetUser(ctx, id); err != nil {
+		return nil, errors.Join(errors.New("invalid invitation token"), domain.ErrInvalidData)
+	}
+
+	var user *domain.User
+	var createDefaultPeer bool
+	now := time.Now()
+	err = m.users.SaveUser(ctx, id, func(u *domain.User) (*domain.User, error) {
+		if err := u.Invitation.Verify(secret, now); err != nil {
+			return nil, errors.Join(err, domain.ErrInvalidData)
+		}
+
+		u.Password = domain.PrivateString(password)
+		if err := u.HashPassword(); err != nil {
+			return nil, err
+		}
+		createDefaultPeer = u.Invitation.DefaultPeer
+		u.Invitation.Reset()
+
+		user = u
+		return u, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("activation failed: %w", err)
+	}
+
+	m.bus.Publish(app.TopicUserInvitationAccepted, domain.UserInvitationAccepted{
+		User:              *user,
+		CreateDefaultPeer: createDefaultPeer,
+	})
+
+	m.bus.Publish(app.TopicAuditEvent, domain.NewAuditEvent(ctx, domain.AuditSeverityLevelMedium,
+		"userInvitationAccepted", domain.AuditObjectTypeUser, string(id),
+		fmt.Sprintf("user %s accepted the invitation", id)))
+
+	return user, nil
+}
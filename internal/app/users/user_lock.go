@@ -0,0 +1,85 @@
+package users
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// LockUserTemporarily locks the user until the given time. Permanent locks set by an admin are not changed.
+func (m Manager) LockUserTemporarily(ctx context.Context, id domain.UserIdentifier, until time.Time, reason string) error {
+	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+		return err
+	}
+
+	if _, err := m.users.GetUser(ctx, id); err != nil {
+		return fmt.Errorf("unable to load user %s: %w", id, err)
+	}
+
+	err := m.users.SaveUser(ctx, id, func(u *domain.User) (*domain.User, error) {
+		if u.IsLocked() && u.LockedUntil == nil {
+			return u, nil // permanently locked
+		}
+
+		now := time.Now()
+		u.Locked = &now
+		u.LockedUntil = &until
+		u.LockedReason = reason
+		return u, nil
+	})
+	if err != nil {
+		return fmt.Errorf("update failure: %w", err)
+	}
+
+	return nil
+}
+
+// GetTemporarilyLockedUsers returns all users with an active temporary lock.
+func (m Manager) GetTemporarilyLockedUsers(ctx context.Context) ([]domain.User, error) {
+	if err := domain.ValidateAdminAccessRights(ctx, domain.PermissionUsersRead); err != nil {
+		return nil, err
+	}
+
+	users, err := m.users.GetAllUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load users: %w", err)
+	}
+
+	lockedUsers := make([]domain.User, 0)
+	for _, user := range users {
+		if user.IsLocked() && user.LockedUntil != nil {
+			lockedUsers = append(lockedUsers, user)
+		}
+	}
+
+	return lockedUsers, nil
+}
+
+// UnlockUser removes a temporary lock of the user.
+func (m Manager) UnlockUser(ctx context.Context, id domain.UserIdentifier) error {
+	if err := domain.ValidateAdminAccessRights(ctx, domain.PermissionUsersWrite); err != nil {
+		return err
+	}
+
+	if _, err := m.users.GetUser(ctx, id); err != nil {
+		return fmt.Errorf("unable to load user %s: %w", id, err)
+	}
+
+	err := m.users.SaveUser(ctx, id, func(u *domain.User) (*domain.User, error) {
+		if u.LockedUntil == nil {
+			return u, nil // not locked temporarily
+		}
+
+		u.Locked = nil
+		u.LockedUntil = nil
+		u.LockedReason = ""
+		return u, nil
+	})
+	if err != nil {
+		return fmt.Errorf("update failure: %w", err)
+	}
+
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"slices"
 	"sync"
 	"time"
 
@@ -27,20 +28,43 @@ type Manager struct {
 	cfg *config.Config
 	bus evbus.MessageBus
 
-	users UserDatabaseRepo
-	peers PeerDatabaseRepo
+	users  UserDatabaseRepo
+	peers  PeerDatabaseRepo
+	tokens ApiTokenDatabaseRepo
 }
 
-func NewUserManager(cfg *config.Config, bus evbus.MessageBus, users UserDatabaseRepo, peers PeerDatabaseRepo) (
+func NewUserManager(
+	cfg *config.Config,
+	bus evbus.MessageBus,
+	users UserDatabaseRepo,
+	peers PeerDatabaseRepo,
+	tokens ApiTokenDatabaseRepo,
+) (
 	*Manager,
 	error,
 ) {
+	customRoles := make([]domain.Role, len(cfg.Auth.Roles))
+	for i, role := range cfg.Auth.Roles {
+		customRoles[i] = domain.Role{
+			Name:        domain.RoleName(role.Name),
+			Description: role.Description,
+			Permissions: make([]domain.Permission, len(role.Permissions)),
+		}
+		for j, permission := range role.Permissions {
+			customRoles[i].Permissions[j] = domain.Permission(permission)
+		}
+	}
+	if err := domain.SetCustomRoles(customRoles); err != nil {
+		return nil, fmt.Errorf("invalid role configuration: %w", err)
+	}
+
 	m := &Manager{
 		cfg: cfg,
 		bus: bus,
 
-		users: users,
-		peers: peers,
+		users:  users,
+		peers:  peers,
+		tokens: tokens,
 	}
 	return m, nil
 }
@@ -74,6 +98,7 @@ func (m Manager) NewUser(ctx context.Context, user *domain.User) error {
 		u.Email = user.Email
 		u.Source = user.Source
 		u.IsAdmin = user.IsAdmin
+		u.Role = user.Role
 		u.Firstname = user.Firstname
 		u.Lastname = user.Lastname
 		u.Phone = user.Phone
@@ -85,6 +110,9 @@ func (m Manager) NewUser(ctx context.Context, user *domain.User) error {
 	}
 
 	m.bus.Publish(app.TopicUserCreated, user)
+	m.bus.Publish(app.TopicAuditEvent, domain.NewAuditEvent(ctx, domain.AuditSeverityLevelMedium, "userCreated",
+		domain.AuditObjectTypeUser, string(user.Identifier), fmt.Sprintf("user %s created", user.Identifier)).
+		WithChanges(nil, user))
 
 	return nil
 }
@@ -96,7 +124,7 @@ func (m Manager) StartBackgroundJobs(ctx context.Context) {
 }
 
 func (m Manager) GetUser(ctx context.Context, id domain.UserIdentifier) (*domain.User, error) {
-	if err := domain.ValidateUserAccessRights(ctx, id); err != nil {
+	if err := m.validateUserAccessRights(ctx, id, domain.PermissionUsersRead); err != nil {
 		return nil, err
 	}
 
@@ -118,7 +146,7 @@ func (m Manager) GetUserByEmail(ctx context.Context, email string) (*domain.User
 		return nil, fmt.Errorf("unable to load user for email %s: %w", email, err)
 	}
 
-	if err := domain.ValidateUserAccessRights(ctx, user.Identifier); err != nil {
+	if err := m.validateUserAccessRights(ctx, user.Identifier, domain.PermissionUsersRead); err != nil {
 		return nil, err
 	}
 
@@ -129,8 +157,10 @@ func (m Manager) GetUserByEmail(ctx context.Context, email string) (*domain.User
 	return user, nil
 }
 
+// GetAllUsers returns all users. For delegated admins, only the users that own peers of the managed interfaces
+// are returned.
 func (m Manager) GetAllUsers(ctx context.Context) ([]domain.User, error) {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	if err := domain.ValidateInterfaceAdminAccessRights(ctx, domain.PermissionUsersRead); err != nil {
 		return nil, err
 	}
 
@@ -138,6 +168,11 @@ func (m Manager) GetAllUsers(ctx context.Context) ([]domain.User, error) {
 	if err != nil {
 		return nil, fmt.Errorf("unable to load users: %w", err)
 	}
+	if !domain.HasAdminAccessRights(ctx, domain.PermissionUsersRead) {
+		users = slices.DeleteFunc(users, func(user domain.User) bool {
+			return !m.isManagedUser(ctx, &user, domain.PermissionUsersRead)
+		})
+	}
 
 	ch := make(chan *domain.User)
 	wg := sync.WaitGroup{}
@@ -162,7 +197,7 @@ func (m Manager) GetAllUsers(ctx context.Context) ([]domain.User, error) {
 }
 
 func (m Manager) UpdateUser(ctx context.Context, user *domain.User) (*domain.User, error) {
-	if err := domain.ValidateUserAccessRights(ctx, user.Identifier); err != nil {
+	if err := m.validateUserAccessRights(ctx, user.Identifier, domain.PermissionUsersWrite); err != nil {
 		return nil, err
 	}
 
@@ -171,6 +206,14 @@ func (m Manager) UpdateUser(ctx context.Context, user *domain.User) (*domain.Use
 		return nil, fmt.Errorf("unable to load existing user %s: %w", user.Identifier, err)
 	}
 
+	if user.Role == "" { // clients that only manage the admin flag keep the current role
+		user.Role = existingUser.Role
+	}
+	if user.ManagedInterfaces == nil { // clients that do not manage interface assignments keep the current ones
+		user.ManagedInterfaces = existingUser.ManagedInterfaces
+	}
+	user.SetRole(user.EffectiveRole())
+
 	if err := m.validateModifications(ctx, existingUser, user); err != nil {
 		return nil, fmt.Errorf("update not allowed: %w", err)
 	}
@@ -183,6 +226,15 @@ func (m Manager) UpdateUser(ctx context.Context, user *domain.User) (*domain.Use
 	if user.Password == "" { // keep old password
 		user.Password = existingUser.Password
 	}
+	user.SecondFactor = existingUser.SecondFactor // the second factor is only managed by the TOTP functions
+	user.PasswordReset = existingUser.PasswordReset
+	user.Invitation = existingUser.Invitation
+	user.Registration = existingUser.Registration
+
+	if user.IsLocked() && existingUser.IsLocked() { // keep the original lock time and a temporary unlock time
+		user.Locked = existingUser.Locked
+		user.LockedUntil = existingUser.LockedUntil
+	}
 
 	err = m.users.SaveUser(ctx, existingUser.Identifier, func(u *domain.User) (*domain.User, error) {
 		user.CopyCalculatedAttributes(u)
@@ -199,11 +251,15 @@ func (m Manager) UpdateUser(ctx context.Context, user *domain.User) (*domain.Use
 		m.bus.Publish(app.TopicUserEnabled, *user)
 	}
 
+	m.bus.Publish(app.TopicAuditEvent, domain.NewAuditEvent(ctx, domain.AuditSeverityLevelMedium, "userUpdated",
+		domain.AuditObjectTypeUser, string(user.Identifier), fmt.Sprintf("user %s updated", user.Identifier)).
+		WithChanges(existingUser, user))
+
 	return user, nil
 }
 
 func (m Manager) CreateUser(ctx context.Context, user *domain.User) (*domain.User, error) {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	if err := domain.ValidateAdminAccessRights(ctx, domain.PermissionUsersWrite); err != nil {
 		return nil, err
 	}
 
@@ -215,6 +271,8 @@ func (m Manager) CreateUser(ctx context.Context, user *domain.User) (*domain.Use
 		return nil, errors.Join(fmt.Errorf("user %s already exists", user.Identifier), domain.ErrDuplicateEntry)
 	}
 
+	user.SetRole(user.EffectiveRole())
+
 	if err := m.validateCreation(ctx, user); err != nil {
 		return nil, fmt.Errorf("creation not allowed: %w", err)
 	}
@@ -232,11 +290,15 @@ func (m Manager) CreateUser(ctx context.Context, user *domain.User) (*domain.Use
 		return nil, fmt.Errorf("creation failure: %w", err)
 	}
 
+	m.bus.Publish(app.TopicAuditEvent, domain.NewAuditEvent(ctx, domain.AuditSeverityLevelMedium, "userCreated",
+		domain.AuditObjectTypeUser, string(user.Identifier), fmt.Sprintf("user %s created", user.Identifier)).
+		WithChanges(nil, user))
+
 	return user, nil
 }
 
 func (m Manager) DeleteUser(ctx context.Context, id domain.UserIdentifier) error {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	if err := domain.ValidateAdminAccessRights(ctx, domain.PermissionUsersWrite); err != nil {
 		return err
 	}
 
@@ -255,6 +317,9 @@ func (m Manager) DeleteUser(ctx context.Context, id domain.UserIdentifier) error
 	}
 
 	m.bus.Publish(app.TopicUserDeleted, *existingUser)
+	m.bus.Publish(app.TopicAuditEvent, domain.NewAuditEvent(ctx, domain.AuditSeverityLevelHigh, "userDeleted",
+		domain.AuditObjectTypeUser, string(id), fmt.Sprintf("user %s deleted", id)).
+		WithChanges(existingUser, nil))
 
 	return nil
 }
@@ -269,6 +334,8 @@ func (m Manager) ActivateApi(ctx context.Context, id domain.UserIdentifier) (*do
 		return nil, err
 	}
 
+	oldUser := *user
+
 	now := time.Now()
 	user.ApiToken = uuid.New().String()
 	user.ApiTokenCreated = &now
@@ -282,6 +349,9 @@ func (m Manager) ActivateApi(ctx context.Context, id domain.UserIdentifier) (*do
 	}
 
 	m.bus.Publish(app.TopicUserApiEnabled, user)
+	m.bus.Publish(app.TopicAuditEvent, domain.NewAuditEvent(ctx, domain.AuditSeverityLevelHigh, "userApiEnabled",
+		domain.AuditObjectTypeUser, string(user.Identifier), fmt.Sprintf("API token of user %s created", id)).
+		WithChanges(&oldUser, user))
 
 	return user, nil
 }
@@ -296,6 +366,8 @@ func (m Manager) DeactivateApi(ctx context.Context, id domain.UserIdentifier) (*
 		return nil, err
 	}
 
+	oldUser := *user
+
 	user.ApiToken = ""
 	user.ApiTokenCreated = nil
 
@@ -308,6 +380,9 @@ func (m Manager) DeactivateApi(ctx context.Context, id domain.UserIdentifier) (*
 	}
 
 	m.bus.Publish(app.TopicUserApiDisabled, user)
+	m.bus.Publish(app.TopicAuditEvent, domain.NewAuditEvent(ctx, domain.AuditSeverityLevelHigh, "userApiDisabled",
+		domain.AuditObjectTypeUser, string(user.Identifier), fmt.Sprintf("API token of user %s removed", id)).
+		WithChanges(&oldUser, user))
 
 	return user, nil
 }
@@ -315,10 +390,17 @@ func (m Manager) DeactivateApi(ctx context.Context, id domain.UserIdentifier) (*
 func (m Manager) validateModifications(ctx context.Context, old, new *domain.User) error {
 	currentUser := domain.GetUserInfo(ctx)
 
-	if currentUser.Id != new.Identifier && !currentUser.IsAdmin {
+	delegated := currentUser.Id != new.Identifier && !currentUser.HasPermissions(domain.PermissionUsersWrite)
+	if delegated && !m.isManagedUser(ctx, old, domain.PermissionUsersWrite) {
 		return fmt.Errorf("insufficient permissions")
 	}
 
+	// the email address is used for password resets, so it is treated as a credential
+	if delegated && (string(new.Password) != "" || new.Email != old.Email ||
+		(new.ApiToken != "" && new.ApiToken != old.ApiToken)) {
+		return fmt.Errorf("delegated admins cannot change credentials: %w", domain.ErrNoPermission)
+	}
+
 	if err := old.EditAllowed(new); err != nil && currentUser.Id != domain.SystemAdminContextUserInfo().Id {
 		return errors.Join(fmt.Errorf("no access: %w", err), domain.ErrInvalidData)
 	}
@@ -327,6 +409,29 @@ func (m Manager) validateModifications(ctx context.Context, old, new *domain.Use
 		return errors.Join(fmt.Errorf("no access: %w", err), domain.ErrInvalidData)
 	}
 
+	if !currentUser.IsAdmin && (old.IsAdmin || old.EffectiveRole() != new.EffectiveRole()) {
+		return fmt.Errorf("only admins can change roles or modify admins: %w", domain.ErrNoPermission)
+	}
+
+	// taking over a user with more permissions than the caller would escalate the caller's privileges
+	targetRole, _ := domain.GetRole(old.EffectiveRole())
+	if !currentUser.IsAdmin && currentUser.Id != old.Identifier && !currentUser.HasPermissions(targetRole.Permissions...) {
+		return fmt.Errorf("cannot modify user with more permissions: %w", domain.ErrNoPermission)
+	}
+
+	if !currentUser.IsAdmin && currentUser.Id != old.Identifier && targetRole.IsPrivileged() &&
+		(string(new.Password) != "" || new.Email != old.Email || (new.ApiToken != "" && new.ApiToken != old.ApiToken)) {
+		return fmt.Errorf("only admins can change credentials of privileged users: %w", domain.ErrNoPermission)
+	}
+
+	if !currentUser.IsAdmin && !slices.Equal(old.ManagedInterfaces, new.ManagedInterfaces) {
+		return fmt.Errorf("only admins can assign managed interfaces: %w", domain.ErrNoPermission)
+	}
+
+	if old.EffectiveRole() != new.EffectiveRole() && !domain.IsValidRole(new.EffectiveRole()) {
+		return fmt.Errorf("unknown role %s: %w", new.Role, domain.ErrInvalidData)
+	}
+
 	if currentUser.Id == old.Identifier && old.IsAdmin && !new.IsAdmin {
 		return fmt.Errorf("cannot remove own admin rights: %w", domain.ErrInvalidData)
 	}
@@ -343,13 +448,18 @@ func (m Manager) validateModifications(ctx context.Context, old, new *domain.Use
 		return fmt.Errorf("cannot change user source: %w", domain.ErrInvalidData)
 	}
 
+	if old.Registration.State() != "" && old.IsDisabled() && !new.IsDisabled() {
+		return fmt.Errorf("registration of user %s is pending, approve it instead: %w", old.Identifier,
+			domain.ErrInvalidData)
+	}
+
 	return nil
 }
 
 func (m Manager) validateCreation(ctx context.Context, new *domain.User) error {
 	currentUser := domain.GetUserInfo(ctx)
 
-	if !currentUser.IsAdmin {
+	if !currentUser.HasPermissions(domain.PermissionUsersWrite) {
 		return fmt.Errorf("insufficient permissions")
 	}
 
@@ -357,6 +467,14 @@ func (m Manager) validateCreation(ctx context.Context, new *domain.User) error {
 		return fmt.Errorf("invalid user identifier: %w", domain.ErrInvalidData)
 	}
 
+	if !currentUser.IsAdmin && new.EffectiveRole() != domain.RoleUser {
+		return fmt.Errorf("only admins can assign roles: %w", domain.ErrNoPermission)
+	}
+
+	if !domain.IsValidRole(new.EffectiveRole()) {
+		return fmt.Errorf("unknown role %s: %w", new.Role, domain.ErrInvalidData)
+	}
+
 	if new.Identifier == "all" { // the 'all' user identifier collides with the rest api routes
 		return fmt.Errorf("reserved user identifier: %w", domain.ErrInvalidData)
 	}
@@ -378,7 +496,7 @@ func (m Manager) validateCreation(ctx context.Context, new *domain.User) error {
 			new.Source, domain.UserSourceDatabase, domain.ErrInvalidData)
 	}
 
-	if string(new.Password) == "" {
+	if string(new.Password) == "" && !new.Invitation.IsPending() { // invited users choose their own password
 		return fmt.Errorf("invalid password: %w", domain.ErrInvalidData)
 	}
 
@@ -388,10 +506,14 @@ func (m Manager) validateCreation(ctx context.Context, new *domain.User) error {
 func (m Manager) validateDeletion(ctx context.Context, del *domain.User) error {
 	currentUser := domain.GetUserInfo(ctx)
 
-	if !currentUser.IsAdmin {
+	if !currentUser.HasPermissions(domain.PermissionUsersWrite) {
 		return domain.ErrNoPermission
 	}
 
+	if !currentUser.IsAdmin && del.IsAdmin {
+		return fmt.Errorf("only admins can delete admins: %w", domain.ErrNoPermission)
+	}
+
 	if err := del.DeleteAllowed(); err != nil {
 		return errors.Join(fmt.Errorf("no access: %w", err), domain.ErrInvalidData)
 	}
@@ -449,6 +571,16 @@ func (m Manager) synchronizeLdapUsers(ctx context.Context, provider *config.Ldap
 	}
 	provider.ParsedAdminGroupDN = dn
 
+	// work on a copy of the rules, they are shared with the LDAP authenticator
+	groupRules := slices.Clone(provider.GroupInterfaces)
+	for i, rule := range groupRules {
+		dn, err := ldap.ParseDN(rule.GroupDN)
+		if err != nil {
+			return fmt.Errorf("failed to parse group DN of interface %s: %w", rule.Interface, err)
+		}
+		groupRules[i].ParsedGroupDN = dn
+	}
+
 	conn, err := internal.LdapConnect(provider)
 	if err != nil {
 		return fmt.Errorf("failed to setup LDAP connection: %w", err)
@@ -462,12 +594,28 @@ func (m Manager) synchronizeLdapUsers(ctx context.Context, provider *config.Ldap
 
 	logrus.Tracef("fetched %d raw ldap users...", len(rawUsers))
 
+	if provider.NestedGroups {
+		err = internal.LdapAddNestedGroupMemberships(conn, provider.BaseDN, provider.SyncFilter, &provider.FieldMap,
+			rawUsers, provider.GroupDNs())
+		if err != nil {
+			return fmt.Errorf("failed to resolve nested groups: %w", err)
+		}
+	}
+
 	// Update existing LDAP users
 	err = m.updateLdapUsers(ctx, provider.ProviderName, rawUsers, &provider.FieldMap, provider.ParsedAdminGroupDN)
 	if err != nil {
 		return err
 	}
 
+	// Provision or disable peers based on the group memberships
+	if len(groupRules) > 0 {
+		err = m.updateLdapInterfaceMemberships(ctx, provider.ProviderName, rawUsers, &provider.FieldMap, groupRules)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Disable missing LDAP users
 	if provider.DisableMissing {
 		err = m.disableMissingLdapUsers(ctx, provider.ProviderName, rawUsers, &provider.FieldMap)
@@ -511,6 +659,7 @@ func (m Manager) updateLdapUsers(
 		if existingUser != nil && existingUser.Source == domain.UserSourceLdap && userChangedInLdap(existingUser,
 			user) {
 
+			var updatedUser domain.User
 			err := m.users.SaveUser(tctx, user.Identifier, func(u *domain.User) (*domain.User, error) {
 				u.UpdatedAt = time.Now()
 				u.UpdatedBy = domain.CtxSystemLdapSyncer
@@ -521,18 +670,59 @@ func (m Manager) updateLdapUsers(
 				u.Department = user.Department
 				u.IsAdmin = user.IsAdmin
 				u.Disabled = user.Disabled
+				updatedUser = *u
 
 				return u, nil
 			})
 			if err != nil {
 				return fmt.Errorf("update error for user id %s: %w", user.Identifier, err)
 			}
+
+			m.bus.Publish(app.TopicAuditEvent, domain.NewAuditEvent(tctx, domain.AuditSeverityLevelLow,
+				"ldapUserUpdated", domain.AuditObjectTypeUser, string(user.Identifier),
+				fmt.Sprintf("user %s updated from LDAP", user.Identifier)).
+				WithChanges(existingUser, &updatedUser))
 		}
 	}
 
 	return nil
 }
 
+// updateLdapInterfaceMemberships evaluates the group interface rules for all LDAP users of the provider.
+func (m Manager) updateLdapInterfaceMemberships(
+	ctx context.Context,
+	providerName string,
+	rawUsers []internal.RawLdapUser,
+	fields *config.LdapFields,
+	rules []config.LdapGroupInterfaceRule,
+) error {
+	for _, rawUser := range rawUsers {
+		userId := domain.UserIdentifier(internal.MapDefaultString(rawUser, fields.UserIdentifier, ""))
+		user, err := m.users.GetUser(ctx, userId)
+		if err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				continue // user could not be created
+			}
+			return fmt.Errorf("find error for user id %s: %w", userId, err)
+		}
+		if user.Source != domain.UserSourceLdap || user.ProviderName != providerName || user.IsDisabled() {
+			continue // peers of disabled users are handled by the user disabled event
+		}
+
+		memberships, err := getLdapInterfaceMemberships(rawUser, fields, rules)
+		if err != nil {
+			return fmt.Errorf("failed to evaluate group memberships of %s: %w", userId, err)
+		}
+
+		m.bus.Publish(app.TopicUserInterfacesAssigned, domain.UserInterfaceMemberships{
+			UserIdentifier: userId,
+			Memberships:    memberships,
+		})
+	}
+
+	return nil
+}
+
 func (m Manager) disableMissingLdapUsers(
 	ctx context.Context,
 	providerName string,
@@ -578,7 +768,52 @@ func (m Manager) disableMissingLdapUsers(
 		}
 
 		m.bus.Publish(app.TopicUserDisabled, user)
+		m.bus.Publish(app.TopicAuditEvent, domain.NewAuditEvent(ctx, domain.AuditSeverityLevelMedium,
+			"ldapUserDisabled", domain.AuditObjectTypeUser, string(user.Identifier),
+			fmt.Sprintf("user %s disabled, missing in LDAP", user.Identifier)))
 	}
 
 	return nil
 }
+
+// validateUserAccessRights works like domain.ValidateUserAccessRights, but also grants delegated interface admins
+// access to the users that own peers of the managed interfaces.
+func (m Manager) validateUserAccessRights(
+	ctx context.Context,
+	id domain.UserIdentifier,
+	permissions ...domain.Permission,
+) error {
+	if domain.HasUserAccessRights(ctx, id, permissions...) {
+		return nil
+	}
+
+	if domain.GetUserInfo(ctx).HasDelegatedPermissions(permissions...) {
+		if user, err := m.users.GetUser(ctx, id); err == nil && m.isManagedUser(ctx, user, permissions...) {
+			return nil
+		}
+	}
+
+	return domain.ValidateUserAccessRights(ctx, id, permissions...)
+}
+
+// isManagedUser checks if the given user owns a peer of an interface that is managed by the current user.
+// Admins, users with a privileged role and other delegated admins are never managed by a delegated admin.
+func (m Manager) isManagedUser(ctx context.Context, user *domain.User, permissions ...domain.Permission) bool {
+	currentUser := domain.GetUserInfo(ctx)
+	if !currentUser.HasDelegatedPermissions(permissions...) {
+		return false
+	}
+
+	if user.IsAdmin || user.EffectiveRole() != domain.RoleUser || len(user.ManagedInterfaces) > 0 {
+		return false
+	}
+
+	peers, err := m.peers.GetUserPeers(ctx, user.Identifier)
+	if err != nil {
+		return false
+	}
+
+	return slices.ContainsFunc(peers, func(peer domain.Peer) bool {
+		return currentUser.ManagesInterface(peer.InterfaceIdentifier, permissions...)
+	})
+}
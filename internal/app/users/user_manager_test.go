@@ -0,0 +1,142 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+type testPeerDatabaseRepo struct {
+	peers []domain.Peer
+}
+
+func (r testPeerDatabaseRepo) GetUserPeers(_ context.Context, id domain.UserIdentifier) ([]domain.Peer, error) {
+	var peers []domain.Peer
+	for _, peer := range r.peers {
+		if peer.UserIdentifier == id {
+			peers = append(peers, peer)
+		}
+	}
+	return peers, nil
+}
+
+func TestManager_UpdateUser_DelegatedAdmin(t *testing.T) {
+	lead := &domain.ContextUserInfo{Id: "lead", Role: domain.RoleUser,
+		ManagedInterfaces: []domain.InterfaceIdentifier{"wg-sales"}}
+
+	newUser := func(id domain.UserIdentifier, modify func(u *domain.User)) *domain.User {
+		user := &domain.User{Identifier: id, Email: string(id) + "@example.com", Source: domain.UserSourceDatabase}
+		if modify != nil {
+			modify(user)
+		}
+		return user
+	}
+
+	tests := []struct {
+		name    string
+		user    *domain.User
+		update  func(u *domain.User)
+		wantErr bool
+	}{
+		{name: "managed user", user: newUser("bob", nil),
+			update: func(u *domain.User) { u.Firstname = "Bobby" }},
+		{name: "managed user password", user: newUser("bob", nil),
+			update: func(u *domain.User) { u.Password = "new-password" }, wantErr: true},
+		{name: "managed user email", user: newUser("bob", nil),
+			update: func(u *domain.User) { u.Email = "lead@example.com" }, wantErr: true},
+		{name: "managed user api token", user: newUser("bob", nil),
+			update: func(u *domain.User) { u.ApiToken = "token" }, wantErr: true},
+		{name: "unmanaged user", user: newUser("carol", nil),
+			update: func(u *domain.User) { u.Firstname = "Caroline" }, wantErr: true},
+		{name: "admin", user: newUser("bob", func(u *domain.User) { u.SetRole(domain.RoleAdmin) }),
+			update: func(u *domain.User) { u.Firstname = "Bobby" }, wantErr: true},
+		{name: "operator", user: newUser("bob", func(u *domain.User) { u.SetRole(domain.RoleOperator) }),
+			update: func(u *domain.User) { u.Firstname = "Bobby" }, wantErr: true},
+		{name: "delegated admin",
+			user: newUser("bob", func(u *domain.User) {
+				u.ManagedInterfaces = []domain.InterfaceIdentifier{"wg-dev"}
+			}),
+			update: func(u *domain.User) { u.Firstname = "Bobby" }, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := &testUserDatabaseRepo{users: map[domain.UserIdentifier]*domain.User{tt.user.Identifier: tt.user}}
+			peers := testPeerDatabaseRepo{peers: []domain.Peer{
+				{Identifier: "p1", UserIdentifier: "bob", InterfaceIdentifier: "wg-sales"},
+				{Identifier: "p2", UserIdentifier: "carol", InterfaceIdentifier: "wg-dev"},
+			}}
+			bus := &testMessageBus{published: map[string]int{}}
+			m := Manager{cfg: &config.Config{}, bus: bus, users: users, peers: peers}
+
+			update := *tt.user
+			tt.update(&update)
+
+			_, err := m.UpdateUser(domain.SetUserInfo(context.Background(), lead), &update)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UpdateUser() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, domain.ErrNoPermission) {
+				t.Errorf("UpdateUser() error = %v, want %v", err, domain.ErrNoPermission)
+			}
+		})
+	}
+}
+
+func TestManager_UpdateUser_PrivilegedUser(t *testing.T) {
+	helpdeskRole := domain.Role{Name: "helpdesk",
+		Permissions: []domain.Permission{domain.PermissionUsersRead, domain.PermissionUsersWrite}}
+	if err := domain.SetCustomRoles([]domain.Role{helpdeskRole}); err != nil {
+		t.Fatalf("SetCustomRoles() error = %v", err)
+	}
+	t.Cleanup(func() { _ = domain.SetCustomRoles(nil) })
+	helpdesk := &domain.ContextUserInfo{Id: "helpdesk", Role: helpdeskRole.Name}
+
+	newUser := func(role domain.RoleName) *domain.User {
+		user := &domain.User{Identifier: "bob", Email: "bob@example.com", Source: domain.UserSourceDatabase}
+		user.SetRole(role)
+		return user
+	}
+
+	tests := []struct {
+		name    string
+		user    *domain.User
+		update  func(u *domain.User)
+		wantErr bool
+	}{
+		{name: "user password", user: newUser(domain.RoleUser),
+			update: func(u *domain.User) { u.Password = "new-password" }},
+		{name: "same role password", user: newUser(helpdeskRole.Name),
+			update: func(u *domain.User) { u.Password = "new-password" }, wantErr: true},
+		{name: "same role name", user: newUser(helpdeskRole.Name),
+			update: func(u *domain.User) { u.Firstname = "Bobby" }},
+		{name: "operator password", user: newUser(domain.RoleOperator),
+			update: func(u *domain.User) { u.Password = "new-password" }, wantErr: true},
+		{name: "operator name", user: newUser(domain.RoleOperator),
+			update: func(u *domain.User) { u.Firstname = "Bobby" }, wantErr: true},
+		{name: "auditor email", user: newUser(domain.RoleAuditor),
+			update: func(u *domain.User) { u.Email = "helpdesk@example.com" }, wantErr: true},
+		{name: "auditor api token", user: newUser(domain.RoleAuditor),
+			update: func(u *domain.User) { u.ApiToken = "token" }, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := &testUserDatabaseRepo{users: map[domain.UserIdentifier]*domain.User{tt.user.Identifier: tt.user}}
+			bus := &testMessageBus{published: map[string]int{}}
+			m := Manager{cfg: &config.Config{}, bus: bus, users: users, peers: testPeerDatabaseRepo{}}
+
+			update := *tt.user
+			tt.update(&update)
+
+			_, err := m.UpdateUser(domain.SetUserInfo(context.Background(), helpdesk), &update)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UpdateUser() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, domain.ErrNoPermission) {
+				t.Errorf("UpdateUser() error = %v, want %v", err, domain.ErrNoPermission)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/h44z/wg-portal/internal/app"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// RequestPasswordReset creates a new password reset token for the database user with the given identifier or
+// email address and publishes it, so that the reset link can be mailed to the user. Previous tokens become invalid.
+// The request is not authenticated, therefore it silently succeeds if no matching user exists.
+func (m Manager) RequestPasswordReset(ctx context.Context, identifier string) error {
+	identifier = strings.TrimSpace(identifier)
+	if identifier == "" {
+		return fmt.Errorf("missing user identifier: %w", domain.ErrInvalidData)
+	}
+
+	user, err := m.users.GetUser(ctx, domain.UserIdentifier(identifier))
+	if errors.Is(err, domain.ErrNotFound) && strings.Contains(identifier, "@") {
+		user, err = m.users.GetUserByEmail(ctx, identifier)
+	}
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		logrus.Debugf("skipping password reset for %s, no such user", identifier)
+		return nil
+	case err != nil:
+		return fmt.Errorf("unable to load user %s: %w", identifier, err)
+	}
+
+	switch {
+	case user.Source != domain.UserSourceDatabase:
+		logrus.Debugf("skipping password reset for %s, user source %s", user.Identifier, user.Source)
+		return nil
+	case user.IsDisabled():
+		logrus.Debugf("skipping password reset for %s, user is disabled", user.Identifier)
+		return nil
+	case user.Locked != nil && user.LockedUntil == nil:
+		logrus.Debugf("skipping password reset for %s, user is locked", user.Identifier)
+		return nil
+	case user.Invitation.IsPending():
+		logrus.Debugf("skipping password reset for %s, invitation is pending", user.Identifier)
+		return nil
+	case user.Email == "":
+		logrus.Debugf("skipping password reset for %s, user has no mail address", user.Identifier)
+		return nil
+	}
+
+	token, tokenHash, err := domain.GenerateUserToken(user.Identifier)
+	if err != nil {
+		return err
+	}
+	expiresAt := time.Now().Add(m.cfg.Auth.PasswordResetTokenValidity)
+
+	err = m.users.SaveUser(ctx, user.Identifier, func(u *domain.User) (*domain.User, error) {
+		u.PasswordReset.TokenHash = tokenHash
+		u.PasswordReset.ExpiresAt = &expiresAt
+		return u, nil
+	})
+	if err != nil {
+		return fmt.Errorf("update failure: %w", err)
+	}
+
+	m.bus.Publish(app.TopicUserPasswordReset, domain.PasswordResetRequest{
+		User:      *user,
+		Token:     token,
+		ExpiresAt: expiresAt,
+	})
+
+	m.bus.Publish(app.TopicAuditEvent, domain.NewAuditEvent(ctx, domain.AuditSeverityLevelMedium,
+		"passwordResetRequested", domain.AuditObjectTypeUser, string(user.Identifier),
+		fmt.Sprintf("password reset requested for user %s", user.Identifier)))
+
+	return nil
+}
+
+// ResetPassword sets a new password using a password reset token. The token can only be used once.
+// The request is authenticated by the token, the returned user is the owner of the token.
+func (m Manager) ResetPassword(ctx context.Context, token, password string) (*domain.User, error) {
+	if strings.TrimSpace(password) == "" {
+		return nil, fmt.Errorf("invalid password: %w", domain.ErrInvalidData)
+	}
+
+	id, secret, err := domain.ParseUserToken(token)
+	if err != nil {
+		return nil, errors.Join(err, domain.ErrInvalidData)
+	}
+
+	if _, err := m.users.GetUser(ctx, id); err != nil {
+		return nil, errors.Join(errors.New("invalid password reset token"), domain.ErrInvalidData)
+	}
+
+	var user *domain.User
+	now := time.Now()
+	err = m.users.SaveUser(ctx, id, func(u *domain.User) (*domain.User, error) {
+		if err := u.PasswordReset.Verify(secret, now); err != nil {
+			return nil, errors.Join(err, domain.ErrInvalidData)
+		}
+		if u.Source != domain.UserSourceDatabase || u.IsDisabled() {
+			return nil, errors.Join(errors.New("password reset not allowed"), domain.ErrInvalidData)
+		}
+
+		u.Password = domain.PrivateString(password)
+		if err := u.HashPassword(); err != nil {
+			return nil, err
+		}
+		u.PasswordReset.Reset()
+
+		user = u
+		return u, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("password reset failed: %w", err)
+	}
+
+	m.bus.Publish(app.TopicAuditEvent, domain.NewAuditEvent(ctx, domain.AuditSeverityLevelMedium,
+		"passwordReset", domain.AuditObjectTypeUser, string(id),
+		fmt.Sprintf("password of user %s reset using a reset token", id)))
+
+	return user, nil
+}
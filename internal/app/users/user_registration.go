@@ -0,0 +1,228 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/h44z/wg-portal/internal/app"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+const (
+	registrationVerificationReason = "registration pending email verification"
+	registrationApprovalReason     = "registration pending admin approval"
+)
+
+// SignupUser creates a disabled database user for a public registration and publishes a verification request, so
+// that the verification link can be mailed to the user. The normalized email address is used as user identifier.
+// To not reveal which accounts exist, the function silently succeeds if the email address is already in use.
+// For registrations that are still waiting for the verification, a new verification link is sent, the registration
+// data (including the password) is kept. Only expired registrations are replaced.
+func (m Manager) SignupUser(ctx context.Context, user *domain.User) error {
+	if !m.cfg.Auth.SignupEnabled {
+		return fmt.Errorf("registration is disabled: %w", domain.ErrNoPermission)
+	}
+
+	user.Email = strings.ToLower(strings.TrimSpace(user.Email))
+	if !m.cfg.Auth.IsSignupEmailAllowed(user.Email) {
+		return fmt.Errorf("email domain not allowed: %w", domain.ErrInvalidData)
+	}
+	if strings.TrimSpace(string(user.Password)) == "" {
+		return fmt.Errorf("invalid password: %w", domain.ErrInvalidData)
+	}
+
+	user.Identifier = domain.UserIdentifier(user.Email)
+	existingUser, err := m.users.GetUser(ctx, user.Identifier)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return fmt.Errorf("unable to load existing user %s: %w", user.Identifier, err)
+	}
+	if existingUser == nil {
+		existingUser, err = m.users.GetUserByEmail(ctx, user.Email)
+		if err != nil && !errors.Is(err, domain.ErrNotFound) {
+			return fmt.Errorf("unable to load existing user %s: %w", user.Email, err)
+		}
+	}
+	if existingUser != nil && !existingUser.Registration.IsVerificationPending() {
+		logrus.Debugf("skipping registration for %s, user already exists", user.Email)
+		return nil
+	}
+	if existingUser != nil {
+		// the pending registration might have been found by email, keep its identifier so that the verification
+		// token and the replaced registration refer to the same user
+		user.Identifier = existingUser.Identifier
+	}
+
+	now := time.Now()
+	if existingUser != nil && existingUser.Registration.ExpiresAt != nil &&
+		now.Before(*existingUser.Registration.ExpiresAt) {
+		return m.resendSignupVerification(ctx, existingUser.Identifier)
+	}
+
+	token, tokenHash, err := domain.GenerateUserToken(user.Identifier)
+	if err != nil {
+		return err
+	}
+	expiresAt := now.Add(m.cfg.Auth.SignupVerificationValidity)
+
+	user.Source = domain.UserSourceDatabase
+	user.SetRole(domain.RoleUser)
+	user.Disabled = &now
+	user.DisabledReason = registrationVerificationReason
+	user.Registration = domain.UserRegistration{
+		RegisteredAt: &now,
+		TokenHash:    tokenHash,
+		ExpiresAt:    &expiresAt,
+	}
+	if err := user.HashPassword(); err != nil {
+		return err
+	}
+
+	err = m.users.SaveUser(ctx, user.Identifier, func(u *domain.User) (*domain.User, error) {
+		user.CopyCalculatedAttributes(u)
+		return user, nil
+	})
+	if err != nil {
+		return fmt.Errorf("creation failure: %w", err)
+	}
+
+	m.bus.Publish(app.TopicUserVerificationRequested, domain.RegistrationVerificationRequest{
+		User:      *user,
+		Token:     token,
+		ExpiresAt: expiresAt,
+	})
+
+	m.bus.Publish(app.TopicAuditEvent, domain.NewAuditEvent(ctx, domain.AuditSeverityLevelMedium, "userSignup",
+		domain.AuditObjectTypeUser, string(user.Identifier),
+		fmt.Sprintf("user %s signed up, email verification pending", user.Identifier)).
+		WithChanges(nil, user))
+
+	return nil
+}
+
+// resendSignupVerification issues a new verification link for a pending registration. The registration data is not
+// modified, so the link can only activate the account that was registered first.
+func (m Manager) resendSignupVerification(ctx context.Context, id domain.UserIdentifier) error {
+	token, tokenHash, err := domain.GenerateUserToken(id)
+	if err != nil {
+		return err
+	}
+
+	var user *domain.User
+	err = m.users.SaveUser(ctx, id, func(u *domain.User) (*domain.User, error) {
+		if !u.Registration.IsVerificationPending() {
+			return nil, errors.Join(fmt.Errorf("user %s has no registration pending verification", id),
+				domain.ErrInvalidData)
+		}
+
+		u.Registration.TokenHash = tokenHash
+
+		user = u
+		return u, nil
+	})
+	if err != nil {
+		return fmt.Errorf("update failure: %w", err)
+	}
+
+	m.bus.Publish(app.TopicUserVerificationRequested, domain.RegistrationVerificationRequest{
+		User:      *user,
+		Token:     token,
+		ExpiresAt: *user.Registration.ExpiresAt,
+	})
+
+	m.bus.Publish(app.TopicAuditEvent, domain.NewAuditEvent(ctx, domain.AuditSeverityLevelLow,
+		"userSignupVerificationResent", domain.AuditObjectTypeUser, string(id),
+		fmt.Sprintf("verification link for pending registration of user %s sent again", id)))
+
+	return nil
+}
+
+// VerifySignup verifies the email address of a self-registered user. If no admin approval is required, the user is
+// enabled and the registration is completed. The request is authenticated by the token.
+func (m Manager) VerifySignup(ctx context.Context, token string) (*domain.User, error) {
+	id, secret, err := domain.ParseUserToken(token)
+	if err != nil {
+		return nil, errors.Join(err, domain.ErrInvalidData)
+	}
+
+	if _, err := m.users.GetUser(ctx, id); err != nil {
+		return nil, errors.Join(errors.New("invalid verification token"), domain.ErrInvalidData)
+	}
+
+	var user *domain.User
+	now := time.Now()
+	err = m.users.SaveUser(ctx, id, func(u *domain.User) (*domain.User, error) {
+		if err := u.Registration.Verify(secret, now); err != nil {
+			return nil, errors.Join(err, domain.ErrInvalidData)
+		}
+
+		u.Registration.Verified = &now
+		u.Registration.TokenHash = ""
+		u.Registration.ExpiresAt = nil
+		if m.cfg.Auth.SignupRequireApproval {
+			u.Registration.ApprovalPending = true
+			u.DisabledReason = registrationApprovalReason
+		} else {
+			u.Disabled = nil
+			u.DisabledReason = ""
+		}
+
+		user = u
+		return u, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("verification failed: %w", err)
+	}
+
+	m.bus.Publish(app.TopicAuditEvent, domain.NewAuditEvent(ctx, domain.AuditSeverityLevelMedium,
+		"userSignupVerified", domain.AuditObjectTypeUser, string(id),
+		fmt.Sprintf("email address of user %s verified", id)).
+		WithInfo("approvalPending", fmt.Sprintf("%t", user.Registration.ApprovalPending)))
+
+	if !user.Registration.ApprovalPending {
+		m.bus.Publish(app.TopicUserRegistered, user)
+	}
+
+	return user, nil
+}
+
+// ApproveSignup enables a verified self-registered user that is waiting for admin approval.
+func (m Manager) ApproveSignup(ctx context.Context, id domain.UserIdentifier) (*domain.User, error) {
+	if err := domain.ValidateAdminAccessRights(ctx, domain.PermissionUsersWrite); err != nil {
+		return nil, err
+	}
+
+	if _, err := m.users.GetUser(ctx, id); err != nil {
+		return nil, fmt.Errorf("unable to load user %s: %w", id, err)
+	}
+
+	var user *domain.User
+	err := m.users.SaveUser(ctx, id, func(u *domain.User) (*domain.User, error) {
+		if u.Registration.State() != "approval" {
+			return nil, errors.Join(fmt.Errorf("user %s has no registration pending approval", id),
+				domain.ErrInvalidData)
+		}
+
+		u.Registration.ApprovalPending = false
+		u.Disabled = nil
+		u.DisabledReason = ""
+
+		user = u
+		return u, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("update failure: %w", err)
+	}
+
+	m.bus.Publish(app.TopicAuditEvent, domain.NewAuditEvent(ctx, domain.AuditSeverityLevelMedium,
+		"userSignupApproved", domain.AuditObjectTypeUser, string(id),
+		fmt.Sprintf("registration of user %s approved", id)))
+
+	m.bus.Publish(app.TopicUserRegistered, user)
+
+	return user, nil
+}
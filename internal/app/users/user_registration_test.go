@@ -0,0 +1,204 @@
+package users
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	evbus "github.com/vardius/message-bus"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/h44z/wg-portal/internal/app"
+	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+type testMessageBus struct {
+	evbus.MessageBus
+	published     map[string]int
+	verifications []domain.RegistrationVerificationRequest
+}
+
+func (b *testMessageBus) Publish(topic string, args ...interface{}) {
+	b.published[topic]++
+	for _, arg := range args {
+		if request, ok := arg.(domain.RegistrationVerificationRequest); ok {
+			b.verifications = append(b.verifications, request)
+		}
+	}
+}
+
+type testUserDatabaseRepo struct {
+	UserDatabaseRepo
+	users map[domain.UserIdentifier]*domain.User
+}
+
+func (r *testUserDatabaseRepo) GetUser(_ context.Context, id domain.UserIdentifier) (*domain.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	userCopy := *user
+	return &userCopy, nil
+}
+
+func (r *testUserDatabaseRepo) GetUserByEmail(_ context.Context, email string) (*domain.User, error) {
+	for _, user := range r.users {
+		if user.Email == email {
+			userCopy := *user
+			return &userCopy, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (r *testUserDatabaseRepo) SaveUser(
+	_ context.Context,
+	id domain.UserIdentifier,
+	updateFunc func(u *domain.User) (*domain.User, error),
+) error {
+	user, ok := r.users[id]
+	if !ok {
+		user = &domain.User{Identifier: id}
+	}
+	userCopy := *user
+
+	updatedUser, err := updateFunc(&userCopy)
+	if err != nil {
+		return err
+	}
+	r.users[id] = updatedUser
+	return nil
+}
+
+func TestManager_SignupUser(t *testing.T) {
+	const email = "alice@example.com"
+	now := time.Now()
+	expired := now.Add(-time.Minute)
+	valid := now.Add(time.Hour)
+
+	pendingUser := func(expiresAt *time.Time) *domain.User {
+		user := &domain.User{
+			Identifier: email,
+			Email:      email,
+			Source:     domain.UserSourceDatabase,
+			Firstname:  "Alice",
+			Password:   "first-password",
+			Registration: domain.UserRegistration{
+				RegisteredAt: &now,
+				TokenHash:    "first-token",
+				ExpiresAt:    expiresAt,
+			},
+		}
+		if err := user.HashPassword(); err != nil {
+			t.Fatal(err)
+		}
+		return user
+	}
+
+	tests := []struct {
+		name         string
+		existing     *domain.User
+		wantPassword string
+		wantName     string
+		wantMail     bool
+	}{
+		{name: "new registration", wantPassword: "second-password", wantName: "Mallory", wantMail: true},
+		{name: "pending registration", existing: pendingUser(&valid), wantPassword: "first-password",
+			wantName: "Alice", wantMail: true},
+		{name: "expired registration", existing: pendingUser(&expired), wantPassword: "second-password",
+			wantName: "Mallory", wantMail: true},
+		{name: "existing user", existing: &domain.User{Identifier: email, Email: email, Firstname: "Alice"},
+			wantName: "Alice"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := &testUserDatabaseRepo{users: map[domain.UserIdentifier]*domain.User{}}
+			if tt.existing != nil {
+				users.users[tt.existing.Identifier] = tt.existing
+			}
+			bus := &testMessageBus{published: map[string]int{}}
+			cfg := &config.Config{Auth: config.Auth{SignupEnabled: true, SignupVerificationValidity: time.Hour}}
+			m := Manager{cfg: cfg, bus: bus, users: users}
+
+			err := m.SignupUser(context.Background(),
+				&domain.User{Email: email, Firstname: "Mallory", Password: "second-password"})
+			if err != nil {
+				t.Fatalf("SignupUser() error = %v", err)
+			}
+
+			user := users.users[email]
+			if user.Firstname != tt.wantName {
+				t.Errorf("SignupUser() firstname = %q, want %q", user.Firstname, tt.wantName)
+			}
+			if tt.wantPassword != "" {
+				err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(tt.wantPassword))
+				if err != nil {
+					t.Errorf("SignupUser() password mismatch: %v", err)
+				}
+				if user.Registration.TokenHash == "first-token" {
+					t.Errorf("SignupUser() did not issue a new verification token")
+				}
+			}
+			if sent := bus.published[app.TopicUserVerificationRequested] > 0; sent != tt.wantMail {
+				t.Errorf("SignupUser() verification sent = %t, want %t", sent, tt.wantMail)
+			}
+		})
+	}
+}
+
+func TestManager_SignupUser_ResendAndVerify(t *testing.T) {
+	now := time.Now()
+	expired := now.Add(-time.Minute)
+
+	tests := []struct {
+		name     string
+		existing *domain.User
+		emails   []string // the email addresses of the sign-up requests, all but the first resend the verification
+		wantId   domain.UserIdentifier
+	}{
+		{name: "new registration", emails: []string{" Alice@Example.com ", "alice@example.com", "ALICE@example.com"},
+			wantId: "alice@example.com"},
+		{name: "expired registration with other identifier",
+			existing: &domain.User{Identifier: "Alice@Example.com", Email: "alice@example.com",
+				Source: domain.UserSourceDatabase, Disabled: &expired,
+				Registration: domain.UserRegistration{RegisteredAt: &expired, TokenHash: "old", ExpiresAt: &expired}},
+			emails: []string{"alice@example.com", "Alice@example.com"},
+			wantId: "Alice@Example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := &testUserDatabaseRepo{users: map[domain.UserIdentifier]*domain.User{}}
+			if tt.existing != nil {
+				users.users[tt.existing.Identifier] = tt.existing
+			}
+			bus := &testMessageBus{published: map[string]int{}}
+			cfg := &config.Config{Auth: config.Auth{SignupEnabled: true, SignupVerificationValidity: time.Hour}}
+			m := Manager{cfg: cfg, bus: bus, users: users}
+
+			for _, email := range tt.emails {
+				err := m.SignupUser(context.Background(), &domain.User{Email: email, Password: "password"})
+				if err != nil {
+					t.Fatalf("SignupUser(%q) error = %v", email, err)
+				}
+			}
+			if len(users.users) != 1 || len(bus.verifications) != len(tt.emails) {
+				t.Fatalf("SignupUser() stored %d users and sent %d verifications, want 1 and %d",
+					len(users.users), len(bus.verifications), len(tt.emails))
+			}
+
+			// only the last verification link is valid
+			if _, err := m.VerifySignup(context.Background(), bus.verifications[0].Token); err == nil {
+				t.Errorf("VerifySignup() accepted a replaced token")
+			}
+			user, err := m.VerifySignup(context.Background(), bus.verifications[len(bus.verifications)-1].Token)
+			if err != nil {
+				t.Fatalf("VerifySignup() error = %v", err)
+			}
+			if user.Identifier != tt.wantId || user.IsDisabled() {
+				t.Errorf("VerifySignup() user = %s, disabled = %t, want %s enabled", user.Identifier,
+					user.IsDisabled(), tt.wantId)
+			}
+		})
+	}
+}
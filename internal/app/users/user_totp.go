@@ -0,0 +1,210 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/h44z/wg-portal/internal/app"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// SetupTotp generates a new TOTP secret for the user. The second factor becomes active after the first code
+// has been verified using ActivateTotp.
+func (m Manager) SetupTotp(ctx context.Context, id domain.UserIdentifier) (*domain.TotpEnrollment, error) {
+	if err := domain.ValidateUserAccessRights(ctx, id); err != nil {
+		return nil, err
+	}
+
+	user, err := m.users.GetUser(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load user %s: %w", id, err)
+	}
+
+	if user.Source != domain.UserSourceDatabase {
+		return nil, errors.Join(errors.New("totp is only available for database users"), domain.ErrInvalidData)
+	}
+	if user.SecondFactor.IsTotpEnabled() {
+		return nil, errors.Join(errors.New("totp is already enabled"), domain.ErrInvalidData)
+	}
+
+	secret, err := domain.GenerateTotpSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	err = m.users.SaveUser(ctx, id, func(u *domain.User) (*domain.User, error) {
+		u.SecondFactor.Reset()
+		u.SecondFactor.TotpSecret = domain.PrivateString(secret)
+		return u, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("update failure: %w", err)
+	}
+
+	return &domain.TotpEnrollment{
+		Secret:          secret,
+		ProvisioningUri: domain.TotpProvisioningUri(m.cfg.Web.SiteTitle, string(id), secret),
+	}, nil
+}
+
+// ActivateTotp verifies the first code of a pending TOTP enrollment and enables the second factor.
+// It returns the recovery codes, they are only shown once.
+func (m Manager) ActivateTotp(ctx context.Context, id domain.UserIdentifier, code string) ([]string, error) {
+	if err := domain.ValidateUserAccessRights(ctx, id); err != nil {
+		return nil, err
+	}
+
+	if _, err := m.users.GetUser(ctx, id); err != nil {
+		return nil, fmt.Errorf("unable to load user %s: %w", id, err)
+	}
+
+	recoveryCodes, hashedCodes, err := domain.GenerateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	err = m.users.SaveUser(ctx, id, func(u *domain.User) (*domain.User, error) {
+		if u.SecondFactor.IsTotpEnabled() {
+			return nil, errors.Join(errors.New("totp is already enabled"), domain.ErrInvalidData)
+		}
+		if u.SecondFactor.TotpSecret == "" {
+			return nil, errors.Join(errors.New("totp enrollment has not been started"), domain.ErrInvalidData)
+		}
+		if err := u.SecondFactor.VerifyTotpCode(code, now); err != nil {
+			return nil, errors.Join(err, domain.ErrInvalidData)
+		}
+
+		u.SecondFactor.TotpEnabled = &now
+		u.SecondFactor.RecoveryCodes = hashedCodes
+		return u, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("activation failed: %w", err)
+	}
+
+	m.bus.Publish(app.TopicAuditEvent, domain.NewAuditEvent(ctx, domain.AuditSeverityLevelMedium, "totpEnabled",
+		domain.AuditObjectTypeUser, string(id), fmt.Sprintf("totp enabled for user %s", id)))
+
+	return recoveryCodes, nil
+}
+
+// DisableTotp removes the second factor of the user. A valid TOTP or recovery code is required.
+// If the TOTP policy requires a second factor for the user, it cannot be disabled.
+func (m Manager) DisableTotp(ctx context.Context, id domain.UserIdentifier, code string) error {
+	if err := domain.ValidateUserAccessRights(ctx, id); err != nil {
+		return err
+	}
+
+	user, err := m.users.GetUser(ctx, id)
+	if err != nil {
+		return fmt.Errorf("unable to load user %s: %w", id, err)
+	}
+
+	if !user.SecondFactor.IsTotpEnabled() {
+		return errors.Join(errors.New("totp is not enabled"), domain.ErrInvalidData)
+	}
+	if m.cfg.Auth.IsTotpRequired(user.IsAdmin) {
+		return errors.Join(errors.New("totp is mandatory"), domain.ErrNoPermission)
+	}
+
+	if err := m.verifySecondFactorCode(user, code); err != nil {
+		return errors.Join(err, domain.ErrInvalidData)
+	}
+
+	err = m.users.SaveUser(ctx, id, func(u *domain.User) (*domain.User, error) {
+		u.SecondFactor.Reset()
+		return u, nil
+	})
+	if err != nil {
+		return fmt.Errorf("update failure: %w", err)
+	}
+
+	m.bus.Publish(app.TopicAuditEvent, domain.NewAuditEvent(ctx, domain.AuditSeverityLevelHigh, "totpDisabled",
+		domain.AuditObjectTypeUser, string(id), fmt.Sprintf("totp disabled for user %s", id)))
+
+	return nil
+}
+
+// ResetTotp removes the second factor of the user, for example if the authenticator device was lost.
+// Only admins are allowed to reset the second factor.
+func (m Manager) ResetTotp(ctx context.Context, id domain.UserIdentifier) error {
+	if err := domain.ValidateAdminAccessRights(ctx, domain.PermissionUsersWrite); err != nil {
+		return err
+	}
+
+	if _, err := m.users.GetUser(ctx, id); err != nil {
+		return fmt.Errorf("unable to load user %s: %w", id, err)
+	}
+
+	err := m.users.SaveUser(ctx, id, func(u *domain.User) (*domain.User, error) {
+		u.SecondFactor.Reset()
+		return u, nil
+	})
+	if err != nil {
+		return fmt.Errorf("update failure: %w", err)
+	}
+
+	m.bus.Publish(app.TopicAuditEvent, domain.NewAuditEvent(ctx, domain.AuditSeverityLevelHigh, "totpReset",
+		domain.AuditObjectTypeUser, string(id), fmt.Sprintf("totp reset for user %s", id)))
+
+	return nil
+}
+
+// VerifySecondFactor checks a TOTP or recovery code during login. Used codes are invalidated.
+func (m Manager) VerifySecondFactor(ctx context.Context, id domain.UserIdentifier, code string) (
+	*domain.User,
+	error,
+) {
+	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+		return nil, err // only the system context of the authenticator is allowed to verify codes
+	}
+
+	if _, err := m.users.GetUser(ctx, id); err != nil {
+		return nil, fmt.Errorf("unable to load user %s: %w", id, err)
+	}
+
+	// the code is verified within the update transaction, so that it cannot be used twice
+	var user domain.User
+	usedRecoveryCode := false
+	err := m.users.SaveUser(ctx, id, func(u *domain.User) (*domain.User, error) {
+		if !u.SecondFactor.IsTotpEnabled() {
+			return nil, errors.New("totp is not enabled")
+		}
+
+		recoveryCodesBefore := u.SecondFactor.RecoveryCodes
+		if err := m.verifySecondFactorCode(u, code); err != nil {
+			return nil, err
+		}
+
+		usedRecoveryCode = recoveryCodesBefore != u.SecondFactor.RecoveryCodes
+		user = *u
+		return u, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("verification failed: %w", err)
+	}
+
+	if usedRecoveryCode {
+		m.bus.Publish(app.TopicAuditEvent, domain.NewAuditEvent(ctx, domain.AuditSeverityLevelMedium,
+			"totpRecoveryCodeUsed", domain.AuditObjectTypeUser, string(id),
+			fmt.Sprintf("recovery code used by user %s", id)))
+	}
+
+	return &user, nil
+}
+
+// verifySecondFactorCode checks the code as TOTP code first, and as recovery code afterwards.
+func (m Manager) verifySecondFactorCode(user *domain.User, code string) error {
+	if err := user.SecondFactor.VerifyTotpCode(code, time.Now()); err == nil {
+		return nil
+	}
+
+	if err := user.SecondFactor.UseRecoveryCode(code); err == nil {
+		return nil
+	}
+
+	return errors.New("invalid second factor code")
+}
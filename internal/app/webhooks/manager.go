@@ -0,0 +1,465 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/h44z/wg-portal/internal"
+	"github.com/h44z/wg-portal/internal/app"
+	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
+	"github.com/sirupsen/logrus"
+	evbus "github.com/vardius/message-bus"
+)
+
+// deliveryLogRetention defines how long successful deliveries are kept in the delivery log.
+const deliveryLogRetention = 7 * 24 * time.Hour
+
+// deliveryWorkers defines how many deliveries are processed concurrently.
+const deliveryWorkers = 4
+
+// deliveryQueueSize defines how many deliveries can be queued. If the queue is full, new deliveries stay pending
+// until the next scan for pending deliveries.
+const deliveryQueueSize = 100
+
+// pendingScanInterval defines how often pending deliveries that are due are queued.
+const pendingScanInterval = 1 * time.Minute
+
+// maxRetryBackoff limits the delay between two delivery attempts.
+const maxRetryBackoff = 24 * time.Hour
+
+// eventTopics contains all topics that can be forwarded to webhook receivers.
+var eventTopics = []string{
+	app.TopicUserCreated,
+	app.TopicUserApiEnabled,
+	app.TopicUserApiDisabled,
+	app.TopicUserRegistered,
+	app.TopicUserDisabled,
+	app.TopicUserEnabled,
+	app.TopicUserDeleted,
+	app.TopicAuthLogin,
+	app.TopicInterfaceUpdated,
+	app.TopicPeerInterfaceUpdated,
+	app.TopicPeerIdentifierUpdated,
+	app.TopicPeerConnected,
+	app.TopicPeerDisconnected,
+}
+
+type Manager struct {
+	cfg *config.Config
+	bus evbus.MessageBus
+
+	db DatabaseRepo
+
+	client   *http.Client
+	webhooks map[string]config.WebhookConfig
+
+	queue    chan deliveryJob
+	inFlight *inFlightDeliveries // deliveries that are queued or currently delivered
+}
+
+type deliveryJob struct {
+	hook     config.WebhookConfig
+	delivery *domain.WebhookDelivery
+}
+
+// inFlightDeliveries tracks the identifiers of queued deliveries, so that a delivery is never queued twice.
+type inFlightDeliveries struct {
+	mux sync.Mutex
+	ids map[string]struct{}
+}
+
+// add marks the delivery as in flight. It returns false if the delivery was already in flight.
+func (d *inFlightDeliveries) add(id string) bool {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	if _, ok := d.ids[id]; ok {
+		return false
+	}
+	d.ids[id] = struct{}{}
+	return true
+}
+
+func (d *inFlightDeliveries) remove(id string) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	delete(d.ids, id)
+}
+
+func NewWebhookManager(cfg *config.Config, bus evbus.MessageBus, db DatabaseRepo) (*Manager, error) {
+	m := &Manager{
+		cfg: cfg,
+		bus: bus,
+
+		db: db,
+
+		client:   &http.Client{},
+		webhooks: make(map[string]config.WebhookConfig, len(cfg.Webhooks)),
+
+		queue:    make(chan deliveryJob, deliveryQueueSize),
+		inFlight: &inFlightDeliveries{ids: make(map[string]struct{})},
+	}
+
+	for _, hook := range cfg.Webhooks {
+		if err := validateWebhook(hook); err != nil {
+			return nil, fmt.Errorf("invalid webhook %s: %w", hook.Name, err)
+		}
+		if _, exists := m.webhooks[hook.Name]; exists {
+			return nil, fmt.Errorf("duplicate webhook name %s", hook.Name)
+		}
+		m.webhooks[hook.Name] = hook
+	}
+
+	if err := m.connectToMessageBus(); err != nil {
+		return nil, fmt.Errorf("failed to setup message bus: %w", err)
+	}
+
+	return m, nil
+}
+
+func validateWebhook(hook config.WebhookConfig) error {
+	if hook.Name == "" {
+		return fmt.Errorf("missing name")
+	}
+	u, err := url.Parse(hook.Url)
+	if err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported url scheme %s", u.Scheme)
+	}
+	if len(hook.Topics) == 0 {
+		return fmt.Errorf("no topics configured")
+	}
+	if hook.MaxRetries < 0 {
+		return fmt.Errorf("max_retries must not be negative")
+	}
+
+	return nil
+}
+
+func (m Manager) connectToMessageBus() error {
+	for _, topic := range eventTopics {
+		if len(m.webhooksForTopic(topic)) == 0 {
+			continue // no subscriber for this topic
+		}
+
+		if err := m.bus.Subscribe(topic, m.eventHandler(topic)); err != nil {
+			return fmt.Errorf("failed to subscribe to %s: %w", topic, err)
+		}
+	}
+
+	return nil
+}
+
+func (m Manager) StartBackgroundJobs(ctx context.Context) {
+	if len(m.webhooks) == 0 {
+		return // noting to do
+	}
+
+	for i := 0; i < deliveryWorkers; i++ {
+		go m.deliveryWorker(ctx)
+	}
+
+	go func() {
+		// pending deliveries that did not fit into the queue, or were left over by the last run, are picked up here
+		running := true
+		for running {
+			m.queuePendingDeliveries(ctx)
+
+			select {
+			case <-ctx.Done():
+				running = false
+			case <-time.After(pendingScanInterval):
+			}
+		}
+	}()
+
+	go func() {
+		running := true
+		for running {
+			select {
+			case <-ctx.Done():
+				running = false
+				continue
+			case <-time.After(1 * time.Hour):
+				// select blocks until one of the cases evaluate to true
+			}
+
+			err := m.db.DeleteWebhookDeliveries(ctx, domain.WebhookDeliveryStateDelivered,
+				time.Now().Add(-deliveryLogRetention))
+			if err != nil {
+				logrus.Errorf("failed to clean up webhook delivery log: %v", err)
+			}
+		}
+	}()
+}
+
+func (m Manager) deliveryWorker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-m.queue:
+			m.processJob(ctx, job)
+		}
+	}
+}
+
+// processJob delivers the queued delivery if it is still due. If another attempt is needed, it is scheduled for
+// the time of the next retry, so the worker does not wait for the backoff.
+func (m Manager) processJob(ctx context.Context, job deliveryJob) {
+	// the job might be outdated if it was queued by both, the retry timer and the scan for pending deliveries
+	delivery, err := m.db.GetWebhookDelivery(ctx, job.delivery.Identifier)
+	if err == nil && delivery.IsDue(time.Now()) {
+		m.deliver(ctx, job.hook, delivery)
+	}
+	m.inFlight.remove(job.delivery.Identifier)
+	if err != nil {
+		logrus.Errorf("failed to load webhook delivery %s: %v", job.delivery.Identifier, err)
+		return
+	}
+
+	if delivery.State == domain.WebhookDeliveryStatePending && delivery.NextAttempt != nil && ctx.Err() == nil {
+		time.AfterFunc(time.Until(*delivery.NextAttempt), func() {
+			if ctx.Err() == nil {
+				m.enqueue(job.hook, delivery)
+			}
+		})
+	}
+}
+
+// enqueue queues the delivery without blocking. If the delivery is already queued or the queue is full, it stays
+// pending and gets picked up by the next scan for pending deliveries.
+func (m Manager) enqueue(hook config.WebhookConfig, delivery *domain.WebhookDelivery) {
+	if !m.inFlight.add(delivery.Identifier) {
+		return
+	}
+
+	select {
+	case m.queue <- deliveryJob{hook: hook, delivery: delivery}:
+	default:
+		m.inFlight.remove(delivery.Identifier)
+		logrus.Warnf("webhook delivery queue is full, postponing webhook %s delivery %s",
+			hook.Name, delivery.Identifier)
+	}
+}
+
+// queuePendingDeliveries queues all pending deliveries that are due. This also resumes deliveries that were still
+// pending when the application was stopped.
+func (m Manager) queuePendingDeliveries(ctx context.Context) {
+	deliveries, err := m.db.GetWebhookDeliveries(ctx, domain.WebhookDeliveryStatePending, 0)
+	if err != nil {
+		logrus.Errorf("failed to load pending webhook deliveries: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for i := len(deliveries) - 1; i >= 0; i-- { // deliveries are sorted by creation time, newest first
+		delivery := deliveries[i]
+		if !delivery.IsDue(now) {
+			continue // the retry timer queues the delivery
+		}
+
+		hook, ok := m.webhooks[delivery.Webhook]
+		if !ok {
+			logrus.Warnf("webhook %s is no longer configured, skipping pending delivery %s",
+				delivery.Webhook, delivery.Identifier)
+			continue
+		}
+
+		m.enqueue(hook, &delivery)
+	}
+}
+
+func (m Manager) webhooksForTopic(topic string) []config.WebhookConfig {
+	var hooks []config.WebhookConfig
+	for _, hook := range m.webhooks {
+		if topicMatches(hook.Topics, topic) {
+			hooks = append(hooks, hook)
+		}
+	}
+	return hooks
+}
+
+func (m Manager) eventHandler(topic string) func(args ...any) {
+	return func(args ...any) {
+		m.handleEvent(topic, args...)
+	}
+}
+
+func (m Manager) handleEvent(topic string, args ...any) {
+	payload := Payload{
+		Version:   PayloadVersion,
+		Id:        uuid.New().String(),
+		Event:     topic,
+		Timestamp: time.Now(),
+		Data:      newPayloadData(args...),
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		logrus.Errorf("failed to encode webhook payload for %s: %v", topic, err)
+		return
+	}
+
+	ctx := context.Background()
+	for _, hook := range m.webhooksForTopic(topic) {
+		delivery := &domain.WebhookDelivery{
+			Identifier: uuid.New().String(),
+			Webhook:    hook.Name,
+			Topic:      topic,
+			State:      domain.WebhookDeliveryStatePending,
+			Payload:    string(body),
+		}
+
+		if err := m.db.SaveWebhookDelivery(ctx, delivery); err != nil {
+			logrus.Errorf("failed to store webhook delivery for %s: %v", hook.Name, err)
+			continue
+		}
+
+		m.enqueue(hook, delivery)
+	}
+}
+
+// deliver posts the payload to the webhook receiver. If the attempt fails, the next retry is scheduled with an
+// exponential backoff. If all retries fail, the delivery is moved to the dead-letter list. If the context is
+// cancelled, the delivery stays pending and is resumed on the next start.
+func (m Manager) deliver(ctx context.Context, hook config.WebhookConfig, delivery *domain.WebhookDelivery) {
+	statusCode, err := m.send(ctx, hook, delivery)
+	if ctx.Err() != nil {
+		return
+	}
+
+	now := time.Now()
+	delivery.Attempts++
+	delivery.LastAttempt = &now
+	delivery.LastStatusCode = statusCode
+	delivery.LastError = ""
+	delivery.NextAttempt = nil
+	switch {
+	case err == nil:
+		delivery.State = domain.WebhookDeliveryStateDelivered
+	case delivery.Retries < hook.MaxRetries:
+		delivery.Retries++
+		nextAttempt := now.Add(retryBackoff(hook, delivery.Retries))
+		delivery.NextAttempt = &nextAttempt
+		delivery.LastError = err.Error()
+		logrus.Debugf("webhook %s delivery %s attempt %d failed: %v",
+			hook.Name, delivery.Identifier, delivery.Attempts, err)
+	default:
+		delivery.State = domain.WebhookDeliveryStateDeadLetter
+		delivery.LastError = err.Error()
+		logrus.Warnf("webhook %s delivery %s failed after %d attempts, moved to dead-letter list",
+			hook.Name, delivery.Identifier, delivery.Attempts)
+	}
+
+	if err := m.db.SaveWebhookDelivery(ctx, delivery); err != nil {
+		logrus.Errorf("failed to update webhook delivery %s: %v", delivery.Identifier, err)
+	}
+}
+
+// retryBackoff returns the delay before the given retry. The delay doubles with each retry.
+func retryBackoff(hook config.WebhookConfig, retry int) time.Duration {
+	backoff := hook.GetRetryBackoff()
+	for i := 1; i < retry && backoff < maxRetryBackoff; i++ {
+		backoff *= 2
+	}
+
+	return min(backoff, maxRetryBackoff)
+}
+
+func (m Manager) send(ctx context.Context, hook config.WebhookConfig, delivery *domain.WebhookDelivery) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, hook.GetTimeout())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.Url, strings.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "wg-portal/"+internal.Version)
+	req.Header.Set("X-WgPortal-Event", delivery.Topic)
+	req.Header.Set("X-WgPortal-Delivery", delivery.Identifier)
+	if hook.Secret != "" {
+		req.Header.Set("X-WgPortal-Signature", signPayload(hook.Secret, []byte(delivery.Payload)))
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// GetWebhooks returns all configured webhook subscriptions.
+func (m Manager) GetWebhooks(ctx context.Context) ([]config.WebhookConfig, error) {
+	if err := domain.ValidateAdminAccessRights(ctx, domain.PermissionSystemRead); err != nil {
+		return nil, err
+	}
+
+	return m.cfg.Webhooks, nil
+}
+
+// GetDeliveries returns the most recent deliveries. If state is empty, deliveries of all states are returned.
+func (m Manager) GetDeliveries(ctx context.Context, state domain.WebhookDeliveryState, limit int) (
+	[]domain.WebhookDelivery,
+	error,
+) {
+	if err := domain.ValidateAdminAccessRights(ctx, domain.PermissionSystemRead); err != nil {
+		return nil, err
+	}
+
+	return m.db.GetWebhookDeliveries(ctx, state, limit)
+}
+
+// ReplayDelivery delivers a dead-letter again. The delivery is queued and processed in the background.
+func (m Manager) ReplayDelivery(ctx context.Context, id string) (*domain.WebhookDelivery, error) {
+	if err := domain.ValidateAdminAccessRights(ctx, domain.PermissionSystemWrite); err != nil {
+		return nil, err
+	}
+
+	delivery, err := m.db.GetWebhookDelivery(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load delivery %s: %w", id, err)
+	}
+
+	if !delivery.IsDeadLetter() {
+		return nil, fmt.Errorf("delivery %s is not a dead-letter: %w", id, domain.ErrInvalidData)
+	}
+
+	hook, ok := m.webhooks[delivery.Webhook]
+	if !ok {
+		return nil, fmt.Errorf("webhook %s is no longer configured: %w", delivery.Webhook, domain.ErrInvalidData)
+	}
+
+	delivery.State = domain.WebhookDeliveryStatePending
+	delivery.Retries = 0
+	delivery.NextAttempt = nil
+	if err := m.db.SaveWebhookDelivery(ctx, delivery); err != nil {
+		return nil, fmt.Errorf("failed to update delivery %s: %w", id, err)
+	}
+
+	replayed := *delivery // the delivery gets modified by the background job
+	m.enqueue(hook, delivery)
+
+	return &replayed, nil
+}
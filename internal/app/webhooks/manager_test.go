@@ -0,0 +1,225 @@
+package webhooks
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+type testDatabaseRepo struct {
+	mux        sync.Mutex
+	deliveries map[string]domain.WebhookDelivery
+}
+
+func (r *testDatabaseRepo) GetWebhookDelivery(_ context.Context, id string) (*domain.WebhookDelivery, error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	delivery, ok := r.deliveries[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return &delivery, nil
+}
+
+func (r *testDatabaseRepo) GetWebhookDeliveries(_ context.Context, state domain.WebhookDeliveryState, _ int) (
+	[]domain.WebhookDelivery,
+	error,
+) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	var deliveries []domain.WebhookDelivery
+	for _, delivery := range r.deliveries {
+		if state == "" || delivery.State == state {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	return deliveries, nil
+}
+
+func (r *testDatabaseRepo) SaveWebhookDelivery(_ context.Context, delivery *domain.WebhookDelivery) error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	r.deliveries[delivery.Identifier] = *delivery
+	return nil
+}
+
+func (r *testDatabaseRepo) DeleteWebhookDeliveries(context.Context, domain.WebhookDeliveryState, time.Time) error {
+	return nil
+}
+
+func TestManager_deliver(t *testing.T) {
+	tests := []struct {
+		name            string
+		fail            bool
+		retries         int
+		maxRetries      int
+		cancel          bool
+		wantState       domain.WebhookDeliveryState
+		wantAttempts    int
+		wantNextAttempt time.Duration // 0 if no retry is scheduled
+	}{
+		{name: "delivered", wantState: domain.WebhookDeliveryStateDelivered, wantAttempts: 1},
+		{name: "retry scheduled", fail: true, maxRetries: 2,
+			wantState: domain.WebhookDeliveryStatePending, wantAttempts: 1, wantNextAttempt: time.Minute},
+		{name: "backoff doubles", fail: true, retries: 1, maxRetries: 2,
+			wantState: domain.WebhookDeliveryStatePending, wantAttempts: 1, wantNextAttempt: 2 * time.Minute},
+		{name: "dead letter", fail: true, retries: 1, maxRetries: 1,
+			wantState: domain.WebhookDeliveryStateDeadLetter, wantAttempts: 1},
+		{name: "cancelled", fail: true, maxRetries: 1, cancel: true,
+			wantState: domain.WebhookDeliveryStatePending, wantAttempts: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.fail {
+					w.WriteHeader(http.StatusServiceUnavailable)
+				}
+			}))
+			defer srv.Close()
+
+			delivery := &domain.WebhookDelivery{Identifier: "d1", Webhook: "test",
+				State: domain.WebhookDeliveryStatePending, Retries: tt.retries}
+			db := &testDatabaseRepo{deliveries: map[string]domain.WebhookDelivery{"d1": *delivery}}
+			m := Manager{db: db, client: srv.Client()}
+			hook := config.WebhookConfig{Name: "test", Url: srv.URL, MaxRetries: tt.maxRetries,
+				RetryBackoff: time.Minute}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancel {
+				cancel()
+			}
+
+			start := time.Now()
+			m.deliver(ctx, hook, delivery)
+			earliest, latest := start.Add(tt.wantNextAttempt), start.Add(tt.wantNextAttempt+time.Second)
+
+			stored, _ := db.GetWebhookDelivery(context.Background(), "d1")
+			if stored.State != tt.wantState || stored.Attempts != tt.wantAttempts {
+				t.Errorf("deliver() state = %s, attempts = %d, want %s, %d",
+					stored.State, stored.Attempts, tt.wantState, tt.wantAttempts)
+			}
+			switch {
+			case tt.wantNextAttempt == 0 && stored.NextAttempt != nil:
+				t.Errorf("deliver() scheduled a retry at %v", stored.NextAttempt)
+			case tt.wantNextAttempt != 0 && (stored.NextAttempt == nil ||
+				stored.NextAttempt.Before(earliest) || stored.NextAttempt.After(latest)):
+				t.Errorf("deliver() next attempt = %v, want in %v", stored.NextAttempt, tt.wantNextAttempt)
+			}
+		})
+	}
+}
+
+func TestManager_processJob(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	hook := config.WebhookConfig{Name: "test", Url: srv.URL, MaxRetries: 2, RetryBackoff: time.Millisecond}
+	db := &testDatabaseRepo{deliveries: map[string]domain.WebhookDelivery{
+		"d1": {Identifier: "d1", Webhook: hook.Name, State: domain.WebhookDeliveryStatePending},
+	}}
+	m := Manager{
+		db:       db,
+		client:   srv.Client(),
+		webhooks: map[string]config.WebhookConfig{hook.Name: hook},
+		queue:    make(chan deliveryJob, deliveryQueueSize),
+		inFlight: &inFlightDeliveries{ids: make(map[string]struct{})},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go m.deliveryWorker(ctx) // a single worker, so retries must not block it
+	m.queuePendingDeliveries(ctx)
+
+	deadline := time.After(5 * time.Second)
+	for {
+		stored, _ := db.GetWebhookDelivery(ctx, "d1")
+		if stored.State == domain.WebhookDeliveryStateDelivered {
+			if stored.Attempts != 3 || stored.Retries != 2 {
+				t.Errorf("attempts = %d, retries = %d, want 3, 2", stored.Attempts, stored.Retries)
+			}
+			return
+		}
+		select {
+		case <-deadline:
+			t.Fatalf("delivery not delivered, state = %s, attempts = %d", stored.State, stored.Attempts)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestManager_handleEvent(t *testing.T) {
+	hook := config.WebhookConfig{Name: "test", Url: "http://localhost", Topics: []string{"*"}}
+	db := &testDatabaseRepo{deliveries: map[string]domain.WebhookDelivery{}}
+	m := Manager{
+		db:       db,
+		webhooks: map[string]config.WebhookConfig{hook.Name: hook},
+		queue:    make(chan deliveryJob, 1),
+		inFlight: &inFlightDeliveries{ids: make(map[string]struct{})},
+	}
+
+	// no workers are running, so the second event does not fit into the queue
+	done := make(chan struct{})
+	go func() {
+		m.handleEvent("user:created", domain.User{Identifier: "alice"})
+		m.handleEvent("user:created", domain.User{Identifier: "bob"})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("handleEvent() blocked on the full queue")
+	}
+
+	pending, _ := db.GetWebhookDeliveries(context.Background(), domain.WebhookDeliveryStatePending, 0)
+	if len(pending) != 2 || len(m.queue) != 1 {
+		t.Errorf("handleEvent() stored %d pending and queued %d deliveries, want 2 and 1", len(pending), len(m.queue))
+	}
+}
+
+func TestManager_queuePendingDeliveries(t *testing.T) {
+	now := time.Now()
+	later := now.Add(time.Minute)
+	db := &testDatabaseRepo{deliveries: map[string]domain.WebhookDelivery{
+		"due": {Identifier: "due", Webhook: "test", State: domain.WebhookDeliveryStatePending,
+			CreatedAt: now.Add(-time.Minute)},
+		"scheduled": {Identifier: "scheduled", Webhook: "test", State: domain.WebhookDeliveryStatePending,
+			CreatedAt: now.Add(-time.Minute), NextAttempt: &later},
+		"queued": {Identifier: "queued", Webhook: "test", State: domain.WebhookDeliveryStatePending,
+			CreatedAt: now.Add(-time.Minute)},
+		"removed": {Identifier: "removed", Webhook: "unknown", State: domain.WebhookDeliveryStatePending,
+			CreatedAt: now.Add(-time.Minute)},
+		"dead": {Identifier: "dead", Webhook: "test", State: domain.WebhookDeliveryStateDeadLetter,
+			CreatedAt: now.Add(-time.Minute)},
+	}}
+	m := Manager{
+		db:       db,
+		webhooks: map[string]config.WebhookConfig{"test": {Name: "test"}},
+		queue:    make(chan deliveryJob, deliveryQueueSize),
+		inFlight: &inFlightDeliveries{ids: map[string]struct{}{"queued": {}}},
+	}
+
+	m.queuePendingDeliveries(context.Background())
+
+	if len(m.queue) != 1 {
+		t.Fatalf("queuePendingDeliveries() queued %d deliveries, want 1", len(m.queue))
+	}
+	if job := <-m.queue; job.delivery.Identifier != "due" {
+		t.Errorf("queuePendingDeliveries() queued %s, want due", job.delivery.Identifier)
+	}
+}
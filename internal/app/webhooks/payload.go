@@ -0,0 +1,174 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// PayloadVersion is increased whenever the structure of the payload changes in an incompatible way.
+const PayloadVersion = 1
+
+// Payload is the JSON document that gets posted to the webhook receiver.
+type Payload struct {
+	Version   int       `json:"version"`
+	Id        string    `json:"id"`
+	Event     string    `json:"event"`
+	Timestamp time.Time `json:"timestamp"`
+	Data      any       `json:"data"`
+}
+
+type UserData struct {
+	Identifier string `json:"identifier"`
+	Email      string `json:"email"`
+	Source     string `json:"source"`
+	Firstname  string `json:"firstname"`
+	Lastname   string `json:"lastname"`
+	Department string `json:"department"`
+	IsAdmin    bool   `json:"is_admin"`
+	Role       string `json:"role"`
+	Disabled   bool   `json:"disabled"`
+	Locked     bool   `json:"locked"`
+}
+
+type InterfaceData struct {
+	Identifier  string `json:"identifier"`
+	DisplayName string `json:"display_name"`
+	Type        string `json:"type"`
+	Disabled    bool   `json:"disabled"`
+}
+
+type PeerConnectionData struct {
+	Peer         *PeerData  `json:"peer"`
+	Endpoint     string     `json:"endpoint"`
+	SessionStart *time.Time `json:"session_start"`
+}
+
+type PeerData struct {
+	Identifier          string `json:"identifier"`
+	DisplayName         string `json:"display_name"`
+	InterfaceIdentifier string `json:"interface_identifier"`
+	UserIdentifier      string `json:"user_identifier"`
+	Disabled            bool   `json:"disabled"`
+}
+
+// newPayloadData converts the event bus arguments to a JSON friendly structure. Sensitive fields like passwords,
+// private keys or API tokens are never part of the payload.
+func newPayloadData(args ...any) any {
+	switch len(args) {
+	case 0:
+		return nil
+	case 1:
+		return convertEventArgument(args[0])
+	}
+
+	data := make([]any, len(args))
+	for i, arg := range args {
+		data[i] = convertEventArgument(arg)
+	}
+	return data
+}
+
+func convertEventArgument(arg any) any {
+	switch v := arg.(type) {
+	case domain.User:
+		return newUserData(&v)
+	case *domain.User:
+		return newUserData(v)
+	case domain.Interface:
+		return newInterfaceData(&v)
+	case *domain.Interface:
+		return newInterfaceData(v)
+	case domain.Peer:
+		return newPeerData(&v)
+	case *domain.Peer:
+		return newPeerData(v)
+	case domain.PeerConnectionEvent:
+		return &PeerConnectionData{Peer: newPeerData(&v.Peer), Endpoint: v.Endpoint, SessionStart: v.SessionStart}
+	case domain.UserIdentifier:
+		return string(v)
+	case domain.InterfaceIdentifier:
+		return string(v)
+	case domain.PeerIdentifier:
+		return string(v)
+	default:
+		return v
+	}
+}
+
+func newUserData(user *domain.User) *UserData {
+	if user == nil {
+		return nil
+	}
+
+	return &UserData{
+		Identifier: string(user.Identifier),
+		Email:      user.Email,
+		Source:     string(user.Source),
+		Firstname:  user.Firstname,
+		Lastname:   user.Lastname,
+		Department: user.Department,
+		IsAdmin:    user.IsAdmin,
+		Role:       string(user.EffectiveRole()),
+		Disabled:   user.IsDisabled(),
+		Locked:     user.IsLocked(),
+	}
+}
+
+func newInterfaceData(iface *domain.Interface) *InterfaceData {
+	if iface == nil {
+		return nil
+	}
+
+	return &InterfaceData{
+		Identifier:  string(iface.Identifier),
+		DisplayName: iface.DisplayName,
+		Type:        string(iface.Type),
+		Disabled:    iface.IsDisabled(),
+	}
+}
+
+func newPeerData(peer *domain.Peer) *PeerData {
+	if peer == nil {
+		return nil
+	}
+
+	return &PeerData{
+		Identifier:          string(peer.Identifier),
+		DisplayName:         peer.DisplayName,
+		InterfaceIdentifier: string(peer.InterfaceIdentifier),
+		UserIdentifier:      string(peer.UserIdentifier),
+		Disabled:            peer.IsDisabled(),
+	}
+}
+
+// topicMatches checks if the topic is covered by one of the given filters. A filter is either an exact topic,
+// "*" for all topics or a prefix ending with "*", for example "user:*".
+func topicMatches(filters []string, topic string) bool {
+	for _, filter := range filters {
+		filter = strings.TrimSpace(filter)
+		switch {
+		case filter == "*":
+			return true
+		case strings.HasSuffix(filter, "*"):
+			if strings.HasPrefix(topic, strings.TrimSuffix(filter, "*")) {
+				return true
+			}
+		case filter == topic:
+			return true
+		}
+	}
+
+	return false
+}
+
+// signPayload returns the HMAC-SHA256 signature of the body in the form "sha256=<hex>".
+func signPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,56 @@
+package webhooks
+
+import (
+	"testing"
+
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+func Test_topicMatches(t *testing.T) {
+	tests := []struct {
+		name    string
+		filters []string
+		topic   string
+		want    bool
+	}{
+		{name: "no filters", filters: nil, topic: "user:created", want: false},
+		{name: "exact match", filters: []string{"user:created"}, topic: "user:created", want: true},
+		{name: "exact mismatch", filters: []string{"user:deleted"}, topic: "user:created", want: false},
+		{name: "wildcard", filters: []string{"*"}, topic: "peer:interface:updated", want: true},
+		{name: "prefix match", filters: []string{"user:*"}, topic: "user:api:enabled", want: true},
+		{name: "prefix mismatch", filters: []string{"user:*"}, topic: "auth:login", want: false},
+		{name: "multiple filters", filters: []string{"auth:login", " peer:* "}, topic: "peer:identifier:updated", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := topicMatches(tt.filters, tt.topic); got != tt.want {
+				t.Errorf("topicMatches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_signPayload(t *testing.T) {
+	// reference value: echo -n '{"version":1}' | openssl dgst -sha256 -hmac secret
+	want := "sha256=5bf41a7738bdf2b8c02d61765ceaf8e6fb4f1f2973b7db89386a3f399fba16bf"
+	if got := signPayload("secret", []byte(`{"version":1}`)); got != want {
+		t.Errorf("signPayload() = %v, want %v", got, want)
+	}
+}
+
+func Test_newPayloadData(t *testing.T) {
+	user := domain.User{Identifier: "uid", Email: "user@example.com", Password: "secret", ApiToken: "token"}
+
+	data, ok := newPayloadData(user).(*UserData)
+	if !ok {
+		t.Fatalf("newPayloadData() returned unexpected type %T", newPayloadData(user))
+	}
+	if data.Identifier != "uid" || data.Email != "user@example.com" {
+		t.Errorf("newPayloadData() = %+v, unexpected user data", data)
+	}
+
+	multi, ok := newPayloadData(domain.PeerIdentifier("old"), domain.PeerIdentifier("new")).([]any)
+	if !ok || len(multi) != 2 || multi[0] != "old" || multi[1] != "new" {
+		t.Errorf("newPayloadData() = %v, want [old new]", multi)
+	}
+}
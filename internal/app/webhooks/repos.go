@@ -0,0 +1,18 @@
+package webhooks
+
+import (
+	"context"
+	"time"
+
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+type DatabaseRepo interface {
+	GetWebhookDelivery(ctx context.Context, id string) (*domain.WebhookDelivery, error)
+	GetWebhookDeliveries(ctx context.Context, state domain.WebhookDeliveryState, limit int) (
+		[]domain.WebhookDelivery,
+		error,
+	)
+	SaveWebhookDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error
+	DeleteWebhookDeliveries(ctx context.Context, state domain.WebhookDeliveryState, before time.Time) error
+}
@@ -84,4 +84,5 @@ type WgQuickController interface {
 type MetricsServer interface {
 	UpdateInterfaceMetrics(status domain.InterfaceStatus)
 	UpdatePeerMetrics(peer *domain.Peer, status domain.PeerStatus)
+	UpdateInterfaceConnectedPeers(id domain.InterfaceIdentifier, count int)
 }
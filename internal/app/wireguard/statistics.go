@@ -13,6 +13,12 @@ import (
 	evbus "github.com/vardius/message-bus"
 )
 
+// peerConnectivity stores the last known connection state of a peer.
+type peerConnectivity struct {
+	interfaceId domain.InterfaceIdentifier
+	connected   bool
+}
+
 type StatisticsCollector struct {
 	cfg *config.Config
 	bus evbus.MessageBus
@@ -20,6 +26,9 @@ type StatisticsCollector struct {
 	pingWaitGroup sync.WaitGroup
 	pingJobs      chan domain.Peer
 
+	connectivityMux sync.Mutex
+	connectivity    map[domain.PeerIdentifier]peerConnectivity
+
 	db StatisticsDatabaseRepo
 	wg InterfaceController
 	ms MetricsServer
@@ -36,6 +45,8 @@ func NewStatisticsCollector(
 		cfg: cfg,
 		bus: bus,
 
+		connectivity: make(map[domain.PeerIdentifier]peerConnectivity),
+
 		db: db,
 		wg: wg,
 		ms: ms,
@@ -135,8 +146,11 @@ func (c *StatisticsCollector) collectPeerData(ctx context.Context) {
 					continue
 				}
 				for _, peer := range peers {
+					update := domain.PeerStatusUpdate{InterfaceId: in.Identifier}
 					err = c.db.UpdatePeerStatus(ctx, peer.Identifier,
 						func(p *domain.PeerStatus) (*domain.PeerStatus, error) {
+							update.Previous = *p
+
 							var lastHandshake *time.Time
 							if !peer.LastHandshake.IsZero() {
 								lastHandshake = &peer.LastHandshake
@@ -151,6 +165,8 @@ func (c *StatisticsCollector) collectPeerData(ctx context.Context) {
 							p.Endpoint = peer.Endpoint
 							p.LastHandshake = lastHandshake
 
+							update.Current = *p
+
 							// Update prometheus metrics
 							go c.updatePeerMetrics(ctx, *p)
 
@@ -160,8 +176,13 @@ func (c *StatisticsCollector) collectPeerData(ctx context.Context) {
 						logrus.Warnf("failed to update peer status for %s: %v", peer.Identifier, err)
 					} else {
 						logrus.Tracef("updated peer status for %s", peer.Identifier)
+						c.bus.Publish(app.TopicPeerStatusUpdated, update)
+						c.updatePeerConnectivity(ctx, in.Identifier, update.Previous, update.Current)
 					}
 				}
+
+				c.removeStaleConnectivity(in.Identifier, peers)
+				c.ms.UpdateInterfaceConnectedPeers(in.Identifier, c.connectedPeerCount(in.Identifier))
 			}
 		}
 	}
@@ -266,8 +287,10 @@ func (c *StatisticsCollector) pingWorker(ctx context.Context) {
 		logrus.Tracef("peer %s pingable: %t", peer.Identifier, peerPingable)
 
 		now := time.Now()
+		var previous, current domain.PeerStatus
 		err := c.db.UpdatePeerStatus(ctx, peer.Identifier,
 			func(p *domain.PeerStatus) (*domain.PeerStatus, error) {
+				previous = *p
 				if peerPingable {
 					p.IsPingable = true
 					p.LastPing = &now
@@ -275,6 +298,7 @@ func (c *StatisticsCollector) pingWorker(ctx context.Context) {
 					p.IsPingable = false
 					p.LastPing = nil
 				}
+				current = *p
 
 				// Update prometheus metrics
 				go c.updatePeerMetrics(ctx, *p)
@@ -285,6 +309,7 @@ func (c *StatisticsCollector) pingWorker(ctx context.Context) {
 			logrus.Warnf("failed to update peer ping status for %s: %v", peer.Identifier, err)
 		} else {
 			logrus.Tracef("updated peer ping status for %s", peer.Identifier)
+			c.updatePeerConnectivity(ctx, peer.InterfaceIdentifier, previous, current)
 		}
 	}
 }
@@ -332,6 +357,81 @@ func (c *StatisticsCollector) updatePeerMetrics(ctx context.Context, status doma
 	c.ms.UpdatePeerMetrics(peer, status)
 }
 
+// updatePeerConnectivity compares the connection state of the peer with the last known state.
+// If the state changed, a TopicPeerConnected or TopicPeerDisconnected event is published.
+func (c *StatisticsCollector) updatePeerConnectivity(
+	ctx context.Context,
+	interfaceId domain.InterfaceIdentifier,
+	previous, current domain.PeerStatus,
+) {
+	connected := current.IsConnected()
+
+	c.connectivityMux.Lock()
+	state, known := c.connectivity[current.PeerId]
+	wasConnected := state.connected
+	if !known {
+		wasConnected = previous.IsConnected() // first check since startup, use the persisted state
+	}
+	c.connectivity[current.PeerId] = peerConnectivity{interfaceId: interfaceId, connected: connected}
+	c.connectivityMux.Unlock()
+
+	if wasConnected == connected {
+		return
+	}
+
+	peer, err := c.db.GetPeer(ctx, current.PeerId)
+	if err != nil {
+		logrus.Warnf("failed to fetch peer data for connection event %s: %v", current.PeerId, err)
+		return
+	}
+
+	event := domain.PeerConnectionEvent{
+		Peer:         *peer,
+		Endpoint:     current.Endpoint,
+		SessionStart: current.LastSessionStart,
+	}
+	if connected {
+		logrus.Debugf("peer %s connected from %s", peer.Identifier, current.Endpoint)
+		c.bus.Publish(app.TopicPeerConnected, event)
+	} else {
+		logrus.Debugf("peer %s disconnected", peer.Identifier)
+		c.bus.Publish(app.TopicPeerDisconnected, event)
+	}
+
+	c.ms.UpdateInterfaceConnectedPeers(interfaceId, c.connectedPeerCount(interfaceId))
+}
+
+// removeStaleConnectivity removes the connection state of peers that no longer exist on the given interface.
+func (c *StatisticsCollector) removeStaleConnectivity(interfaceId domain.InterfaceIdentifier, peers []domain.PhysicalPeer) {
+	existing := make(map[domain.PeerIdentifier]struct{}, len(peers))
+	for _, peer := range peers {
+		existing[peer.Identifier] = struct{}{}
+	}
+
+	c.connectivityMux.Lock()
+	defer c.connectivityMux.Unlock()
+
+	for id, state := range c.connectivity {
+		if _, ok := existing[id]; !ok && state.interfaceId == interfaceId {
+			delete(c.connectivity, id)
+		}
+	}
+}
+
+func (c *StatisticsCollector) connectedPeerCount(interfaceId domain.InterfaceIdentifier) int {
+	c.connectivityMux.Lock()
+	defer c.connectivityMux.Unlock()
+
+	count := 0
+	for _, state := range c.connectivity {
+		if state.interfaceId == interfaceId && state.connected {
+			count++
+		}
+	}
+
+	return count
+}
+
 func (c *StatisticsCollector) connectToMessageBus() {
 	_ = c.bus.Subscribe(app.TopicPeerIdentifierUpdated, c.handlePeerIdentifierChangeEvent)
 }
@@ -339,6 +439,10 @@ func (c *StatisticsCollector) connectToMessageBus() {
 func (c *StatisticsCollector) handlePeerIdentifierChangeEvent(oldIdentifier, newIdentifier domain.PeerIdentifier) {
 	ctx := domain.SetUserInfo(context.Background(), domain.SystemAdminContextUserInfo())
 
+	c.connectivityMux.Lock()
+	delete(c.connectivity, oldIdentifier)
+	c.connectivityMux.Unlock()
+
 	// remove potential left-over status data
 	err := c.db.DeletePeerStatus(ctx, oldIdentifier)
 	if err != nil {
@@ -1,11 +1,14 @@
 package wireguard
 
 import (
+	"context"
 	"reflect"
 	"testing"
 	"time"
 
+	"github.com/h44z/wg-portal/internal/app"
 	"github.com/h44z/wg-portal/internal/domain"
+	evbus "github.com/vardius/message-bus"
 )
 
 func Test_getSessionStartTime(t *testing.T) {
@@ -140,3 +143,103 @@ func Test_getSessionStartTime(t *testing.T) {
 		})
 	}
 }
+
+type testMessageBus struct {
+	evbus.MessageBus
+	topics []string
+}
+
+func (b *testMessageBus) Publish(topic string, _ ...interface{}) {
+	b.topics = append(b.topics, topic)
+}
+
+type testStatisticsDatabaseRepo struct {
+	StatisticsDatabaseRepo
+}
+
+func (r testStatisticsDatabaseRepo) GetPeer(_ context.Context, id domain.PeerIdentifier) (*domain.Peer, error) {
+	return &domain.Peer{Identifier: id, InterfaceIdentifier: "wg0"}, nil
+}
+
+type testMetricsServer struct {
+	MetricsServer
+	connectedPeers int
+}
+
+func (m *testMetricsServer) UpdateInterfaceConnectedPeers(_ domain.InterfaceIdentifier, count int) {
+	m.connectedPeers = count
+}
+
+func TestStatisticsCollector_updatePeerConnectivity(t *testing.T) {
+	now := time.Now()
+	old := now.Add(-3 * time.Minute)
+	connected := domain.PeerStatus{PeerId: "p1", LastHandshake: &now}
+	pingable := domain.PeerStatus{PeerId: "p1", IsPingable: true}
+	stale := domain.PeerStatus{PeerId: "p1", LastHandshake: &old}
+	offline := domain.PeerStatus{PeerId: "p1"}
+
+	tests := []struct {
+		name           string
+		persisted      domain.PeerStatus   // the previous status, loaded from the database
+		updates        []domain.PeerStatus // the current status of consecutive collection runs
+		wantTopics     []string
+		wantConnection int
+	}{
+		{name: "stays offline", persisted: offline, updates: []domain.PeerStatus{offline, stale}},
+		{name: "connects", persisted: offline, updates: []domain.PeerStatus{connected},
+			wantTopics: []string{app.TopicPeerConnected}, wantConnection: 1},
+		{name: "already connected at startup", persisted: connected, updates: []domain.PeerStatus{connected},
+			wantConnection: 1},
+		{name: "disconnected at startup", persisted: connected, updates: []domain.PeerStatus{stale},
+			wantTopics: []string{app.TopicPeerDisconnected}},
+		{name: "connected event is only sent once", persisted: offline,
+			updates: []domain.PeerStatus{connected, connected, pingable}, wantTopics: []string{app.TopicPeerConnected},
+			wantConnection: 1},
+		{name: "disconnects and reconnects", persisted: offline,
+			updates:        []domain.PeerStatus{connected, stale, offline, pingable},
+			wantTopics:     []string{app.TopicPeerConnected, app.TopicPeerDisconnected, app.TopicPeerConnected},
+			wantConnection: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := &testMessageBus{}
+			ms := &testMetricsServer{}
+			c := &StatisticsCollector{
+				bus:          bus,
+				connectivity: make(map[domain.PeerIdentifier]peerConnectivity),
+				db:           testStatisticsDatabaseRepo{},
+				ms:           ms,
+			}
+
+			previous := tt.persisted
+			for _, current := range tt.updates {
+				c.updatePeerConnectivity(context.Background(), "wg0", previous, current)
+				previous = current
+			}
+
+			if !reflect.DeepEqual(bus.topics, tt.wantTopics) {
+				t.Errorf("updatePeerConnectivity() published %v, want %v", bus.topics, tt.wantTopics)
+			}
+			if got := c.connectedPeerCount("wg0"); got != tt.wantConnection {
+				t.Errorf("connectedPeerCount() = %d, want %d", got, tt.wantConnection)
+			}
+		})
+	}
+}
+
+func TestStatisticsCollector_removeStaleConnectivity(t *testing.T) {
+	c := &StatisticsCollector{connectivity: map[domain.PeerIdentifier]peerConnectivity{
+		"p1": {interfaceId: "wg0", connected: true},
+		"p2": {interfaceId: "wg0", connected: true},
+		"p3": {interfaceId: "wg1", connected: true},
+	}}
+
+	c.removeStaleConnectivity("wg0", []domain.PhysicalPeer{{Identifier: "p1"}})
+
+	if _, ok := c.connectivity["p2"]; ok {
+		t.Errorf("removeStaleConnectivity() kept removed peer")
+	}
+	if len(c.connectivity) != 2 {
+		t.Errorf("removeStaleConnectivity() left %d peers, want 2", len(c.connectivity))
+	}
+}
@@ -2,6 +2,7 @@ package wireguard
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/h44z/wg-portal/internal/app"
@@ -20,6 +21,10 @@ type Manager struct {
 	db    InterfaceAndPeerDatabaseRepo
 	wg    InterfaceController
 	quick WgQuickController
+
+	// eventPeerMux serializes the event handlers that create peers automatically. Otherwise, concurrent events, like
+	// a login and a group assignment, could create duplicate peers for the same user.
+	eventPeerMux *sync.Mutex
 }
 
 func NewWireGuardManager(
@@ -35,6 +40,8 @@ func NewWireGuardManager(
 		wg:    wg,
 		db:    db,
 		quick: quick,
+
+		eventPeerMux: &sync.Mutex{},
 	}
 
 	m.connectToMessageBus()
@@ -48,10 +55,13 @@ func (m Manager) StartBackgroundJobs(ctx context.Context) {
 
 func (m Manager) connectToMessageBus() {
 	_ = m.bus.Subscribe(app.TopicUserCreated, m.handleUserCreationEvent)
+	_ = m.bus.Subscribe(app.TopicUserInvitationAccepted, m.handleUserInvitationAcceptedEvent)
+	_ = m.bus.Subscribe(app.TopicUserRegistered, m.handleUserRegisteredEvent)
 	_ = m.bus.Subscribe(app.TopicAuthLogin, m.handleUserLoginEvent)
 	_ = m.bus.Subscribe(app.TopicUserDisabled, m.handleUserDisabledEvent)
 	_ = m.bus.Subscribe(app.TopicUserEnabled, m.handleUserEnabledEvent)
 	_ = m.bus.Subscribe(app.TopicUserDeleted, m.handleUserDeletedEvent)
+	_ = m.bus.Subscribe(app.TopicUserInterfacesAssigned, m.handleUserInterfacesAssignedEvent)
 }
 
 func (m Manager) handleUserCreationEvent(user *domain.User) {
@@ -59,6 +69,9 @@ func (m Manager) handleUserCreationEvent(user *domain.User) {
 		return
 	}
 
+	m.eventPeerMux.Lock()
+	defer m.eventPeerMux.Unlock()
+
 	logrus.Tracef("handling new user event for %s", user.Identifier)
 
 	ctx := domain.SetUserInfo(context.Background(), domain.SystemAdminContextUserInfo())
@@ -69,11 +82,66 @@ func (m Manager) handleUserCreationEvent(user *domain.User) {
 	}
 }
 
+func (m Manager) handleUserInvitationAcceptedEvent(event domain.UserInvitationAccepted) {
+	if !event.CreateDefaultPeer {
+		return
+	}
+
+	m.eventPeerMux.Lock()
+	defer m.eventPeerMux.Unlock()
+
+	userId := event.User.Identifier
+	userPeers, err := m.db.GetUserPeers(context.Background(), userId)
+	if err != nil {
+		logrus.Errorf("failed to retrieve existing peers for %s prior to default peer creation: %v", userId, err)
+		return
+	}
+
+	if len(userPeers) > 0 {
+		return // user already has peers, skip creation
+	}
+
+	logrus.Tracef("handling accepted invitation for %s", userId)
+
+	ctx := domain.SetUserInfo(context.Background(), domain.SystemAdminContextUserInfo())
+	err = m.CreateDefaultPeer(ctx, userId)
+	if err != nil {
+		logrus.Errorf("failed to create default peer for %s: %v", userId, err)
+		return
+	}
+}
+
+func (m Manager) handleUserRegisteredEvent(user *domain.User) {
+	if !user.Registration.IsSelfRegistered() || len(m.cfg.Auth.SignupDefaultPeerInterfaces) == 0 {
+		return
+	}
+
+	m.eventPeerMux.Lock()
+	defer m.eventPeerMux.Unlock()
+
+	logrus.Tracef("handling completed registration for %s", user.Identifier)
+
+	interfaces := make([]domain.InterfaceIdentifier, len(m.cfg.Auth.SignupDefaultPeerInterfaces))
+	for i, iface := range m.cfg.Auth.SignupDefaultPeerInterfaces {
+		interfaces[i] = domain.InterfaceIdentifier(iface)
+	}
+
+	ctx := domain.SetUserInfo(context.Background(), domain.SystemAdminContextUserInfo())
+	err := m.CreateDefaultPeer(ctx, user.Identifier, interfaces...)
+	if err != nil {
+		logrus.Errorf("failed to create default peer for %s: %v", user.Identifier, err)
+		return
+	}
+}
+
 func (m Manager) handleUserLoginEvent(userId domain.UserIdentifier) {
 	if !m.cfg.Core.CreateDefaultPeer {
 		return
 	}
 
+	m.eventPeerMux.Lock()
+	defer m.eventPeerMux.Unlock()
+
 	userPeers, err := m.db.GetUserPeers(context.Background(), userId)
 	if err != nil {
 		logrus.Errorf("failed to retrieve existing peers for %s prior to default peer creation: %v", userId, err)
@@ -192,6 +260,121 @@ func (m Manager) handleUserDeletedEvent(user domain.User) {
 	}
 }
 
+// handleUserInterfacesAssignedEvent creates a peer on each interface the user is a member of, and re-enables peers that
+// were disabled due to a missing group membership. Peers on interfaces the user is no longer a member of are disabled
+// or deleted.
+func (m Manager) handleUserInterfacesAssignedEvent(event domain.UserInterfaceMemberships) {
+	m.eventPeerMux.Lock()
+	defer m.eventPeerMux.Unlock()
+
+	userId := event.UserIdentifier
+	ctx := domain.SetUserInfo(context.Background(), domain.SystemAdminContextUserInfo())
+	userPeers, err := m.db.GetUserPeers(ctx, userId)
+	if err != nil {
+		logrus.Errorf("failed to retrieve peers for user %s: %v", userId, err)
+		return
+	}
+
+	changes := groupMembershipChanges(event.Memberships, userPeers)
+
+	for _, iface := range changes.create {
+		logrus.Debugf("creating peer on interface %s for group member %s", iface, userId)
+
+		if err := m.CreateDefaultPeer(ctx, userId, iface); err != nil {
+			logrus.Errorf("failed to create peer on interface %s for group member %s: %v", iface, userId, err)
+		}
+	}
+
+	for _, peer := range changes.enable {
+		logrus.Debugf("enabling peer %s due to user %s joining the group", peer.Identifier, userId)
+
+		peer.Disabled = nil
+		peer.DisabledReason = ""
+
+		if _, err := m.UpdatePeer(ctx, &peer); err != nil {
+			logrus.Errorf("failed to enable peer %s for group member %s: %v", peer.Identifier, userId, err)
+		}
+	}
+
+	now := time.Now()
+	for _, peer := range changes.disable {
+		logrus.Debugf("disabling peer %s due to user %s leaving the group", peer.Identifier, userId)
+
+		peer.Disabled = &now
+		peer.DisabledReason = domain.DisabledReasonLdapGroupMissing
+
+		if _, err := m.UpdatePeer(ctx, &peer); err != nil {
+			logrus.Errorf("failed to disable peer %s of former group member %s: %v", peer.Identifier, userId, err)
+		}
+	}
+
+	for _, peer := range changes.delete {
+		logrus.Debugf("deleting peer %s due to user %s leaving the group", peer.Identifier, userId)
+
+		if err := m.DeletePeer(ctx, peer.Identifier); err != nil {
+			logrus.Errorf("failed to delete peer %s of former group member %s: %v", peer.Identifier, userId, err)
+		}
+	}
+}
+
+// peerMembershipChanges contains the peer changes that result from the interface memberships of a user.
+type peerMembershipChanges struct {
+	create  []domain.InterfaceIdentifier // interfaces without a peer of the group member
+	enable  []domain.Peer                // peers that were disabled due to a missing group membership
+	disable []domain.Peer
+	delete  []domain.Peer
+}
+
+// groupMembershipChanges determines how the peers of a user change due to the given interface memberships.
+// Peers of interfaces without a membership rule are not modified.
+func groupMembershipChanges(memberships []domain.InterfaceMembership, userPeers []domain.Peer) peerMembershipChanges {
+	// multiple groups can be mapped to the same interface, a membership in one of them is sufficient
+	var interfaces []domain.InterfaceIdentifier
+	merged := make(map[domain.InterfaceIdentifier]domain.InterfaceMembership, len(memberships))
+	for _, membership := range memberships {
+		if existing, ok := merged[membership.Interface]; ok {
+			membership.IsMember = membership.IsMember || existing.IsMember
+			membership.DeletePeers = membership.DeletePeers || existing.DeletePeers
+		} else {
+			interfaces = append(interfaces, membership.Interface)
+		}
+		merged[membership.Interface] = membership
+	}
+
+	var changes peerMembershipChanges
+	for _, iface := range interfaces {
+		membership := merged[iface]
+
+		var interfacePeers []domain.Peer
+		for _, peer := range userPeers {
+			if peer.InterfaceIdentifier == iface {
+				interfacePeers = append(interfacePeers, peer)
+			}
+		}
+
+		switch {
+		case membership.IsMember && len(interfacePeers) == 0:
+			changes.create = append(changes.create, iface)
+		case membership.IsMember:
+			for _, peer := range interfacePeers {
+				if peer.IsDisabled() && peer.DisabledReason == domain.DisabledReasonLdapGroupMissing {
+					changes.enable = append(changes.enable, peer)
+				} // otherwise, the peer is active or was disabled for another reason
+			}
+		case membership.DeletePeers:
+			changes.delete = append(changes.delete, interfacePeers...)
+		default:
+			for _, peer := range interfacePeers {
+				if !peer.IsDisabled() {
+					changes.disable = append(changes.disable, peer)
+				}
+			}
+		}
+	}
+
+	return changes
+}
+
 func (m Manager) runExpiredPeersCheck(ctx context.Context) {
 	ctx = domain.SetUserInfo(ctx, domain.SystemAdminContextUserInfo())
 
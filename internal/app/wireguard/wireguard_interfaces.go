@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"slices"
 	"time"
 
 	"github.com/h44z/wg-portal/internal"
@@ -14,7 +15,7 @@ import (
 )
 
 func (m Manager) GetImportableInterfaces(ctx context.Context) ([]domain.PhysicalInterface, error) {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	if err := domain.ValidateAdminAccessRights(ctx, domain.PermissionInterfacesWrite); err != nil {
 		return nil, err
 	}
 
@@ -31,23 +32,33 @@ func (m Manager) GetInterfaceAndPeers(ctx context.Context, id domain.InterfaceId
 	[]domain.Peer,
 	error,
 ) {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	if err := domain.ValidateInterfaceAccessRights(ctx, id, domain.PermissionInterfacesRead,
+		domain.PermissionPeersRead); err != nil {
 		return nil, nil, err
 	}
 
 	return m.db.GetInterfaceAndPeers(ctx, id)
 }
 
+// GetAllInterfaces returns all interfaces. For delegated admins, only the managed interfaces are returned.
 func (m Manager) GetAllInterfaces(ctx context.Context) ([]domain.Interface, error) {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	if err := domain.ValidateInterfaceAdminAccessRights(ctx, domain.PermissionInterfacesRead); err != nil {
 		return nil, err
 	}
 
-	return m.db.GetAllInterfaces(ctx)
+	interfaces, err := m.db.GetAllInterfaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return filterAccessibleInterfaces(ctx, interfaces, domain.PermissionInterfacesRead), nil
 }
 
+// GetAllInterfacesAndPeers returns all interfaces and their peers. For delegated admins, only the managed
+// interfaces are returned.
 func (m Manager) GetAllInterfacesAndPeers(ctx context.Context) ([]domain.Interface, [][]domain.Peer, error) {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	if err := domain.ValidateInterfaceAdminAccessRights(ctx, domain.PermissionInterfacesRead,
+		domain.PermissionPeersRead); err != nil {
 		return nil, nil, err
 	}
 
@@ -55,6 +66,8 @@ func (m Manager) GetAllInterfacesAndPeers(ctx context.Context) ([]domain.Interfa
 	if err != nil {
 		return nil, nil, fmt.Errorf("unable to load all interfaces: %w", err)
 	}
+	interfaces = filterAccessibleInterfaces(ctx, interfaces, domain.PermissionInterfacesRead,
+		domain.PermissionPeersRead)
 
 	allPeers := make([][]domain.Peer, len(interfaces))
 	for i, iface := range interfaces {
@@ -68,8 +81,19 @@ func (m Manager) GetAllInterfacesAndPeers(ctx context.Context) ([]domain.Interfa
 	return interfaces, allPeers, nil
 }
 
+// filterAccessibleInterfaces removes all interfaces that the current user cannot access with the given permissions.
+func filterAccessibleInterfaces(
+	ctx context.Context,
+	interfaces []domain.Interface,
+	permissions ...domain.Permission,
+) []domain.Interface {
+	return slices.DeleteFunc(interfaces, func(iface domain.Interface) bool {
+		return !domain.HasInterfaceAccessRights(ctx, iface.Identifier, permissions...)
+	})
+}
+
 func (m Manager) ImportNewInterfaces(ctx context.Context, filter ...domain.InterfaceIdentifier) (int, error) {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	if err := domain.ValidateAdminAccessRights(ctx, domain.PermissionInterfacesWrite); err != nil {
 		return 0, err
 	}
 
@@ -120,7 +144,7 @@ func (m Manager) ImportNewInterfaces(ctx context.Context, filter ...domain.Inter
 }
 
 func (m Manager) ApplyPeerDefaults(ctx context.Context, in *domain.Interface) error {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	if err := domain.ValidateInterfaceAccessRights(ctx, in.Identifier, domain.PermissionInterfacesWrite); err != nil {
 		return err
 	}
 
@@ -155,7 +179,7 @@ func (m Manager) RestoreInterfaceState(
 	updateDbOnError bool,
 	filter ...domain.InterfaceIdentifier,
 ) error {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	if err := domain.ValidateAdminAccessRights(ctx, domain.PermissionInterfacesWrite); err != nil {
 		return err
 	}
 
@@ -268,7 +292,7 @@ func (m Manager) RestoreInterfaceState(
 }
 
 func (m Manager) PrepareInterface(ctx context.Context) (*domain.Interface, error) {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	if err := domain.ValidateAdminAccessRights(ctx, domain.PermissionInterfacesWrite); err != nil {
 		return nil, err
 	}
 
@@ -348,7 +372,7 @@ func (m Manager) PrepareInterface(ctx context.Context) (*domain.Interface, error
 }
 
 func (m Manager) CreateInterface(ctx context.Context, in *domain.Interface) (*domain.Interface, error) {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	if err := domain.ValidateAdminAccessRights(ctx, domain.PermissionInterfacesWrite); err != nil {
 		return nil, err
 	}
 
@@ -369,11 +393,15 @@ func (m Manager) CreateInterface(ctx context.Context, in *domain.Interface) (*do
 		return nil, fmt.Errorf("creation failure: %w", err)
 	}
 
+	m.bus.Publish(app.TopicAuditEvent, domain.NewAuditEvent(ctx, domain.AuditSeverityLevelMedium, "interfaceCreated",
+		domain.AuditObjectTypeInterface, string(in.Identifier), fmt.Sprintf("interface %s created", in.Identifier)).
+		WithChanges(nil, in))
+
 	return in, nil
 }
 
 func (m Manager) UpdateInterface(ctx context.Context, in *domain.Interface) (*domain.Interface, []domain.Peer, error) {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	if err := domain.ValidateInterfaceAccessRights(ctx, in.Identifier, domain.PermissionInterfacesWrite); err != nil {
 		return nil, nil, err
 	}
 
@@ -391,11 +419,15 @@ func (m Manager) UpdateInterface(ctx context.Context, in *domain.Interface) (*do
 		return nil, nil, fmt.Errorf("update failure: %w", err)
 	}
 
+	m.bus.Publish(app.TopicAuditEvent, domain.NewAuditEvent(ctx, domain.AuditSeverityLevelMedium, "interfaceUpdated",
+		domain.AuditObjectTypeInterface, string(in.Identifier), fmt.Sprintf("interface %s updated", in.Identifier)).
+		WithChanges(existingInterface, in))
+
 	return in, existingPeers, nil
 }
 
 func (m Manager) DeleteInterface(ctx context.Context, id domain.InterfaceIdentifier) error {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	if err := domain.ValidateAdminAccessRights(ctx, domain.PermissionInterfacesWrite); err != nil {
 		return err
 	}
 
@@ -408,6 +440,8 @@ func (m Manager) DeleteInterface(ctx context.Context, id domain.InterfaceIdentif
 		return fmt.Errorf("deletion not allowed: %w", err)
 	}
 
+	deletedInterface := *existingInterface
+
 	now := time.Now()
 	existingInterface.Disabled = &now // simulate a disabled interface
 	existingInterface.DisabledReason = domain.DisabledReasonDeleted
@@ -434,13 +468,19 @@ func (m Manager) DeleteInterface(ctx context.Context, id domain.InterfaceIdentif
 		return fmt.Errorf("deletion failure: %w", err)
 	}
 
+	m.bus.Publish(app.TopicAuditEvent, domain.NewAuditEvent(ctx, domain.AuditSeverityLevelHigh, "interfaceDeleted",
+		domain.AuditObjectTypeInterface, string(id), fmt.Sprintf("interface %s deleted", id)).
+		WithChanges(&deletedInterface, nil))
+	m.bus.Publish(app.TopicInterfaceDeleted, id)
+
 	fwMark := existingInterface.FirewallMark
 	if physicalInterface != nil && fwMark == 0 {
 		fwMark = physicalInterface.FirewallMark
 	}
 	m.bus.Publish(app.TopicRouteRemove, domain.RoutingTableInfo{
-		FwMark: fwMark,
-		Table:  existingInterface.GetRoutingTable(),
+		FwMark:      fwMark,
+		Table:       existingInterface.GetRoutingTable(),
+		PolicyRules: existingInterface.PolicyRules,
 	})
 
 	if err := m.handleInterfacePostSaveHooks(true, existingInterface); err != nil {
@@ -491,8 +531,9 @@ func (m Manager) saveInterface(ctx context.Context, iface *domain.Interface) (
 			fwMark = physicalInterface.FirewallMark
 		}
 		m.bus.Publish(app.TopicRouteRemove, domain.RoutingTableInfo{
-			FwMark: fwMark,
-			Table:  iface.GetRoutingTable(),
+			FwMark:      fwMark,
+			Table:       iface.GetRoutingTable(),
+			PolicyRules: iface.PolicyRules,
 		})
 	} else {
 		m.bus.Publish(app.TopicRouteUpdate, "interface updated: "+string(iface.Identifier))
@@ -805,12 +846,18 @@ func (m Manager) deleteInterfacePeers(ctx context.Context, id domain.InterfaceId
 }
 
 func (m Manager) validateInterfaceModifications(ctx context.Context, old, new *domain.Interface) error {
-	currentUser := domain.GetUserInfo(ctx)
-
-	if !currentUser.IsAdmin {
+	if !domain.HasInterfaceAccessRights(ctx, new.Identifier, domain.PermissionInterfacesWrite) {
 		return fmt.Errorf("insufficient permissions")
 	}
 
+	peers, err := m.db.GetInterfacePeers(ctx, new.Identifier)
+	if err != nil {
+		return fmt.Errorf("unable to load peers of %s: %w", new.Identifier, err)
+	}
+	if err := new.ValidateStaticRouting(peers); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -821,7 +868,7 @@ func (m Manager) validateInterfaceCreation(ctx context.Context, old, new *domain
 		return fmt.Errorf("invalid interface identifier")
 	}
 
-	if !currentUser.IsAdmin {
+	if !currentUser.HasPermissions(domain.PermissionInterfacesWrite) {
 		return fmt.Errorf("insufficient permissions")
 	}
 
@@ -832,13 +879,17 @@ func (m Manager) validateInterfaceCreation(ctx context.Context, old, new *domain
 		}
 	}
 
+	if err := new.ValidateStaticRouting(nil); err != nil {
+		return err
+	}
+
 	return nil
 }
 
 func (m Manager) validateInterfaceDeletion(ctx context.Context, del *domain.Interface) error {
 	currentUser := domain.GetUserInfo(ctx)
 
-	if !currentUser.IsAdmin {
+	if !currentUser.HasPermissions(domain.PermissionInterfacesWrite) {
 		return fmt.Errorf("insufficient permissions")
 	}
 
@@ -4,15 +4,23 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"time"
 
+	"github.com/h44z/wg-portal/internal"
 	"github.com/h44z/wg-portal/internal/app"
 	"github.com/h44z/wg-portal/internal/domain"
 	"github.com/sirupsen/logrus"
 )
 
-func (m Manager) CreateDefaultPeer(ctx context.Context, userId domain.UserIdentifier) error {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+// CreateDefaultPeer creates a peer for the given user on each server interface. If a filter is given, only the
+// listed interfaces are considered.
+func (m Manager) CreateDefaultPeer(
+	ctx context.Context,
+	userId domain.UserIdentifier,
+	filter ...domain.InterfaceIdentifier,
+) error {
+	if err := domain.ValidateAdminAccessRights(ctx, domain.PermissionPeersWrite); err != nil {
 		return err
 	}
 
@@ -26,6 +34,9 @@ func (m Manager) CreateDefaultPeer(ctx context.Context, userId domain.UserIdenti
 		if iface.Type != domain.InterfaceTypeServer {
 			continue // only create default peers for server interfaces
 		}
+		if len(filter) != 0 && !internal.SliceContains(filter, iface.Identifier) {
+			continue // ignore filtered interface
+		}
 
 		peer, err := m.PreparePeer(ctx, iface.Identifier)
 		if err != nil {
@@ -53,16 +64,26 @@ func (m Manager) CreateDefaultPeer(ctx context.Context, userId domain.UserIdenti
 	return nil
 }
 
+// GetUserPeers returns all peers of the given user. For delegated admins, only the peers of the managed interfaces
+// are returned.
 func (m Manager) GetUserPeers(ctx context.Context, id domain.UserIdentifier) ([]domain.Peer, error) {
-	if err := domain.ValidateUserAccessRights(ctx, id); err != nil {
+	if domain.HasUserAccessRights(ctx, id, domain.PermissionPeersRead) {
+		return m.db.GetUserPeers(ctx, id)
+	}
+
+	peers, err := m.getManagedUserPeers(ctx, id, domain.PermissionPeersRead)
+	if err != nil {
 		return nil, err
 	}
+	if len(peers) == 0 {
+		return nil, domain.ValidateUserAccessRights(ctx, id, domain.PermissionPeersRead)
+	}
 
-	return m.db.GetUserPeers(ctx, id)
+	return peers, nil
 }
 
 func (m Manager) PreparePeer(ctx context.Context, id domain.InterfaceIdentifier) (*domain.Peer, error) {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	if err := domain.ValidateInterfaceAccessRights(ctx, id, domain.PermissionPeersWrite); err != nil {
 		return nil, err // TODO: self provisioning?
 	}
 
@@ -141,7 +162,7 @@ func (m Manager) GetPeer(ctx context.Context, id domain.PeerIdentifier) (*domain
 		return nil, fmt.Errorf("unable to find peer %s: %w", id, err)
 	}
 
-	if err := domain.ValidateUserAccessRights(ctx, peer.UserIdentifier); err != nil {
+	if err := domain.ValidatePeerAccessRights(ctx, peer, domain.PermissionPeersRead); err != nil {
 		return nil, err
 	}
 
@@ -149,7 +170,7 @@ func (m Manager) GetPeer(ctx context.Context, id domain.PeerIdentifier) (*domain
 }
 
 func (m Manager) CreatePeer(ctx context.Context, peer *domain.Peer) (*domain.Peer, error) {
-	if err := domain.ValidateUserAccessRights(ctx, peer.UserIdentifier); err != nil {
+	if err := domain.ValidatePeerAccessRights(ctx, peer, domain.PermissionPeersWrite); err != nil {
 		return nil, err
 	}
 
@@ -170,6 +191,10 @@ func (m Manager) CreatePeer(ctx context.Context, peer *domain.Peer) (*domain.Pee
 		return nil, fmt.Errorf("creation failure: %w", err)
 	}
 
+	m.bus.Publish(app.TopicAuditEvent, domain.NewAuditEvent(ctx, domain.AuditSeverityLevelMedium, "peerCreated",
+		domain.AuditObjectTypePeer, string(peer.Identifier), fmt.Sprintf("peer %s created", peer.Identifier)).
+		WithChanges(nil, peer))
+
 	return peer, nil
 }
 
@@ -178,7 +203,7 @@ func (m Manager) CreateMultiplePeers(
 	interfaceId domain.InterfaceIdentifier,
 	r *domain.PeerCreationRequest,
 ) ([]domain.Peer, error) {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	if err := domain.ValidateInterfaceAccessRights(ctx, interfaceId, domain.PermissionPeersWrite); err != nil {
 		return nil, err
 	}
 
@@ -210,6 +235,11 @@ func (m Manager) CreateMultiplePeers(
 	createdPeers := make([]domain.Peer, len(newPeers))
 	for i := range newPeers {
 		createdPeers[i] = *newPeers[i]
+
+		m.bus.Publish(app.TopicAuditEvent, domain.NewAuditEvent(ctx, domain.AuditSeverityLevelMedium, "peerCreated",
+			domain.AuditObjectTypePeer, string(newPeers[i].Identifier),
+			fmt.Sprintf("peer %s created", newPeers[i].Identifier)).
+			WithChanges(nil, newPeers[i]))
 	}
 
 	return createdPeers, nil
@@ -221,7 +251,7 @@ func (m Manager) UpdatePeer(ctx context.Context, peer *domain.Peer) (*domain.Pee
 		return nil, fmt.Errorf("unable to load existing peer %s: %w", peer.Identifier, err)
 	}
 
-	if err := domain.ValidateUserAccessRights(ctx, existingPeer.UserIdentifier); err != nil {
+	if err := domain.ValidatePeerAccessRights(ctx, existingPeer, domain.PermissionPeersWrite); err != nil {
 		return nil, err
 	}
 
@@ -265,6 +295,10 @@ func (m Manager) UpdatePeer(ctx context.Context, peer *domain.Peer) (*domain.Pee
 		}
 	}
 
+	m.bus.Publish(app.TopicAuditEvent, domain.NewAuditEvent(ctx, domain.AuditSeverityLevelMedium, "peerUpdated",
+		domain.AuditObjectTypePeer, string(peer.Identifier), fmt.Sprintf("peer %s updated", peer.Identifier)).
+		WithChanges(existingPeer, peer))
+
 	return peer, nil
 }
 
@@ -274,7 +308,7 @@ func (m Manager) DeletePeer(ctx context.Context, id domain.PeerIdentifier) error
 		return fmt.Errorf("unable to find peer %s: %w", id, err)
 	}
 
-	if err := domain.ValidateUserAccessRights(ctx, peer.UserIdentifier); err != nil {
+	if err := domain.ValidatePeerAccessRights(ctx, peer, domain.PermissionPeersWrite); err != nil {
 		return err
 	}
 
@@ -297,6 +331,10 @@ func (m Manager) DeletePeer(ctx context.Context, id domain.PeerIdentifier) error
 	// Update interface after peers have changed
 	m.bus.Publish(app.TopicPeerInterfaceUpdated, peer.InterfaceIdentifier)
 
+	m.bus.Publish(app.TopicAuditEvent, domain.NewAuditEvent(ctx, domain.AuditSeverityLevelHigh, "peerDeleted",
+		domain.AuditObjectTypePeer, string(id), fmt.Sprintf("peer %s deleted", id)).
+		WithChanges(peer, nil))
+
 	return nil
 }
 
@@ -308,7 +346,7 @@ func (m Manager) GetPeerStats(ctx context.Context, id domain.InterfaceIdentifier
 
 	peerIds := make([]domain.PeerIdentifier, len(peers))
 	for i, peer := range peers {
-		if err := domain.ValidateUserAccessRights(ctx, peer.UserIdentifier); err != nil {
+		if err := domain.ValidatePeerAccessRights(ctx, &peer, domain.PermissionPeersRead); err != nil {
 			return nil, err
 		}
 
@@ -319,11 +357,7 @@ func (m Manager) GetPeerStats(ctx context.Context, id domain.InterfaceIdentifier
 }
 
 func (m Manager) GetUserPeerStats(ctx context.Context, id domain.UserIdentifier) ([]domain.PeerStatus, error) {
-	if err := domain.ValidateUserAccessRights(ctx, id); err != nil {
-		return nil, err
-	}
-
-	peers, err := m.db.GetUserPeers(ctx, id)
+	peers, err := m.GetUserPeers(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch peers for user %s: %w", id, err)
 	}
@@ -338,6 +372,27 @@ func (m Manager) GetUserPeerStats(ctx context.Context, id domain.UserIdentifier)
 
 // region helper-functions
 
+// getManagedUserPeers returns the peers of the given user that belong to interfaces managed by the current user.
+func (m Manager) getManagedUserPeers(
+	ctx context.Context,
+	id domain.UserIdentifier,
+	permissions ...domain.Permission,
+) ([]domain.Peer, error) {
+	currentUser := domain.GetUserInfo(ctx)
+	if !currentUser.HasDelegatedPermissions(permissions...) {
+		return nil, nil
+	}
+
+	peers, err := m.db.GetUserPeers(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch peers for user %s: %w", id, err)
+	}
+
+	return slices.DeleteFunc(peers, func(peer domain.Peer) bool {
+		return !currentUser.ManagesInterface(peer.InterfaceIdentifier, permissions...)
+	}), nil
+}
+
 func (m Manager) savePeers(ctx context.Context, peers ...*domain.Peer) error {
 	interfaces := make(map[domain.InterfaceIdentifier]struct{})
 
@@ -436,38 +491,75 @@ func (m Manager) getFreshPeerIpConfig(ctx context.Context, iface *domain.Interfa
 }
 
 func (m Manager) validatePeerModifications(ctx context.Context, old, new *domain.Peer) error {
-	currentUser := domain.GetUserInfo(ctx)
-
-	if !currentUser.IsAdmin {
+	if !domain.HasInterfaceAccessRights(ctx, old.InterfaceIdentifier, domain.PermissionPeersWrite) ||
+		!domain.HasInterfaceAccessRights(ctx, new.InterfaceIdentifier, domain.PermissionPeersWrite) {
 		return domain.ErrNoPermission
 	}
 
+	iface, err := m.db.GetInterface(ctx, new.InterfaceIdentifier)
+	if err != nil {
+		return fmt.Errorf("invalid interface: %w", domain.ErrInvalidData)
+	}
+	if err := iface.ValidateStaticRouting([]domain.Peer{*new}); err != nil {
+		return err
+	}
+
+	if old.UserIdentifier != new.UserIdentifier {
+		if err := m.validatePeerOwner(ctx, new.UserIdentifier); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
 func (m Manager) validatePeerCreation(ctx context.Context, old, new *domain.Peer) error {
-	currentUser := domain.GetUserInfo(ctx)
-
 	if new.Identifier == "" {
 		return fmt.Errorf("invalid peer identifier: %w", domain.ErrInvalidData)
 	}
 
-	if !currentUser.IsAdmin {
+	if !domain.HasInterfaceAccessRights(ctx, new.InterfaceIdentifier, domain.PermissionPeersWrite) {
 		return domain.ErrNoPermission
 	}
 
-	_, err := m.db.GetInterface(ctx, new.InterfaceIdentifier)
+	iface, err := m.db.GetInterface(ctx, new.InterfaceIdentifier)
 	if err != nil {
 		return fmt.Errorf("invalid interface: %w", domain.ErrInvalidData)
 	}
+	if err := iface.ValidateStaticRouting([]domain.Peer{*new}); err != nil {
+		return err
+	}
+
+	if err := m.validatePeerOwner(ctx, new.UserIdentifier); err != nil {
+		return err
+	}
 
 	return nil
 }
 
-func (m Manager) validatePeerDeletion(ctx context.Context, del *domain.Peer) error {
+// validatePeerOwner ensures that delegated interface admins only assign peers to themselves or to users that
+// already own a peer of a managed interface. Otherwise, they could take over arbitrary users.
+func (m Manager) validatePeerOwner(ctx context.Context, userId domain.UserIdentifier) error {
 	currentUser := domain.GetUserInfo(ctx)
+	if userId == "" || userId == currentUser.Id || domain.HasAdminAccessRights(ctx, domain.PermissionPeersWrite) {
+		return nil
+	}
 
-	if !currentUser.IsAdmin {
+	peers, err := m.db.GetUserPeers(ctx, userId)
+	if err != nil {
+		return fmt.Errorf("unable to load peers of user %s: %w", userId, err)
+	}
+	if !slices.ContainsFunc(peers, func(peer domain.Peer) bool {
+		return currentUser.ManagesInterface(peer.InterfaceIdentifier, domain.PermissionPeersWrite)
+	}) {
+		return fmt.Errorf("user %s is not managed by %s: %w", userId, currentUser.Id, domain.ErrNoPermission)
+	}
+
+	return nil
+}
+
+func (m Manager) validatePeerDeletion(ctx context.Context, del *domain.Peer) error {
+	if !domain.HasInterfaceAccessRights(ctx, del.InterfaceIdentifier, domain.PermissionPeersWrite) {
 		return domain.ErrNoPermission
 	}
 
@@ -0,0 +1,74 @@
+package wireguard
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+type testPeerDatabaseRepo struct {
+	InterfaceAndPeerDatabaseRepo
+	peers []domain.Peer
+}
+
+func (r testPeerDatabaseRepo) GetInterface(
+	_ context.Context,
+	id domain.InterfaceIdentifier,
+) (*domain.Interface, error) {
+	return &domain.Interface{Identifier: id}, nil
+}
+
+func (r testPeerDatabaseRepo) GetUserPeers(_ context.Context, id domain.UserIdentifier) ([]domain.Peer, error) {
+	var peers []domain.Peer
+	for _, peer := range r.peers {
+		if peer.UserIdentifier == id {
+			peers = append(peers, peer)
+		}
+	}
+	return peers, nil
+}
+
+func TestManager_validatePeerOwner(t *testing.T) {
+	lead := &domain.ContextUserInfo{Id: "lead", Role: domain.RoleUser,
+		ManagedInterfaces: []domain.InterfaceIdentifier{"wg-sales"}}
+	m := Manager{db: testPeerDatabaseRepo{peers: []domain.Peer{
+		{Identifier: "p1", UserIdentifier: "bob", InterfaceIdentifier: "wg-sales"},
+		{Identifier: "p2", UserIdentifier: "carol", InterfaceIdentifier: "wg-dev"},
+	}}}
+
+	tests := []struct {
+		name    string
+		user    *domain.ContextUserInfo
+		owner   domain.UserIdentifier
+		wantErr bool
+	}{
+		{name: "no owner", user: lead, owner: ""},
+		{name: "own peer", user: lead, owner: "lead"},
+		{name: "managed user", user: lead, owner: "bob"},
+		{name: "unmanaged user", user: lead, owner: "carol", wantErr: true},
+		{name: "user without peers", user: lead, owner: "admin", wantErr: true},
+		{name: "admin", user: &domain.ContextUserInfo{Id: "admin", IsAdmin: true}, owner: "carol"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := domain.SetUserInfo(context.Background(), tt.user)
+			peer := &domain.Peer{Identifier: "new", UserIdentifier: tt.owner, InterfaceIdentifier: "wg-sales"}
+
+			err := m.validatePeerCreation(ctx, nil, peer)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validatePeerCreation() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, domain.ErrNoPermission) {
+				t.Errorf("validatePeerCreation() error = %v, want %v", err, domain.ErrNoPermission)
+			}
+
+			existing := &domain.Peer{Identifier: "new", UserIdentifier: "lead", InterfaceIdentifier: "wg-sales"}
+			err = m.validatePeerModifications(ctx, existing, peer)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validatePeerModifications() error = %v, wantErr %t", err, tt.wantErr)
+			}
+		})
+	}
+}
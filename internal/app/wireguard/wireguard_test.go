@@ -0,0 +1,85 @@
+package wireguard
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+func peerIds(peers []domain.Peer) []domain.PeerIdentifier {
+	ids := make([]domain.PeerIdentifier, len(peers))
+	for i, peer := range peers {
+		ids[i] = peer.Identifier
+	}
+	return ids
+}
+
+func Test_groupMembershipChanges(t *testing.T) {
+	now := time.Now()
+	activePeer := domain.Peer{Identifier: "active", InterfaceIdentifier: "wg-sales"}
+	groupDisabledPeer := domain.Peer{Identifier: "group-disabled", InterfaceIdentifier: "wg-sales", Disabled: &now,
+		DisabledReason: domain.DisabledReasonLdapGroupMissing}
+	adminDisabledPeer := domain.Peer{Identifier: "admin-disabled", InterfaceIdentifier: "wg-sales", Disabled: &now,
+		DisabledReason: domain.DisabledReasonAdminEdit}
+	otherPeer := domain.Peer{Identifier: "other", InterfaceIdentifier: "wg-dev"}
+
+	member := domain.InterfaceMembership{Interface: "wg-sales", IsMember: true}
+	formerMember := domain.InterfaceMembership{Interface: "wg-sales"}
+	deletingFormerMember := domain.InterfaceMembership{Interface: "wg-sales", DeletePeers: true}
+
+	tests := []struct {
+		name        string
+		memberships []domain.InterfaceMembership
+		peers       []domain.Peer
+		wantCreate  []domain.InterfaceIdentifier
+		wantEnable  []domain.PeerIdentifier
+		wantDisable []domain.PeerIdentifier
+		wantDelete  []domain.PeerIdentifier
+	}{
+		{name: "new member", memberships: []domain.InterfaceMembership{member}, peers: []domain.Peer{otherPeer},
+			wantCreate: []domain.InterfaceIdentifier{"wg-sales"}},
+		{name: "member with peers",
+			memberships: []domain.InterfaceMembership{member},
+			peers:       []domain.Peer{activePeer, groupDisabledPeer, adminDisabledPeer},
+			wantEnable:  []domain.PeerIdentifier{"group-disabled"}},
+		{name: "former member",
+			memberships: []domain.InterfaceMembership{formerMember},
+			peers:       []domain.Peer{activePeer, groupDisabledPeer, adminDisabledPeer, otherPeer},
+			wantDisable: []domain.PeerIdentifier{"active"}},
+		{name: "former member without peers", memberships: []domain.InterfaceMembership{formerMember},
+			peers: []domain.Peer{otherPeer}},
+		{name: "former member with peer deletion",
+			memberships: []domain.InterfaceMembership{deletingFormerMember},
+			peers:       []domain.Peer{activePeer, groupDisabledPeer, otherPeer},
+			wantDelete:  []domain.PeerIdentifier{"active", "group-disabled"}},
+		{name: "member of one of multiple groups",
+			memberships: []domain.InterfaceMembership{deletingFormerMember, member, formerMember},
+			peers:       []domain.Peer{activePeer}},
+		{name: "multiple interfaces",
+			memberships: []domain.InterfaceMembership{formerMember, {Interface: "wg-dev", IsMember: true},
+				{Interface: "wg-ops", IsMember: true}},
+			peers:       []domain.Peer{activePeer, otherPeer},
+			wantCreate:  []domain.InterfaceIdentifier{"wg-ops"},
+			wantDisable: []domain.PeerIdentifier{"active"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := groupMembershipChanges(tt.memberships, tt.peers)
+
+			if !slices.Equal(got.create, tt.wantCreate) {
+				t.Errorf("create = %v, want %v", got.create, tt.wantCreate)
+			}
+			if ids := peerIds(got.enable); !slices.Equal(ids, tt.wantEnable) {
+				t.Errorf("enable = %v, want %v", ids, tt.wantEnable)
+			}
+			if ids := peerIds(got.disable); !slices.Equal(ids, tt.wantDisable) {
+				t.Errorf("disable = %v, want %v", ids, tt.wantDisable)
+			}
+			if ids := peerIds(got.delete); !slices.Equal(ids, tt.wantDelete) {
+				t.Errorf("delete = %v, want %v", ids, tt.wantDelete)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package config
+
+type AuditSinkType string
+
+const (
+	AuditSinkTypeSyslog AuditSinkType = "syslog"
+	AuditSinkTypeFile   AuditSinkType = "file"
+)
+
+type AuditSinkConfig struct {
+	// Name is a unique name for the sink, it is used in log messages.
+	Name string `yaml:"name"`
+	// Type is the sink type, either syslog or file.
+	Type AuditSinkType `yaml:"type"`
+	// MinSeverity is the minimum severity (low, medium or high) of the entries that are forwarded to the sink.
+	MinSeverity string `yaml:"min_severity"`
+	// BufferSize is the number of entries that are buffered. If the buffer is full, new entries are dropped.
+	BufferSize int `yaml:"buffer_size"`
+
+	// Network is the syslog transport, either udp, tcp or tls. Keep empty to use the local syslog socket.
+	Network string `yaml:"network"`
+	// Address is the address of the remote syslog server, for example siem.example.com:6514.
+	Address string `yaml:"address"`
+	// AppName is the syslog APP-NAME field.
+	AppName string `yaml:"app_name"`
+	// Facility is the syslog facility code, defaults to 13 (log audit).
+	Facility int `yaml:"facility"`
+	// TlsSkipVerify disables the certificate validation of the remote syslog server.
+	TlsSkipVerify bool `yaml:"tls_skip_verify"`
+
+	// Path is the path of the JSON Lines file.
+	Path string `yaml:"path"`
+	// MaxSizeMB is the maximum size of the file in megabytes before it gets rotated.
+	MaxSizeMB int `yaml:"max_size_mb"`
+	// MaxBackups is the number of rotated files that are kept.
+	MaxBackups int `yaml:"max_backups"`
+}
+
+// GetBufferSize returns the configured buffer size or a default of 1000 entries.
+func (s AuditSinkConfig) GetBufferSize() int {
+	if s.BufferSize <= 0 {
+		return 1000
+	}
+	return s.BufferSize
+}
+
+// GetAppName returns the configured syslog app name or a default of wg-portal.
+func (s AuditSinkConfig) GetAppName() string {
+	if s.AppName == "" {
+		return "wg-portal"
+	}
+	return s.AppName
+}
+
+// GetFacility returns the configured syslog facility or a default of 13 (log audit).
+func (s AuditSinkConfig) GetFacility() int {
+	if s.Facility <= 0 || s.Facility > 23 {
+		return 13
+	}
+	return s.Facility
+}
+
+// GetMaxSizeMB returns the configured maximum file size or a default of 100 megabytes.
+func (s AuditSinkConfig) GetMaxSizeMB() int {
+	if s.MaxSizeMB <= 0 {
+		return 100
+	}
+	return s.MaxSizeMB
+}
+
+// GetMaxBackups returns the configured number of rotated files or a default of 5.
+func (s AuditSinkConfig) GetMaxBackups() int {
+	if s.MaxBackups <= 0 {
+		return 5
+	}
+	return s.MaxBackups
+}
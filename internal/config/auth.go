@@ -1,15 +1,106 @@
 package config
 
 import (
+	"strings"
 	"time"
 
 	"github.com/go-ldap/ldap/v3"
 )
 
+type TotpPolicy string
+
+const (
+	TotpPolicyOptional TotpPolicy = "optional" // users can enable TOTP voluntarily
+	TotpPolicyAdmins   TotpPolicy = "admins"   // TOTP is mandatory for admin users
+	TotpPolicyAll      TotpPolicy = "all"      // TOTP is mandatory for all database users
+)
+
 type Auth struct {
 	OpenIDConnect []OpenIDConnectProvider `yaml:"oidc"`
 	OAuth         []OAuthProvider         `yaml:"oauth"`
 	Ldap          []LdapProvider          `yaml:"ldap"`
+
+	// TotpPolicy defines if the TOTP second factor is mandatory for database users.
+	TotpPolicy TotpPolicy `yaml:"totp_policy"`
+
+	// WebauthnEnabled allows users to register passkeys or security keys for passwordless login or as second factor.
+	WebauthnEnabled bool `yaml:"webauthn_enabled"`
+
+	// MaxLoginAttempts is the number of failed logins after which further logins of the user from the same source IP
+	// are blocked temporarily, 0 disables the block.
+	MaxLoginAttempts int `yaml:"max_login_attempts"`
+	// LoginLockAccounts locks the user account for all source IPs instead, once MaxLoginAttempts is exceeded.
+	// This is disabled by default, as anyone who knows a user identifier could lock out the user.
+	LoginLockAccounts bool `yaml:"login_lock_accounts"`
+	// MaxLoginAttemptsPerIp is the number of failed logins after which a source IP is blocked temporarily,
+	// 0 disables the block.
+	MaxLoginAttemptsPerIp int `yaml:"max_login_attempts_per_ip"`
+	// LoginLockDuration defines how long users and source IPs stay locked. Failed attempts are forgotten after the
+	// same duration without further failures.
+	LoginLockDuration time.Duration `yaml:"login_lock_duration"`
+
+	// PasswordResetEnabled allows database users to reset a forgotten password using a link that is sent by mail.
+	PasswordResetEnabled bool `yaml:"password_reset_enabled"`
+	// PasswordResetTokenValidity defines how long a password reset link can be used.
+	PasswordResetTokenValidity time.Duration `yaml:"password_reset_validity"`
+
+	// InvitationValidity defines how long the activation link of an invited user can be used.
+	InvitationValidity time.Duration `yaml:"invitation_validity"`
+
+	// SignupEnabled allows visitors to register as database users. The email address has to be verified before the
+	// user can log in.
+	SignupEnabled bool `yaml:"signup_enabled"`
+	// SignupAllowedDomains restricts the registration to email addresses of the given domains. If empty, all domains
+	// are allowed.
+	SignupAllowedDomains []string `yaml:"signup_allowed_domains"`
+	// SignupRequireApproval requires an admin to approve each verified registration.
+	SignupRequireApproval bool `yaml:"signup_require_approval"`
+	// SignupVerificationValidity defines how long the email verification link of a new registration can be used.
+	SignupVerificationValidity time.Duration `yaml:"signup_verification_validity"`
+	// SignupDefaultPeerInterfaces contains the interfaces that get a default peer once a registration is completed.
+	SignupDefaultPeerInterfaces []string `yaml:"signup_default_peer_interfaces"`
+
+	// Roles contains custom roles in addition to the built-in admin, operator, auditor and user roles.
+	Roles []RoleConfig `yaml:"roles"`
+}
+
+// RoleConfig defines a custom role, a named set of permissions that can be assigned to users.
+type RoleConfig struct {
+	Name        string   `yaml:"name"`
+	Description string   `yaml:"description"`
+	Permissions []string `yaml:"permissions"` // for example: users:read, peers:read or peers:write
+}
+
+// IsSignupEmailAllowed returns true if the domain of the given email address is allowed to sign up.
+func (a Auth) IsSignupEmailAllowed(email string) bool {
+	_, emailDomain, found := strings.Cut(email, "@")
+	if !found || emailDomain == "" {
+		return false
+	}
+
+	if len(a.SignupAllowedDomains) == 0 {
+		return true
+	}
+
+	for _, allowedDomain := range a.SignupAllowedDomains {
+		if strings.EqualFold(strings.TrimPrefix(strings.TrimSpace(allowedDomain), "@"), emailDomain) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsTotpRequired returns true if the TOTP policy requires a second factor for the given user type.
+func (a Auth) IsTotpRequired(isAdmin bool) bool {
+	switch a.TotpPolicy {
+	case TotpPolicyAll:
+		return true
+	case TotpPolicyAdmins:
+		return isAdmin
+	default:
+		return false
+	}
 }
 
 type BaseFields struct {
@@ -23,7 +114,26 @@ type BaseFields struct {
 
 type OauthFields struct {
 	BaseFields `yaml:",inline"`
-	IsAdmin    string `yaml:"is_admin"` // If the value is "true", the user is an admin.
+	IsAdmin    string `yaml:"is_admin"`    // If the value is "true", the user is an admin.
+	UserGroups string `yaml:"user_groups"` // The claim that contains the groups of the user, a list or a comma separated string.
+}
+
+// OauthGroupMapping maps the groups of OAuth and OpenID Connect users to permissions in WireGuard Portal.
+// It is evaluated on every login.
+type OauthGroupMapping struct {
+	// AdminGroups contains groups whose members receive admin rights.
+	AdminGroups []string `yaml:"admin_groups"`
+	// Roles assigns roles based on group memberships, the first matching entry wins. Users that match no entry
+	// receive the user role. If empty, roles are not managed by the provider.
+	Roles []OauthRoleMapping `yaml:"roles"`
+	// AllowedGroups restricts the login to members of the given groups. Members of admin groups are always allowed.
+	// If empty, all users may log in.
+	AllowedGroups []string `yaml:"allowed_groups"`
+}
+
+type OauthRoleMapping struct {
+	Group string `yaml:"group"`
+	Role  string `yaml:"role"`
 }
 
 type LdapFields struct {
@@ -58,6 +168,35 @@ type LdapProvider struct {
 
 	// If RegistrationEnabled is set to true, wg-portal will create new users that do not exist in the database.
 	RegistrationEnabled bool `yaml:"registration_enabled"`
+
+	// GroupInterfaces maps LDAP groups to interfaces. The rules are evaluated during the synchronization and on login.
+	GroupInterfaces []LdapGroupInterfaceRule `yaml:"group_interfaces"`
+	// If NestedGroups is set to true, members of nested groups are considered members of the admin group and the
+	// groups of the GroupInterfaces rules. This uses LDAP_MATCHING_RULE_IN_CHAIN, which is only supported by
+	// Active Directory.
+	NestedGroups bool `yaml:"nested_groups"`
+}
+
+// LdapGroupInterfaceRule provisions a peer on the interface for each member of the group. If a user leaves the group,
+// the peers of the user on the interface are disabled, or deleted if DeletePeers is set.
+type LdapGroupInterfaceRule struct {
+	GroupDN       string   `yaml:"group"`
+	ParsedGroupDN *ldap.DN `yaml:"-"`
+	Interface     string   `yaml:"interface"`
+	DeletePeers   bool     `yaml:"delete_peers"`
+}
+
+// GroupDNs returns the admin group and the groups of all GroupInterfaces rules.
+func (l *LdapProvider) GroupDNs() []string {
+	groups := make([]string, 0, len(l.GroupInterfaces)+1)
+	if l.AdminGroupDN != "" {
+		groups = append(groups, l.AdminGroupDN)
+	}
+	for _, rule := range l.GroupInterfaces {
+		groups = append(groups, rule.GroupDN)
+	}
+
+	return groups
 }
 
 type OpenIDConnectProvider struct {
@@ -81,6 +220,12 @@ type OpenIDConnectProvider struct {
 	// FieldMap is used to map the names of the user-info endpoint fields to wg-portal fields
 	FieldMap OauthFields `yaml:"field_map"`
 
+	// GroupMapping is used to map the groups of the user to admin rights and roles
+	GroupMapping OauthGroupMapping `yaml:"group_mapping"`
+
+	// UsePkce enables the Proof Key for Code Exchange (PKCE) with the S256 challenge method for the login flow.
+	UsePkce bool `yaml:"use_pkce"`
+
 	// If RegistrationEnabled is set to true, missing users will be created in the database
 	RegistrationEnabled bool `yaml:"registration_enabled"`
 }
@@ -108,6 +253,12 @@ type OAuthProvider struct {
 	// FieldMap is used to map the names of the user-info endpoint fields to wg-portal fields
 	FieldMap OauthFields `yaml:"field_map"`
 
+	// GroupMapping is used to map the groups of the user to admin rights and roles
+	GroupMapping OauthGroupMapping `yaml:"group_mapping"`
+
+	// UsePkce enables the Proof Key for Code Exchange (PKCE) with the S256 challenge method for the login flow.
+	UsePkce bool `yaml:"use_pkce"`
+
 	// If RegistrationEnabled is set to true, wg-portal will create new users that do not exist in the database.
 	RegistrationEnabled bool `yaml:"registration_enabled"`
 }
@@ -0,0 +1,28 @@
+package config
+
+import "testing"
+
+func TestAuth_IsSignupEmailAllowed(t *testing.T) {
+	tests := []struct {
+		name    string
+		domains []string
+		email   string
+		want    bool
+	}{
+		{name: "no allow-list", email: "alice@example.com", want: true},
+		{name: "allowed domain", domains: []string{"example.com"}, email: "alice@example.com", want: true},
+		{name: "case insensitive", domains: []string{"@Example.com"}, email: "alice@EXAMPLE.COM", want: true},
+		{name: "other domain", domains: []string{"example.com"}, email: "alice@example.org", want: false},
+		{name: "subdomain", domains: []string{"example.com"}, email: "alice@mail.example.com", want: false},
+		{name: "suffix attack", domains: []string{"example.com"}, email: "alice@evilexample.com", want: false},
+		{name: "missing domain", email: "alice", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := Auth{SignupAllowedDomains: tt.domains}
+			if got := a.IsSignupEmailAllowed(tt.email); got != tt.want {
+				t.Errorf("IsSignupEmailAllowed(%q) = %t, want %t", tt.email, got, tt.want)
+			}
+		})
+	}
+}
@@ -11,6 +11,14 @@ import (
 	"gopkg.in/yaml.v2"
 )
 
+type DnsBackend string
+
+const (
+	DnsBackendAuto       DnsBackend = "auto"
+	DnsBackendResolvconf DnsBackend = "resolvconf"
+	DnsBackendResolved   DnsBackend = "systemd-resolved"
+)
+
 type Config struct {
 	Core struct {
 		// AdminUser defines the default administrator account that will be created
@@ -39,7 +47,10 @@ type Config struct {
 		ExpiryCheckInterval time.Duration `yaml:"expiry_check_interval"`
 		RulePrioOffset      int           `yaml:"rule_prio_offset"`
 		RouteTableOffset    int           `yaml:"route_table_offset"`
-		ApiAdminOnly        bool          `yaml:"api_admin_only"` // if true, only admin users can access the API
+		ApiAdminOnly        bool          `yaml:"api_admin_only"`    // if true, only admin users can access the API
+		DnsExport           bool          `yaml:"dns_export"`        // if true, peer DNS records are stored in the config storage path
+		DnsExportDomain     string        `yaml:"dns_export_domain"` // the parent domain for exported peer DNS records
+		DnsBackend          DnsBackend    `yaml:"dns_backend"`       // the backend that is used to apply interface DNS settings
 	} `yaml:"advanced"`
 
 	Statistics struct {
@@ -51,6 +62,9 @@ type Config struct {
 		CollectInterfaceData   bool          `yaml:"collect_interface_data"`
 		CollectPeerData        bool          `yaml:"collect_peer_data"`
 		CollectAuditData       bool          `yaml:"collect_audit_data"`
+		AuditRetention         time.Duration `yaml:"audit_retention"`
+		AuditArchivePath       string        `yaml:"audit_archive_path"`
+		AuditCheckpointKey     string        `yaml:"audit_checkpoint_key"`
 		ListeningAddress       string        `yaml:"listening_address"`
 	} `yaml:"statistics"`
 
@@ -61,6 +75,10 @@ type Config struct {
 	Database DatabaseConfig `yaml:"database"`
 
 	Web WebConfig `yaml:"web"`
+
+	Webhooks []WebhookConfig `yaml:"webhooks"`
+
+	AuditSinks []AuditSinkConfig `yaml:"audit_sinks"`
 }
 
 func (c *Config) LogStartupValues() {
@@ -78,15 +96,32 @@ func (c *Config) LogStartupValues() {
 	logrus.Debugf("  - CollectInterfaceData: %t", c.Statistics.CollectInterfaceData)
 	logrus.Debugf("  - CollectPeerData: %t", c.Statistics.CollectPeerData)
 	logrus.Debugf("  - CollectAuditData: %t", c.Statistics.CollectAuditData)
+	logrus.Debugf("  - AuditRetention: %s", c.Statistics.AuditRetention)
+	logrus.Debugf("  - AuditCheckpointSigning: %t", c.Statistics.AuditCheckpointKey != "")
 
 	logrus.Debug("WireGuard Portal Settings:")
 	logrus.Debugf("  - ConfigStoragePath: %s", c.Advanced.ConfigStoragePath)
+	logrus.Debugf("  - DnsExport: %t (%s)", c.Advanced.DnsExport, c.Advanced.DnsExportDomain)
+	logrus.Debugf("  - DnsBackend: %s", c.Advanced.DnsBackend)
 	logrus.Debugf("  - ExternalUrl: %s", c.Web.ExternalUrl)
+	logrus.Debugf("  - Webhooks: %d", len(c.Webhooks))
+	logrus.Debugf("  - AuditSinks: %d", len(c.AuditSinks))
 
 	logrus.Debug("WireGuard Portal Authentication:")
 	logrus.Debugf("  - OIDC Providers: %d", len(c.Auth.OpenIDConnect))
 	logrus.Debugf("  - OAuth Providers: %d", len(c.Auth.OAuth))
 	logrus.Debugf("  - Ldap Providers: %d", len(c.Auth.Ldap))
+	logrus.Debugf("  - TOTP Policy: %s", c.Auth.TotpPolicy)
+	logrus.Debugf("  - WebAuthn Enabled: %t", c.Auth.WebauthnEnabled)
+	logrus.Debugf("  - Max Login Attempts: %d (per IP: %d)", c.Auth.MaxLoginAttempts, c.Auth.MaxLoginAttemptsPerIp)
+	logrus.Debugf("  - Login Lock Accounts: %t", c.Auth.LoginLockAccounts)
+	logrus.Debugf("  - Login Lock Duration: %s", c.Auth.LoginLockDuration)
+	logrus.Debugf("  - Password Reset Enabled: %t (validity: %s)", c.Auth.PasswordResetEnabled,
+		c.Auth.PasswordResetTokenValidity)
+	logrus.Debugf("  - Invitation Validity: %s", c.Auth.InvitationValidity)
+	logrus.Debugf("  - Signup Enabled: %t (approval: %t, domains: %v)", c.Auth.SignupEnabled,
+		c.Auth.SignupRequireApproval, c.Auth.SignupAllowedDomains)
+	logrus.Debugf("  - Custom Roles: %d", len(c.Auth.Roles))
 }
 
 func defaultConfig() *Config {
@@ -128,6 +163,9 @@ func defaultConfig() *Config {
 	cfg.Advanced.RulePrioOffset = 20000
 	cfg.Advanced.RouteTableOffset = 20000
 	cfg.Advanced.ApiAdminOnly = true
+	cfg.Advanced.DnsExport = false
+	cfg.Advanced.DnsExportDomain = "wg.local"
+	cfg.Advanced.DnsBackend = DnsBackendAuto
 
 	cfg.Statistics.UsePingChecks = true
 	cfg.Statistics.PingCheckWorkers = 10
@@ -137,8 +175,23 @@ func defaultConfig() *Config {
 	cfg.Statistics.CollectInterfaceData = true
 	cfg.Statistics.CollectPeerData = true
 	cfg.Statistics.CollectAuditData = true
+	cfg.Statistics.AuditRetention = 0
+	cfg.Statistics.AuditArchivePath = ""
+	cfg.Statistics.AuditCheckpointKey = ""
 	cfg.Statistics.ListeningAddress = ":8787"
 
+	cfg.Auth.TotpPolicy = TotpPolicyOptional
+	cfg.Auth.WebauthnEnabled = false
+	cfg.Auth.MaxLoginAttempts = 10
+	cfg.Auth.MaxLoginAttemptsPerIp = 50
+	cfg.Auth.LoginLockDuration = 15 * time.Minute
+	cfg.Auth.PasswordResetEnabled = false
+	cfg.Auth.PasswordResetTokenValidity = 1 * time.Hour
+	cfg.Auth.InvitationValidity = 72 * time.Hour
+	cfg.Auth.SignupEnabled = false
+	cfg.Auth.SignupRequireApproval = false
+	cfg.Auth.SignupVerificationValidity = 24 * time.Hour
+
 	cfg.Mail = MailConfig{
 		Host:           "127.0.0.1",
 		Port:           25,
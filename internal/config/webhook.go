@@ -0,0 +1,36 @@
+package config
+
+import "time"
+
+type WebhookConfig struct {
+	// Name is a unique name for the webhook subscription.
+	Name string `yaml:"name"`
+	// Url is the endpoint that receives the POST requests.
+	Url string `yaml:"url"`
+	// Topics is a list of event topics that should be delivered. Use "*" for all topics or a prefix like "user:*".
+	Topics []string `yaml:"topics"`
+	// Secret is used to sign the payload (HMAC-SHA256). Keep empty to disable signing.
+	Secret string `yaml:"secret"`
+	// Timeout is the maximum duration of a single delivery attempt.
+	Timeout time.Duration `yaml:"timeout"`
+	// MaxRetries is the number of retries after the first failed attempt.
+	MaxRetries int `yaml:"max_retries"`
+	// RetryBackoff is the initial delay between two attempts, it doubles after each failed attempt.
+	RetryBackoff time.Duration `yaml:"retry_backoff"`
+}
+
+// GetTimeout returns the configured timeout or a default of 10 seconds.
+func (w WebhookConfig) GetTimeout() time.Duration {
+	if w.Timeout <= 0 {
+		return 10 * time.Second
+	}
+	return w.Timeout
+}
+
+// GetRetryBackoff returns the configured initial retry delay or a default of 5 seconds.
+func (w WebhookConfig) GetRetryBackoff() time.Duration {
+	if w.RetryBackoff <= 0 {
+		return 5 * time.Second
+	}
+	return w.RetryBackoff
+}
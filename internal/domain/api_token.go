@@ -0,0 +1,156 @@
+package domain
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ApiTokenPrefix is prepended to all named API tokens, so that they can be told apart from legacy user API tokens.
+const ApiTokenPrefix = "wgp_"
+
+// ApiTokenDefaultValidity is used if no expiry date is given for a new API token.
+const ApiTokenDefaultValidity = 90 * 24 * time.Hour
+
+type ApiTokenScope string
+
+const (
+	ApiTokenScopeAdmin        ApiTokenScope = "admin"        // all permissions of the token owner
+	ApiTokenScopePeersRead    ApiTokenScope = "peers:read"   // read peers
+	ApiTokenScopePeersWrite   ApiTokenScope = "peers:write"  // create, update and delete peers
+	ApiTokenScopeProvisioning ApiTokenScope = "provisioning" // use the provisioning endpoints
+	ApiTokenScopeMetricsRead  ApiTokenScope = "metrics:read" // read interface, peer and user metrics
+	ApiTokenScopeTokens       ApiTokenScope = "tokens"       // manage the API tokens of the owner
+	ApiTokenScopeSelf         ApiTokenScope = "self"         // read the user record of the token owner
+	ApiTokenScopeEventsRead   ApiTokenScope = "events:read"  // subscribe to the event stream
+
+	// The following scopes correspond to role permissions, the token owner must hold the permission as well.
+
+	ApiTokenScopeUsersRead       = ApiTokenScope(PermissionUsersRead)
+	ApiTokenScopeUsersWrite      = ApiTokenScope(PermissionUsersWrite)
+	ApiTokenScopeInterfacesRead  = ApiTokenScope(PermissionInterfacesRead)
+	ApiTokenScopeInterfacesWrite = ApiTokenScope(PermissionInterfacesWrite)
+	ApiTokenScopeAuditRead       = ApiTokenScope(PermissionAuditRead)
+	ApiTokenScopeSystemRead      = ApiTokenScope(PermissionSystemRead)
+	ApiTokenScopeSystemWrite     = ApiTokenScope(PermissionSystemWrite)
+)
+
+// ApiTokenScopes contains all scopes that can be granted to an API token.
+var ApiTokenScopes = []ApiTokenScope{
+	ApiTokenScopeAdmin,
+	ApiTokenScopePeersRead,
+	ApiTokenScopePeersWrite,
+	ApiTokenScopeProvisioning,
+	ApiTokenScopeMetricsRead,
+	ApiTokenScopeTokens,
+	ApiTokenScopeSelf,
+	ApiTokenScopeEventsRead,
+	ApiTokenScopeUsersRead,
+	ApiTokenScopeUsersWrite,
+	ApiTokenScopeInterfacesRead,
+	ApiTokenScopeInterfacesWrite,
+	ApiTokenScopeAuditRead,
+	ApiTokenScopeSystemRead,
+	ApiTokenScopeSystemWrite,
+}
+
+// ApiToken is a named, scoped and expiring token that can be used to access the REST API on behalf of a user.
+// Only a hash of the token secret is stored, the token itself is shown once after creation.
+type ApiToken struct {
+	Identifier     string          `gorm:"primaryKey;column:identifier"` // random hex identifier, also part of the token
+	UserIdentifier UserIdentifier  `gorm:"index;column:user_identifier"`
+	Name           string          // a user defined name, for example "Monitoring" or "CI"
+	TokenHash      PrivateString   `gorm:"column:token_hash"` // SHA-256 hash of the secret part of the token
+	Scopes         []ApiTokenScope `gorm:"serializer:json"`
+	CreatedAt      time.Time
+	CreatedBy      string
+	ExpiresAt      time.Time
+	LastUsed       *time.Time
+	LastUsedIp     string
+}
+
+// NewApiToken creates a new API token for the given user. The returned token string is never stored.
+func NewApiToken(
+	userId UserIdentifier,
+	name string,
+	scopes []ApiTokenScope,
+	expiresAt time.Time,
+) (*ApiToken, string, error) {
+	raw := make([]byte, 48)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	id := hex.EncodeToString(raw[:16])
+	secret := base64.RawURLEncoding.EncodeToString(raw[16:])
+
+	t := &ApiToken{
+		Identifier:     id,
+		UserIdentifier: userId,
+		Name:           name,
+		TokenHash:      PrivateString(hashUserTokenSecret(secret)),
+		Scopes:         scopes,
+		CreatedAt:      time.Now(),
+		ExpiresAt:      expiresAt,
+	}
+
+	return t, ApiTokenPrefix + t.Identifier + "." + secret, nil
+}
+
+// IsApiToken checks if the given string looks like a named API token.
+func IsApiToken(token string) bool {
+	return strings.HasPrefix(token, ApiTokenPrefix)
+}
+
+// ParseApiToken splits a token created by NewApiToken into the token identifier and the secret.
+func ParseApiToken(token string) (string, string, error) {
+	id, secret, found := strings.Cut(strings.TrimPrefix(strings.TrimSpace(token), ApiTokenPrefix), ".")
+	if !IsApiToken(token) || !found || id == "" || secret == "" {
+		return "", "", errors.New("malformed token")
+	}
+
+	return id, secret, nil
+}
+
+// Verify checks the secret of the token. It fails if the token has expired.
+func (t *ApiToken) Verify(secret string, now time.Time) error {
+	if !now.Before(t.ExpiresAt) {
+		return errors.New("token expired")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(t.TokenHash), []byte(hashUserTokenSecret(secret))) != 1 {
+		return errors.New("invalid token")
+	}
+
+	return nil
+}
+
+// IsExpired checks if the token can no longer be used.
+func (t *ApiToken) IsExpired() bool {
+	return !time.Now().Before(t.ExpiresAt)
+}
+
+// HasScope checks if the given scope has been granted to the token. The admin scope contains all other scopes.
+func (t *ApiToken) HasScope(scope ApiTokenScope) bool {
+	return slices.Contains(t.Scopes, ApiTokenScopeAdmin) || slices.Contains(t.Scopes, scope)
+}
+
+// ValidateApiTokenScopes checks that all given scopes are known and not empty.
+func ValidateApiTokenScopes(scopes []ApiTokenScope) error {
+	if len(scopes) == 0 {
+		return errors.New("no scopes given")
+	}
+
+	for _, scope := range scopes {
+		if !slices.Contains(ApiTokenScopes, scope) {
+			return fmt.Errorf("unknown scope %s", scope)
+		}
+	}
+
+	return nil
+}
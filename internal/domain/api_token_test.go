@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestApiToken_Verify(t *testing.T) {
+	now := time.Now()
+	token, plainToken, err := NewApiToken("alice", "ci", []ApiTokenScope{ApiTokenScopePeersRead}, now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("NewApiToken() error = %v", err)
+	}
+
+	id, secret, err := ParseApiToken(plainToken)
+	if err != nil || id != token.Identifier {
+		t.Fatalf("ParseApiToken() = %s, %v, want %s", id, err, token.Identifier)
+	}
+
+	tests := []struct {
+		name    string
+		secret  string
+		now     time.Time
+		wantErr bool
+	}{
+		{name: "valid", secret: secret, now: now},
+		{name: "expired", secret: secret, now: token.ExpiresAt, wantErr: true},
+		{name: "wrong secret", secret: "x" + secret, now: now, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := token.Verify(tt.secret, tt.now); (err != nil) != tt.wantErr {
+				t.Errorf("Verify() error = %v, wantErr %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestApiToken_HasScope(t *testing.T) {
+	tests := []struct {
+		name   string
+		scopes []ApiTokenScope
+		scope  ApiTokenScope
+		want   bool
+	}{
+		{name: "granted", scopes: []ApiTokenScope{ApiTokenScopePeersRead}, scope: ApiTokenScopePeersRead, want: true},
+		{name: "missing", scopes: []ApiTokenScope{ApiTokenScopePeersRead}, scope: ApiTokenScopePeersWrite},
+		{name: "admin", scopes: []ApiTokenScope{ApiTokenScopeAdmin}, scope: ApiTokenScopeMetricsRead, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := ApiToken{Scopes: tt.scopes}
+			if got := token.HasScope(tt.scope); got != tt.want {
+				t.Errorf("HasScope() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseApiToken_Malformed(t *testing.T) {
+	for _, token := range []string{"", "abc", "wgp_", "wgp_id", "wgp_.secret", "wgp_id.", "id.secret"} {
+		if _, _, err := ParseApiToken(token); err == nil {
+			t.Errorf("ParseApiToken(%q) expected error", token)
+		}
+	}
+}
@@ -1,6 +1,15 @@
 package domain
 
-import "time"
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
 
 type AuditSeverityLevel string
 
@@ -8,6 +17,27 @@ const AuditSeverityLevelLow AuditSeverityLevel = "low"
 const AuditSeverityLevelMedium AuditSeverityLevel = "medium"
 const AuditSeverityLevelHigh AuditSeverityLevel = "high"
 
+// auditSeverityRanks orders the severity levels, unknown levels have the lowest rank.
+var auditSeverityRanks = map[AuditSeverityLevel]int{
+	AuditSeverityLevelLow:    1,
+	AuditSeverityLevelMedium: 2,
+	AuditSeverityLevelHigh:   3,
+}
+
+// AtLeast returns true if the severity level is equal to or higher than the given minimum level.
+func (l AuditSeverityLevel) AtLeast(min AuditSeverityLevel) bool {
+	return auditSeverityRanks[l] >= auditSeverityRanks[min]
+}
+
+type AuditObjectType string
+
+const (
+	AuditObjectTypeUser      AuditObjectType = "user"
+	AuditObjectTypeInterface AuditObjectType = "interface"
+	AuditObjectTypePeer      AuditObjectType = "peer"
+	AuditObjectTypeSourceIp  AuditObjectType = "sourceIp"
+)
+
 type AuditEntry struct {
 	UniqueId  uint64    `gorm:"primaryKey;autoIncrement:true;column:id"`
 	CreatedAt time.Time `gorm:"column:created_at;index:idx_au_created"`
@@ -16,5 +46,335 @@ type AuditEntry struct {
 
 	Origin string `gorm:"column:origin"` // origin: for example user auth, stats, ...
 
+	Actor    UserIdentifier `gorm:"column:actor;index:idx_au_actor"` // the user that triggered the event
+	ClientIp string         `gorm:"column:client_ip"`                // the IP address of the actor, empty for system events
+
+	ObjectType AuditObjectType `gorm:"column:object_type;index:idx_au_object"` // the type of the affected object
+	ObjectId   string          `gorm:"column:object_id;index:idx_au_object"`   // the identifier of the affected object
+
 	Message string `gorm:"column:message"`
+
+	Details AuditDetails `gorm:"column:details;serializer:json"` // structured details, like a field-level diff
+
+	PrevHash string `gorm:"column:prev_hash"`              // the hash of the previous entry in the chain
+	Hash     string `gorm:"column:hash;index:idx_au_hash"` // the hash of this entry, including the previous hash
+}
+
+// CalculateHash returns the chain hash of the entry. The hash covers the content of the entry and the hash
+// of the previous entry, so that modifications or deletions break the chain.
+func (e *AuditEntry) CalculateHash() string {
+	content, _ := json.Marshal(struct {
+		PrevHash   string
+		CreatedAt  int64
+		Severity   AuditSeverityLevel
+		Origin     string
+		Actor      UserIdentifier
+		ClientIp   string
+		ObjectType AuditObjectType
+		ObjectId   string
+		Message    string
+		Details    AuditDetails
+	}{
+		PrevHash:   e.PrevHash,
+		CreatedAt:  e.CreatedAt.UnixMilli(),
+		Severity:   e.Severity,
+		Origin:     e.Origin,
+		Actor:      e.Actor,
+		ClientIp:   e.ClientIp,
+		ObjectType: e.ObjectType,
+		ObjectId:   e.ObjectId,
+		Message:    e.Message,
+		Details:    e.Details,
+	})
+
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// AuditCheckpoint stores the hash of an audit entry at a certain point in time. Checkpoints make it possible to
+// detect the deletion of the newest entries and to anchor the chain after old entries have been purged.
+type AuditCheckpoint struct {
+	Id        uint64    `gorm:"primaryKey;autoIncrement:true;column:id"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+
+	EntryId   uint64 `gorm:"column:entry_id;index:idx_au_cp_entry"`
+	EntryHash string `gorm:"column:entry_hash"`
+
+	Signature string `gorm:"column:signature"` // HMAC-SHA256 signature, empty if no signing key was configured
+}
+
+// CalculateSignature returns the HMAC-SHA256 signature of the checkpoint for the given key.
+func (c *AuditCheckpoint) CalculateSignature(key string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(fmt.Sprintf("%d:%s:%d", c.EntryId, c.EntryHash, c.CreatedAt.UnixMilli())))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature checks the signature of the checkpoint using the given key.
+func (c *AuditCheckpoint) VerifySignature(key string) bool {
+	return hmac.Equal([]byte(c.Signature), []byte(c.CalculateSignature(key)))
+}
+
+// AuditVerificationResult is the result of an audit chain verification.
+type AuditVerificationResult struct {
+	Valid bool
+
+	CheckedEntries      int
+	UnchainedEntries    int // entries that were recorded before hash chaining was enabled
+	CheckedCheckpoints  int
+	UnsignedCheckpoints int
+
+	FirstEntryId uint64
+	LastEntryId  uint64
+	Anchored     bool // true if the first chained entry starts the chain or is anchored by a checkpoint of a purged entry
+
+	BrokenEntryId uint64 // the first entry where the chain is broken
+	Reason        string
+}
+
+// Fail marks the verification result as invalid.
+func (r *AuditVerificationResult) Fail(entryId uint64, reason string) {
+	r.Valid = false
+	r.BrokenEntryId = entryId
+	r.Reason = reason
+}
+
+// AuditEntryFilter restricts the audit entries that are returned by a query. Empty fields are ignored.
+type AuditEntryFilter struct {
+	From *time.Time // only entries created at or after this time
+	To   *time.Time // only entries created before this time
+
+	Severity   AuditSeverityLevel
+	Origin     string
+	Actor      UserIdentifier
+	ObjectType AuditObjectType
+	ObjectId   string
+
+	// Cursor is the id of the last entry of the previous page. Only older entries will be returned,
+	// or newer entries if Ascending is set.
+	Cursor    uint64
+	Limit     int
+	Ascending bool // if set, the oldest entries are returned first
+}
+
+// AuditDetails contains structured information for an audit entry.
+type AuditDetails struct {
+	Changes []AuditFieldChange `json:"changes,omitempty"`
+	Info    map[string]string  `json:"info,omitempty"`
+}
+
+// AuditFieldChange describes the change of a single field. Sensitive values are masked.
+type AuditFieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// AuditEvent is published on the message bus to record a new audit entry.
+type AuditEvent struct {
+	Severity AuditSeverityLevel
+	Origin   string
+
+	Actor    UserIdentifier
+	ClientIp string
+
+	ObjectType AuditObjectType
+	ObjectId   string
+
+	Message string
+	Details AuditDetails
+}
+
+// NewAuditEvent creates a new audit event. The actor and the client IP are taken from the context user info.
+func NewAuditEvent(
+	ctx context.Context,
+	severity AuditSeverityLevel,
+	origin string,
+	objectType AuditObjectType,
+	objectId string,
+	message string,
+) AuditEvent {
+	info := GetUserInfo(ctx)
+
+	return AuditEvent{
+		Severity:   severity,
+		Origin:     origin,
+		Actor:      info.Id,
+		ClientIp:   info.ClientIp,
+		ObjectType: objectType,
+		ObjectId:   objectId,
+		Message:    message,
+	}
+}
+
+// WithChanges adds a field-level diff of the two objects to the event. One of the objects may be nil,
+// for example if the object was created or deleted.
+func (e AuditEvent) WithChanges(old, new any) AuditEvent {
+	e.Details.Changes = AuditDiff(old, new)
+	return e
+}
+
+// WithInfo adds additional key-value information to the event.
+func (e AuditEvent) WithInfo(key, value string) AuditEvent {
+	info := make(map[string]string, len(e.Details.Info)+1)
+	for k, v := range e.Details.Info {
+		info[k] = v
+	}
+	info[key] = value
+	e.Details.Info = info
+	return e
+}
+
+// auditMaskedValue replaces the value of sensitive fields in audit diffs.
+const auditMaskedValue = "********"
+
+// auditSensitiveFields contains field names whose values must never be stored in the audit log.
+var auditSensitiveFields = map[string]struct{}{
+	"PrivateKey": {},
+	"ApiToken":   {},
+	"Password":   {},
+}
+
+// auditIgnoredTypes contains types that are not part of audit diffs, as they only contain calculated values.
+var auditIgnoredTypes = map[reflect.Type]struct{}{
+	reflect.TypeOf(BaseModel{}): {},
+}
+
+// AuditDiff calculates a field-level diff of two structs of the same type. Pointers are dereferenced and nested
+// structs are flattened, the field names are joined with a dot. Sensitive fields are masked.
+func AuditDiff(old, new any) []AuditFieldChange {
+	oldVal := auditIndirect(reflect.ValueOf(old))
+	newVal := auditIndirect(reflect.ValueOf(new))
+
+	switch {
+	case !oldVal.IsValid() && !newVal.IsValid():
+		return nil
+	case !oldVal.IsValid():
+		oldVal = reflect.Zero(newVal.Type())
+	case !newVal.IsValid():
+		newVal = reflect.Zero(oldVal.Type())
+	}
+
+	if oldVal.Type() != newVal.Type() || oldVal.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var changes []AuditFieldChange
+	auditDiffStruct("", oldVal, newVal, &changes)
+	return changes
+}
+
+func auditDiffStruct(prefix string, oldVal, newVal reflect.Value, changes *[]AuditFieldChange) {
+	for i := 0; i < oldVal.NumField(); i++ {
+		field := oldVal.Type().Field(i)
+		if !field.IsExported() || field.Tag.Get("gorm") == "-" {
+			continue
+		}
+		if _, ignored := auditIgnoredTypes[field.Type]; ignored {
+			continue
+		}
+
+		name := field.Name
+		if !field.Anonymous {
+			name = prefix + field.Name
+		} else {
+			name = prefix
+		}
+
+		oldField := oldVal.Field(i)
+		newField := newVal.Field(i)
+
+		if auditIsNestedStruct(field.Type) {
+			nestedPrefix := name
+			if !field.Anonymous {
+				nestedPrefix += "."
+			}
+			auditDiffStruct(nestedPrefix, oldField, newField, changes)
+			continue
+		}
+
+		if auditIsSensitive(field) {
+			// sensitive values might hide their content in String(), so compare the raw values
+			if reflect.DeepEqual(oldField.Interface(), newField.Interface()) {
+				continue
+			}
+			*changes = append(*changes, AuditFieldChange{
+				Field: name,
+				Old:   auditMaskValue(oldField),
+				New:   auditMaskValue(newField),
+			})
+			continue
+		}
+
+		oldStr := auditFormatValue(oldField)
+		newStr := auditFormatValue(newField)
+		if oldStr == newStr {
+			continue
+		}
+
+		*changes = append(*changes, AuditFieldChange{Field: name, Old: oldStr, New: newStr})
+	}
+}
+
+func auditIsNestedStruct(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct || t == reflect.TypeOf(time.Time{}) {
+		return false
+	}
+
+	_, isStringer := reflect.Zero(t).Interface().(fmt.Stringer)
+	return !isStringer
+}
+
+func auditIsSensitive(field reflect.StructField) bool {
+	if _, sensitive := auditSensitiveFields[field.Name]; sensitive {
+		return true
+	}
+
+	switch field.Type {
+	case reflect.TypeOf(PrivateString("")), reflect.TypeOf(PreSharedKey("")):
+		return true
+	}
+
+	return false
+}
+
+func auditMaskValue(v reflect.Value) string {
+	v = auditIndirect(v)
+	if !v.IsValid() || v.IsZero() {
+		return ""
+	}
+	return auditMaskedValue
+}
+
+func auditIndirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+func auditFormatValue(v reflect.Value) string {
+	v = auditIndirect(v)
+	if !v.IsValid() {
+		return ""
+	}
+
+	switch value := v.Interface().(type) {
+	case time.Time:
+		if value.IsZero() {
+			return ""
+		}
+		return value.Format(time.RFC3339)
+	case fmt.Stringer:
+		return value.String()
+	}
+
+	if v.Kind() == reflect.Slice && v.Len() == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("%v", v.Interface())
 }
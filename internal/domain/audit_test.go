@@ -0,0 +1,126 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAuditDiff(t *testing.T) {
+	type nested struct {
+		Name       string
+		PrivateKey string
+	}
+	type object struct {
+		BaseModel
+		Name     string
+		Secret   PrivateString
+		Nested   nested
+		Internal string `gorm:"-"`
+		hidden   string
+	}
+
+	tests := []struct {
+		name string
+		old  any
+		new  any
+		want []AuditFieldChange
+	}{
+		{
+			name: "both nil",
+		},
+		{
+			name: "unchanged",
+			old:  object{Name: "a"},
+			new:  &object{Name: "a", Internal: "x", hidden: "y"},
+		},
+		{
+			name: "created",
+			new:  &object{Name: "a", Secret: "s"},
+			want: []AuditFieldChange{
+				{Field: "Name", Old: "", New: "a"},
+				{Field: "Secret", Old: "", New: auditMaskedValue},
+			},
+		},
+		{
+			name: "nested update",
+			old:  &object{Nested: nested{Name: "a", PrivateKey: "k1"}},
+			new:  &object{Nested: nested{Name: "b", PrivateKey: "k2"}},
+			want: []AuditFieldChange{
+				{Field: "Nested.Name", Old: "a", New: "b"},
+				{Field: "Nested.PrivateKey", Old: auditMaskedValue, New: auditMaskedValue},
+			},
+		},
+		{
+			name: "deleted",
+			old:  &object{Name: "a"},
+			want: []AuditFieldChange{
+				{Field: "Name", Old: "a", New: ""},
+			},
+		},
+		{
+			name: "type mismatch",
+			old:  object{Name: "a"},
+			new:  nested{Name: "b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AuditDiff(tt.old, tt.new); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AuditDiff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuditEntry_CalculateHash(t *testing.T) {
+	base := AuditEntry{
+		CreatedAt: time.Unix(1700000000, 0),
+		Severity:  AuditSeverityLevelLow,
+		Origin:    "test",
+		Message:   "message",
+		PrevHash:  "abc",
+	}
+	baseHash := base.CalculateHash()
+
+	tests := []struct {
+		name     string
+		modify   func(e *AuditEntry)
+		wantSame bool
+	}{
+		{name: "unchanged", modify: func(e *AuditEntry) {}, wantSame: true},
+		{name: "id is not hashed", modify: func(e *AuditEntry) { e.UniqueId = 5 }, wantSame: true},
+		{name: "sub-millisecond time", modify: func(e *AuditEntry) {
+			e.CreatedAt = e.CreatedAt.Add(time.Microsecond)
+		}, wantSame: true},
+		{name: "message", modify: func(e *AuditEntry) { e.Message = "other" }},
+		{name: "previous hash", modify: func(e *AuditEntry) { e.PrevHash = "abd" }},
+		{name: "details", modify: func(e *AuditEntry) { e.Details.Info = map[string]string{"a": "b"} }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := base
+			tt.modify(&entry)
+			if got := entry.CalculateHash() == baseHash; got != tt.wantSame {
+				t.Errorf("CalculateHash() unchanged = %v, want %v", got, tt.wantSame)
+			}
+		})
+	}
+}
+
+func TestAuditCheckpoint_VerifySignature(t *testing.T) {
+	checkpoint := AuditCheckpoint{CreatedAt: time.Unix(1700000000, 0), EntryId: 42, EntryHash: "abc"}
+	checkpoint.Signature = checkpoint.CalculateSignature("secret")
+
+	if !checkpoint.VerifySignature("secret") {
+		t.Errorf("VerifySignature() = false for the signing key")
+	}
+	if checkpoint.VerifySignature("other") {
+		t.Errorf("VerifySignature() = true for a different key")
+	}
+
+	checkpoint.EntryId = 43
+	if checkpoint.VerifySignature("secret") {
+		t.Errorf("VerifySignature() = true for a modified checkpoint")
+	}
+}
@@ -23,6 +23,25 @@ type AuthenticatorUserInfo struct {
 	Phone      string
 	Department string
 	IsAdmin    bool
+	Role       RoleName // empty if roles are not managed by the authentication provider
+
+	LoginDenied bool // set if the provider does not allow the user to log in, for example due to group memberships
+
+	InterfaceMemberships []InterfaceMembership // interfaces assigned through LDAP group memberships
+}
+
+// InterfaceMembership states if a user is a member of the group that is mapped to the interface.
+type InterfaceMembership struct {
+	Interface   InterfaceIdentifier
+	IsMember    bool
+	DeletePeers bool // delete the peers of non-members instead of disabling them
+}
+
+// UserInterfaceMemberships is published on the message bus once the group memberships of a user have been evaluated.
+// Members get a peer on the interface, the peers of non-members are disabled or deleted.
+type UserInterfaceMemberships struct {
+	UserIdentifier UserIdentifier
+	Memberships    []InterfaceMembership
 }
 
 type AuthenticatorType string
@@ -40,6 +59,19 @@ type OauthAuthenticator interface {
 	GetUserInfo(ctx context.Context, token *oauth2.Token, nonce string) (map[string]interface{}, error)
 	ParseUserInfo(raw map[string]interface{}) (*AuthenticatorUserInfo, error)
 	RegistrationEnabled() bool
+	PkceEnabled() bool
+	GetSession(token *oauth2.Token, raw map[string]interface{}) *OidcSession
+	EndSessionURL(session *OidcSession, postLogoutRedirectUrl string) string
+	VerifyLogoutToken(ctx context.Context, rawToken string) (*OidcSession, error)
+}
+
+// OidcSession identifies the session of a user at an OpenID Connect provider. It is used to end the session at the
+// provider on logout, and to end the portal sessions if the provider reports a logout.
+type OidcSession struct {
+	Provider  string
+	Subject   string // sub claim of the ID token
+	SessionId string // sid claim of the ID token, empty if the provider does not support session identifiers
+	IdToken   string // raw ID token, passed as hint to the end session endpoint
 }
 
 type LdapAuthenticator interface {
@@ -58,6 +58,7 @@ const (
 	DisabledReasonApiEdit          = "api edit action"
 	DisabledReasonApiCreate        = "api create action"
 	DisabledReasonLdapMissing      = "missing in ldap"
+	DisabledReasonLdapGroupMissing = "missing ldap group membership"
 	DisabledReasonUserMissing      = "missing user"
 	DisabledReasonMigrationDummy   = "migration dummy user"
 	DisabledReasonInterfaceMissing = "missing WireGuard interface"
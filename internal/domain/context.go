@@ -3,6 +3,7 @@ package domain
 import (
 	"context"
 	"fmt"
+	"slices"
 
 	"github.com/sirupsen/logrus"
 
@@ -20,18 +21,68 @@ const (
 )
 
 type ContextUserInfo struct {
-	Id      UserIdentifier
-	IsAdmin bool
+	Id                UserIdentifier
+	IsAdmin           bool
+	Role              RoleName              // the role of the user, the permissions are resolved using GetRole
+	ManagedInterfaces []InterfaceIdentifier // interfaces the user administers as delegated admin
+	ClientIp          string                // the IP address of the client, empty for system users
+}
+
+// NewContextUserInfo returns the context user info for the given user.
+func NewContextUserInfo(user *User) *ContextUserInfo {
+	return &ContextUserInfo{
+		Id:                user.Identifier,
+		IsAdmin:           user.IsAdmin,
+		Role:              user.EffectiveRole(),
+		ManagedInterfaces: user.ManagedInterfaces,
+	}
 }
 
 func (u *ContextUserInfo) String() string {
-	return fmt.Sprintf("%s|%t", u.Id, u.IsAdmin)
+	return fmt.Sprintf("%s|%t|%s", u.Id, u.IsAdmin, u.Role)
 }
 
 func (u *ContextUserInfo) UserId() string {
 	return string(u.Id)
 }
 
+// HasPermissions checks if the role of the user contains all given permissions. Admins have all permissions.
+func (u *ContextUserInfo) HasPermissions(permissions ...Permission) bool {
+	if u.IsAdmin {
+		return true
+	}
+
+	role, _ := GetRole(u.Role)
+	return role.HasPermissions(permissions...)
+}
+
+// IsInterfaceAdmin checks if the user administers at least one interface as delegated admin.
+func (u *ContextUserInfo) IsInterfaceAdmin() bool {
+	return len(u.ManagedInterfaces) > 0
+}
+
+// HasDelegatedPermissions checks if the user is a delegated interface admin and if the given permissions can be
+// delegated. The permissions are only valid for the managed interfaces.
+func (u *ContextUserInfo) HasDelegatedPermissions(permissions ...Permission) bool {
+	if !u.IsInterfaceAdmin() {
+		return false
+	}
+
+	for _, permission := range permissions {
+		if !slices.Contains(DelegatedPermissions, permission) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ManagesInterface checks if the user administers the given interface as delegated admin and if the given
+// permissions can be delegated.
+func (u *ContextUserInfo) ManagesInterface(id InterfaceIdentifier, permissions ...Permission) bool {
+	return slices.Contains(u.ManagedInterfaces, id) && u.HasDelegatedPermissions(permissions...)
+}
+
 // DefaultContextUserInfo returns a default context user info.
 func DefaultContextUserInfo() *ContextUserInfo {
 	return &ContextUserInfo{
@@ -58,6 +109,9 @@ func SetUserInfoFromGin(c *gin.Context) context.Context {
 			info = ginInfo
 		}
 	}
+	if info.ClientIp == "" {
+		info.ClientIp = c.ClientIP()
+	}
 
 	ctx := SetUserInfo(c.Request.Context(), info)
 	return ctx
@@ -83,31 +137,135 @@ func GetUserInfo(ctx context.Context) *ContextUserInfo {
 	return DefaultContextUserInfo()
 }
 
-// ValidateUserAccessRights checks if the current user has access rights to the requested user.
-// If the user is an admin, access is granted.
-func ValidateUserAccessRights(ctx context.Context, requiredUser UserIdentifier) error {
+// accessDeniedHandler is called whenever an access rights check fails.
+var accessDeniedHandler func(ctx context.Context, message string)
+
+// SetAccessDeniedHandler registers a function that is called whenever an access rights check fails,
+// for example to record permission denials in the audit log. It must be set before requests are served.
+func SetAccessDeniedHandler(handler func(ctx context.Context, message string)) {
+	accessDeniedHandler = handler
+}
+
+// NotifyAccessDenied reports a failed access rights check to the registered access denied handler.
+func NotifyAccessDenied(ctx context.Context, message string) {
+	if accessDeniedHandler != nil {
+		accessDeniedHandler(ctx, message)
+	}
+}
+
+// HasUserAccessRights checks if the current user has access rights to the requested user.
+// Access to data of other users requires the given permissions, or the admin role if no permission is given.
+// In contrast to ValidateUserAccessRights, denied access is not logged.
+func HasUserAccessRights(ctx context.Context, requiredUser UserIdentifier, permissions ...Permission) bool {
+	sessionUser := GetUserInfo(ctx)
+
+	if sessionUser.Id == requiredUser {
+		return true // User can access own data
+	}
+
+	return HasAdminAccessRights(ctx, permissions...)
+}
+
+// HasAdminAccessRights checks if the current user has the given permissions. If no permission is given,
+// the admin role is required.
+// In contrast to ValidateAdminAccessRights, denied access is not logged.
+func HasAdminAccessRights(ctx context.Context, permissions ...Permission) bool {
 	sessionUser := GetUserInfo(ctx)
 
 	if sessionUser.IsAdmin {
-		return nil // Admins can do everything
+		return true // Admins can do everything
 	}
 
-	if sessionUser.Id == requiredUser {
-		return nil // User can access own data
+	return len(permissions) > 0 && sessionUser.HasPermissions(permissions...)
+}
+
+// ValidateUserAccessRights checks if the current user has access rights to the requested user.
+// Access to data of other users requires the given permissions, or the admin role if no permission is given.
+func ValidateUserAccessRights(ctx context.Context, requiredUser UserIdentifier, permissions ...Permission) error {
+	sessionUser := GetUserInfo(ctx)
+
+	if HasUserAccessRights(ctx, requiredUser, permissions...) {
+		return nil
 	}
 
 	logrus.Warnf("insufficient permissions for %s (want %s), stack: %s", sessionUser.Id, requiredUser, GetStackTrace())
+	NotifyAccessDenied(ctx, fmt.Sprintf("access to data of user %s denied", requiredUser))
 	return ErrNoPermission
 }
 
-// ValidateAdminAccessRights checks if the current user has admin access rights.
-func ValidateAdminAccessRights(ctx context.Context) error {
+// ValidateAdminAccessRights checks if the current user has the given permissions. If no permission is given,
+// the admin role is required.
+func ValidateAdminAccessRights(ctx context.Context, permissions ...Permission) error {
 	sessionUser := GetUserInfo(ctx)
 
-	if sessionUser.IsAdmin {
+	if HasAdminAccessRights(ctx, permissions...) {
+		return nil
+	}
+
+	logrus.Warnf("insufficient admin permissions %v for %s, stack: %s", permissions, sessionUser.Id,
+		GetStackTrace())
+	if len(permissions) > 0 {
+		NotifyAccessDenied(ctx, fmt.Sprintf("permissions %v denied", permissions))
+	} else {
+		NotifyAccessDenied(ctx, "admin access denied")
+	}
+	return ErrNoPermission
+}
+
+// HasInterfaceAccessRights checks if the current user has the given permissions, either globally or as delegated
+// admin of the given interface.
+func HasInterfaceAccessRights(ctx context.Context, id InterfaceIdentifier, permissions ...Permission) bool {
+	if HasAdminAccessRights(ctx, permissions...) {
+		return true
+	}
+
+	return GetUserInfo(ctx).ManagesInterface(id, permissions...)
+}
+
+// ValidateInterfaceAccessRights checks if the current user has the given permissions for the requested interface.
+func ValidateInterfaceAccessRights(ctx context.Context, id InterfaceIdentifier, permissions ...Permission) error {
+	sessionUser := GetUserInfo(ctx)
+
+	if HasInterfaceAccessRights(ctx, id, permissions...) {
+		return nil
+	}
+
+	logrus.Warnf("insufficient permissions %v for %s (want interface %s), stack: %s", permissions, sessionUser.Id, id,
+		GetStackTrace())
+	NotifyAccessDenied(ctx, fmt.Sprintf("access to interface %s denied", id))
+	return ErrNoPermission
+}
+
+// HasPeerAccessRights checks if the current user has access rights to the given peer. Access is granted to the owner
+// of the peer, to users with the given permissions and to delegated admins of the interface of the peer.
+func HasPeerAccessRights(ctx context.Context, peer *Peer, permissions ...Permission) bool {
+	if HasUserAccessRights(ctx, peer.UserIdentifier, permissions...) {
+		return true
+	}
+
+	return GetUserInfo(ctx).ManagesInterface(peer.InterfaceIdentifier, permissions...)
+}
+
+// ValidatePeerAccessRights checks if the current user has access rights to the given peer.
+func ValidatePeerAccessRights(ctx context.Context, peer *Peer, permissions ...Permission) error {
+	sessionUser := GetUserInfo(ctx)
+
+	if HasPeerAccessRights(ctx, peer, permissions...) {
 		return nil
 	}
 
-	logrus.Warnf("insufficient admin permissions for %s, stack: %s", sessionUser.Id, GetStackTrace())
+	logrus.Warnf("insufficient permissions for %s (want peer %s), stack: %s", sessionUser.Id, peer.Identifier,
+		GetStackTrace())
+	NotifyAccessDenied(ctx, fmt.Sprintf("access to peer %s denied", peer.Identifier))
 	return ErrNoPermission
 }
+
+// ValidateInterfaceAdminAccessRights checks if the current user has the given permissions, or if the user is a
+// delegated admin of at least one interface. It is used for lists that are filtered to the managed interfaces.
+func ValidateInterfaceAdminAccessRights(ctx context.Context, permissions ...Permission) error {
+	if GetUserInfo(ctx).HasDelegatedPermissions(permissions...) {
+		return nil
+	}
+
+	return ValidateAdminAccessRights(ctx, permissions...)
+}
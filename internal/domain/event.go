@@ -0,0 +1,27 @@
+package domain
+
+import "time"
+
+type StreamEventType string
+
+const (
+	StreamEventPeerConnected    StreamEventType = "peer:connected"
+	StreamEventPeerDisconnected StreamEventType = "peer:disconnected"
+	StreamEventPeerHandshake    StreamEventType = "peer:handshake"
+	StreamEventPeerTraffic      StreamEventType = "peer:traffic"
+)
+
+// StreamEvent is a single event of the live event stream.
+type StreamEvent struct {
+	Id   string          // a unique, ordered identifier that can be used to resume the stream
+	Type StreamEventType // the event type, either one of the StreamEvent constants or a message bus topic
+	Time time.Time
+
+	// UserIdentifier is the owner of the event. If it is empty, only admins will receive the event.
+	UserIdentifier UserIdentifier
+	// InterfaceIdentifier is the interface the event belongs to. If it is set, delegated admins of the interface
+	// receive the event as well.
+	InterfaceIdentifier InterfaceIdentifier
+
+	Data any // the event data, for example a PeerStatusUpdate, a User or an identifier
+}
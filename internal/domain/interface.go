@@ -38,6 +38,9 @@ type Interface struct {
 	FirewallMark uint32 // a firewall mark
 	RoutingTable string // the routing table number or "off" if the routing table should not be managed
 
+	StaticRoutes []InterfaceStaticRoute `gorm:"serializer:json"` // additional routes that are installed for the interface
+	PolicyRules  []InterfacePolicyRule  `gorm:"serializer:json"` // additional source based routing rules
+
 	PreUp    string // action that is executed before the device is up
 	PostUp   string // action that is executed after the device is up
 	PreDown  string // action that is executed before the device is down
@@ -224,8 +227,9 @@ func MergeToPhysicalInterface(pi *PhysicalInterface, i *Interface) {
 }
 
 type RoutingTableInfo struct {
-	FwMark uint32
-	Table  int
+	FwMark      uint32
+	Table       int
+	PolicyRules []InterfacePolicyRule // the source based policy rules of the interface
 }
 
 func (r RoutingTableInfo) String() string {
@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// UserInvitation contains the pending invitation of a database user. Invited users can not log in until they
+// accepted the invitation and chose a password.
+type UserInvitation struct {
+	InvitedAt   *time.Time    `gorm:"column:invited_at"`              // if this field is set, the invitation is pending
+	TokenHash   PrivateString `gorm:"column:invitation_token"`        // SHA-256 hash of the secret part of the token
+	ExpiresAt   *time.Time    `gorm:"column:invitation_expires"`      // the invitation can not be accepted after this time
+	DefaultPeer bool          `gorm:"column:invitation_default_peer"` // create a default peer once the invitation is accepted
+}
+
+// IsPending returns true if the invitation has not been accepted yet.
+func (i *UserInvitation) IsPending() bool {
+	return i.InvitedAt != nil
+}
+
+// IsExpired returns true if a pending invitation can no longer be accepted.
+func (i *UserInvitation) IsExpired(now time.Time) bool {
+	return i.IsPending() && (i.ExpiresAt == nil || !now.Before(*i.ExpiresAt))
+}
+
+// Verify checks the secret of an invitation token. It fails if no invitation is pending or the token has expired.
+func (i *UserInvitation) Verify(secret string, now time.Time) error {
+	if !i.IsPending() {
+		return fmt.Errorf("invitation: not pending")
+	}
+	if err := verifyUserToken(i.TokenHash, i.ExpiresAt, secret, now); err != nil {
+		return fmt.Errorf("invitation: %w", err)
+	}
+
+	return nil
+}
+
+// Reset removes the invitation, it is used once the invitation has been accepted.
+func (i *UserInvitation) Reset() {
+	i.InvitedAt = nil
+	i.TokenHash = ""
+	i.ExpiresAt = nil
+	i.DefaultPeer = false
+}
+
+// UserInvitationRequest is published on the message bus if a user has been invited or the invitation is resent.
+// The Token is only available in this event, the database only stores a hash of it.
+type UserInvitationRequest struct {
+	User      User
+	Token     string
+	ExpiresAt time.Time
+}
+
+// UserInvitationAccepted is published on the message bus once an invited user has chosen a password.
+type UserInvitationAccepted struct {
+	User              User
+	CreateDefaultPeer bool
+}
@@ -0,0 +1,20 @@
+package domain
+
+import "time"
+
+type LoginLockType string
+
+const (
+	LoginLockTypeUser     LoginLockType = "user"
+	LoginLockTypeSourceIp LoginLockType = "ip"
+)
+
+// LoginLock describes a user or source IP that is temporarily blocked because of too many failed login attempts.
+type LoginLock struct {
+	Type           LoginLockType
+	Identifier     string // the user identifier or the source IP address
+	SourceIp       string // set for user locks that only block logins from this source IP
+	Reason         string
+	LockedUntil    time.Time
+	FailedAttempts int
+}
@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// UserPasswordReset contains the pending self-service password reset of a user.
+type UserPasswordReset struct {
+	TokenHash PrivateString `gorm:"column:password_reset_token"`   // SHA-256 hash of the secret part of the reset token
+	ExpiresAt *time.Time    `gorm:"column:password_reset_expires"` // the reset token can not be used after this time
+}
+
+// Verify checks the secret of a reset token. It fails if no reset is pending or the token has expired.
+func (r *UserPasswordReset) Verify(secret string, now time.Time) error {
+	if err := verifyUserToken(r.TokenHash, r.ExpiresAt, secret, now); err != nil {
+		return fmt.Errorf("password reset: %w", err)
+	}
+
+	return nil
+}
+
+// Reset removes the pending password reset.
+func (r *UserPasswordReset) Reset() {
+	r.TokenHash = ""
+	r.ExpiresAt = nil
+}
+
+// PasswordResetRequest is published on the message bus if a user requested a password reset.
+// The Token is only available in this event, the database only stores a hash of it.
+type PasswordResetRequest struct {
+	User      User
+	Token     string
+	ExpiresAt time.Time
+}
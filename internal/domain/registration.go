@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// UserRegistration contains the state of a self-registered database user. The user stays disabled until the email
+// address has been verified and, if required, an admin approved the registration.
+type UserRegistration struct {
+	RegisteredAt    *time.Time    `gorm:"column:registered_at"`                 // set for self-registered users
+	TokenHash       PrivateString `gorm:"column:registration_token"`            // SHA-256 hash of the verification token
+	ExpiresAt       *time.Time    `gorm:"column:registration_expires"`          // the token can not be used after this time
+	Verified        *time.Time    `gorm:"column:registration_verified"`         // set once the email address is verified
+	ApprovalPending bool          `gorm:"column:registration_approval_pending"` // an admin has to approve the registration
+}
+
+// IsSelfRegistered returns true if the user signed up using the public registration.
+func (r *UserRegistration) IsSelfRegistered() bool {
+	return r.RegisteredAt != nil
+}
+
+// IsVerificationPending returns true if the email address of a self-registered user has not been verified yet.
+func (r *UserRegistration) IsVerificationPending() bool {
+	return r.RegisteredAt != nil && r.Verified == nil
+}
+
+// State returns the pending registration step, or an empty string if the registration is completed.
+func (r *UserRegistration) State() string {
+	switch {
+	case r.IsVerificationPending():
+		return "verification"
+	case r.ApprovalPending:
+		return "approval"
+	default:
+		return ""
+	}
+}
+
+// Verify checks the secret of a verification token. It fails if no verification is pending or the token has expired.
+func (r *UserRegistration) Verify(secret string, now time.Time) error {
+	if !r.IsVerificationPending() {
+		return fmt.Errorf("registration: no verification pending")
+	}
+	if err := verifyUserToken(r.TokenHash, r.ExpiresAt, secret, now); err != nil {
+		return fmt.Errorf("registration: %w", err)
+	}
+
+	return nil
+}
+
+// RegistrationVerificationRequest is published on the message bus if a user signed up and has to verify the email
+// address. The Token is only available in this event, the database only stores a hash of it.
+type RegistrationVerificationRequest struct {
+	User      User
+	Token     string
+	ExpiresAt time.Time
+}
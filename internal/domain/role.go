@@ -0,0 +1,182 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// Permission grants access to data of other users or to system wide functionality.
+// Users can always access their own data, regardless of their role.
+type Permission string
+
+const (
+	PermissionUsersRead       Permission = "users:read"       // list and read all users
+	PermissionUsersWrite      Permission = "users:write"      // create, update, delete, invite and unlock users
+	PermissionInterfacesRead  Permission = "interfaces:read"  // list and read all interfaces
+	PermissionInterfacesWrite Permission = "interfaces:write" // create, update and delete interfaces
+	PermissionPeersRead       Permission = "peers:read"       // list and read all peers
+	PermissionPeersWrite      Permission = "peers:write"      // create, update and delete peers of all users
+	PermissionAuditRead       Permission = "audit:read"       // read, export and verify the audit log
+	PermissionSystemRead      Permission = "system:read"      // read routing, DNS and webhook state
+	PermissionSystemWrite     Permission = "system:write"     // replay webhooks and change system state
+)
+
+// Permissions contains all known permissions.
+var Permissions = []Permission{
+	PermissionUsersRead,
+	PermissionUsersWrite,
+	PermissionInterfacesRead,
+	PermissionInterfacesWrite,
+	PermissionPeersRead,
+	PermissionPeersWrite,
+	PermissionAuditRead,
+	PermissionSystemRead,
+	PermissionSystemWrite,
+}
+
+// DelegatedPermissions contains the permissions that delegated interface admins hold. They are limited to the
+// managed interfaces, their peers and the users that own those peers.
+var DelegatedPermissions = []Permission{
+	PermissionUsersRead,
+	PermissionUsersWrite,
+	PermissionInterfacesRead,
+	PermissionInterfacesWrite,
+	PermissionPeersRead,
+	PermissionPeersWrite,
+}
+
+type RoleName string
+
+const (
+	RoleAdmin    RoleName = "admin"    // full access, equivalent to the admin flag of a user
+	RoleOperator RoleName = "operator" // manage the peers of all users
+	RoleAuditor  RoleName = "auditor"  // read-only access to everything
+	RoleUser     RoleName = "user"     // access to the own data only
+)
+
+// Role is a named set of permissions that can be assigned to users.
+type Role struct {
+	Name        RoleName
+	Description string
+	Permissions []Permission
+	BuiltIn     bool
+}
+
+// HasPermissions checks if all given permissions are part of the role.
+func (r Role) HasPermissions(permissions ...Permission) bool {
+	for _, permission := range permissions {
+		if !slices.Contains(r.Permissions, permission) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// IsPrivileged checks if the role grants access to data of other users.
+func (r Role) IsPrivileged() bool {
+	return len(r.Permissions) > 0
+}
+
+var builtInRoles = []Role{
+	{
+		Name:        RoleAdmin,
+		Description: "Full access",
+		Permissions: Permissions,
+		BuiltIn:     true,
+	},
+	{
+		Name:        RoleOperator,
+		Description: "Manage the peers of all users",
+		Permissions: []Permission{
+			PermissionUsersRead,
+			PermissionInterfacesRead,
+			PermissionPeersRead,
+			PermissionPeersWrite,
+		},
+		BuiltIn: true,
+	},
+	{
+		Name:        RoleAuditor,
+		Description: "Read-only access to everything",
+		Permissions: []Permission{
+			PermissionUsersRead,
+			PermissionInterfacesRead,
+			PermissionPeersRead,
+			PermissionAuditRead,
+			PermissionSystemRead,
+		},
+		BuiltIn: true,
+	},
+	{
+		Name:        RoleUser,
+		Description: "Access to the own data only",
+		Permissions: []Permission{},
+		BuiltIn:     true,
+	},
+}
+
+var (
+	customRolesMutex sync.RWMutex
+	customRoles      []Role
+)
+
+// SetCustomRoles registers additional roles, for example from the configuration file.
+// Custom roles must not override the built-in roles and may only contain known permissions.
+// It must be called before requests are served.
+func SetCustomRoles(roles []Role) error {
+	names := make(map[RoleName]struct{}, len(roles))
+	for i, role := range roles {
+		if role.Name == "" {
+			return errors.New("missing role name")
+		}
+		if _, ok := names[role.Name]; ok || isBuiltInRole(role.Name) {
+			return fmt.Errorf("duplicate role %s", role.Name)
+		}
+		names[role.Name] = struct{}{}
+
+		for _, permission := range role.Permissions {
+			if !slices.Contains(Permissions, permission) {
+				return fmt.Errorf("unknown permission %s in role %s", permission, role.Name)
+			}
+		}
+		roles[i].BuiltIn = false
+	}
+
+	customRolesMutex.Lock()
+	defer customRolesMutex.Unlock()
+	customRoles = roles
+
+	return nil
+}
+
+// GetRoles returns all built-in and custom roles.
+func GetRoles() []Role {
+	customRolesMutex.RLock()
+	defer customRolesMutex.RUnlock()
+
+	return append(slices.Clone(builtInRoles), customRoles...)
+}
+
+// GetRole returns the role with the given name. Unknown roles have no permissions.
+func GetRole(name RoleName) (Role, bool) {
+	for _, role := range GetRoles() {
+		if role.Name == name {
+			return role, true
+		}
+	}
+
+	return Role{Name: name, Permissions: []Permission{}}, false
+}
+
+// IsValidRole checks if a role with the given name exists.
+func IsValidRole(name RoleName) bool {
+	_, ok := GetRole(name)
+	return ok
+}
+
+func isBuiltInRole(name RoleName) bool {
+	return slices.ContainsFunc(builtInRoles, func(r Role) bool { return r.Name == name })
+}
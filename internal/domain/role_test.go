@@ -0,0 +1,107 @@
+package domain
+
+import (
+	"context"
+	"testing"
+)
+
+func TestHasAdminAccessRights(t *testing.T) {
+	tests := []struct {
+		name        string
+		user        ContextUserInfo
+		permissions []Permission
+		want        bool
+	}{
+		{name: "admin", user: ContextUserInfo{Id: "a", IsAdmin: true, Role: RoleAdmin}, want: true},
+		{name: "operator without permission", user: ContextUserInfo{Id: "o", Role: RoleOperator}, want: false},
+		{name: "operator peers", user: ContextUserInfo{Id: "o", Role: RoleOperator},
+			permissions: []Permission{PermissionPeersRead, PermissionPeersWrite}, want: true},
+		{name: "operator interfaces", user: ContextUserInfo{Id: "o", Role: RoleOperator},
+			permissions: []Permission{PermissionInterfacesWrite}, want: false},
+		{name: "auditor read", user: ContextUserInfo{Id: "r", Role: RoleAuditor},
+			permissions: []Permission{PermissionAuditRead}, want: true},
+		{name: "auditor write", user: ContextUserInfo{Id: "r", Role: RoleAuditor},
+			permissions: []Permission{PermissionPeersWrite}, want: false},
+		{name: "user", user: ContextUserInfo{Id: "u", Role: RoleUser},
+			permissions: []Permission{PermissionPeersRead}, want: false},
+		{name: "unknown role", user: ContextUserInfo{Id: "u", Role: "unknown"},
+			permissions: []Permission{PermissionPeersRead}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := SetUserInfo(context.Background(), &tt.user)
+			if got := HasAdminAccessRights(ctx, tt.permissions...); got != tt.want {
+				t.Errorf("HasAdminAccessRights() = %t, want %t", got, tt.want)
+			}
+			if !HasUserAccessRights(ctx, tt.user.Id, tt.permissions...) {
+				t.Errorf("HasUserAccessRights() denied access to own data")
+			}
+		})
+	}
+}
+
+func TestSetCustomRoles(t *testing.T) {
+	t.Cleanup(func() { _ = SetCustomRoles(nil) })
+
+	tests := []struct {
+		name    string
+		roles   []Role
+		wantErr bool
+	}{
+		{name: "valid", roles: []Role{{Name: "helpdesk", Permissions: []Permission{PermissionUsersRead}}}},
+		{name: "missing name", roles: []Role{{Permissions: []Permission{PermissionUsersRead}}}, wantErr: true},
+		{name: "built-in name", roles: []Role{{Name: RoleOperator}}, wantErr: true},
+		{name: "duplicate", roles: []Role{{Name: "helpdesk"}, {Name: "helpdesk"}}, wantErr: true},
+		{name: "unknown permission", roles: []Role{{Name: "helpdesk", Permissions: []Permission{"peers:*"}}},
+			wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := SetCustomRoles(tt.roles); (err != nil) != tt.wantErr {
+				t.Errorf("SetCustomRoles() error = %v, wantErr %t", err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := SetCustomRoles([]Role{{Name: "helpdesk", Permissions: []Permission{PermissionUsersRead}}}); err != nil {
+		t.Fatalf("SetCustomRoles() error = %v", err)
+	}
+	role, ok := GetRole("helpdesk")
+	if !ok || role.BuiltIn || !role.HasPermissions(PermissionUsersRead) || role.HasPermissions(PermissionUsersWrite) {
+		t.Errorf("GetRole() = %+v, %t", role, ok)
+	}
+}
+
+func TestHasPeerAccessRights(t *testing.T) {
+	delegated := &ContextUserInfo{Id: "lead", Role: RoleUser, ManagedInterfaces: []InterfaceIdentifier{"wg-sales"}}
+	salesPeer := &Peer{Identifier: "p1", UserIdentifier: "bob", InterfaceIdentifier: "wg-sales"}
+	otherPeer := &Peer{Identifier: "p2", UserIdentifier: "bob", InterfaceIdentifier: "wg-dev"}
+	ownPeer := &Peer{Identifier: "p3", UserIdentifier: "lead", InterfaceIdentifier: "wg-dev"}
+
+	tests := []struct {
+		name        string
+		peer        *Peer
+		permissions []Permission
+		want        bool
+	}{
+		{name: "managed interface", peer: salesPeer, permissions: []Permission{PermissionPeersWrite}, want: true},
+		{name: "other interface", peer: otherPeer, permissions: []Permission{PermissionPeersRead}, want: false},
+		{name: "own peer", peer: ownPeer, permissions: []Permission{PermissionPeersWrite}, want: true},
+		{name: "not delegated permission", peer: salesPeer, permissions: []Permission{PermissionSystemRead},
+			want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := SetUserInfo(context.Background(), delegated)
+			if got := HasPeerAccessRights(ctx, tt.peer, tt.permissions...); got != tt.want {
+				t.Errorf("HasPeerAccessRights() = %t, want %t", got, tt.want)
+			}
+			if tt.peer == ownPeer {
+				return // access to own peers is not granted by the interface
+			}
+			if got := HasInterfaceAccessRights(ctx, tt.peer.InterfaceIdentifier, tt.permissions...); got != tt.want {
+				t.Errorf("HasInterfaceAccessRights() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
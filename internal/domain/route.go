@@ -0,0 +1,129 @@
+package domain
+
+import (
+	"fmt"
+	"net/netip"
+)
+
+// InterfaceStaticRoute is a custom route that is installed in addition to the peer routes of an interface.
+type InterfaceStaticRoute struct {
+	Destination string // the destination network in CIDR format, for example: 192.168.100.0/24
+	Gateway     string // an optional gateway address, if empty, the route is a link scoped route
+	Metric      int    // an optional route metric (priority)
+	Table       int    // an optional routing table, if zero, the routing table of the interface is used
+}
+
+// InterfacePolicyRule is a custom source based routing rule that is installed for an interface.
+type InterfacePolicyRule struct {
+	Source   string // the source network in CIDR format, for example: 10.11.12.0/24
+	Table    int    // an optional routing table, if zero, the routing table of the interface is used
+	Priority int    // an optional rule priority, if zero, a free priority is chosen automatically
+}
+
+// ValidateStaticRouting checks the custom routes and policy rules of the interface.
+// Custom routes must not overlap the allowed IPs of the given peers, otherwise peer traffic would be redirected.
+// Policy rules may overlap the allowed IPs, as routing the traffic of the peer networks is their main use case.
+func (i *Interface) ValidateStaticRouting(peers []Peer) error {
+	allowedIPs := i.GetAllowedIPs(peers)
+
+	for _, route := range i.StaticRoutes {
+		dst, err := netip.ParsePrefix(route.Destination)
+		if err != nil {
+			return fmt.Errorf("invalid route destination %s: %w", route.Destination, ErrInvalidData)
+		}
+		if route.Gateway != "" {
+			gw, err := netip.ParseAddr(route.Gateway)
+			if err != nil {
+				return fmt.Errorf("invalid route gateway %s: %w", route.Gateway, ErrInvalidData)
+			}
+			if gw.Is4() != dst.Addr().Is4() {
+				return fmt.Errorf("route gateway %s does not match the address family of %s: %w",
+					route.Gateway, route.Destination, ErrInvalidData)
+			}
+		}
+		if route.Metric < 0 || route.Table < 0 {
+			return fmt.Errorf("invalid metric or table for route %s: %w", route.Destination, ErrInvalidData)
+		}
+		if overlap := findOverlappingCidr(dst, allowedIPs); overlap != nil {
+			return fmt.Errorf("route %s overlaps peer allowed IP %s: %w", route.Destination, overlap, ErrInvalidData)
+		}
+	}
+
+	for _, rule := range i.PolicyRules {
+		if _, err := netip.ParsePrefix(rule.Source); err != nil {
+			return fmt.Errorf("invalid rule source %s: %w", rule.Source, ErrInvalidData)
+		}
+		if rule.Priority < 0 || rule.Table < 0 {
+			return fmt.Errorf("invalid priority or table for rule %s: %w", rule.Source, ErrInvalidData)
+		}
+	}
+
+	return nil
+}
+
+func findOverlappingCidr(prefix netip.Prefix, cidrs []Cidr) *Cidr {
+	for _, cidr := range cidrs {
+		if prefix.Overlaps(cidr.Prefix()) {
+			return &cidr
+		}
+	}
+
+	return nil
+}
+
+// RouteEntry is a single route that is (or should be) installed for an interface.
+type RouteEntry struct {
+	Destination Cidr
+	Table       int
+}
+
+// RuleEntry is a single routing policy rule that is (or should be) installed for an interface.
+type RuleEntry struct {
+	Family            int // netlink.FAMILY_V4 or netlink.FAMILY_V6
+	Source            *Cidr
+	Priority          int
+	Table             int
+	FwMark            uint32
+	Invert            bool
+	SuppressPrefixlen int
+}
+
+// Matches checks if the other rule has the same selector and target. The priority is not compared,
+// as it is dynamically assigned. The suppress prefix length is only compared if it is set (>= 0).
+func (r RuleEntry) Matches(other RuleEntry) bool {
+	sameSource := r.Source == nil && other.Source == nil ||
+		r.Source != nil && other.Source != nil && *r.Source == *other.Source
+
+	return r.Family == other.Family &&
+		sameSource &&
+		r.Table == other.Table &&
+		r.FwMark == other.FwMark &&
+		(r.SuppressPrefixlen < 0 || r.SuppressPrefixlen == other.SuppressPrefixlen)
+}
+
+// RoutingStatus compares the expected routing state of an interface with the state of the host system.
+type RoutingStatus struct {
+	InterfaceId       InterfaceIdentifier
+	ManagementEnabled bool
+	Table             int
+	FwMark            uint32
+
+	ExpectedRoutes []RouteEntry
+	ActualRoutes   []RouteEntry
+	ExpectedRules  []RuleEntry
+	ActualRules    []RuleEntry
+
+	MissingRoutes    []RouteEntry // expected, but not installed
+	UnexpectedRoutes []RouteEntry // installed, but not expected
+	MissingRules     []RuleEntry  // expected, but not installed
+	UnexpectedRules  []RuleEntry  // installed, but not expected
+
+	Error string // set if the state of the host system could not be inspected
+}
+
+// InSync returns true if there are no differences between the expected and actual state.
+func (s RoutingStatus) InSync() bool {
+	return s.Error == "" &&
+		len(s.MissingRoutes) == 0 && len(s.UnexpectedRoutes) == 0 &&
+		len(s.MissingRules) == 0 && len(s.UnexpectedRules) == 0
+}
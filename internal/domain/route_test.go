@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestRuleEntry_Matches(t *testing.T) {
+	src := CidrFromPrefix(netip.MustParsePrefix("10.11.12.0/24"))
+	otherSrc := CidrFromPrefix(netip.MustParsePrefix("10.11.13.0/24"))
+	base := RuleEntry{Family: 2, Table: 20001, FwMark: 20001, Invert: true, SuppressPrefixlen: -1}
+
+	tests := []struct {
+		name     string
+		expected RuleEntry
+		actual   RuleEntry
+		want     bool
+	}{
+		{name: "equal", expected: base, actual: base, want: true},
+		{name: "priority is ignored", expected: base,
+			actual: RuleEntry{Family: 2, Table: 20001, FwMark: 20001, Priority: 32700, SuppressPrefixlen: -1}, want: true},
+		{name: "unset suppress prefixlen is ignored", expected: base,
+			actual: RuleEntry{Family: 2, Table: 20001, FwMark: 20001, SuppressPrefixlen: 0}, want: true},
+		{name: "suppress prefixlen differs",
+			expected: RuleEntry{Family: 2, Table: 254, SuppressPrefixlen: 0},
+			actual:   RuleEntry{Family: 2, Table: 254, SuppressPrefixlen: -1}, want: false},
+		{name: "family differs", expected: base,
+			actual: RuleEntry{Family: 10, Table: 20001, FwMark: 20001, SuppressPrefixlen: -1}, want: false},
+		{name: "table differs", expected: base,
+			actual: RuleEntry{Family: 2, Table: 20002, FwMark: 20001, SuppressPrefixlen: -1}, want: false},
+		{name: "fwmark differs", expected: base,
+			actual: RuleEntry{Family: 2, Table: 20001, FwMark: 20002, SuppressPrefixlen: -1}, want: false},
+		{name: "same source",
+			expected: RuleEntry{Family: 2, Source: &src, Table: 100, SuppressPrefixlen: -1},
+			actual:   RuleEntry{Family: 2, Source: &src, Table: 100, SuppressPrefixlen: -1}, want: true},
+		{name: "different source",
+			expected: RuleEntry{Family: 2, Source: &src, Table: 100, SuppressPrefixlen: -1},
+			actual:   RuleEntry{Family: 2, Source: &otherSrc, Table: 100, SuppressPrefixlen: -1}, want: false},
+		{name: "missing source",
+			expected: RuleEntry{Family: 2, Source: &src, Table: 100, SuppressPrefixlen: -1},
+			actual:   RuleEntry{Family: 2, Table: 100, SuppressPrefixlen: -1}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.expected.Matches(tt.actual); got != tt.want {
+				t.Errorf("Matches() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInterface_ValidateStaticRouting(t *testing.T) {
+	peers := []Peer{
+		{Interface: PeerInterfaceConfig{Addresses: []Cidr{CidrFromPrefix(netip.MustParsePrefix("10.11.12.2/24"))}}},
+		{Interface: PeerInterfaceConfig{Addresses: []Cidr{CidrFromPrefix(netip.MustParsePrefix("10.11.12.3/24"))}},
+			ExtraAllowedIPsStr: "192.168.50.0/24"},
+	}
+
+	tests := []struct {
+		name    string
+		routes  []InterfaceStaticRoute
+		rules   []InterfacePolicyRule
+		wantErr bool
+	}{
+		{name: "empty"},
+		{name: "valid route", routes: []InterfaceStaticRoute{{Destination: "172.16.0.0/16", Gateway: "10.0.0.1"}}},
+		{name: "invalid destination", routes: []InterfaceStaticRoute{{Destination: "172.16.0.0"}}, wantErr: true},
+		{name: "invalid gateway", routes: []InterfaceStaticRoute{{Destination: "172.16.0.0/16", Gateway: "gw"}},
+			wantErr: true},
+		{name: "gateway family mismatch",
+			routes: []InterfaceStaticRoute{{Destination: "172.16.0.0/16", Gateway: "fd00::1"}}, wantErr: true},
+		{name: "negative metric", routes: []InterfaceStaticRoute{{Destination: "172.16.0.0/16", Metric: -1}},
+			wantErr: true},
+		{name: "route overlaps peer address", routes: []InterfaceStaticRoute{{Destination: "10.11.12.0/24"}},
+			wantErr: true},
+		{name: "route overlaps extra allowed ip", routes: []InterfaceStaticRoute{{Destination: "192.168.50.128/25"}},
+			wantErr: true},
+		{name: "valid rule", rules: []InterfacePolicyRule{{Source: "172.16.0.0/16", Table: 100}}},
+		{name: "rule for peer subnet", rules: []InterfacePolicyRule{{Source: "10.11.12.0/24"}}},
+		{name: "rule for extra allowed ip", rules: []InterfacePolicyRule{{Source: "192.168.50.0/24", Priority: 100}}},
+		{name: "invalid source", rules: []InterfacePolicyRule{{Source: "10.11.12.0/33"}}, wantErr: true},
+		{name: "negative table", rules: []InterfacePolicyRule{{Source: "10.11.12.0/24", Table: -1}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			iface := &Interface{StaticRoutes: tt.routes, PolicyRules: tt.rules}
+			if err := iface.ValidateStaticRouting(peers); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateStaticRouting() error = %v, wantErr %t", err, tt.wantErr)
+			}
+		})
+	}
+}
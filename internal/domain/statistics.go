@@ -28,6 +28,51 @@ func (s PeerStatus) IsConnected() bool {
 	return s.IsPingable || handshakeValid
 }
 
+// PeerStatusUpdate contains the previous and the current status of a peer after a data collection run.
+type PeerStatusUpdate struct {
+	InterfaceId InterfaceIdentifier
+	Previous    PeerStatus
+	Current     PeerStatus
+}
+
+// ReceivedDelta returns the number of bytes that were received since the previous update.
+func (u PeerStatusUpdate) ReceivedDelta() uint64 {
+	return counterDelta(u.Previous.BytesReceived, u.Current.BytesReceived)
+}
+
+// TransmittedDelta returns the number of bytes that were transmitted since the previous update.
+func (u PeerStatusUpdate) TransmittedDelta() uint64 {
+	return counterDelta(u.Previous.BytesTransmitted, u.Current.BytesTransmitted)
+}
+
+// HandshakeUpdated returns true if a new handshake happened since the previous update.
+func (u PeerStatusUpdate) HandshakeUpdated() bool {
+	switch {
+	case u.Current.LastHandshake == nil:
+		return false
+	case u.Previous.LastHandshake == nil:
+		return true
+	default:
+		return !u.Current.LastHandshake.Equal(*u.Previous.LastHandshake)
+	}
+}
+
+// counterDelta calculates the difference of two counter values. If the counter was reset (for example because
+// the session was restarted), the current value is returned.
+func counterDelta(previous, current uint64) uint64 {
+	if current < previous {
+		return current
+	}
+	return current - previous
+}
+
+// PeerConnectionEvent is published if the connection state of a peer changed.
+type PeerConnectionEvent struct {
+	Peer         Peer
+	Endpoint     string     // the current endpoint of the peer, might be empty if the peer disconnected
+	SessionStart *time.Time // the start time of the current (or last) session
+}
+
 type InterfaceStatus struct {
 	InterfaceId InterfaceIdentifier `gorm:"primaryKey;column:identifier"`
 	UpdatedAt   time.Time           `gorm:"column:updated_at"`
@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPeerStatusUpdate_Deltas(t *testing.T) {
+	now := time.Now()
+	before := now.Add(-1 * time.Minute)
+
+	tests := []struct {
+		name            string
+		update          PeerStatusUpdate
+		wantReceived    uint64
+		wantTransmitted uint64
+		wantHandshake   bool
+	}{
+		{
+			name:   "no data",
+			update: PeerStatusUpdate{},
+		},
+		{
+			name: "first handshake",
+			update: PeerStatusUpdate{
+				Current: PeerStatus{BytesReceived: 100, BytesTransmitted: 50, LastHandshake: &now},
+			},
+			wantReceived:    100,
+			wantTransmitted: 50,
+			wantHandshake:   true,
+		},
+		{
+			name: "ongoing session",
+			update: PeerStatusUpdate{
+				Previous: PeerStatus{BytesReceived: 100, BytesTransmitted: 50, LastHandshake: &before},
+				Current:  PeerStatus{BytesReceived: 150, BytesTransmitted: 80, LastHandshake: &before},
+			},
+			wantReceived:    50,
+			wantTransmitted: 30,
+			wantHandshake:   false,
+		},
+		{
+			name: "counter reset",
+			update: PeerStatusUpdate{
+				Previous: PeerStatus{BytesReceived: 100, BytesTransmitted: 50, LastHandshake: &before},
+				Current:  PeerStatus{BytesReceived: 10, BytesTransmitted: 5, LastHandshake: &now},
+			},
+			wantReceived:    10,
+			wantTransmitted: 5,
+			wantHandshake:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.update.ReceivedDelta(); got != tt.wantReceived {
+				t.Errorf("ReceivedDelta() = %v, want %v", got, tt.wantReceived)
+			}
+			if got := tt.update.TransmittedDelta(); got != tt.wantTransmitted {
+				t.Errorf("TransmittedDelta() = %v, want %v", got, tt.wantTransmitted)
+			}
+			if got := tt.update.HandshakeUpdated(); got != tt.wantHandshake {
+				t.Errorf("HandshakeUpdated() = %v, want %v", got, tt.wantHandshake)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package domain
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	TotpPeriod            = 30 * time.Second // the time step of the TOTP codes (RFC 6238)
+	TotpDigits            = 6                // the number of digits of the TOTP codes
+	TotpSkew              = 1                // the number of time steps that are accepted before and after the current one
+	TotpRecoveryCodeCount = 10               // the number of generated recovery codes
+)
+
+// SecondFactorState describes which second factor action is required to complete a login.
+type SecondFactorState string
+
+const (
+	SecondFactorStateNone       SecondFactorState = ""           // no second factor required
+	SecondFactorStateVerify     SecondFactorState = "verify"     // a TOTP or recovery code must be verified
+	SecondFactorStateEnrollment SecondFactorState = "enrollment" // TOTP is mandatory, but the user is not enrolled yet
+)
+
+// UserSecondFactor contains the TOTP two-factor authentication settings of a user.
+type UserSecondFactor struct {
+	TotpSecret    PrivateString `gorm:"column:totp_secret"`         // base32 encoded secret, set during enrollment
+	TotpEnabled   *time.Time    `gorm:"column:totp_enabled"`        // if this field is set, TOTP is active for the user
+	TotpLastStep  int64         `gorm:"column:totp_last_step"`      // the last accepted time step, prevents code reuse
+	RecoveryCodes PrivateString `gorm:"column:totp_recovery_codes"` // comma separated SHA-256 hashes of unused codes
+}
+
+// IsTotpEnabled returns true if the user has completed the TOTP enrollment.
+func (f *UserSecondFactor) IsTotpEnabled() bool {
+	return f.TotpEnabled != nil && f.TotpSecret != ""
+}
+
+// VerifyTotpCode checks the given code against the TOTP secret. A code can only be used once.
+func (f *UserSecondFactor) VerifyTotpCode(code string, now time.Time) error {
+	if f.TotpSecret == "" {
+		return errors.New("no totp secret")
+	}
+
+	step, ok := ValidateTotpCode(string(f.TotpSecret), code, now)
+	if !ok {
+		return errors.New("invalid totp code")
+	}
+	if step <= f.TotpLastStep {
+		return errors.New("totp code already used")
+	}
+
+	f.TotpLastStep = step
+	return nil
+}
+
+// UseRecoveryCode checks the given recovery code and removes it from the list of unused codes.
+func (f *UserSecondFactor) UseRecoveryCode(code string) error {
+	codeHash := hashRecoveryCode(code)
+
+	hashes := strings.Split(string(f.RecoveryCodes), ",")
+	for i, hash := range hashes {
+		if hash != "" && subtle.ConstantTimeCompare([]byte(hash), []byte(codeHash)) == 1 {
+			f.RecoveryCodes = PrivateString(strings.Join(append(hashes[:i], hashes[i+1:]...), ","))
+			return nil
+		}
+	}
+
+	return errors.New("invalid recovery code")
+}
+
+// Reset removes all second factor settings.
+func (f *UserSecondFactor) Reset() {
+	*f = UserSecondFactor{}
+}
+
+// GenerateTotpSecret returns a new random, base32 encoded TOTP secret.
+func GenerateTotpSecret() (string, error) {
+	secret := make([]byte, 20)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate totp secret: %w", err)
+	}
+
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(secret), nil
+}
+
+// GenerateRecoveryCodes returns new random recovery codes and the comma separated hashes that should be stored.
+func GenerateRecoveryCodes() ([]string, PrivateString, error) {
+	codes := make([]string, TotpRecoveryCodeCount)
+	hashes := make([]string, TotpRecoveryCodeCount)
+	for i := range codes {
+		raw := make([]byte, 5)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, "", fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		code := hex.EncodeToString(raw)
+		codes[i] = code[:5] + "-" + code[5:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+
+	return codes, PrivateString(strings.Join(hashes, ",")), nil
+}
+
+// TotpProvisioningUri returns the otpauth URI that is encoded in the enrollment QR code.
+func TotpProvisioningUri(issuer, account, secret string) string {
+	v := url.Values{}
+	v.Set("secret", secret)
+	v.Set("issuer", issuer)
+	v.Set("algorithm", "SHA1")
+	v.Set("digits", fmt.Sprintf("%d", TotpDigits))
+	v.Set("period", fmt.Sprintf("%d", int(TotpPeriod.Seconds())))
+
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+	return "otpauth://totp/" + label + "?" + v.Encode()
+}
+
+// TotpCode calculates the TOTP code for the given secret and time step.
+func TotpCode(secret string, step int64) (string, error) {
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", fmt.Errorf("invalid totp secret: %w", err)
+	}
+
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", TotpDigits, value%1000000), nil
+}
+
+// ValidateTotpCode checks the code for the current time step and the allowed skew.
+// It returns the matching time step.
+func ValidateTotpCode(secret, code string, now time.Time) (int64, bool) {
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	if len(code) != TotpDigits {
+		return 0, false
+	}
+
+	current := now.Unix() / int64(TotpPeriod.Seconds())
+	for step := current - TotpSkew; step <= current+TotpSkew; step++ {
+		expected, err := TotpCode(secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// TotpEnrollment contains the information that is needed to register the TOTP secret in an authenticator app.
+type TotpEnrollment struct {
+	Secret          string
+	ProvisioningUri string
+}
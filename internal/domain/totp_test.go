@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidateTotpCode(t *testing.T) {
+	// RFC 6238 test secret "12345678901234567890"
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	now := time.Unix(59, 0)
+
+	tests := []struct {
+		name     string
+		code     string
+		now      time.Time
+		wantStep int64
+		wantOk   bool
+	}{
+		{name: "valid", code: "287082", now: now, wantStep: 1, wantOk: true},
+		{name: "valid with spaces", code: " 287 082 ", now: now, wantStep: 1, wantOk: true},
+		{name: "previous step", code: "287082", now: now.Add(TotpPeriod), wantStep: 1, wantOk: true},
+		{name: "outside skew", code: "287082", now: now.Add(2 * TotpPeriod), wantOk: false},
+		{name: "wrong code", code: "287083", now: now, wantOk: false},
+		{name: "wrong length", code: "28708", now: now, wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := ValidateTotpCode(secret, tt.code, tt.now)
+			if ok != tt.wantOk || step != tt.wantStep {
+				t.Errorf("ValidateTotpCode() = %d, %t, want %d, %t", step, ok, tt.wantStep, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestUserSecondFactor_VerifyTotpCode(t *testing.T) {
+	f := UserSecondFactor{TotpSecret: "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"}
+	now := time.Unix(59, 0)
+
+	if err := f.VerifyTotpCode("287082", now); err != nil {
+		t.Fatalf("first use: unexpected error: %v", err)
+	}
+	if err := f.VerifyTotpCode("287082", now); err == nil {
+		t.Error("second use: expected replay to be rejected")
+	}
+}
+
+func TestUserSecondFactor_UseRecoveryCode(t *testing.T) {
+	codes, hashes, err := GenerateRecoveryCodes()
+	if err != nil {
+		t.Fatalf("GenerateRecoveryCodes() error: %v", err)
+	}
+	if len(codes) != TotpRecoveryCodeCount {
+		t.Fatalf("GenerateRecoveryCodes() returned %d codes, want %d", len(codes), TotpRecoveryCodeCount)
+	}
+
+	f := UserSecondFactor{RecoveryCodes: hashes}
+	if err := f.UseRecoveryCode(codes[0]); err != nil {
+		t.Fatalf("first use: unexpected error: %v", err)
+	}
+	if err := f.UseRecoveryCode(codes[0]); err == nil {
+		t.Error("second use: expected used code to be rejected")
+	}
+	if err := f.UseRecoveryCode(codes[1]); err != nil {
+		t.Errorf("other code: unexpected error: %v", err)
+	}
+}
@@ -28,6 +28,10 @@ type User struct {
 	Source       UserSource
 	ProviderName string
 	IsAdmin      bool
+	Role         RoleName `gorm:"column:role"` // the role of the user, admins always have the admin role
+
+	// optional, interfaces that are administered by the user (delegated admin), including their peers and users
+	ManagedInterfaces []InterfaceIdentifier `gorm:"serializer:json;column:managed_interfaces"`
 
 	// optional fields
 	Firstname  string `form:"firstname" binding:"omitempty"`
@@ -42,11 +46,24 @@ type User struct {
 	DisabledReason string        // the reason why the user has been disabled
 	Locked         *time.Time    `gorm:"index;column:locked"` // if this field is set, the user is locked and can no longer login (WireGuard peers still can connect)
 	LockedReason   string        // the reason why the user has been locked
+	LockedUntil    *time.Time    `gorm:"column:locked_until"` // if this field is set, the lock is lifted automatically after this time
 
 	// API token for REST API access
 	ApiToken        string `form:"api_token" binding:"omitempty"`
 	ApiTokenCreated *time.Time
 
+	// optional, TOTP two-factor authentication for database users
+	SecondFactor UserSecondFactor `gorm:"embedded"`
+
+	// optional, pending self-service password reset of database users
+	PasswordReset UserPasswordReset `gorm:"embedded"`
+
+	// optional, pending invitation of database users
+	Invitation UserInvitation `gorm:"embedded"`
+
+	// optional, self-registration state of database users
+	Registration UserRegistration `gorm:"embedded"`
+
 	LinkedPeerCount int `gorm:"-"`
 }
 
@@ -57,8 +74,30 @@ func (u *User) IsDisabled() bool {
 }
 
 // IsLocked returns true if the user is locked. In such a case, no login is possible, WireGuard connections still work.
+// Temporary locks expire automatically once LockedUntil has passed.
 func (u *User) IsLocked() bool {
-	return u.Locked != nil
+	return u.Locked != nil && (u.LockedUntil == nil || time.Now().Before(*u.LockedUntil))
+}
+
+// EffectiveRole returns the role of the user. Admins always have the admin role, users without role the user role.
+func (u *User) EffectiveRole() RoleName {
+	switch {
+	case u.IsAdmin:
+		return RoleAdmin
+	case u.Role == "" || u.Role == RoleAdmin:
+		return RoleUser
+	default:
+		return u.Role
+	}
+}
+
+// SetRole assigns the given role and keeps the admin flag in sync with it.
+func (u *User) SetRole(role RoleName) {
+	u.IsAdmin = role == RoleAdmin
+	u.Role = role
+	if u.Role == "" {
+		u.Role = RoleUser
+	}
 }
 
 func (u *User) IsApiEnabled() bool {
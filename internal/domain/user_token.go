@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// GenerateUserToken creates a new single-use token for links that are mailed to the given user, like password resets
+// or invitations. The token contains the user identifier, so that the user can be looked up without storing the
+// token in clear text. The returned hash is stored instead of the token.
+func GenerateUserToken(id UserIdentifier) (string, PrivateString, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	secret := base64.RawURLEncoding.EncodeToString(raw)
+
+	token := base64.RawURLEncoding.EncodeToString([]byte(id)) + "." + secret
+
+	return token, PrivateString(hashUserTokenSecret(secret)), nil
+}
+
+// ParseUserToken splits a token created by GenerateUserToken into the user identifier and the secret.
+func ParseUserToken(token string) (UserIdentifier, string, error) {
+	encodedId, secret, found := strings.Cut(strings.TrimSpace(token), ".")
+	if !found || encodedId == "" || secret == "" {
+		return "", "", errors.New("malformed token")
+	}
+
+	id, err := base64.RawURLEncoding.DecodeString(encodedId)
+	if err != nil {
+		return "", "", errors.New("malformed token")
+	}
+
+	return UserIdentifier(id), secret, nil
+}
+
+// verifyUserToken checks the secret of a token against the stored hash and expiry time.
+func verifyUserToken(hash PrivateString, expiresAt *time.Time, secret string, now time.Time) error {
+	if hash == "" || expiresAt == nil {
+		return errors.New("no token issued")
+	}
+
+	if !now.Before(*expiresAt) {
+		return errors.New("token expired")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(hashUserTokenSecret(secret))) != 1 {
+		return errors.New("invalid token")
+	}
+
+	return nil
+}
+
+func hashUserTokenSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
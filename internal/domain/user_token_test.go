@@ -0,0 +1,71 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUserPasswordReset_Verify(t *testing.T) {
+	token, hash, err := GenerateUserToken("alice")
+	if err != nil {
+		t.Fatalf("GenerateUserToken() error = %v", err)
+	}
+
+	id, secret, err := ParseUserToken(token)
+	if err != nil || id != "alice" {
+		t.Fatalf("ParseUserToken() = %s, %v, want alice", id, err)
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(time.Hour)
+	tests := []struct {
+		name    string
+		reset   UserPasswordReset
+		secret  string
+		now     time.Time
+		wantErr bool
+	}{
+		{name: "valid", reset: UserPasswordReset{TokenHash: hash, ExpiresAt: &expiresAt}, secret: secret, now: now},
+		{name: "expired", reset: UserPasswordReset{TokenHash: hash, ExpiresAt: &expiresAt}, secret: secret,
+			now: expiresAt, wantErr: true},
+		{name: "wrong secret", reset: UserPasswordReset{TokenHash: hash, ExpiresAt: &expiresAt}, secret: "x" + secret,
+			now: now, wantErr: true},
+		{name: "no reset pending", reset: UserPasswordReset{}, secret: secret, now: now, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.reset.Verify(tt.secret, tt.now); (err != nil) != tt.wantErr {
+				t.Errorf("Verify() error = %v, wantErr %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseUserToken_Malformed(t *testing.T) {
+	for _, token := range []string{"", "abc", ".secret", "YWxpY2U.", "!!!.secret", strings.Repeat(".", 3)} {
+		if _, _, err := ParseUserToken(token); err == nil {
+			t.Errorf("ParseUserToken(%q) expected error", token)
+		}
+	}
+}
+
+func TestUserInvitation_Verify(t *testing.T) {
+	token, hash, _ := GenerateUserToken("bob")
+	_, secret, _ := ParseUserToken(token)
+
+	now := time.Now()
+	expiresAt := now.Add(time.Hour)
+	invitation := UserInvitation{InvitedAt: &now, TokenHash: hash, ExpiresAt: &expiresAt}
+	if err := invitation.Verify(secret, now); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+	if !invitation.IsExpired(expiresAt) {
+		t.Errorf("IsExpired() = false, want true")
+	}
+
+	invitation.Reset()
+	if invitation.IsPending() || invitation.Verify(secret, now) == nil {
+		t.Errorf("accepted invitation must not verify")
+	}
+}
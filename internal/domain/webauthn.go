@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"time"
+)
+
+// WebauthnCredential is a passkey or security key that has been registered by a user.
+type WebauthnCredential struct {
+	CredentialIdentifier string         `gorm:"primaryKey;column:credential_identifier"` // base64 url encoded credential id
+	UserIdentifier       UserIdentifier `gorm:"index;column:user_identifier"`
+	Name                 string         // a user defined name, for example "YubiKey" or "Laptop"
+	CreatedAt            time.Time
+	LastUsed             *time.Time
+
+	Data string `gorm:"type:text"` // JSON encoded credential data (public key, flags and signature counter)
+}
+
+// WebauthnUserHandle returns the opaque user handle that is stored on the authenticator.
+// It is derived from the user identifier, so no additional user field is required.
+func WebauthnUserHandle(id UserIdentifier) []byte {
+	sum := sha256.Sum256([]byte(id))
+	return sum[:]
+}
+
+// WebauthnChallenge contains the options for the browser WebAuthn API and the server side ceremony state
+// that is required to verify the response of the authenticator.
+type WebauthnChallenge struct {
+	Options json.RawMessage // passed to navigator.credentials.create() or navigator.credentials.get()
+	Session string          // JSON encoded ceremony state, must be kept in the user session
+}
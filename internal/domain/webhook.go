@@ -0,0 +1,41 @@
+package domain
+
+import "time"
+
+type WebhookDeliveryState string
+
+const (
+	WebhookDeliveryStatePending    WebhookDeliveryState = "pending"
+	WebhookDeliveryStateDelivered  WebhookDeliveryState = "delivered"
+	WebhookDeliveryStateDeadLetter WebhookDeliveryState = "dead-letter"
+)
+
+// WebhookDelivery stores the state of a single webhook event delivery. Failed deliveries are kept as dead-letters
+// until they get replayed.
+type WebhookDelivery struct {
+	Identifier string    `gorm:"primaryKey;column:identifier"` // a unique delivery id, also sent to the receiver
+	CreatedAt  time.Time `gorm:"column:created_at;index:idx_wh_created"`
+	UpdatedAt  time.Time `gorm:"column:updated_at"`
+
+	Webhook string               `gorm:"column:webhook;index:idx_wh_webhook"` // the name of the webhook subscription
+	Topic   string               `gorm:"column:topic"`                        // the event topic
+	State   WebhookDeliveryState `gorm:"column:state;index:idx_wh_state"`
+	Payload string               `gorm:"column:payload"` // the JSON encoded payload
+
+	Attempts       int        `gorm:"column:attempts"`
+	LastAttempt    *time.Time `gorm:"column:last_attempt"`
+	LastStatusCode int        `gorm:"column:last_status_code"` // the HTTP status code of the last attempt, 0 if no response was received
+	LastError      string     `gorm:"column:last_error"`
+
+	Retries     int        `gorm:"column:retries"`      // the retries since the delivery was created or last replayed
+	NextAttempt *time.Time `gorm:"column:next_attempt"` // the time of the next scheduled retry, nil if not scheduled
+}
+
+func (d *WebhookDelivery) IsDeadLetter() bool {
+	return d.State == WebhookDeliveryStateDeadLetter
+}
+
+// IsDue checks if the delivery is pending and no retry is scheduled for a later time.
+func (d *WebhookDelivery) IsDue(now time.Time) bool {
+	return d.State == WebhookDeliveryStatePending && (d.NextAttempt == nil || !d.NextAttempt.After(now))
+}
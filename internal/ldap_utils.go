@@ -4,6 +4,7 @@ import (
 	"crypto/tls"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/sirupsen/logrus"
 
@@ -13,7 +14,7 @@ import (
 
 type RawLdapUser map[string]any
 
-func LdapFindAllUsers(conn *ldap.Conn, baseDn, filter string, fields *config.LdapFields) ([]RawLdapUser, error) {
+func LdapFindAllUsers(conn ldap.Client, baseDn, filter string, fields *config.LdapFields) ([]RawLdapUser, error) {
 	// Search all users
 	attrs := LdapSearchAttributes(fields)
 	searchRequest := ldap.NewSearchRequest(
@@ -137,3 +138,51 @@ func LdapIsMemberOf(groupData [][]byte, groupDN *ldap.DN) (bool, error) {
 
 	return false, nil
 }
+
+// LdapMatchingRuleInChain is the LDAP_MATCHING_RULE_IN_CHAIN rule of Active Directory, it also matches members of
+// nested groups.
+const LdapMatchingRuleInChain = "1.2.840.113556.1.4.1941"
+
+// LdapAddNestedGroupMemberships adds each of the given groups to the group memberships of the users that are direct
+// or nested members of the group. The filter selects the users to consider, for example the sync or login filter.
+// Nested memberships are resolved using LdapMatchingRuleInChain, which is only supported by Active Directory.
+func LdapAddNestedGroupMemberships(
+	conn ldap.Client,
+	baseDn, filter string,
+	fields *config.LdapFields,
+	users []RawLdapUser,
+	groupDNs []string,
+) error {
+	if !strings.HasPrefix(filter, "(") {
+		filter = "(" + filter + ")"
+	}
+
+	usersById := make(map[string]RawLdapUser, len(users))
+	for _, user := range users {
+		usersById[MapDefaultString(user, fields.UserIdentifier, "")] = user
+	}
+
+	for _, groupDN := range UniqueStringSlice(groupDNs) {
+		if groupDN == "" {
+			continue
+		}
+
+		memberFilter := fmt.Sprintf("(&%s(%s:%s:=%s))", filter, fields.GroupMembership, LdapMatchingRuleInChain,
+			ldap.EscapeFilter(groupDN))
+		members, err := LdapFindAllUsers(conn, baseDn, memberFilter, fields)
+		if err != nil {
+			return fmt.Errorf("failed to search members of group %s: %w", groupDN, err)
+		}
+
+		for _, member := range members {
+			user, ok := usersById[MapDefaultString(member, fields.UserIdentifier, "")]
+			if !ok {
+				continue
+			}
+			groups, _ := user[fields.GroupMembership].([][]byte)
+			user[fields.GroupMembership] = append(groups, []byte(groupDN))
+		}
+	}
+
+	return nil
+}
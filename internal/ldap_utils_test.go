@@ -0,0 +1,90 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/go-ldap/ldap/v3"
+
+	"github.com/h44z/wg-portal/internal/config"
+)
+
+type testLdapClient struct {
+	ldap.Client
+	results map[string][]*ldap.Entry // filter -> entries
+	err     error
+}
+
+func (c *testLdapClient) Search(req *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &ldap.SearchResult{Entries: c.results[req.Filter]}, nil
+}
+
+func TestLdapAddNestedGroupMemberships(t *testing.T) {
+	const vpnGroup = "cn=vpn,ou=groups,dc=example,dc=com"
+	const adminGroup = "cn=admins (it),ou=groups,dc=example,dc=com"
+
+	fields := &config.LdapFields{BaseFields: config.BaseFields{UserIdentifier: "uid"}, GroupMembership: "memberOf"}
+	memberFilter := func(groupDN string) string {
+		return fmt.Sprintf("(&(objectClass=person)(memberOf:1.2.840.113556.1.4.1941:=%s))", ldap.EscapeFilter(groupDN))
+	}
+	entry := func(uid string) *ldap.Entry {
+		return ldap.NewEntry("uid="+uid+",ou=users,dc=example,dc=com", map[string][]string{"uid": {uid}})
+	}
+	client := &testLdapClient{results: map[string][]*ldap.Entry{
+		memberFilter(vpnGroup):   {entry("alice"), entry("bob"), entry("mallory")},
+		memberFilter(adminGroup): {entry("alice")},
+	}}
+
+	tests := []struct {
+		name       string
+		client     *testLdapClient
+		filter     string
+		groups     []string
+		wantGroups map[string][]string
+		wantErr    bool
+	}{
+		{name: "nested members", client: client, filter: "objectClass=person", groups: []string{vpnGroup, adminGroup},
+			wantGroups: map[string][]string{"alice": {vpnGroup, adminGroup}, "bob": {vpnGroup}, "carol": nil}},
+		{name: "filter with parentheses", client: client, filter: "(objectClass=person)", groups: []string{adminGroup},
+			wantGroups: map[string][]string{"alice": {adminGroup}, "bob": nil}},
+		{name: "duplicate and empty groups", client: client, filter: "objectClass=person",
+			groups:     []string{vpnGroup, "", vpnGroup},
+			wantGroups: map[string][]string{"alice": {vpnGroup}, "bob": {vpnGroup}, "carol": nil}},
+		{name: "other filter", client: client, filter: "objectClass=user", groups: []string{vpnGroup},
+			wantGroups: map[string][]string{"alice": nil}},
+		{name: "search error", client: &testLdapClient{err: errors.New("connection lost")},
+			filter: "objectClass=person", groups: []string{vpnGroup}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := []RawLdapUser{{"uid": "alice"}, {"uid": "bob"}, {"uid": "carol"}}
+
+			err := LdapAddNestedGroupMemberships(tt.client, "dc=example,dc=com", tt.filter, fields, users, tt.groups)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LdapAddNestedGroupMemberships() error = %v, wantErr %t", err, tt.wantErr)
+			}
+
+			for _, user := range users {
+				uid := MapDefaultString(user, "uid", "")
+				want, ok := tt.wantGroups[uid]
+				if !ok {
+					continue
+				}
+
+				var groups []string
+				rawGroups, _ := user["memberOf"].([][]byte)
+				for _, group := range rawGroups {
+					groups = append(groups, string(group))
+				}
+				if !slices.Equal(groups, want) {
+					t.Errorf("groups of %s = %v, want %v", uid, groups, want)
+				}
+			}
+		})
+	}
+}
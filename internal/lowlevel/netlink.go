@@ -2,8 +2,15 @@ package lowlevel
 
 import (
 	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
 )
 
+// RuleUpdate is sent by RuleSubscribe if a routing policy rule was added or removed.
+type RuleUpdate struct {
+	Type uint16 // unix.RTM_NEWRULE or unix.RTM_DELRULE
+}
+
 // A NetlinkClient is a type which can control a netlink device.
 type NetlinkClient interface {
 	LinkAdd(link netlink.Link) error
@@ -24,6 +31,9 @@ type NetlinkClient interface {
 	RuleAdd(rule *netlink.Rule) error
 	RuleDel(rule *netlink.Rule) error
 	RuleList(family int) ([]netlink.Rule, error)
+	LinkSubscribe(ch chan<- netlink.LinkUpdate, done <-chan struct{}) error
+	RouteSubscribe(ch chan<- netlink.RouteUpdate, done <-chan struct{}) error
+	RuleSubscribe(ch chan<- RuleUpdate, done <-chan struct{}) error
 }
 
 type NetlinkManager struct {
@@ -106,3 +116,53 @@ func (n NetlinkManager) RuleDel(rule *netlink.Rule) error {
 func (n NetlinkManager) RuleList(family int) ([]netlink.Rule, error) {
 	return netlink.RuleList(family)
 }
+
+func (n NetlinkManager) LinkSubscribe(ch chan<- netlink.LinkUpdate, done <-chan struct{}) error {
+	return netlink.LinkSubscribe(ch, done)
+}
+
+func (n NetlinkManager) RouteSubscribe(ch chan<- netlink.RouteUpdate, done <-chan struct{}) error {
+	return netlink.RouteSubscribe(ch, done)
+}
+
+// RuleSubscribe sends a notification to the given channel whenever a routing policy rule is added or deleted.
+// The netlink library does not provide a rule subscription, so the raw netlink messages are processed here.
+// Close the done channel to stop the subscription.
+func (n NetlinkManager) RuleSubscribe(ch chan<- RuleUpdate, done <-chan struct{}) error {
+	s, err := nl.Subscribe(unix.NETLINK_ROUTE, unix.RTNLGRP_IPV4_RULE, unix.RTNLGRP_IPV6_RULE)
+	if err != nil {
+		return err
+	}
+
+	if done != nil {
+		go func() {
+			<-done
+			s.Close()
+		}()
+	}
+
+	go func() {
+		defer close(ch)
+		for {
+			msgs, from, err := s.Receive()
+			if err != nil {
+				return
+			}
+			if from.Pid != nl.PidKernel {
+				continue
+			}
+			for _, m := range msgs {
+				if m.Header.Type != unix.RTM_NEWRULE && m.Header.Type != unix.RTM_DELRULE {
+					continue
+				}
+				select {
+				case ch <- RuleUpdate{Type: m.Header.Type}:
+				case <-done:
+					return
+				}
+			}
+		}
+	}()
+
+	return nil
+}